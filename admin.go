@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// isAdminRequest reports whether a request carries "Authorization: Bearer
+// <LEAGUE_ADMIN_TOKEN>"; every request counts as an admin's when the token is unset
+func isAdminRequest(r *http.Request) bool {
+	token := os.Getenv("LEAGUE_ADMIN_TOKEN")
+	return token == "" || r.Header.Get("Authorization") == "Bearer "+token
+}
+
+// adminMiddleware requires the admin token on admin routes when it is set
+func adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdminRequest(r) {
+			http.Error(w, "Admin token required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// POST /admin/reload - Re-reads the simulation config file and applies it to
+// subsequent simulations. Storage settings are not reloaded.
+func reloadConfigHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	scope := requestScope(r)
+
+	config, err := sim.LoadSimulationConfig(sim.SimulationConfigPath())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid simulation config: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Wait for any simulation in progress so no week is played with mixed parameters
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	sim.SetConfig(config)
+	unlock()
+
+	if err := json.NewEncoder(w).Encode(sim.CurrentConfig()); err != nil {
+		http.Error(w, "Error encoding config", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /admin/config - Returns the effective simulation configuration
+func getConfigHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(sim.CurrentConfig()); err != nil {
+		http.Error(w, "Error encoding config", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /admin/integrity - Checks the invariants of the league as stored, e.g. that the
+// team statistics agree with the match results; 409 Conflict when any is broken
+func integrityHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	scope := requestScope(r)
+
+	// Hold the shared league lock so the check never sees a mutation half-way through.
+	// The check reads storage, not the scope's league, which the request already holds
+	// for reading.
+	unlock, err := scope.storage.LockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+
+	league, err := loadLeague(scope.storage)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	report := checkLeagueIntegrity(league)
+	if !report.OK {
+		w.WriteHeader(http.StatusConflict)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Error encoding integrity report", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RepairReport lists the teams a repair rewrote and the league's integrity afterwards
+type RepairReport struct {
+	Repaired  []*TeamRepair
+	Integrity *IntegrityReport
+}
+
+// POST /admin/repair - Recomputes the team statistics and table from the stored match
+// results and rewrites the teams table in one transaction
+func repairHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+
+	// Repair what is stored rather than the in-memory league, which may hold writes
+	// that never reached the database
+	league, err := loadLeague(scope.storage)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	repairs, err := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context()).RepairTeamStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	scope.setLeague(league)
+
+	report := RepairReport{Repaired: repairs, Integrity: checkLeagueIntegrity(league)}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Error encoding repair report", http.StatusInternalServerError)
+		return
+	}
+}
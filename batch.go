@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// defaultBatchSeasons and defaultBatchOutput are used by `batch` when not told otherwise
+const (
+	defaultBatchSeasons = 1000
+	defaultBatchOutput  = "batch.csv"
+)
+
+// BatchSummary aggregates the final tables of many independently simulated seasons
+type BatchSummary struct {
+	Seasons   int
+	Seed      int64 // season i is simulated with seed Seed+i
+	Engine    string
+	Teams     []*BatchTeam
+	Positions []*BatchPosition
+}
+
+// BatchTeam is one team's record across a batch of seasons
+type BatchTeam struct {
+	TeamName        string
+	Titles          int
+	TitleShare      float64 // percentage of seasons won
+	AveragePoints   float64
+	AveragePosition float64
+	Finishes        []int // seasons finished in each position, champions first
+}
+
+// BatchPosition is the average points total needed to finish in a position
+type BatchPosition struct {
+	Position      int
+	AveragePoints float64
+	MinPoints     int
+	MaxPoints     int
+}
+
+// simulateSeason plays a fresh league to the end in memory with a seeded random
+// source and any further engine options
+func simulateSeason(seed int64, options ...sim.EngineOption) (*sim.League, error) {
+	engine, err := sim.NewEngine(append([]sim.EngineOption{sim.WithSeed(seed)}, options...)...)
+	if err == nil {
+		err = engine.SimulateSeason()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate season %d: %v", seed, err)
+	}
+	return engine.League(), nil
+}
+
+// simulateSeasons plays seasons in parallel, one worker per CPU, and hands each
+// finished league to visit in season order; season i (from 0) uses seed+i, so results
+// depend only on the seed. Seasons are played in chunks to bound memory. The first
+// season in order that fails to play stops the run with its error.
+func simulateSeasons(seasons int, seed int64, visit func(season int, league *sim.League) error) error {
+	workers := runtime.NumCPU()
+	chunk := make([]*sim.League, workers*4)
+	errs := make([]error, len(chunk))
+	for start := 0; start < seasons; start += len(chunk) {
+		count := min(len(chunk), seasons-start)
+		next := make(chan int)
+		var wg sync.WaitGroup
+		for worker := 0; worker < workers; worker++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					chunk[i], errs[i] = simulateSeason(seed + int64(start+i))
+				}
+			}()
+		}
+		for i := 0; i < count; i++ {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+
+		for i := 0; i < count; i++ {
+			if errs[i] != nil {
+				return errs[i]
+			}
+			if err := visit(start+i, chunk[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runBatchSeasons simulates seasons and aggregates their final tables
+func runBatchSeasons(seasons int, seed int64) (*BatchSummary, error) {
+	tables := make([][]*sim.LeagueTableEntry, 0, seasons)
+	err := simulateSeasons(seasons, seed, func(season int, league *sim.League) error {
+		tables = append(tables, league.LeagueTable)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summarizeBatch(tables, seed), nil
+}
+
+// summarizeBatch aggregates final tables into title counts, average points and
+// positions per team and the average points of each finishing position
+func summarizeBatch(tables [][]*sim.LeagueTableEntry, seed int64) *BatchSummary {
+	summary := &BatchSummary{Seasons: len(tables), Seed: seed, Engine: sim.Config().Engine}
+	if len(tables) == 0 {
+		return summary
+	}
+
+	size := len(tables[0])
+	teams := make(map[string]*BatchTeam)
+	points := make(map[string]int)
+	positions := make(map[string]int)
+	for position := 1; position <= size; position++ {
+		summary.Positions = append(summary.Positions, &BatchPosition{Position: position})
+	}
+
+	for season, table := range tables {
+		for _, entry := range table {
+			team := teams[entry.TeamName]
+			if team == nil {
+				team = &BatchTeam{TeamName: entry.TeamName, Finishes: make([]int, size)}
+				teams[entry.TeamName] = team
+				summary.Teams = append(summary.Teams, team)
+			}
+			team.Finishes[entry.Position-1]++
+			points[entry.TeamName] += entry.Points
+			positions[entry.TeamName] += entry.Position
+
+			position := summary.Positions[entry.Position-1]
+			if season == 0 || entry.Points < position.MinPoints {
+				position.MinPoints = entry.Points
+			}
+			position.MaxPoints = max(position.MaxPoints, entry.Points)
+			position.AveragePoints += float64(entry.Points) // summed here, averaged below
+		}
+	}
+
+	seasons := float64(len(tables))
+	for _, team := range summary.Teams {
+		team.Titles = team.Finishes[0]
+		team.TitleShare = math.Round(float64(team.Titles)/seasons*1000) / 10
+		team.AveragePoints = math.Round(float64(points[team.TeamName])/seasons*100) / 100
+		team.AveragePosition = math.Round(float64(positions[team.TeamName])/seasons*100) / 100
+	}
+	for _, position := range summary.Positions {
+		position.AveragePoints = math.Round(position.AveragePoints/seasons*100) / 100
+	}
+	sort.SliceStable(summary.Teams, func(i, j int) bool {
+		return summary.Teams[i].AveragePosition < summary.Teams[j].AveragePosition
+	})
+	return summary
+}
+
+// writeBatchSummary writes the summary as JSON when path ends in .json, else as CSV
+func writeBatchSummary(summary *BatchSummary, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summary)
+	}
+
+	// One row per finishing position: its average, lowest and highest points, then how
+	// many seasons each team finished there, so the first row holds the title counts
+	writer := csv.NewWriter(file)
+	header := []string{"position", "average_points", "min_points", "max_points"}
+	for _, team := range summary.Teams {
+		header = append(header, team.TeamName)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for i, position := range summary.Positions {
+		row := []string{
+			strconv.Itoa(position.Position),
+			strconv.FormatFloat(position.AveragePoints, 'f', 2, 64),
+			strconv.Itoa(position.MinPoints),
+			strconv.Itoa(position.MaxPoints),
+		}
+		for _, team := range summary.Teams {
+			row = append(row, strconv.Itoa(team.Finishes[i]))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// runBatch implements `./main batch [-profile prefix] [seasons] [output] [seed]`;
+// -profile writes CPU and heap profiles of the run to <prefix>.cpu.pprof and
+// <prefix>.heap.pprof
+func runBatch(args []string) {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	profile := flags.String("profile", "", "write CPU and heap profiles of the run to `prefix`.cpu.pprof and prefix.heap.pprof")
+	flags.Parse(args)
+	args = flags.Args()
+
+	seasons, output, seed := defaultBatchSeasons, defaultBatchOutput, time.Now().UnixNano()
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 1 {
+			fmt.Fprintf(os.Stderr, "Invalid number of seasons %q\n", args[0])
+			os.Exit(1)
+		}
+		seasons = parsed
+	}
+	if len(args) > 1 {
+		output = args[1]
+	}
+	if len(args) > 2 {
+		parsed, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid seed %q\n", args[2])
+			os.Exit(1)
+		}
+		seed = parsed
+	}
+
+	stopProfiles := func() error { return nil }
+	if *profile != "" {
+		stop, err := startProfiles(*profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to start profiling: %v\n", err)
+			os.Exit(1)
+		}
+		stopProfiles = stop
+	}
+
+	started := time.Now()
+	summary, err := runBatchSeasons(seasons, seed)
+	elapsed := time.Since(started)
+	if err := stopProfiles(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write profiles: %v\n", err)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Batch failed: %v\n", err)
+		os.Exit(1)
+	}
+	if err := writeBatchSummary(summary, output); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write batch summary: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Simulated %d seasons with the %s engine in %s (seed %d)\n\n", summary.Seasons, summary.Engine, elapsed.Round(time.Millisecond), summary.Seed)
+	fmt.Printf("%-25s %7s %7s %9s %9s\n", "Team", "Titles", "Share", "Avg Pts", "Avg Pos")
+	for _, team := range summary.Teams {
+		fmt.Printf("%-25s %7d %6.1f%% %9.2f %9.2f\n", asciiName(team.TeamName), team.Titles, team.TitleShare, team.AveragePoints, team.AveragePosition)
+	}
+	fmt.Printf("\n%-25s %9s %7s %7s\n", "Position", "Avg Pts", "Min", "Max")
+	for _, position := range summary.Positions {
+		fmt.Printf("%-25d %9.2f %7d %7d\n", position.Position, position.AveragePoints, position.MinPoints, position.MaxPoints)
+	}
+	fmt.Printf("\nWrote %s\n", output)
+	if *profile != "" {
+		fmt.Printf("Wrote %s.cpu.pprof and %s.heap.pprof; inspect them with go tool pprof\n", *profile, *profile)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+func TestSimulateSeasonReturnsErrors(t *testing.T) {
+	if _, err := simulateSeason(1, sim.WithSport("quidditch")); err == nil {
+		t.Fatal("simulateSeason played a season of an unknown sport")
+	}
+
+	league, err := simulateSeason(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining := remainingMatches(league); remaining != 0 {
+		t.Errorf("season ended with %d matches unplayed", remaining)
+	}
+}
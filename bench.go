@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// benchmarkSeed is the seed the benchmark league is played with, so runs compare
+const benchmarkSeed = 20240601
+
+// benchmarkTime is how long each benchmark repeats its prediction, as go test -bench
+// does by default
+const benchmarkTime = time.Second
+
+// benchmarkCase is one benchmark of `bench`
+type benchmarkCase struct {
+	name  string
+	model string
+}
+
+// benchmarkCases cover the Monte Carlo models that ship with the simulator
+var benchmarkCases = []benchmarkCase{
+	{"positions/poisson", ModelPoisson},
+	{"positions/elo", ModelElo},
+}
+
+// benchmarkLeague plays the first half of a seeded season, then drops the seed so
+// predictions reuse their samples like an unseeded league does, and the benchmarks
+// time the replay of the run-in rather than the sampling
+func benchmarkLeague() (*sim.League, error) {
+	engine, err := sim.NewEngine(sim.WithSeed(benchmarkSeed))
+	for err == nil && 2*remainingMatches(engine.League()) > len(engine.League().Matches) {
+		_, err = engine.SimulateWeek()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the benchmark league: %v", err)
+	}
+	league := engine.League()
+	league.Random = nil
+	return league, nil
+}
+
+// benchmarkResult is the cost of one prediction, averaged over a benchmark's runs
+type benchmarkResult struct {
+	NsPerOp     int64
+	BytesPerOp  int64
+	AllocsPerOp int64
+}
+
+// benchmarkPrediction repeats a position prediction for benchmarkTime, after one run
+// to warm up, and returns its average time and allocations
+func benchmarkPrediction(predictor PositionPredictor, league *sim.League, iterations int) (benchmarkResult, error) {
+	if _, err := predictor.PredictPositions(context.Background(), league, iterations); err != nil {
+		return benchmarkResult{}, err
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	runs, started := 0, time.Now()
+	for runs == 0 || time.Since(started) < benchmarkTime {
+		if _, err := predictor.PredictPositions(context.Background(), league, iterations); err != nil {
+			return benchmarkResult{}, err
+		}
+		runs++
+	}
+	elapsed := time.Since(started)
+	runtime.ReadMemStats(&after)
+
+	return benchmarkResult{
+		NsPerOp:     elapsed.Nanoseconds() / int64(runs),
+		BytesPerOp:  int64(after.TotalAlloc-before.TotalAlloc) / int64(runs),
+		AllocsPerOp: int64(after.Mallocs-before.Mallocs) / int64(runs),
+	}, nil
+}
+
+// runBenchmarks implements `./main bench [iterations]`: it times a position prediction
+// of each Monte Carlo model and reports the allocations of every simulated season
+func runBenchmarks(args []string) {
+	iterations := defaultPredictionIterations
+	if len(args) > 0 {
+		parsed, err := parsePredictionIterations(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid iterations %q, expected %d to %d\n", args[0], minPredictionIterations, maxPredictionIterations)
+			os.Exit(1)
+		}
+		iterations = parsed
+	}
+
+	league, err := benchmarkLeague()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d of %d matches left, %d iterations per prediction\n\n", remainingMatches(league), len(league.Matches), iterations)
+	fmt.Printf("%-20s %14s %14s %12s %16s\n", "Benchmark", "ns/op", "B/op", "allocs/op", "allocs/season")
+	for _, bench := range benchmarkCases {
+		predictor := predictionModels[bench.model].(PositionPredictor)
+		result, err := benchmarkPrediction(predictor, league, iterations)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Benchmark %s failed: %v\n", bench.name, err)
+			os.Exit(1)
+		}
+		perSeason := float64(result.AllocsPerOp) / float64(iterations)
+		fmt.Printf("%-20s %14d %14d %12d %16s\n", bench.name, result.NsPerOp, result.BytesPerOp, result.AllocsPerOp, strconv.FormatFloat(perSeason, 'f', 2, 64))
+	}
+}
+
+// remainingMatches counts the fixtures still to be played
+func remainingMatches(league *sim.League) int {
+	remaining := 0
+	for _, match := range league.Matches {
+		if !match.Played {
+			remaining++
+		}
+	}
+	return remaining
+}
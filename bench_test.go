@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// unpooledMonteCarloPositions is monteCarloPositions with a fresh scratch table for
+// every season, the allocations seasonScratchPool saves
+func unpooledMonteCarloPositions(league *sim.League, fixtures fixtureModel, iterations int) map[string][]int {
+	run := newMonteCarloRun(league, fixtures, iterations)
+	teams := len(league.LeagueTable)
+	positions := make(map[string][]int, teams)
+	for _, entry := range league.LeagueTable {
+		positions[entry.TeamName] = make([]int, teams)
+	}
+
+	for i := 0; i < iterations; i++ {
+		scratch := &seasonScratch{}
+		scratch.resize(teams)
+		run.play(scratch, i)
+		for position, t := range scratch.order {
+			positions[league.LeagueTable[t].TeamName][position]++
+		}
+	}
+	return positions
+}
+
+func TestMonteCarloPositionsMatchUnpooled(t *testing.T) {
+	league, err := benchmarkLeague()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := poissonFixtures(league)
+	// The unseeded benchmark league reuses its samples, so both runs play the same seasons
+	pooled := monteCarloPositions(league, fixtures, 1000)
+	unpooled := unpooledMonteCarloPositions(league, fixtures, 1000)
+	if !reflect.DeepEqual(pooled, unpooled) {
+		t.Fatalf("pooled run counted %v, unpooled run %v", pooled, unpooled)
+	}
+}
+
+func benchmarkMonteCarloPositions(b *testing.B, positions func(league *sim.League, fixtures fixtureModel, iterations int) map[string][]int) {
+	league, err := benchmarkLeague()
+	if err != nil {
+		b.Fatal(err)
+	}
+	fixtures := poissonFixtures(league)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		positions(league, fixtures, defaultPredictionIterations)
+	}
+}
+
+func BenchmarkMonteCarloPositionsPooled(b *testing.B) {
+	benchmarkMonteCarloPositions(b, monteCarloPositions)
+}
+
+func BenchmarkMonteCarloPositionsUnpooled(b *testing.B) {
+	benchmarkMonteCarloPositions(b, unpooledMonteCarloPositions)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResponseCache stores encoded read responses keyed by name and league revision.
+// Bumping the revision makes every older entry unreachable. The revision is
+// persisted, so instances sharing a database also agree on Redis keys.
+type ResponseCache interface {
+	Get(key string, version int) ([]byte, bool)
+	Set(key string, version int, data []byte)
+}
+
+// newResponseCache picks Redis when LEAGUE_REDIS_ADDR is set, otherwise an in-process cache
+func newResponseCache() ResponseCache {
+	addr := os.Getenv("LEAGUE_REDIS_ADDR")
+	if addr == "" {
+		return NewMemoryResponseCache()
+	}
+	log.Printf("Using Redis response cache at %s", addr)
+	return NewRedisResponseCache(addr, 10*time.Minute)
+}
+
+// MemoryResponseCache keeps only entries for the latest version seen
+type MemoryResponseCache struct {
+	mu      sync.RWMutex
+	version int
+	entries map[string][]byte
+}
+
+func NewMemoryResponseCache() *MemoryResponseCache {
+	return &MemoryResponseCache{entries: make(map[string][]byte)}
+}
+
+func (c *MemoryResponseCache) Get(key string, version int) ([]byte, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if version != c.version {
+		return nil, false
+	}
+	data, ok := c.entries[key]
+	return data, ok
+}
+
+func (c *MemoryResponseCache) Set(key string, version int, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version != c.version {
+		// A new version invalidates everything cached so far
+		c.version = version
+		c.entries = make(map[string][]byte)
+	}
+	c.entries[key] = data
+}
+
+// RedisResponseCache shares cached responses between instances through Redis.
+// It speaks the plain RESP protocol so no client library is required; any
+// Redis error degrades to a cache miss.
+type RedisResponseCache struct {
+	addr string
+	ttl  time.Duration
+	mu   sync.Mutex
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+func NewRedisResponseCache(addr string, ttl time.Duration) *RedisResponseCache {
+	return &RedisResponseCache{addr: addr, ttl: ttl}
+}
+
+func (c *RedisResponseCache) cacheKey(key string, version int) string {
+	return fmt.Sprintf("league:v%d:%s", version, key)
+}
+
+func (c *RedisResponseCache) Get(key string, version int) ([]byte, bool) {
+	reply, err := c.command("GET", c.cacheKey(key, version))
+	if err != nil {
+		log.Printf("Redis cache get failed: %v", err)
+		return nil, false
+	}
+	if reply == nil {
+		return nil, false
+	}
+	return reply, true
+}
+
+func (c *RedisResponseCache) Set(key string, version int, data []byte) {
+	ttl := strconv.FormatInt(c.ttl.Milliseconds(), 10)
+	if _, err := c.command("SET", c.cacheKey(key, version), string(data), "PX", ttl); err != nil {
+		log.Printf("Redis cache set failed: %v", err)
+	}
+}
+
+// command sends one RESP command and returns a bulk string reply (nil for a missing key)
+func (c *RedisResponseCache) command(args ...string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		conn, err := net.DialTimeout("tcp", c.addr, 2*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+		c.rw = bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	}
+	c.conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	reply, err := c.roundTrip(args)
+	if err != nil {
+		// Drop the connection so the next command reconnects
+		c.conn.Close()
+		c.conn = nil
+	}
+	return reply, err
+}
+
+func (c *RedisResponseCache) roundTrip(args []string) ([]byte, error) {
+	fmt.Fprintf(c.rw, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.rw, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := c.rw.Flush(); err != nil {
+		return nil, err
+	}
+
+	line, err := c.rw.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, fmt.Errorf("redis error: %s", line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.rw, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	default:
+		return nil, fmt.Errorf("unexpected reply %q", line)
+	}
+}
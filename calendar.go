@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// Week completion states reported by the calendar
+const (
+	WeekStatusBreak      = "break"
+	WeekStatusNotStarted = "not_started"
+	WeekStatusPartial    = "partial"
+	WeekStatusCompleted  = "completed"
+)
+
+// CalendarWeek describes one week of the season schedule
+type CalendarWeek struct {
+	Week      int
+	Label     string
+	IsBreak   bool
+	Matches   int
+	Played    int
+	Status    string
+	Completed bool
+	Locked    bool // results can no longer be edited, see weekLockState
+
+	Kickoff      *time.Time `json:",omitempty"`       // the week's first kickoff, in UTC
+	LocalKickoff string     `json:",omitempty"`       // Kickoff in the client's time zone, set on responses
+	Links        sim.Links  `json:"_links,omitempty"` // the week's matches and its neighbours, set on responses
+}
+
+// buildCalendar lists every week up to the last fixture, labelling blank weeks as breaks
+func buildCalendar(league *sim.League) []CalendarWeek {
+	totalWeeks := 0
+	matchesPerWeek := make(map[int]int)
+	playedPerWeek := make(map[int]int)
+	firstKickoffs := make(map[int]*time.Time)
+	for _, match := range league.Matches {
+		matchesPerWeek[match.Week]++
+		if first := firstKickoffs[match.Week]; match.Kickoff != nil && (first == nil || match.Kickoff.Before(*first)) {
+			firstKickoffs[match.Week] = match.Kickoff
+		}
+		if match.Played {
+			playedPerWeek[match.Week]++
+		}
+		if match.Week > totalWeeks {
+			totalWeeks = match.Week
+		}
+	}
+
+	labels := sim.BreakWeeks(sim.Config().Breaks)
+	calendar := []CalendarWeek{}
+	round := 0
+	for week := 1; week <= totalWeeks; week++ {
+		entry := CalendarWeek{
+			Week:      week,
+			Matches:   matchesPerWeek[week],
+			Played:    playedPerWeek[week],
+			Completed: week <= league.CurrentWeek,
+			Kickoff:   firstKickoffs[week],
+		}
+
+		if entry.Matches == 0 {
+			entry.IsBreak = true
+			entry.Status = WeekStatusBreak
+			entry.Label = labels[week]
+			if entry.Label == "" {
+				entry.Label = "Break"
+			}
+		} else {
+			round++
+			entry.Label = fmt.Sprintf("Matchweek %d", round)
+			entry.Locked = weekLocked(league, week)
+			entry.Completed = entry.Played == entry.Matches
+			switch {
+			case entry.Completed:
+				entry.Status = WeekStatusCompleted
+			case entry.Played > 0:
+				entry.Status = WeekStatusPartial
+			default:
+				entry.Status = WeekStatusNotStarted
+			}
+		}
+
+		calendar = append(calendar, entry)
+	}
+
+	return calendar
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// defaultCalibrationSeasons is how many seasons `calibrate` simulates when not told
+const defaultCalibrationSeasons = 500
+
+// realDrawRate is roughly the share of draws in top-flight football
+const realDrawRate = 0.25
+
+// closeMatchupGap is the expected-goals gap under which a matchup counts as close
+const closeMatchupGap = 0.5
+
+// Calibration summarizes results over many simulated seasons with the active config
+type Calibration struct {
+	Seasons          int
+	Matches          int
+	DrawRate         float64
+	HomeWinRate      float64
+	AwayWinRate      float64
+	AverageGoals     float64
+	CloseMatches     int
+	CloseDrawRate    float64
+	DrawBiasAdjusted int // results changed by draw_bias
+}
+
+// calibrate simulates whole seasons in memory, without touching any database
+func calibrate(seasons int) Calibration {
+	calibration := Calibration{Seasons: seasons}
+	draws, homeWins, awayWins, goals, closeDraws := 0, 0, 0, 0, 0
+
+	for i := 0; i < seasons; i++ {
+		teams := sim.CreatePremierLeagueTeams()
+		league := &sim.League{Teams: teams, Matches: sim.CreatePremierLeagueMatches(teams)}
+		for sim.HasUnplayedMatches(league) {
+			sim.WeeklySimulator(league)
+		}
+
+		for _, match := range league.Matches {
+			calibration.Matches++
+			goals += match.HomeTeamScore + match.AwayTeamScore
+			isClose := math.Abs(match.HomeXG-match.AwayXG) < closeMatchupGap
+			if isClose {
+				calibration.CloseMatches++
+			}
+			if match.Explanation != nil && match.Explanation.DrawBiasApplied {
+				calibration.DrawBiasAdjusted++
+			}
+			switch {
+			case match.HomeTeamScore > match.AwayTeamScore:
+				homeWins++
+			case match.HomeTeamScore < match.AwayTeamScore:
+				awayWins++
+			default:
+				draws++
+				if isClose {
+					closeDraws++
+				}
+			}
+		}
+	}
+
+	if calibration.Matches > 0 {
+		count := float64(calibration.Matches)
+		calibration.DrawRate = float64(draws) / count
+		calibration.HomeWinRate = float64(homeWins) / count
+		calibration.AwayWinRate = float64(awayWins) / count
+		calibration.AverageGoals = float64(goals) / count
+	}
+	if calibration.CloseMatches > 0 {
+		calibration.CloseDrawRate = float64(closeDraws) / float64(calibration.CloseMatches)
+	}
+	return calibration
+}
+
+// runCalibration implements `./main calibrate [seasons]`
+func runCalibration(args []string) {
+	seasons := defaultCalibrationSeasons
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 1 {
+			fmt.Fprintf(os.Stderr, "Invalid number of seasons %q\n", args[0])
+			os.Exit(1)
+		}
+		seasons = parsed
+	}
+
+	calibration := calibrate(seasons)
+
+	fmt.Printf("╔══════════════════════════════════════════════════════════════╗\n")
+	fmt.Printf("║                    SIMULATION CALIBRATION                    ║\n")
+	fmt.Printf("╠══════════════════════════════════════════════════════════════╣\n")
+	fmt.Printf("║ Seasons simulated          %8d                          ║\n", calibration.Seasons)
+	fmt.Printf("║ Matches                    %8d                          ║\n", calibration.Matches)
+	fmt.Printf("║ Engine / draw_bias         %8s / %+.2f                   ║\n", sim.Config().Engine, sim.Config().DrawBias)
+	fmt.Printf("║ Home wins                  %7.1f%%                          ║\n", calibration.HomeWinRate*100)
+	fmt.Printf("║ Draws                      %7.1f%%   (real leagues ~%.0f%%)   ║\n", calibration.DrawRate*100, realDrawRate*100)
+	fmt.Printf("║ Away wins                  %7.1f%%                          ║\n", calibration.AwayWinRate*100)
+	fmt.Printf("║ Draws in close matchups    %7.1f%%   (%d matches)        ║\n", calibration.CloseDrawRate*100, calibration.CloseMatches)
+	fmt.Printf("║ Average goals per match    %8.2f                          ║\n", calibration.AverageGoals)
+	fmt.Printf("║ Results changed by bias    %8d                          ║\n", calibration.DrawBiasAdjusted)
+	fmt.Printf("╚══════════════════════════════════════════════════════════════╝\n")
+
+	switch gap := calibration.DrawRate - realDrawRate; {
+	case gap < -0.03:
+		fmt.Printf("\nThe model under-produces draws; raise draw_bias (currently %+.2f).\n", sim.Config().DrawBias)
+	case gap > 0.03:
+		fmt.Printf("\nThe model over-produces draws; lower draw_bias (currently %+.2f).\n", sim.Config().DrawBias)
+	default:
+		fmt.Printf("\nThe draw rate is within 3 points of real leagues.\n")
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// Chat webhook formats
+const (
+	ChatFormatDiscord = "discord"
+	ChatFormatSlack   = "slack"
+)
+
+// titleRaceSize is how many contenders a week update lists
+const titleRaceSize = 3
+
+// WeekUpdate is what a chat channel hears after a simulated week
+type WeekUpdate struct {
+	Season    int
+	Week      int
+	Results   []*sim.Match
+	Table     []*sim.LeagueTableEntry
+	TitleRace []TitleContender   // championship chances, most likely first
+	Champion  string             `json:",omitempty"` // set once the season is over
+	Playoff   *sim.PlayoffStatus `json:",omitempty"` // set when the title goes to a play-off
+}
+
+// TitleContender is one team's chance of winning the league
+type TitleContender struct {
+	TeamName   string
+	Percentage float64
+}
+
+// WeekNotifier posts week updates to a chat channel. Implementations should return
+// quickly; notifying happens on the simulation path.
+type WeekNotifier interface {
+	NotifyWeek(ctx context.Context, update *WeekUpdate)
+}
+
+// weekNotifier receives every simulated week; nil sends nothing
+var weekNotifier WeekNotifier
+
+// chatWebhookNotifier posts week updates to a Discord or Slack incoming webhook
+type chatWebhookNotifier struct {
+	url    string
+	format string
+	client *http.Client
+}
+
+// newWeekNotifier returns a webhook notifier when LEAGUE_CHAT_WEBHOOK_URL is set. The
+// format comes from LEAGUE_CHAT_WEBHOOK_FORMAT, or from the URL when unset: Slack for
+// hooks.slack.com, Discord otherwise.
+func newWeekNotifier() (WeekNotifier, error) {
+	url := os.Getenv("LEAGUE_CHAT_WEBHOOK_URL")
+	if url == "" {
+		return nil, nil
+	}
+
+	format := os.Getenv("LEAGUE_CHAT_WEBHOOK_FORMAT")
+	switch format {
+	case "":
+		format = ChatFormatDiscord
+		if strings.Contains(url, "hooks.slack.com") {
+			format = ChatFormatSlack
+		}
+	case ChatFormatDiscord, ChatFormatSlack:
+	default:
+		return nil, fmt.Errorf("LEAGUE_CHAT_WEBHOOK_FORMAT must be %q or %q", ChatFormatDiscord, ChatFormatSlack)
+	}
+	return &chatWebhookNotifier{url: url, format: format, client: &http.Client{Timeout: 5 * time.Second}}, nil
+}
+
+func (n *chatWebhookNotifier) NotifyWeek(ctx context.Context, update *WeekUpdate) {
+	var payload interface{}
+	if n.format == ChatFormatSlack {
+		payload = slackWeekPayload(update)
+	} else {
+		payload = discordWeekPayload(update)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Failed to encode week %d update: %v", update.Week, err)
+		return
+	}
+
+	// Sent in the background so a slow chat service never holds up the simulation
+	go func() {
+		resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("Failed to post week %d update: %v", update.Week, err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("Chat webhook rejected week %d update: %s", update.Week, resp.Status)
+		}
+	}()
+}
+
+// notifyWeek hands the week just played to the configured notifier
+func notifyWeek(ctx context.Context, league *sim.League, results []*sim.Match) {
+	// A hosted tenant's results are not the operator's channel's business
+	if weekNotifier == nil || len(results) == 0 || tenantRegistry != nil {
+		return
+	}
+	weekNotifier.NotifyWeek(ctx, newWeekUpdate(league, results))
+}
+
+// newWeekUpdate describes the week just played: its results, the table and the title
+// race, or how the title was decided once the season is over
+func newWeekUpdate(league *sim.League, results []*sim.Match) *WeekUpdate {
+	update := &WeekUpdate{
+		Season:  league.Season,
+		Results: results,
+		Table:   league.LeagueTable,
+	}
+	if len(results) > 0 {
+		update.Week = results[len(results)-1].Week
+	}
+
+	finished := true
+	for _, match := range league.Matches {
+		if !match.Played {
+			finished = false
+			break
+		}
+	}
+	if finished && len(league.LeagueTable) > 0 {
+		if playoff := sim.BuildStandings(league, false).Playoff; playoff != nil && playoff.Required {
+			update.Playoff = playoff
+		} else {
+			update.Champion = league.LeagueTable[0].TeamName
+		}
+		return update
+	}
+
+	for name, percentage := range sim.PredictChampionship(league) {
+		if percentage > 0 {
+			update.TitleRace = append(update.TitleRace, TitleContender{TeamName: name, Percentage: percentage})
+		}
+	}
+	sort.Slice(update.TitleRace, func(i, j int) bool {
+		if update.TitleRace[i].Percentage != update.TitleRace[j].Percentage {
+			return update.TitleRace[i].Percentage > update.TitleRace[j].Percentage
+		}
+		return update.TitleRace[i].TeamName < update.TitleRace[j].TeamName
+	})
+	if len(update.TitleRace) > titleRaceSize {
+		update.TitleRace = update.TitleRace[:titleRaceSize]
+	}
+	return update
+}
+
+// formatWeekResults lists the week's scores, one match per line
+func formatWeekResults(update *WeekUpdate) string {
+	lines := []string{}
+	for _, match := range update.Results {
+		lines = append(lines, fmt.Sprintf("%s %d-%d %s", match.HomeTeam.TeamName, match.HomeTeamScore, match.AwayTeamScore, match.AwayTeam.TeamName))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatTableText renders the table in fixed-width columns for a code block
+func formatTableText(table []*sim.LeagueTableEntry) string {
+	width := len("Team")
+	for _, entry := range table {
+		width = max(width, len([]rune(entry.TeamName)))
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%-3s %-*s %3s %4s %4s\n", "#", width, "Team", "P", "GD", "Pts")
+	for _, entry := range table {
+		fmt.Fprintf(&text, "%-3d %-*s %3d %+4d %4d\n", entry.Position, width, entry.TeamName, entry.Played, entry.GoalsDifference, entry.Points)
+	}
+	return strings.TrimRight(text.String(), "\n")
+}
+
+// formatTitleRace lists the leading contenders' championship chances, or how the
+// finished season's title was decided
+func formatTitleRace(update *WeekUpdate) string {
+	switch {
+	case update.Champion != "":
+		return fmt.Sprintf("%s are champions!", update.Champion)
+	case update.Playoff != nil:
+		return fmt.Sprintf("%s: %s host %s in a title play-off", update.Playoff.Reason, update.Playoff.HomeTeam, update.Playoff.AwayTeam)
+	}
+	lines := []string{}
+	for _, contender := range update.TitleRace {
+		lines = append(lines, fmt.Sprintf("%s %.1f%%", contender.TeamName, contender.Percentage))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hasTitleNews reports whether a week update has anything to say about the title
+func hasTitleNews(update *WeekUpdate) bool {
+	return len(update.TitleRace) > 0 || update.Champion != "" || update.Playoff != nil
+}
+
+// weekUpdateTitle heads a week update
+func weekUpdateTitle(update *WeekUpdate) string {
+	return fmt.Sprintf("Season %d, week %d results", update.Season, update.Week)
+}
+
+// discordWeekPayload formats a week update as a Discord webhook message with one embed
+func discordWeekPayload(update *WeekUpdate) map[string]interface{} {
+	fields := []map[string]interface{}{
+		{"name": "Table", "value": "```\n" + formatTableText(update.Table) + "\n```"},
+	}
+	if hasTitleNews(update) {
+		fields = append(fields, map[string]interface{}{"name": "Title race", "value": formatTitleRace(update)})
+	}
+	return map[string]interface{}{
+		"username": "League Simulator",
+		"embeds": []map[string]interface{}{{
+			"title":       weekUpdateTitle(update),
+			"description": formatWeekResults(update),
+			"fields":      fields,
+		}},
+	}
+}
+
+// slackWeekPayload formats a week update as a Slack webhook message with blocks
+func slackWeekPayload(update *WeekUpdate) map[string]interface{} {
+	section := func(text string) map[string]interface{} {
+		return map[string]interface{}{"type": "section", "text": map[string]interface{}{"type": "mrkdwn", "text": text}}
+	}
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": map[string]interface{}{"type": "plain_text", "text": weekUpdateTitle(update)}},
+		section(formatWeekResults(update)),
+		section("```" + formatTableText(update.Table) + "```"),
+	}
+	if hasTitleNews(update) {
+		blocks = append(blocks, section("*Title race*\n"+formatTitleRace(update)))
+	}
+	return map[string]interface{}{
+		"text":   weekUpdateTitle(update), // notification fallback
+		"blocks": blocks,
+	}
+}
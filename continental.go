@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// continentalGroupSize is the most teams drawn into one continental group
+const continentalGroupSize = 4
+
+// ContinentalEntrant is a team that earned a continental place with its league finish
+type ContinentalEntrant struct {
+	Competition string
+	Season      int // season the competition is played in, the one after qualifying
+	TeamId      int
+	TeamName    string
+	Position    int // league position that earned the place
+}
+
+// ContinentalCompetition lists a season's entrants for one continental competition
+type ContinentalCompetition struct {
+	Name     string
+	Season   int
+	Entrants []*ContinentalEntrant
+	Played   bool
+}
+
+// ContinentalGroup is one group of a continental competition's group stage
+type ContinentalGroup struct {
+	Name    string
+	Table   []*sim.LeagueTableEntry
+	Matches []*sim.ShowpieceMatch
+}
+
+// ContinentalResult is a played continental competition
+type ContinentalResult struct {
+	Competition string
+	Season      int
+	Groups      []*ContinentalGroup
+	Knockout    []*sim.ShowpieceMatch
+	Winner      string
+}
+
+// continentalQualifiers lists the teams whose final position falls in a qualification
+// spot with a continental competition, as entrants for the given season
+func continentalQualifiers(league *sim.League, standings []*sim.LeagueTableEntry, season int) []*ContinentalEntrant {
+	entrants := []*ContinentalEntrant{}
+	for _, entry := range standings {
+		for _, spot := range sim.Rules().Qualification {
+			if spot.Continental == "" || entry.Position < spot.From || entry.Position > spot.To {
+				continue
+			}
+			entrant := &ContinentalEntrant{
+				Competition: spot.Continental,
+				Season:      season,
+				TeamName:    entry.TeamName,
+				Position:    entry.Position,
+			}
+			for _, team := range league.Teams {
+				if team.TeamName == entry.TeamName {
+					entrant.TeamId = team.TeamId
+				}
+			}
+			entrants = append(entrants, entrant)
+			break
+		}
+	}
+	sort.SliceStable(entrants, func(i, j int) bool { return entrants[i].Position < entrants[j].Position })
+	return entrants
+}
+
+// continentalCompetitions groups a season's entrants by competition, in qualifying order
+func continentalCompetitions(entrants []*ContinentalEntrant, season int) []*ContinentalCompetition {
+	competitions := []*ContinentalCompetition{}
+	byName := make(map[string]*ContinentalCompetition)
+	for _, entrant := range entrants {
+		competition := byName[entrant.Competition]
+		if competition == nil {
+			competition = &ContinentalCompetition{Name: entrant.Competition, Season: season}
+			byName[entrant.Competition] = competition
+			competitions = append(competitions, competition)
+		}
+		competition.Entrants = append(competition.Entrants, entrant)
+	}
+	return competitions
+}
+
+// playContinentalCompetition plays a group stage of up to four teams per group, the
+// entrants being dealt into groups in qualifying order, then knockout rounds between
+// the top two of each group. Rounds before the final are two-legged ties under the
+// default tie rules, the seeded side (group winners first) hosting the second leg.
+// The final is a single match at a neutral venue named after the competition, so its
+// winner and runner-up are recorded as its honours.
+func playContinentalCompetition(league *sim.League, competition *ContinentalCompetition) (*ContinentalResult, error) {
+	teamsById := make(map[int]*sim.Team)
+	for _, team := range league.Teams {
+		teamsById[team.TeamId] = team
+	}
+	entrants := []*sim.Team{}
+	for _, entrant := range competition.Entrants {
+		if team := teamsById[entrant.TeamId]; team != nil {
+			entrants = append(entrants, team)
+		}
+	}
+	if len(entrants) < 2 {
+		return nil, fmt.Errorf("%s needs at least 2 entrants still in the league", competition.Name)
+	}
+
+	result := &ContinentalResult{Competition: competition.Name, Season: competition.Season}
+	groupCount := (len(entrants) + continentalGroupSize - 1) / continentalGroupSize
+	winners, runnersUp := []*sim.Team{}, []*sim.Team{}
+	for g := 0; g < groupCount; g++ {
+		members := []*sim.Team{}
+		for i := g; i < len(entrants); i += groupCount {
+			members = append(members, entrants[i])
+		}
+
+		group := &ContinentalGroup{Name: fmt.Sprintf("Group %c", 'A'+g)}
+		for _, fixtures := range sim.RoundRobinRounds(len(members)) {
+			for _, fixture := range fixtures {
+				match, err := playShowpieceMatch(league, competition.Name+" "+group.Name, members[fixture[0]].TeamId, members[fixture[1]].TeamId, false, "")
+				if err != nil {
+					return nil, err
+				}
+				group.Matches = append(group.Matches, match)
+			}
+		}
+		group.Table = continentalGroupTable(members, group.Matches)
+		result.Groups = append(result.Groups, group)
+
+		winners = append(winners, teamByName(members, group.Table[0].TeamName))
+		if len(group.Table) > 1 {
+			runnersUp = append(runnersUp, teamByName(members, group.Table[1].TeamName))
+		}
+	}
+
+	// Seeds play the lowest remaining seed; with an odd number left the top seed has a bye
+	remaining := append(winners, runnersUp...)
+	for len(remaining) > 1 {
+		next := []*sim.Team{}
+		if len(remaining)%2 == 1 {
+			next = append(next, remaining[0])
+			remaining = remaining[1:]
+		}
+		// A round is named by the teams in it, a side with a bye included
+		teams := len(next) + len(remaining)
+		name, venue := competition.Name+" "+knockoutRoundName(teams+teams%2), ""
+		if len(next)+len(remaining)/2 == 1 {
+			name, venue = competition.Name, sim.VenueNeutral
+		}
+		for i := 0; i < len(remaining)/2; i++ {
+			home, away := remaining[i], remaining[len(remaining)-1-i]
+			if venue == sim.VenueNeutral {
+				match, err := playShowpieceMatch(league, name, home.TeamId, away.TeamId, true, venue)
+				if err != nil {
+					return nil, err
+				}
+				result.Knockout = append(result.Knockout, match)
+				next = append(next, teamByName(remaining, match.WinnerName))
+				continue
+			}
+
+			legs, tie, err := playTwoLeggedTie(league, name, home, away, DefaultTieRules())
+			if err != nil {
+				return nil, err
+			}
+			result.Knockout = append(result.Knockout, legs...)
+			next = append(next, tie.Winner)
+		}
+		remaining = next
+	}
+	result.Winner = remaining[0].TeamName
+	return result, nil
+}
+
+// knockoutRoundName names a knockout round by the number of teams in it
+func knockoutRoundName(teams int) string {
+	switch teams {
+	case 2:
+		return "Final"
+	case 4:
+		return "Semi-final"
+	case 8:
+		return "Quarter-final"
+	}
+	return fmt.Sprintf("Round of %d", teams)
+}
+
+// teamByName finds a team in a list by name
+func teamByName(teams []*sim.Team, name string) *sim.Team {
+	for _, team := range teams {
+		if team.TeamName == name {
+			return team
+		}
+	}
+	return nil
+}
+
+// continentalGroupTable ranks a group by points, goal difference, goals scored and
+// name. Continental matches are football, so results earn football's points.
+func continentalGroupTable(members []*sim.Team, matches []*sim.ShowpieceMatch) []*sim.LeagueTableEntry {
+	sport, _ := sim.LookupSport(sim.SportFootball)
+	entries := make(map[string]*sim.LeagueTableEntry)
+	table := []*sim.LeagueTableEntry{}
+	for _, team := range members {
+		entry := &sim.LeagueTableEntry{TeamName: team.TeamName, TeamBranding: team.TeamBranding}
+		entries[team.TeamName] = entry
+		table = append(table, entry)
+	}
+
+	record := func(entry *sim.LeagueTableEntry, goalsFor, goalsAgainst int) {
+		entry.Played++
+		entry.GoalsFor += goalsFor
+		entry.GoalsAgainst += goalsAgainst
+		entry.GoalsDifference = entry.GoalsFor - entry.GoalsAgainst
+		points, _ := sport.ResultPoints(float64(goalsFor-goalsAgainst), false)
+		entry.Points += points
+		switch {
+		case goalsFor > goalsAgainst:
+			entry.Wins++
+		case goalsFor == goalsAgainst:
+			entry.Draws++
+		default:
+			entry.Losses++
+		}
+	}
+	for _, match := range matches {
+		record(entries[match.HomeTeam.TeamName], match.HomeTeamScore, match.AwayTeamScore)
+		record(entries[match.AwayTeam.TeamName], match.AwayTeamScore, match.HomeTeamScore)
+	}
+
+	sort.SliceStable(table, func(i, j int) bool {
+		a, b := table[i], table[j]
+		switch {
+		case a.Points != b.Points:
+			return a.Points > b.Points
+		case a.GoalsDifference != b.GoalsDifference:
+			return a.GoalsDifference > b.GoalsDifference
+		case a.GoalsFor != b.GoalsFor:
+			return a.GoalsFor > b.GoalsFor
+		}
+		return a.TeamName < b.TeamName
+	})
+	for i, entry := range table {
+		entry.Position = i + 1
+	}
+	return table
+}
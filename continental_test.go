@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// continentalLeague is a league of the given number of teams, each entered in one competition
+func continentalLeague(t *testing.T, teams int) (*sim.League, *ContinentalCompetition) {
+	t.Helper()
+	members := []*sim.Team{}
+	for i := 1; i <= teams; i++ {
+		members = append(members, &sim.Team{TeamId: i, TeamName: fmt.Sprintf("Team %d", i), TeamStrength: 50 + i})
+	}
+	engine, err := sim.NewEngine(sim.WithTeams(members...), sim.WithSeed(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	competition := &ContinentalCompetition{Name: "Champions Cup", Season: 1}
+	for i, team := range members {
+		competition.Entrants = append(competition.Entrants, &ContinentalEntrant{Competition: competition.Name, TeamId: team.TeamId, TeamName: team.TeamName, Position: i + 1})
+	}
+	return engine.League(), competition
+}
+
+func TestContinentalKnockoutRounds(t *testing.T) {
+	tests := []struct {
+		entrants int
+		want     []string // knockout matches by name, both legs of a tie listed
+	}{
+		// Two groups send four teams through: two-legged semi-finals, then the final
+		{entrants: 8, want: []string{"Champions Cup Semi-final", "Champions Cup Semi-final", "Champions Cup Semi-final", "Champions Cup Semi-final", "Champions Cup"}},
+		// Three groups send six teams through, and the top seed of the last three has a
+		// bye in the semi-final
+		{entrants: 9, want: []string{
+			"Champions Cup Round of 6", "Champions Cup Round of 6", "Champions Cup Round of 6",
+			"Champions Cup Round of 6", "Champions Cup Round of 6", "Champions Cup Round of 6",
+			"Champions Cup Semi-final", "Champions Cup Semi-final", "Champions Cup",
+		}},
+		// One group sends two teams straight to the final
+		{entrants: 3, want: []string{"Champions Cup"}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d entrants", test.entrants), func(t *testing.T) {
+			league, competition := continentalLeague(t, test.entrants)
+			result, err := playContinentalCompetition(league, competition)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := []string{}
+			for _, match := range result.Knockout {
+				names = append(names, match.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(test.want) {
+				t.Fatalf("knockout matches %q, want %q", names, test.want)
+			}
+			final := result.Knockout[len(result.Knockout)-1]
+			if final.WinnerName != result.Winner {
+				t.Errorf("the final was won by %q but the competition by %q", final.WinnerName, result.Winner)
+			}
+		})
+	}
+}
+
+func TestContinentalGroupTable(t *testing.T) {
+	a, b, c := &sim.Team{TeamName: "A"}, &sim.Team{TeamName: "B"}, &sim.Team{TeamName: "C"}
+	matches := []*sim.ShowpieceMatch{
+		{HomeTeam: a, AwayTeam: b, HomeTeamScore: 1, AwayTeamScore: 1},
+		{HomeTeam: c, AwayTeam: a, HomeTeamScore: 0, AwayTeamScore: 2},
+		{HomeTeam: b, AwayTeam: c, HomeTeamScore: 3, AwayTeamScore: 0},
+	}
+	table := continentalGroupTable([]*sim.Team{a, b, c}, matches)
+
+	// A and B are level on football's points, B ahead on goal difference
+	want := []struct {
+		name   string
+		points int
+	}{{"B", 4}, {"A", 4}, {"C", 0}}
+	for i, entry := range table {
+		if entry.TeamName != want[i].name || entry.Points != want[i].points || entry.Position != i+1 {
+			t.Errorf("position %d is %s on %d points, want %s on %d", entry.Position, entry.TeamName, entry.Points, want[i].name, want[i].points)
+		}
+	}
+}
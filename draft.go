@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// startDraftClockJob makes the picks whose time has run out, so a draft moves on
+// without anyone having to call the API
+func startDraftClockJob(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			eachScope(func(scope *leagueScope) {
+				if !draftPickOverdue(scope) {
+					return
+				}
+				unlock, err := scope.lockLeague()
+				if err != nil {
+					log.Printf("Failed to lock the league for the draft clock: %v", err)
+					return
+				}
+				defer unlock()
+				// Locking may have reloaded the league
+				if err := NewLeagueSimulatorService(scope.League(), scope.storage).AdvanceDraft(); err != nil {
+					log.Printf("Failed to make the draft's timed-out picks: %v", err)
+				}
+			})
+		}
+	}()
+}
+
+// draftPickOverdue reports whether the scope's draft has a pick whose time has run out
+func draftPickOverdue(scope *leagueScope) bool {
+	league, done := scope.readLeague()
+	defer done()
+	if !sim.DraftInProgress(league) {
+		return false
+	}
+	turn := sim.CurrentDraftTurn(league, league.Draft)
+	return turn.Deadline != nil && !time.Now().Before(*turn.Deadline)
+}
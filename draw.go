@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// DrawEntrant is a team entering a cup draw with its seeding pot and previous group
+type DrawEntrant struct {
+	TeamId int    `json:"team_id"`
+	Group  string `json:"group"`
+}
+
+// DrawnTie is one pairing produced by the draw
+type DrawnTie struct {
+	TieNumber    int
+	HomeTeamId   int
+	HomeTeamName string
+	AwayTeamId   int
+	AwayTeamName string
+}
+
+// CupDraw is the persisted bracket of a competition round
+type CupDraw struct {
+	CompetitionId string
+	Round         string
+	Ties          []DrawnTie
+}
+
+// maxDrawPotSize is the most teams a pot may hold
+const maxDrawPotSize = 64
+
+// drawTies pairs entrants so that teams from the same group never meet.
+// With two pots each seeded team (pot 1) meets an unseeded team (pot 2) and the
+// unseeded team plays the first leg at home; a single pot is paired freely.
+func drawTies(pots [][]DrawEntrant, rng *sim.PCG) ([][2]DrawEntrant, error) {
+	switch len(pots) {
+	case 1:
+		if len(pots[0])%2 != 0 {
+			return nil, fmt.Errorf("a single pot needs an even number of teams")
+		}
+		entrants := shuffledEntrants(pots[0], rng)
+		pairs, ok := pairWithinPot(entrants, nil)
+		if !ok {
+			return nil, fmt.Errorf("no valid draw satisfies the group constraint")
+		}
+		return pairs, nil
+	case 2:
+		if len(pots[0]) != len(pots[1]) {
+			return nil, fmt.Errorf("seeded and unseeded pots must be the same size")
+		}
+		seeded := shuffledEntrants(pots[0], rng)
+		unseeded := shuffledEntrants(pots[1], rng)
+		pairs, ok := pairAcrossPots(seeded, unseeded, make([]bool, len(unseeded)), nil)
+		if !ok {
+			return nil, fmt.Errorf("no valid draw satisfies the group constraint")
+		}
+		return pairs, nil
+	default:
+		return nil, fmt.Errorf("draw supports one or two pots, got %d", len(pots))
+	}
+}
+
+func shuffledEntrants(entrants []DrawEntrant, rng *sim.PCG) []DrawEntrant {
+	shuffled := append([]DrawEntrant(nil), entrants...)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+// canMeet reports whether two entrants may be drawn together
+func canMeet(a, b DrawEntrant) bool {
+	return a.Group == "" || a.Group != b.Group
+}
+
+// groupsFit reports whether no group has more than limit of the entrants. Entrants
+// left to draw can all be paired if no group has more than half of a single pot, or
+// more across two pots than one of them holds.
+func groupsFit(entrants []DrawEntrant, limit int) bool {
+	counts := make(map[string]int)
+	for _, entrant := range entrants {
+		if entrant.Group == "" {
+			continue
+		}
+		counts[entrant.Group]++
+		if counts[entrant.Group] > limit {
+			return false
+		}
+	}
+	return true
+}
+
+// pairAcrossPots pairs each seeded team in turn with the first unseeded team that
+// leaves the rest drawable, so the search never has to back out of a dead end
+func pairAcrossPots(seeded, unseeded []DrawEntrant, used []bool, pairs [][2]DrawEntrant) ([][2]DrawEntrant, bool) {
+	if len(pairs) == len(seeded) {
+		return pairs, true
+	}
+
+	current := seeded[len(pairs)]
+	for i, candidate := range unseeded {
+		if used[i] || !canMeet(current, candidate) {
+			continue
+		}
+		used[i] = true
+		rest := append([]DrawEntrant(nil), seeded[len(pairs)+1:]...)
+		for j, entrant := range unseeded {
+			if !used[j] {
+				rest = append(rest, entrant)
+			}
+		}
+		if groupsFit(rest, len(rest)/2) {
+			return pairAcrossPots(seeded, unseeded, used, append(pairs, [2]DrawEntrant{candidate, current}))
+		}
+		used[i] = false
+	}
+	return nil, false
+}
+
+// pairWithinPot pairs the first team of a single shuffled pot with the first team
+// that leaves the rest drawable, and so on through the pot
+func pairWithinPot(remaining []DrawEntrant, pairs [][2]DrawEntrant) ([][2]DrawEntrant, bool) {
+	if len(remaining) == 0 {
+		return pairs, true
+	}
+
+	home := remaining[0]
+	for i := 1; i < len(remaining); i++ {
+		if !canMeet(home, remaining[i]) {
+			continue
+		}
+		rest := make([]DrawEntrant, 0, len(remaining)-2)
+		rest = append(rest, remaining[1:i]...)
+		rest = append(rest, remaining[i+1:]...)
+		if groupsFit(rest, len(rest)/2) {
+			return pairWithinPot(rest, append(pairs, [2]DrawEntrant{home, remaining[i]}))
+		}
+	}
+	return nil, false
+}
+
+// performCupDraw runs the draw for a competition round against the league's teams
+func performCupDraw(league *sim.League, competitionId, round string, pots [][]DrawEntrant, rng *sim.PCG) (*CupDraw, error) {
+	teamsById := make(map[int]*sim.Team)
+	for _, team := range league.Teams {
+		teamsById[team.TeamId] = team
+	}
+
+	seen := make(map[int]bool)
+	for _, pot := range pots {
+		for _, entrant := range pot {
+			if teamsById[entrant.TeamId] == nil {
+				return nil, fmt.Errorf("unknown team %d", entrant.TeamId)
+			}
+			if seen[entrant.TeamId] {
+				return nil, fmt.Errorf("team %d appears more than once", entrant.TeamId)
+			}
+			seen[entrant.TeamId] = true
+		}
+	}
+
+	pairs, err := drawTies(pots, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	draw := &CupDraw{CompetitionId: competitionId, Round: round}
+	for i, pair := range pairs {
+		home := teamsById[pair[0].TeamId]
+		away := teamsById[pair[1].TeamId]
+		draw.Ties = append(draw.Ties, DrawnTie{
+			TieNumber:    i + 1,
+			HomeTeamId:   home.TeamId,
+			HomeTeamName: home.TeamName,
+			AwayTeamId:   away.TeamId,
+			AwayTeamName: away.TeamName,
+		})
+	}
+	return draw, nil
+}
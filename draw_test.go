@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// drawPot is a pot of teams numbered from first, in the groups given
+func drawPot(first int, groups ...string) []DrawEntrant {
+	pot := []DrawEntrant{}
+	for i, group := range groups {
+		pot = append(pot, DrawEntrant{TeamId: first + i, Group: group})
+	}
+	return pot
+}
+
+// spreadGroups is n groups named by letter in turn, so no group holds more than its share
+func spreadGroups(n, groups int) []string {
+	names := []string{}
+	for i := 0; i < n; i++ {
+		names = append(names, string(rune('A'+i%groups)))
+	}
+	return names
+}
+
+// sameGroup is n entrants' groups, all the same
+func sameGroup(n int, group string) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = group
+	}
+	return names
+}
+
+func TestDrawTies(t *testing.T) {
+	tests := []struct {
+		name    string
+		pots    [][]DrawEntrant
+		wantErr string
+	}{
+		{name: "single pot", pots: [][]DrawEntrant{drawPot(1, "A", "A", "B", "B")}},
+		{name: "single pot, half in one group", pots: [][]DrawEntrant{drawPot(1, "A", "A", "A", "B", "C", "")}},
+		{name: "two pots", pots: [][]DrawEntrant{drawPot(1, "A", "B"), drawPot(3, "A", "B")}},
+		{name: "two pots, a group filling a pot", pots: [][]DrawEntrant{drawPot(1, "A", "A", "A"), drawPot(4, "B", "C", "")}},
+		{name: "a full pot of groups", pots: [][]DrawEntrant{drawPot(1, spreadGroups(maxDrawPotSize, 8)...)}},
+		{name: "two full pots of groups", pots: [][]DrawEntrant{drawPot(1, spreadGroups(maxDrawPotSize, 8)...), drawPot(101, spreadGroups(maxDrawPotSize, 8)...)}},
+		{name: "single pot, over half in one group", pots: [][]DrawEntrant{drawPot(1, "A", "A", "A", "B")}, wantErr: "no valid draw"},
+		{name: "two pots, a group in more than a pot's worth", pots: [][]DrawEntrant{drawPot(1, "A", "A", "B"), drawPot(4, "A", "A", "C")}, wantErr: "no valid draw"},
+		// A dead end only found after trying every pairing of the rest, if the search
+		// did not look ahead
+		{name: "a full pot, over half in one group", pots: [][]DrawEntrant{drawPot(1, append(sameGroup(maxDrawPotSize/2-1, ""), sameGroup(maxDrawPotSize/2+1, "A")...)...)}, wantErr: "no valid draw"},
+		{name: "odd pot", pots: [][]DrawEntrant{drawPot(1, "", "", "")}, wantErr: "even number"},
+		{name: "uneven pots", pots: [][]DrawEntrant{drawPot(1, "", ""), drawPot(3, "")}, wantErr: "same size"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for seed := int64(1); seed <= 5; seed++ {
+				start := time.Now()
+				pairs, err := drawTies(test.pots, sim.NewPCG(seed))
+				if elapsed := time.Since(start); elapsed > time.Second {
+					t.Fatalf("the draw took %v", elapsed)
+				}
+				if test.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+						t.Fatalf("draw returned %v, want an error containing %q", err, test.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				drawn := make(map[int]bool)
+				for _, pair := range pairs {
+					if !canMeet(pair[0], pair[1]) {
+						t.Errorf("seed %d drew %d and %d of group %s together", seed, pair[0].TeamId, pair[1].TeamId, pair[0].Group)
+					}
+					drawn[pair[0].TeamId], drawn[pair[1].TeamId] = true, true
+				}
+				for _, pot := range test.pots {
+					for _, entrant := range pot {
+						if !drawn[entrant.TeamId] {
+							t.Errorf("seed %d left team %d out of the draw", seed, entrant.TeamId)
+						}
+					}
+				}
+				if len(test.pots) == 2 {
+					for _, pair := range pairs {
+						if pair[0].TeamId <= len(test.pots[0]) {
+							t.Errorf("seed %d has seeded team %d hosting the first leg", seed, pair[0].TeamId)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDrawTiesIsReproducible(t *testing.T) {
+	pots := [][]DrawEntrant{drawPot(1, spreadGroups(16, 4)...)}
+	first, err := drawTies(pots, sim.NewPCG(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := drawTies(pots, sim.NewPCG(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(first) != fmt.Sprint(again) {
+		t.Errorf("the same seed drew %v, then %v", first, again)
+	}
+}
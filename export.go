@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// defaultExportSeasons and defaultExportOutput are used by `export-matches` when not told otherwise
+const (
+	defaultExportSeasons = 100
+	defaultExportOutput  = "matches.csv"
+)
+
+// MatchRecord is one simulated match's inputs and outputs, flattened for analysis
+type MatchRecord struct {
+	Season          int     `json:"season"`
+	Seed            int64   `json:"seed"`
+	MatchId         int     `json:"match_id"`
+	Week            int     `json:"week"`
+	HomeTeam        string  `json:"home_team"`
+	AwayTeam        string  `json:"away_team"`
+	Engine          string  `json:"engine"`
+	Features        string  `json:"features"` // experimental models used, separated by ';'
+	Venue           string  `json:"venue"`
+	HomeRating      float64 `json:"home_rating"`
+	AwayRating      float64 `json:"away_rating"`
+	HomeAdvantage   float64 `json:"home_advantage"`
+	HomeStrength    float64 `json:"home_strength"`
+	AwayStrength    float64 `json:"away_strength"`
+	HomeXG          float64 `json:"home_xg"`
+	AwayXG          float64 `json:"away_xg"`
+	HomeScore       int     `json:"home_score"`
+	AwayScore       int     `json:"away_score"`
+	DrawBiasApplied bool    `json:"draw_bias_applied"`
+}
+
+// matchRecordColumns names MatchRecord's fields in values order
+var matchRecordColumns = []string{
+	"season", "seed", "match_id", "week", "home_team", "away_team", "engine", "features", "venue",
+	"home_rating", "away_rating", "home_advantage", "home_strength", "away_strength",
+	"home_xg", "away_xg", "home_score", "away_score", "draw_bias_applied",
+}
+
+// values renders a record's fields as text, in matchRecordColumns order
+func (r *MatchRecord) values() []string {
+	number := func(value float64) string { return strconv.FormatFloat(value, 'f', -1, 64) }
+	return []string{
+		strconv.Itoa(r.Season), strconv.FormatInt(r.Seed, 10), strconv.Itoa(r.MatchId), strconv.Itoa(r.Week),
+		r.HomeTeam, r.AwayTeam, r.Engine, r.Features, r.Venue,
+		number(r.HomeRating), number(r.AwayRating), number(r.HomeAdvantage), number(r.HomeStrength), number(r.AwayStrength),
+		number(r.HomeXG), number(r.AwayXG), strconv.Itoa(r.HomeScore), strconv.Itoa(r.AwayScore), strconv.FormatBool(r.DrawBiasApplied),
+	}
+}
+
+// newMatchRecord flattens a simulated match of a seeded season
+func newMatchRecord(season int, seed int64, match *sim.Match) *MatchRecord {
+	record := &MatchRecord{
+		Season:    season,
+		Seed:      seed,
+		MatchId:   match.MatchId,
+		Week:      match.Week,
+		HomeTeam:  match.HomeTeam.TeamName,
+		AwayTeam:  match.AwayTeam.TeamName,
+		Features:  strings.Join(match.Features, ";"),
+		Venue:     match.Venue,
+		HomeXG:    match.HomeXG,
+		AwayXG:    match.AwayXG,
+		HomeScore: match.HomeTeamScore,
+		AwayScore: match.AwayTeamScore,
+	}
+	if explanation := match.Explanation; explanation != nil {
+		record.Engine = explanation.Engine
+		record.HomeRating = explanation.HomeRating
+		record.AwayRating = explanation.AwayRating
+		record.HomeAdvantage = explanation.HomeAdvantage
+		record.HomeStrength = explanation.HomeStrength
+		record.AwayStrength = explanation.AwayStrength
+		record.DrawBiasApplied = explanation.DrawBiasApplied
+	}
+	return record
+}
+
+// MatchRecordWriter writes match records in one file format
+type MatchRecordWriter interface {
+	Write(record *MatchRecord) error
+	// Close flushes buffered records; it does not close the underlying writer
+	Close() error
+}
+
+// matchRecordWriters creates a writer per output file extension. Register another
+// format here, e.g. a parquet writer under ".parquet", to export it.
+var matchRecordWriters = map[string]func(w io.Writer) (MatchRecordWriter, error){
+	".csv":   newCSVRecordWriter,
+	".jsonl": newJSONLinesRecordWriter,
+}
+
+// csvRecordWriter writes a header row, then one row per record
+type csvRecordWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVRecordWriter(w io.Writer) (MatchRecordWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(matchRecordColumns); err != nil {
+		return nil, err
+	}
+	return &csvRecordWriter{writer: writer}, nil
+}
+
+func (c *csvRecordWriter) Write(record *MatchRecord) error {
+	return c.writer.Write(record.values())
+}
+
+func (c *csvRecordWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// jsonLinesRecordWriter writes one JSON object per line
+type jsonLinesRecordWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newJSONLinesRecordWriter(w io.Writer) (MatchRecordWriter, error) {
+	buffer := bufio.NewWriter(w)
+	return &jsonLinesRecordWriter{buffer: buffer, encoder: json.NewEncoder(buffer)}, nil
+}
+
+func (j *jsonLinesRecordWriter) Write(record *MatchRecord) error {
+	return j.encoder.Encode(record)
+}
+
+func (j *jsonLinesRecordWriter) Close() error {
+	return j.buffer.Flush()
+}
+
+// exportMatchRecords simulates seeded seasons and writes every match to the writer,
+// in season and match order. It returns the number of matches written.
+func exportMatchRecords(writer MatchRecordWriter, seasons int, seed int64) (int, error) {
+	written := 0
+	err := simulateSeasons(seasons, seed, func(season int, league *sim.League) error {
+		for _, match := range league.Matches {
+			if err := writer.Write(newMatchRecord(season+1, seed+int64(season), match)); err != nil {
+				return err
+			}
+			written++
+		}
+		return nil
+	})
+	if err != nil {
+		return written, err
+	}
+	return written, writer.Close()
+}
+
+// runMatchExport implements `./main export-matches [seasons] [output] [seed]`
+func runMatchExport(args []string) {
+	seasons, output, seed := defaultExportSeasons, defaultExportOutput, time.Now().UnixNano()
+	if len(args) > 0 {
+		parsed, err := strconv.Atoi(args[0])
+		if err != nil || parsed < 1 {
+			fmt.Fprintf(os.Stderr, "Invalid number of seasons %q\n", args[0])
+			os.Exit(1)
+		}
+		seasons = parsed
+	}
+	if len(args) > 1 {
+		output = args[1]
+	}
+	if len(args) > 2 {
+		parsed, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid seed %q\n", args[2])
+			os.Exit(1)
+		}
+		seed = parsed
+	}
+
+	newWriter, ok := matchRecordWriters[strings.ToLower(filepath.Ext(output))]
+	if !ok {
+		formats := []string{}
+		for extension := range matchRecordWriters {
+			formats = append(formats, extension)
+		}
+		sort.Strings(formats)
+		fmt.Fprintf(os.Stderr, "Unsupported output format %q, use one of %s\n", filepath.Ext(output), strings.Join(formats, ", "))
+		os.Exit(1)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	writer, err := newWriter(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export matches: %v\n", err)
+		os.Exit(1)
+	}
+	written, err := exportMatchRecords(writer, seasons, seed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export matches: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %d matches from %d seasons with the %s engine to %s (seed %d)\n", written, seasons, sim.Config().Engine, output, seed)
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+var (
+	errFixtureConflict      = errors.New("regenerated fixtures conflict with results already played")
+	errConfirmationMismatch = errors.New("confirmation token does not match the current plan")
+	errInvalidFixtureImport = errors.New("invalid fixture import")
+)
+
+// FixtureChange is a fixture added or removed by a regeneration
+type FixtureChange struct {
+	MatchId  int
+	Week     int
+	HomeTeam string
+	AwayTeam string
+}
+
+// FixtureConflict is a fixture that keeps a regeneration from being applied
+type FixtureConflict struct {
+	FixtureChange
+	Reason string
+}
+
+// FixtureRegeneration is the diff between the stored fixtures and a fresh schedule for
+// the current teams, config and competition rules. Played matches and unplayed fixtures
+// the fresh schedule repeats keep their IDs.
+type FixtureRegeneration struct {
+	Removed           []FixtureChange
+	Added             []FixtureChange
+	Kept              int
+	Conflicts         []FixtureConflict `json:",omitempty"`
+	ConfirmationToken string            `json:",omitempty"` // send back to apply the plan; empty when it cannot or need not be applied
+	Applied           bool
+
+	matches []*sim.Match // the regenerated schedule
+}
+
+// fixtureKey identifies a fixture by its week and teams, whatever its match ID
+type fixtureKey struct {
+	week, home, away int
+}
+
+func keyOf(match *sim.Match) fixtureKey {
+	return fixtureKey{match.Week, match.HomeTeam.TeamId, match.AwayTeam.TeamId}
+}
+
+func fixtureChange(match *sim.Match) FixtureChange {
+	return FixtureChange{MatchId: match.MatchId, Week: match.Week, HomeTeam: match.HomeTeam.TeamName, AwayTeam: match.AwayTeam.TeamName}
+}
+
+// planFixtureRegeneration compares the league's fixtures with a fresh schedule. It
+// reports a conflict for every played result the fresh schedule lacks and for every new
+// fixture that would land in a week already completed.
+func planFixtureRegeneration(league *sim.League) *FixtureRegeneration {
+	plan := &FixtureRegeneration{Removed: []FixtureChange{}, Added: []FixtureChange{}}
+
+	fresh := make(map[fixtureKey]*sim.Match)
+	freshOrder := sim.CreatePremierLeagueMatches(league.Teams)
+	for _, match := range freshOrder {
+		fresh[keyOf(match)] = match
+	}
+
+	existing := make(map[fixtureKey]bool)
+	nextId := 1
+	for _, match := range league.Matches {
+		key := keyOf(match)
+		existing[key] = true
+		if match.MatchId >= nextId {
+			nextId = match.MatchId + 1
+		}
+
+		switch {
+		case fresh[key] != nil:
+			plan.Kept++
+			plan.matches = append(plan.matches, match)
+		case match.Played:
+			plan.Conflicts = append(plan.Conflicts, FixtureConflict{fixtureChange(match), "played result is not in the new schedule"})
+		default:
+			plan.Removed = append(plan.Removed, fixtureChange(match))
+		}
+	}
+
+	for _, match := range freshOrder {
+		if existing[keyOf(match)] {
+			continue
+		}
+		match.MatchId = nextId
+		nextId++
+		if match.Week <= league.CurrentWeek {
+			plan.Conflicts = append(plan.Conflicts, FixtureConflict{fixtureChange(match), fmt.Sprintf("week %d has already been completed", match.Week)})
+			continue
+		}
+		plan.Added = append(plan.Added, fixtureChange(match))
+		plan.matches = append(plan.matches, match)
+	}
+
+	sort.SliceStable(plan.matches, func(i, j int) bool {
+		if plan.matches[i].Week != plan.matches[j].Week {
+			return plan.matches[i].Week < plan.matches[j].Week
+		}
+		return plan.matches[i].MatchId < plan.matches[j].MatchId
+	})
+
+	if len(plan.Conflicts) == 0 && len(plan.Removed)+len(plan.Added) > 0 {
+		plan.ConfirmationToken = fixturePlanToken(league.Revision, plan)
+	}
+	return plan
+}
+
+// fixturePlanToken fingerprints a plan against the revision it was made at, so a token
+// only confirms the exact diff that was previewed
+func fixturePlanToken(revision int, plan *FixtureRegeneration) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "revision:%d\n", revision)
+	for _, change := range plan.Removed {
+		fmt.Fprintf(hash, "-%d:%d:%s:%s\n", change.MatchId, change.Week, change.HomeTeam, change.AwayTeam)
+	}
+	for _, change := range plan.Added {
+		fmt.Fprintf(hash, "+%d:%d:%s:%s\n", change.MatchId, change.Week, change.HomeTeam, change.AwayTeam)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// FixtureImport is one fixture in a fixtures/results import. Teams may be given by
+// name, slug, short code or ID; a fixture without scores is unplayed.
+type FixtureImport struct {
+	Week      int    `json:"week"`
+	HomeTeam  string `json:"home_team"`
+	AwayTeam  string `json:"away_team"`
+	HomeScore *int   `json:"home_score"`
+	AwayScore *int   `json:"away_score"`
+
+	row int // CSV data row or JSON position, for problems
+}
+
+// ImportProblem is one thing wrong with an import; Row is 0 for the file as a whole
+type ImportProblem struct {
+	Row     int `json:",omitempty"`
+	Problem string
+}
+
+// FixtureImportResult reports what an import contains and everything wrong with it
+type FixtureImportResult struct {
+	Valid    bool
+	Fixtures int
+	Played   int
+	Problems []ImportProblem
+	Applied  bool
+}
+
+// parseFixtureImportCSV reads fixtures from CSV with a header row naming the columns
+// (week, home_team and away_team required; home_score and away_score optional; in any
+// order). Rows that cannot be read are reported as problems rather than stopping the parse.
+func parseFixtureImportCSV(r io.Reader) ([]FixtureImport, []ImportProblem, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("CSV is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+	for _, required := range []string{"week", "home_team", "away_team"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV header must include a %q column", required)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	fixtures := []FixtureImport{}
+	problems := []ImportProblem{}
+	for i, record := range records[1:] {
+		fixture := FixtureImport{HomeTeam: field(record, "home_team"), AwayTeam: field(record, "away_team"), row: i + 1}
+		week, err := strconv.Atoi(field(record, "week"))
+		if err != nil {
+			problems = append(problems, ImportProblem{Row: i + 1, Problem: "week must be a number"})
+			continue
+		}
+		fixture.Week = week
+
+		readable := true
+		scores := []struct {
+			column string
+			target **int
+		}{{"home_score", &fixture.HomeScore}, {"away_score", &fixture.AwayScore}}
+		for _, score := range scores {
+			if value := field(record, score.column); value != "" {
+				parsed, err := strconv.Atoi(value)
+				if err != nil {
+					problems = append(problems, ImportProblem{Row: i + 1, Problem: score.column + " must be a number"})
+					readable = false
+					continue
+				}
+				*score.target = &parsed
+			}
+		}
+		if readable {
+			fixtures = append(fixtures, fixture)
+		}
+	}
+	return fixtures, problems, nil
+}
+
+// numberFixtureImports sets the row of fixtures decoded from JSON to their position
+func numberFixtureImports(fixtures []FixtureImport) {
+	for i := range fixtures {
+		fixtures[i].row = i + 1
+	}
+}
+
+// validateFixtureImport checks imported fixtures against the league's teams and rules:
+// unknown teams, scores, duplicate fixtures, teams playing twice in a week, and
+// changes to results in locked weeks
+func validateFixtureImport(league *sim.League, fixtures []FixtureImport) []ImportProblem {
+	problems := []ImportProblem{}
+	problem := func(row int, format string, args ...interface{}) {
+		problems = append(problems, ImportProblem{Row: row, Problem: fmt.Sprintf(format, args...)})
+	}
+	if len(fixtures) == 0 {
+		problem(0, "import has no fixtures")
+	}
+
+	// Each pair meets once per leg, alternating home and away
+	legs := sim.Rules().Legs
+	type pair struct{ home, away int }
+	pairRows := make(map[pair][]int)
+	weekRows := make(map[[2]int]int) // week and team to the row it already plays in
+
+	imported := make(map[int][]string)
+	for _, fixture := range fixtures {
+		row := fixture.row
+		if fixture.Week < 1 {
+			problem(row, "week must be at least 1")
+		}
+		if (fixture.HomeScore == nil) != (fixture.AwayScore == nil) {
+			problem(row, "give both scores for a played match, or neither")
+		}
+		for _, score := range []*int{fixture.HomeScore, fixture.AwayScore} {
+			if score != nil && *score < 0 {
+				problem(row, "scores cannot be negative")
+				break
+			}
+		}
+
+		home, _ := findTeamByReference(league.Teams, fixture.HomeTeam)
+		away, _ := findTeamByReference(league.Teams, fixture.AwayTeam)
+		if home == nil {
+			problem(row, "unknown team %q", fixture.HomeTeam)
+		}
+		if away == nil {
+			problem(row, "unknown team %q", fixture.AwayTeam)
+		}
+		if home == nil || away == nil {
+			continue
+		}
+		if home == away {
+			problem(row, "%s cannot play itself", home.TeamName)
+			continue
+		}
+
+		for _, team := range []*sim.Team{home, away} {
+			key := [2]int{fixture.Week, team.TeamId}
+			if other, ok := weekRows[key]; ok {
+				problem(row, "%s already plays in week %d (row %d)", team.TeamName, fixture.Week, other)
+			} else {
+				weekRows[key] = row
+			}
+		}
+
+		ordered := pair{home.TeamId, away.TeamId}
+		reversed := pair{away.TeamId, home.TeamId}
+		pairRows[ordered] = append(pairRows[ordered], row)
+		if len(pairRows[ordered]) > (legs+1)/2 || len(pairRows[ordered])+len(pairRows[reversed]) > legs {
+			problem(row, "duplicate fixture %s v %s (first in row %d)", home.TeamName, away.TeamName, pairRows[ordered][0])
+		}
+
+		imported[fixture.Week] = append(imported[fixture.Week], fixtureResultKey(home.TeamName, away.TeamName, fixture.HomeScore, fixture.AwayScore))
+	}
+
+	// A locked week must come through the import unchanged
+	stored := make(map[int][]string)
+	for _, match := range league.Matches {
+		var homeScore, awayScore *int
+		if match.Played {
+			homeScore, awayScore = &match.HomeTeamScore, &match.AwayTeamScore
+		}
+		stored[match.Week] = append(stored[match.Week], fixtureResultKey(match.HomeTeam.TeamName, match.AwayTeam.TeamName, homeScore, awayScore))
+	}
+	weeks := make(map[int]bool)
+	for week := range stored {
+		weeks[week] = true
+	}
+	for week := range imported {
+		weeks[week] = true
+	}
+	lockedWeeks := []int{}
+	for week := range weeks {
+		if weekLocked(league, week) && !sameFixtureResults(stored[week], imported[week]) {
+			lockedWeeks = append(lockedWeeks, week)
+		}
+	}
+	sort.Ints(lockedWeeks)
+	for _, week := range lockedWeeks {
+		problem(0, "week %d is locked and the import changes its fixtures or results", week)
+	}
+
+	return problems
+}
+
+func fixtureResultKey(home, away string, homeScore, awayScore *int) string {
+	if homeScore == nil || awayScore == nil {
+		return home + " v " + away
+	}
+	return fmt.Sprintf("%s %d-%d %s", home, *homeScore, *awayScore, away)
+}
+
+func sameFixtureResults(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkFixtureImport validates an import and summarizes it
+func checkFixtureImport(league *sim.League, fixtures []FixtureImport, parseProblems []ImportProblem) *FixtureImportResult {
+	result := &FixtureImportResult{Fixtures: len(fixtures), Problems: append(append([]ImportProblem{}, parseProblems...), validateFixtureImport(league, fixtures)...)}
+
+	// Problems are listed by row, with those about the file as a whole last
+	sort.SliceStable(result.Problems, func(i, j int) bool {
+		a, b := result.Problems[i].Row, result.Problems[j].Row
+		return a != 0 && (b == 0 || a < b)
+	})
+	for _, fixture := range fixtures {
+		if fixture.HomeScore != nil && fixture.AwayScore != nil {
+			result.Played++
+		}
+	}
+	result.Valid = len(result.Problems) == 0
+	return result
+}
+
+// importedMatches builds the league's matches from a valid import, numbered in week order
+func importedMatches(league *sim.League, fixtures []FixtureImport) []*sim.Match {
+	ordered := append([]FixtureImport{}, fixtures...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Week < ordered[j].Week })
+
+	matches := make([]*sim.Match, len(ordered))
+	for i, fixture := range ordered {
+		home, _ := findTeamByReference(league.Teams, fixture.HomeTeam)
+		away, _ := findTeamByReference(league.Teams, fixture.AwayTeam)
+		match := &sim.Match{MatchId: i + 1, Week: fixture.Week, HomeTeam: home, AwayTeam: away}
+		if fixture.HomeScore != nil && fixture.AwayScore != nil {
+			match.HomeTeamScore, match.AwayTeamScore = *fixture.HomeScore, *fixture.AwayScore
+			match.Played = true
+		}
+		matches[i] = match
+	}
+	return matches
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// modelPlayerName is the leaderboard entry for the simulator's own forecasts
+const modelPlayerName = "model"
+
+// UserPrediction is a player's forecast for an unplayed match as home-win, draw and
+// away-win probabilities summing to 1
+type UserPrediction struct {
+	Season      int
+	MatchId     int
+	Player      string
+	HomeWin     float64
+	Draw        float64
+	AwayWin     float64
+	SubmittedAt time.Time
+}
+
+// newUserPrediction validates a forecast given either as probabilities or as a pick
+// ("home", "draw" or "away"), which counts as full confidence in that outcome
+func newUserPrediction(player, pick string, homeWin, draw, awayWin float64) (*UserPrediction, error) {
+	player = strings.TrimSpace(player)
+	if player == "" {
+		return nil, fmt.Errorf("player is required")
+	}
+	if strings.EqualFold(player, modelPlayerName) {
+		return nil, fmt.Errorf("player name %q is reserved", modelPlayerName)
+	}
+
+	switch pick {
+	case "":
+	case "home":
+		homeWin, draw, awayWin = 1, 0, 0
+	case "draw":
+		homeWin, draw, awayWin = 0, 1, 0
+	case "away":
+		homeWin, draw, awayWin = 0, 0, 1
+	default:
+		return nil, fmt.Errorf("pick must be home, draw or away")
+	}
+
+	if homeWin < 0 || draw < 0 || awayWin < 0 || math.Abs(homeWin+draw+awayWin-1) > 0.01 {
+		return nil, fmt.Errorf("home_win, draw and away_win must be non-negative and sum to 1")
+	}
+	return &UserPrediction{Player: player, HomeWin: homeWin, Draw: draw, AwayWin: awayWin, SubmittedAt: time.Now().UTC()}, nil
+}
+
+// PlayerProfile holds a prediction game player's settings
+type PlayerProfile struct {
+	Player   string
+	TimeZone string // IANA time zone kickoffs are shown in, e.g. "Europe/Istanbul"
+}
+
+// newPlayerProfile validates a player's settings
+func newPlayerProfile(player, timeZone string) (*PlayerProfile, error) {
+	player = strings.TrimSpace(player)
+	if player == "" {
+		return nil, fmt.Errorf("player is required")
+	}
+	if strings.EqualFold(player, modelPlayerName) {
+		return nil, fmt.Errorf("player name %q is reserved", modelPlayerName)
+	}
+	if _, err := time.LoadLocation(timeZone); err != nil || timeZone == "" || timeZone == "Local" {
+		return nil, fmt.Errorf("timezone %q is not an IANA time zone like \"Europe/Istanbul\"", timeZone)
+	}
+	return &PlayerProfile{Player: player, TimeZone: timeZone}, nil
+}
+
+// poissonOutcomeProbabilities returns home-win, draw and away-win probabilities for
+// independent Poisson goals with the given means, capped like the simulation
+func poissonOutcomeProbabilities(homeMean, awayMean float64, maxGoals int) (float64, float64, float64) {
+	goalProbabilities := func(mean float64) []float64 {
+		probabilities := make([]float64, maxGoals+1)
+		term, total := math.Exp(-mean), 0.0
+		for goals := 0; goals < maxGoals; goals++ {
+			probabilities[goals] = term
+			total += term
+			term *= mean / float64(goals+1)
+		}
+		// Everything above the cap is scored as the cap
+		probabilities[maxGoals] = math.Max(1-total, 0)
+		return probabilities
+	}
+
+	home, away := goalProbabilities(homeMean), goalProbabilities(awayMean)
+	homeWin, draw, awayWin := 0.0, 0.0, 0.0
+	for h, ph := range home {
+		for a, pa := range away {
+			switch {
+			case h > a:
+				homeWin += ph * pa
+			case h == a:
+				draw += ph * pa
+			default:
+				awayWin += ph * pa
+			}
+		}
+	}
+	return homeWin, draw, awayWin
+}
+
+// modelOutcomeProbabilities is the simulator's pre-match forecast, derived from the
+// expected goals it assigned to the match
+func modelOutcomeProbabilities(match *sim.Match) (float64, float64, float64) {
+	return poissonOutcomeProbabilities(match.HomeXG, match.AwayXG, sim.Config().MaxGoals)
+}
+
+// brierScore measures a three-way forecast against the result: 0 is perfect, 2 is
+// full confidence in an outcome that did not happen
+func brierScore(homeWin, draw, awayWin float64, match *sim.Match) float64 {
+	actualHome, actualDraw, actualAway := 0.0, 0.0, 0.0
+	switch {
+	case match.HomeTeamScore > match.AwayTeamScore:
+		actualHome = 1
+	case match.HomeTeamScore < match.AwayTeamScore:
+		actualAway = 1
+	default:
+		actualDraw = 1
+	}
+	return math.Pow(homeWin-actualHome, 2) + math.Pow(draw-actualDraw, 2) + math.Pow(awayWin-actualAway, 2)
+}
+
+// LeaderboardEntry ranks a player, or the model, by mean Brier score (lower is better).
+// ModelBrierScore is the model's score on the same matches, so every player is
+// compared with the simulator on equal terms.
+type LeaderboardEntry struct {
+	Rank            int
+	Player          string
+	Matches         int
+	BrierScore      float64
+	ModelBrierScore float64
+	BeatModel       bool
+}
+
+// buildLeaderboard scores every forecast for a played match up to uptoWeek (0 for
+// all weeks). The model is scored on every played match any player forecast.
+func buildLeaderboard(league *sim.League, predictions []*UserPrediction, uptoWeek int) []*LeaderboardEntry {
+	played := make(map[int]*sim.Match)
+	for _, match := range league.Matches {
+		if match.Played && (uptoWeek == 0 || match.Week <= uptoWeek) {
+			played[match.MatchId] = match
+		}
+	}
+
+	modelScore := func(match *sim.Match) float64 {
+		homeWin, draw, awayWin := modelOutcomeProbabilities(match)
+		return brierScore(homeWin, draw, awayWin, match)
+	}
+
+	entries := make(map[string]*LeaderboardEntry)
+	modelMatches := make(map[int]bool)
+	for _, prediction := range predictions {
+		match, ok := played[prediction.MatchId]
+		if !ok {
+			continue
+		}
+		entry, ok := entries[prediction.Player]
+		if !ok {
+			entry = &LeaderboardEntry{Player: prediction.Player}
+			entries[prediction.Player] = entry
+		}
+		entry.Matches++
+		entry.BrierScore += brierScore(prediction.HomeWin, prediction.Draw, prediction.AwayWin, match)
+		entry.ModelBrierScore += modelScore(match)
+		modelMatches[match.MatchId] = true
+	}
+
+	if len(modelMatches) > 0 {
+		model := &LeaderboardEntry{Player: modelPlayerName}
+		for matchId := range modelMatches {
+			model.Matches++
+			model.BrierScore += modelScore(played[matchId])
+		}
+		model.ModelBrierScore = model.BrierScore
+		entries[modelPlayerName] = model
+	}
+
+	leaderboard := make([]*LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		count := float64(entry.Matches)
+		entry.BrierScore = math.Round(entry.BrierScore/count*1000) / 1000
+		entry.ModelBrierScore = math.Round(entry.ModelBrierScore/count*1000) / 1000
+		entry.BeatModel = entry.Player != modelPlayerName && entry.BrierScore < entry.ModelBrierScore
+		leaderboard = append(leaderboard, entry)
+	}
+
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if leaderboard[i].BrierScore == leaderboard[j].BrierScore {
+			return leaderboard[i].Player < leaderboard[j].Player
+		}
+		return leaderboard[i].BrierScore < leaderboard[j].BrierScore
+	})
+	for i, entry := range leaderboard {
+		entry.Rank = i + 1
+	}
+	return leaderboard
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// simulationModelVersion identifies the simulation math. Bump it with any change meant
+// to alter simulated results, and run `./main golden update` to record the new outputs.
+const simulationModelVersion = 2
+
+// defaultGoldenDir holds the committed golden season outputs
+const defaultGoldenDir = "./testdata/golden"
+
+// goldenSeed is the seed every golden season is simulated with
+const goldenSeed = 20240601
+
+// goldenCase is a configuration whose full season is kept as a golden file
+type goldenCase struct {
+	name      string
+	configure func(config *sim.SimulationConfig)
+}
+
+// goldenCases cover the classic engine, the Dixon-Coles model and the minute engine
+var goldenCases = []goldenCase{
+	{"classic", func(config *sim.SimulationConfig) {}},
+	{"dixon-coles", func(config *sim.SimulationConfig) { config.Features = map[string]float64{sim.FeatureDixonColes: 1} }},
+	{"minute", func(config *sim.SimulationConfig) { config.Engine = sim.EngineMinute }},
+}
+
+// GoldenSeason is the recorded output of a seeded season: every result and the final table
+type GoldenSeason struct {
+	ModelVersion int
+	Case         string
+	Seed         int64
+	Results      []GoldenResult
+	Table        []*sim.LeagueTableEntry
+}
+
+// GoldenResult is one match of a golden season; xG is rounded so the files stay readable
+type GoldenResult struct {
+	MatchId   int
+	Week      int
+	HomeTeam  string
+	AwayTeam  string
+	HomeScore int
+	AwayScore int
+	HomeXG    float64
+	AwayXG    float64
+	Events    int `json:",omitempty"` // length of the minute engine's timeline
+}
+
+// simulateGoldenSeason plays a case's season with the default config and competition
+// rules and without house rules hooks, so local setups never affect the golden outputs
+func simulateGoldenSeason(golden goldenCase) (*GoldenSeason, error) {
+	savedRules, savedHooks := sim.Rules(), sim.RulesHooks()
+	defer func() { sim.SetRules(savedRules); sim.SetRulesHooks(savedHooks) }()
+	sim.SetRulesHooks(nil)
+	sim.SetRules(sim.DefaultCompetitionRules())
+
+	config := sim.DefaultSimulationConfig()
+	golden.configure(&config)
+	league, err := simulateSeason(goldenSeed, sim.WithSimulator(config))
+	if err != nil {
+		return nil, err
+	}
+	season := &GoldenSeason{ModelVersion: simulationModelVersion, Case: golden.name, Seed: goldenSeed, Table: league.LeagueTable}
+	for _, match := range league.Matches {
+		season.Results = append(season.Results, GoldenResult{
+			MatchId:   match.MatchId,
+			Week:      match.Week,
+			HomeTeam:  match.HomeTeam.TeamName,
+			AwayTeam:  match.AwayTeam.TeamName,
+			HomeScore: match.HomeTeamScore,
+			AwayScore: match.AwayTeamScore,
+			HomeXG:    math.Round(match.HomeXG*10000) / 10000,
+			AwayXG:    math.Round(match.AwayXG*10000) / 10000,
+			Events:    len(match.Events),
+		})
+	}
+	return season, nil
+}
+
+// encodeGoldenSeason renders a golden season as the indented JSON kept on disk
+func encodeGoldenSeason(season *GoldenSeason) ([]byte, error) {
+	data, err := json.MarshalIndent(season, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// checkGoldenSeason compares a case's season with its golden file and describes the
+// first difference; an empty string means it matches
+func checkGoldenSeason(dir string, golden goldenCase) (string, error) {
+	path := filepath.Join(dir, golden.name+".json")
+	want, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read golden file: %v", err)
+	}
+	season, err := simulateGoldenSeason(golden)
+	if err != nil {
+		return "", err
+	}
+	got, err := encodeGoldenSeason(season)
+	if err != nil {
+		return "", err
+	}
+	if bytes.Equal(got, want) {
+		return "", nil
+	}
+
+	var recorded GoldenSeason
+	if err := json.Unmarshal(want, &recorded); err == nil && recorded.ModelVersion != simulationModelVersion {
+		return fmt.Sprintf("%s was recorded with model version %d, the simulation is at version %d", path, recorded.ModelVersion, simulationModelVersion), nil
+	}
+
+	return firstGoldenDifference(path, want, got), nil
+}
+
+// firstGoldenDifference describes the first line where a golden file and the
+// current output differ
+func firstGoldenDifference(path string, want, got []byte) string {
+	wantLines, gotLines := strings.Split(string(want), "\n"), strings.Split(string(got), "\n")
+	for i := 0; i < max(len(wantLines), len(gotLines)); i++ {
+		var wantLine, gotLine string
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if wantLine != gotLine {
+			return fmt.Sprintf("%s:%d\n    golden:    %s\n    simulated: %s", path, i+1, strings.TrimSpace(wantLine), strings.TrimSpace(gotLine))
+		}
+	}
+	return ""
+}
+
+// goldenRandomFile holds the recorded start of the seeded generator's sequence
+const goldenRandomFile = "random.json"
+
+// encodeRandomSequence renders the generator's recorded outputs for the golden seed
+func encodeRandomSequence() ([]byte, error) {
+	data, err := json.MarshalIndent(sim.RecordRandomSequence(goldenSeed), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// checkRandomSequence checks the generator against the PCG reference outputs and its
+// recorded golden sequence, so a change to the generator shows before any season does
+func checkRandomSequence(dir string) (string, error) {
+	if difference := sim.CheckPCGReference(); difference != "" {
+		return difference, nil
+	}
+	path := filepath.Join(dir, goldenRandomFile)
+	want, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read golden file: %v", err)
+	}
+	got, err := encodeRandomSequence()
+	if err != nil {
+		return "", err
+	}
+	if bytes.Equal(got, want) {
+		return "", nil
+	}
+	return firstGoldenDifference(path, want, got), nil
+}
+
+// runGolden implements `./main golden [check|update] [dir]`: check compares every
+// golden case with the committed outputs and exits 1 on a difference, update rewrites them
+func runGolden(args []string) {
+	mode, dir := "check", defaultGoldenDir
+	if len(args) > 0 {
+		mode = args[0]
+	}
+	if len(args) > 1 {
+		dir = args[1]
+	}
+
+	switch mode {
+	case "update":
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+		data, err := encodeRandomSequence()
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, goldenRandomFile), data, 0o644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write the random sequence: %v\n", err)
+			os.Exit(1)
+		}
+		for _, golden := range goldenCases {
+			season, err := simulateGoldenSeason(golden)
+			var data []byte
+			if err == nil {
+				data, err = encodeGoldenSeason(season)
+			}
+			if err == nil {
+				err = os.WriteFile(filepath.Join(dir, golden.name+".json"), data, 0o644)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write golden season %s: %v\n", golden.name, err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("Recorded %d golden seasons at model version %d in %s\n", len(goldenCases), simulationModelVersion, dir)
+
+	case "check":
+		failed := 0
+		switch difference, err := checkRandomSequence(dir); {
+		case err != nil:
+			fmt.Printf("FAIL random: %v\n", err)
+			failed++
+		case difference != "":
+			fmt.Printf("FAIL random: %s\n", difference)
+			failed++
+		default:
+			fmt.Printf("ok   random\n")
+		}
+		for _, golden := range goldenCases {
+			difference, err := checkGoldenSeason(dir, golden)
+			switch {
+			case err != nil:
+				fmt.Printf("FAIL %s: %v\n", golden.name, err)
+				failed++
+			case difference != "":
+				fmt.Printf("FAIL %s: %s\n", golden.name, difference)
+				failed++
+			default:
+				fmt.Printf("ok   %s\n", golden.name)
+			}
+		}
+		if failed > 0 {
+			fmt.Printf("\n%d of %d golden checks changed. If the change is intended, bump simulationModelVersion and run ./main golden update.\n", failed, len(goldenCases)+1)
+			os.Exit(1)
+		}
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown golden mode %q, use check or update\n", mode)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestGoldenRandomSequence(t *testing.T) {
+	difference, err := checkRandomSequence(defaultGoldenDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if difference != "" {
+		t.Fatal(difference)
+	}
+}
+
+func TestGoldenSeasons(t *testing.T) {
+	for _, golden := range goldenCases {
+		t.Run(golden.name, func(t *testing.T) {
+			difference, err := checkGoldenSeason(defaultGoldenDir, golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if difference != "" {
+				t.Fatal(difference)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// SeasonSummary is the final record of a completed season
+type SeasonSummary struct {
+	Season               int
+	Standings            []*sim.LeagueTableEntry
+	MatchesPlayed        int
+	TotalGoals           int
+	AverageGoalsPerMatch float64
+	HomeWinPercentage    float64
+	CompletedAt          *time.Time            `json:",omitempty"` // nil while the season is in progress
+	Honours              []*Honour             `json:",omitempty"` // set in the end-of-season report
+	Records              []*Record             `json:",omitempty"` // records set or broken, end-of-season report only
+	Sackings             []*sim.Manager        `json:",omitempty"` // managers sacked during the season, end-of-season report only
+	Qualified            []*ContinentalEntrant `json:",omitempty"` // continental places earned, end-of-season report only
+	Notes                []string              `json:",omitempty"` // from house rules hooks, end-of-season report only
+}
+
+// SeasonTeamDelta compares one team across two seasons; deltas are B minus A,
+// so a negative PositionDelta means the team climbed the table
+type SeasonTeamDelta struct {
+	TeamName          string
+	PointsA           int
+	PointsB           int
+	PointsDelta       int
+	PositionA         int
+	PositionB         int
+	PositionDelta     int
+	GoalsForA         int
+	GoalsForB         int
+	GoalsForDelta     int
+	GoalsAgainstA     int
+	GoalsAgainstB     int
+	GoalsAgainstDelta int
+}
+
+// SeasonComparison holds per-team deltas and league-wide trends between two seasons
+type SeasonComparison struct {
+	SeasonA                int
+	SeasonB                int
+	Teams                  []*SeasonTeamDelta
+	AverageGoalsA          float64
+	AverageGoalsB          float64
+	AverageGoalsDelta      float64
+	HomeWinPercentageA     float64
+	HomeWinPercentageB     float64
+	HomeWinPercentageDelta float64
+}
+
+// AllTimeEntry is a team's cumulative record across every season
+type AllTimeEntry struct {
+	TeamName        string
+	Seasons         int
+	Played          int
+	Wins            int
+	Draws           int
+	Losses          int
+	GoalsFor        int
+	GoalsAgainst    int
+	GoalsDifference int
+	Points          int
+	Titles          int
+	Position        int
+}
+
+// buildAllTimeTable adds the season in progress to the stored cumulative standings.
+// Titles only count completed seasons.
+func buildAllTimeTable(stored []*AllTimeEntry, current []*sim.LeagueTableEntry) []*AllTimeEntry {
+	entries := make(map[string]*AllTimeEntry)
+	table := []*AllTimeEntry{}
+	for _, entry := range stored {
+		copied := *entry
+		entries[entry.TeamName] = &copied
+		table = append(table, &copied)
+	}
+
+	for _, entry := range current {
+		if entry.Played == 0 {
+			continue
+		}
+		total, ok := entries[entry.TeamName]
+		if !ok {
+			total = &AllTimeEntry{TeamName: entry.TeamName}
+			entries[entry.TeamName] = total
+			table = append(table, total)
+		}
+		total.Seasons++
+		total.Played += entry.Played
+		total.Wins += entry.Wins
+		total.Draws += entry.Draws
+		total.Losses += entry.Losses
+		total.GoalsFor += entry.GoalsFor
+		total.GoalsAgainst += entry.GoalsAgainst
+		total.Points += entry.Points
+	}
+
+	for _, total := range table {
+		total.GoalsDifference = total.GoalsFor - total.GoalsAgainst
+	}
+
+	// Sort by points (descending), then by goal difference (descending)
+	sort.Slice(table, func(i, j int) bool {
+		if table[i].Points == table[j].Points {
+			return table[i].GoalsDifference > table[j].GoalsDifference
+		}
+		return table[i].Points > table[j].Points
+	})
+
+	for i, total := range table {
+		total.Position = i + 1
+	}
+
+	return table
+}
+
+// summarizeSeason captures the league's current standings and aggregates
+func summarizeSeason(league *sim.League) *SeasonSummary {
+	lastWeek := 0
+	for _, match := range league.Matches {
+		if match.Week > lastWeek {
+			lastWeek = match.Week
+		}
+	}
+	stats := computeLeagueStats(league, lastWeek)
+
+	standings := make([]*sim.LeagueTableEntry, 0, len(league.LeagueTable))
+	for _, entry := range league.LeagueTable {
+		copied := *entry
+		standings = append(standings, &copied)
+	}
+
+	return &SeasonSummary{
+		Season:               league.Season,
+		Standings:            standings,
+		MatchesPlayed:        stats.MatchesPlayed,
+		TotalGoals:           stats.TotalGoals,
+		AverageGoalsPerMatch: stats.AverageGoalsPerMatch,
+		HomeWinPercentage:    stats.HomeWinPercentage,
+	}
+}
+
+// compareSeasons lines up teams present in either season, ordered by season B position
+func compareSeasons(a, b *SeasonSummary) *SeasonComparison {
+	comparison := &SeasonComparison{
+		SeasonA:                a.Season,
+		SeasonB:                b.Season,
+		AverageGoalsA:          a.AverageGoalsPerMatch,
+		AverageGoalsB:          b.AverageGoalsPerMatch,
+		AverageGoalsDelta:      b.AverageGoalsPerMatch - a.AverageGoalsPerMatch,
+		HomeWinPercentageA:     a.HomeWinPercentage,
+		HomeWinPercentageB:     b.HomeWinPercentage,
+		HomeWinPercentageDelta: b.HomeWinPercentage - a.HomeWinPercentage,
+	}
+
+	deltas := make(map[string]*SeasonTeamDelta)
+	deltaFor := func(teamName string) *SeasonTeamDelta {
+		delta, ok := deltas[teamName]
+		if !ok {
+			delta = &SeasonTeamDelta{TeamName: teamName}
+			deltas[teamName] = delta
+			comparison.Teams = append(comparison.Teams, delta)
+		}
+		return delta
+	}
+
+	for _, entry := range a.Standings {
+		delta := deltaFor(entry.TeamName)
+		delta.PointsA = entry.Points
+		delta.PositionA = entry.Position
+		delta.GoalsForA = entry.GoalsFor
+		delta.GoalsAgainstA = entry.GoalsAgainst
+	}
+	for _, entry := range b.Standings {
+		delta := deltaFor(entry.TeamName)
+		delta.PointsB = entry.Points
+		delta.PositionB = entry.Position
+		delta.GoalsForB = entry.GoalsFor
+		delta.GoalsAgainstB = entry.GoalsAgainst
+	}
+
+	for _, delta := range comparison.Teams {
+		delta.PointsDelta = delta.PointsB - delta.PointsA
+		delta.GoalsForDelta = delta.GoalsForB - delta.GoalsForA
+		delta.GoalsAgainstDelta = delta.GoalsAgainstB - delta.GoalsAgainstA
+		// Teams missing from a season have no position to compare
+		if delta.PositionA > 0 && delta.PositionB > 0 {
+			delta.PositionDelta = delta.PositionB - delta.PositionA
+		}
+	}
+
+	sort.SliceStable(comparison.Teams, func(i, j int) bool {
+		pi, pj := comparison.Teams[i].PositionB, comparison.Teams[j].PositionB
+		if pi == 0 || pj == 0 {
+			return pj == 0 && pi != 0
+		}
+		return pi < pj
+	})
+
+	return comparison
+}
+
+// resetForNewSeason clears results and team statistics so the fixtures can be replayed
+func resetForNewSeason(league *sim.League) {
+	for _, team := range league.Teams {
+		team.GoalsFor = 0
+		team.GoalsAgainst = 0
+		team.Wins = 0
+		team.Draws = 0
+		team.Losses = 0
+		team.Points = 0
+		team.GoalsDifference = 0
+	}
+
+	for _, match := range league.Matches {
+		match.HomeTeamScore = 0
+		match.AwayTeamScore = 0
+		match.DecidedBy = ""
+		match.HomeXG = 0
+		match.AwayXG = 0
+		match.Events = nil
+		match.Played = false
+	}
+
+	league.CurrentWeek = 0
+	league.Season++
+	league.LockedWeeks = nil
+	league.Orders = nil
+	league.Draft = nil
+	league.Handicaps = sim.TeamHandicaps(league.Teams, league.Handicap)
+	sim.ScheduleKickoffs(league)
+	sim.UpdateLeagueTable(league)
+}
+
+// historyCache keeps the history tables read from storage since the league's revision
+// last changed. History is not part of the loaded league, so startup cost does not
+// grow with the seasons played; each table is read the first time it is asked for.
+// Every write bumps the revision, which empties the cache. Cached slices are shared
+// and must not be modified.
+type historyCache struct {
+	mu       sync.Mutex
+	revision int
+	entries  map[string]interface{}
+}
+
+// load returns the entry for key at the given revision, reading it on a miss
+func (c *historyCache) load(key string, revision int, read func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil || c.revision != revision {
+		c.revision = revision
+		c.entries = make(map[string]interface{})
+	}
+	if entry, ok := c.entries[key]; ok {
+		return entry, nil
+	}
+
+	entry, err := read()
+	if err != nil {
+		return nil, err
+	}
+	c.entries[key] = entry
+	return entry, nil
+}
+
+// storedSeasonSummaries returns the completed seasons' summaries
+func (s *leagueScope) storedSeasonSummaries(league *sim.League) ([]*SeasonSummary, error) {
+	entry, err := s.history.load("seasons", league.Revision, func() (interface{}, error) {
+		return s.readStorage().GetSeasonSummaries()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry.([]*SeasonSummary), nil
+}
+
+// storedAllTimeStandings returns the all-time standings over the completed seasons
+func (s *leagueScope) storedAllTimeStandings(league *sim.League) ([]*AllTimeEntry, error) {
+	entry, err := s.history.load("all-time", league.Revision, func() (interface{}, error) {
+		return s.readStorage().GetAllTimeStandings()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry.([]*AllTimeEntry), nil
+}
+
+// storedHonours returns every honour won
+func (s *leagueScope) storedHonours(league *sim.League) ([]*Honour, error) {
+	entry, err := s.history.load("honours", league.Revision, func() (interface{}, error) {
+		return s.readStorage().GetHonours()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry.([]*Honour), nil
+}
+
+// storedRecords returns the all-time record book
+func (s *leagueScope) storedRecords(league *sim.League) ([]*Record, error) {
+	entry, err := s.history.load("records", league.Revision, func() (interface{}, error) {
+		return s.readStorage().GetRecords()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry.([]*Record), nil
+}
+
+// storedSeasonMatches returns a completed season's matches from the archive
+func (s *leagueScope) storedSeasonMatches(league *sim.League, season int) ([]*sim.Match, error) {
+	entry, err := s.history.load(fmt.Sprintf("matches:%d", season), league.Revision, func() (interface{}, error) {
+		return s.readStorage().GetArchivedMatches(season)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry.([]*sim.Match), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import sim "github.com/Melotachi/GoLeagueMelo/league"
+
+// Honour achievements
+const (
+	HonourWinner   = "winner"
+	HonourRunnerUp = "runner_up"
+)
+
+// LeagueCompetition names the league title in the honours table; cups use the showpiece name
+const LeagueCompetition = "League"
+
+// Honour is a single trophy or runner-up finish won by a team
+type Honour struct {
+	TeamId      int
+	TeamName    string
+	Season      int
+	Competition string
+	Achievement string
+}
+
+// TeamHonours is a team's trophy cabinet
+type TeamHonours struct {
+	TeamId    int
+	TeamName  string
+	Titles    int // league titles
+	CupWins   int
+	RunnerUps int // runner-up finishes in any competition
+	Honours   []*Honour
+}
+
+// leagueHonours awards the title and runner-up spot from a completed season's standings
+func leagueHonours(league *sim.League, summary *SeasonSummary) []*Honour {
+	honours := []*Honour{}
+	for _, entry := range summary.Standings {
+		achievement := ""
+		switch entry.Position {
+		case 1:
+			achievement = HonourWinner
+		case 2:
+			achievement = HonourRunnerUp
+		default:
+			continue
+		}
+
+		honour := &Honour{
+			TeamName:    entry.TeamName,
+			Season:      summary.Season,
+			Competition: LeagueCompetition,
+			Achievement: achievement,
+		}
+		for _, team := range league.Teams {
+			if team.TeamName == entry.TeamName {
+				honour.TeamId = team.TeamId
+			}
+		}
+		honours = append(honours, honour)
+	}
+	return honours
+}
+
+// showpieceHonours awards a decided cup final to its winner and runner-up
+func showpieceHonours(showpiece *sim.ShowpieceMatch, season int) []*Honour {
+	if showpiece.WinnerName == "" {
+		return nil
+	}
+
+	winner, runnerUp := showpiece.HomeTeam, showpiece.AwayTeam
+	if showpiece.WinnerName != winner.TeamName {
+		winner, runnerUp = runnerUp, winner
+	}
+
+	return []*Honour{
+		{TeamId: winner.TeamId, TeamName: winner.TeamName, Season: season, Competition: showpiece.Name, Achievement: HonourWinner},
+		{TeamId: runnerUp.TeamId, TeamName: runnerUp.TeamName, Season: season, Competition: showpiece.Name, Achievement: HonourRunnerUp},
+	}
+}
+
+// buildTeamHonours tallies a team's honours by kind
+func buildTeamHonours(team *sim.Team, honours []*Honour) *TeamHonours {
+	cabinet := &TeamHonours{
+		TeamId:   team.TeamId,
+		TeamName: team.TeamName,
+		Honours:  []*Honour{},
+	}
+
+	for _, honour := range honours {
+		cabinet.Honours = append(cabinet.Honours, honour)
+		switch {
+		case honour.Achievement == HonourRunnerUp:
+			cabinet.RunnerUps++
+		case honour.Competition == LeagueCompetition:
+			cabinet.Titles++
+		default:
+			cabinet.CupWins++
+		}
+	}
+
+	return cabinet
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// Invariants checked by checkLeagueIntegrity
+const (
+	CheckGoalsBalance   = "goals_balance"   // goals scored across the league equal goals conceded
+	CheckPlayedCount    = "played_count"    // wins, draws and losses add up to the matches played
+	CheckPointsFormula  = "points_formula"  // points are three per win and one per draw, plus any rule points
+	CheckGoalDifference = "goal_difference" // goal difference is goals for minus goals against
+	CheckTeamStats      = "team_stats"      // stored team statistics agree with the match results
+	CheckTablePositions = "table_positions" // the table lists every team once at positions 1..N
+	CheckCurrentWeek    = "current_week"    // every fixture up to the current week is played
+)
+
+// errInvariantViolated is returned by persist in debug mode when a mutation breaks an invariant
+var errInvariantViolated = errors.New("league invariant violated")
+
+// IntegrityViolation is one broken invariant
+type IntegrityViolation struct {
+	Check    string
+	TeamName string `json:",omitempty"`
+	Detail   string
+}
+
+// IntegrityReport is the result of checking a league's invariants
+type IntegrityReport struct {
+	OK         bool
+	CheckedAt  time.Time
+	Teams      int
+	Matches    int
+	Played     int
+	Violations []IntegrityViolation
+}
+
+// invariantChecksEnabled reports whether LEAGUE_DEBUG_INVARIANTS asks for the
+// invariants to be checked after every mutation
+func invariantChecksEnabled() bool {
+	value := os.Getenv("LEAGUE_DEBUG_INVARIANTS")
+	return value != "" && value != "0" && value != "false"
+}
+
+// checkLeagueIntegrity verifies the team statistics and the table against the match
+// results, which are the source of truth
+func checkLeagueIntegrity(league *sim.League) *IntegrityReport {
+	report := &IntegrityReport{CheckedAt: time.Now().UTC(), Teams: len(league.Teams), Matches: len(league.Matches), Violations: []IntegrityViolation{}}
+	violate := func(check, team, format string, args ...interface{}) {
+		report.Violations = append(report.Violations, IntegrityViolation{Check: check, TeamName: team, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	// Statistics recomputed from the played matches
+	expected := sim.BuildLeagueTable(league, func(match *sim.Match) bool { return true })
+	fromMatches := make(map[string]*sim.LeagueTableEntry)
+	for _, entry := range expected {
+		fromMatches[entry.TeamName] = entry
+	}
+	for _, match := range league.Matches {
+		if match.Played {
+			report.Played++
+		} else if match.Week <= league.CurrentWeek {
+			violate(CheckCurrentWeek, "", "match %d of week %d is unplayed but the current week is %d", match.MatchId, match.Week, league.CurrentWeek)
+		}
+	}
+
+	goalsFor, goalsAgainst := 0, 0
+	sport := sim.LeagueSport(league)
+	for _, team := range league.Teams {
+		goalsFor += team.GoalsFor
+		goalsAgainst += team.GoalsAgainst
+		// Team records do not split out results after regulation time; the matches do
+		want := fromMatches[team.TeamName]
+		if points := sport.RecordPoints(team.Wins, team.Draws, want.OvertimeWins, want.OvertimeLosses); team.Points != points {
+			violate(CheckPointsFormula, team.TeamName, "team has %d points, its %d wins and %d draws give %d", team.Points, team.Wins, team.Draws, points)
+		}
+		if team.GoalsDifference != team.GoalsFor-team.GoalsAgainst {
+			violate(CheckGoalDifference, team.TeamName, "team goal difference is %d, goals %d-%d give %d", team.GoalsDifference, team.GoalsFor, team.GoalsAgainst, team.GoalsFor-team.GoalsAgainst)
+		}
+
+		if played := team.Wins + team.Draws + team.Losses; played != want.Played {
+			violate(CheckPlayedCount, team.TeamName, "team has %d results recorded but played %d matches", played, want.Played)
+		}
+		if team.Wins != want.Wins || team.Draws != want.Draws || team.Losses != want.Losses ||
+			team.GoalsFor != want.GoalsFor || team.GoalsAgainst != want.GoalsAgainst {
+			violate(CheckTeamStats, team.TeamName, "team record W%d D%d L%d %d-%d, matches give W%d D%d L%d %d-%d",
+				team.Wins, team.Draws, team.Losses, team.GoalsFor, team.GoalsAgainst,
+				want.Wins, want.Draws, want.Losses, want.GoalsFor, want.GoalsAgainst)
+		}
+	}
+	if goalsFor != goalsAgainst {
+		violate(CheckGoalsBalance, "", "teams have scored %d goals but conceded %d", goalsFor, goalsAgainst)
+	}
+
+	checkTableIntegrity(league, fromMatches, violate)
+
+	report.OK = len(report.Violations) == 0
+	return report
+}
+
+// checkTableIntegrity verifies the league's current table against the match results
+func checkTableIntegrity(league *sim.League, fromMatches map[string]*sim.LeagueTableEntry, violate func(check, team, format string, args ...interface{})) {
+	seen := make(map[string]bool)
+	positions := make(map[int]bool)
+	goalsFor, goalsAgainst := 0, 0
+	for _, entry := range league.LeagueTable {
+		goalsFor += entry.GoalsFor
+		goalsAgainst += entry.GoalsAgainst
+		if seen[entry.TeamName] {
+			violate(CheckTablePositions, entry.TeamName, "team is listed more than once")
+		}
+		seen[entry.TeamName] = true
+		positions[entry.Position] = true
+
+		want := fromMatches[entry.TeamName]
+		switch {
+		case want == nil:
+			violate(CheckTablePositions, entry.TeamName, "table lists a team that is not in the league")
+			continue
+		case entry.Played != want.Played:
+			violate(CheckPlayedCount, entry.TeamName, "table shows %d played, matches give %d", entry.Played, want.Played)
+		case entry.Points != sim.LeagueSport(league).RecordPoints(entry.Wins, entry.Draws, entry.OvertimeWins, entry.OvertimeLosses)+entry.RulePoints+entry.HandicapPoints:
+			violate(CheckPointsFormula, entry.TeamName, "table shows %d points for %d wins, %d draws, %d rule points and %d handicap points", entry.Points, entry.Wins, entry.Draws, entry.RulePoints, entry.HandicapPoints)
+		case entry.GoalsDifference != entry.GoalsFor-entry.GoalsAgainst:
+			violate(CheckGoalDifference, entry.TeamName, "table goal difference is %d, goals %d-%d", entry.GoalsDifference, entry.GoalsFor, entry.GoalsAgainst)
+		}
+	}
+	if goalsFor != goalsAgainst {
+		violate(CheckGoalsBalance, "", "table shows %d goals scored but %d conceded", goalsFor, goalsAgainst)
+	}
+
+	missing := []string{}
+	for _, team := range league.Teams {
+		if !seen[team.TeamName] {
+			missing = append(missing, team.TeamName)
+		}
+	}
+	if len(missing) > 0 {
+		violate(CheckTablePositions, "", "table is missing %s", strings.Join(missing, ", "))
+	}
+	for position := 1; position <= len(league.LeagueTable); position++ {
+		if !positions[position] {
+			violate(CheckTablePositions, "", "no team is at position %d of %d", position, len(league.LeagueTable))
+		}
+	}
+}
+
+// invariantError summarizes a failed report as an errInvariantViolated error
+func invariantError(report *IntegrityReport) error {
+	details := make([]string, len(report.Violations))
+	for i, violation := range report.Violations {
+		details[i] = violation.Check + ": " + violation.Detail
+		if violation.TeamName != "" {
+			details[i] = violation.Check + " (" + violation.TeamName + "): " + violation.Detail
+		}
+	}
+	return fmt.Errorf("%w: %s", errInvariantViolated, strings.Join(details, "; "))
+}
+
+// TeamRecord is the part of a team's statistics derived from its match results
+type TeamRecord struct {
+	Wins            int
+	Draws           int
+	Losses          int
+	GoalsFor        int
+	GoalsAgainst    int
+	GoalsDifference int
+	Points          int
+}
+
+// TeamRepair is a team whose statistics were recomputed, with its record before and after
+type TeamRepair struct {
+	TeamName string
+	Before   TeamRecord
+	After    TeamRecord
+}
+
+// teamRecord returns a team's current record
+func teamRecord(team *sim.Team) TeamRecord {
+	return TeamRecord{
+		Wins: team.Wins, Draws: team.Draws, Losses: team.Losses, GoalsFor: team.GoalsFor,
+		GoalsAgainst: team.GoalsAgainst, GoalsDifference: team.GoalsDifference, Points: team.Points,
+	}
+}
+
+// recomputeTeamStats resets every team's statistics to those given by the played
+// matches and rebuilds the table; it returns the teams whose statistics changed
+func recomputeTeamStats(league *sim.League) []*TeamRepair {
+	fromMatches := make(map[string]*sim.LeagueTableEntry)
+	for _, entry := range sim.BuildLeagueTable(league, func(match *sim.Match) bool { return true }) {
+		fromMatches[entry.TeamName] = entry
+	}
+
+	repairs := []*TeamRepair{}
+	for _, team := range league.Teams {
+		entry := fromMatches[team.TeamName]
+		before := teamRecord(team)
+		team.Wins, team.Draws, team.Losses = entry.Wins, entry.Draws, entry.Losses
+		team.GoalsFor, team.GoalsAgainst = entry.GoalsFor, entry.GoalsAgainst
+		// Rule and handicap points belong to the table, not the team's record
+		team.GoalsDifference, team.Points = entry.GoalsDifference, entry.Points-entry.RulePoints-entry.HandicapPoints
+		if after := teamRecord(team); after != before {
+			repairs = append(repairs, &TeamRepair{TeamName: team.TeamName, Before: before, After: after})
+		}
+	}
+
+	sim.UpdateLeagueTable(league)
+	return repairs
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Job statuses reported by GET /jobs/{id}
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// Job tracks a long-running operation executed by the background worker
+type Job struct {
+	JobId      int
+	Type       string
+	Status     string
+	Progress   int         // units of work done, e.g. weeks simulated
+	Total      int         // total units of work, 0 while unknown
+	Result     interface{} `json:",omitempty"`
+	Error      string      `json:",omitempty"`
+	CreatedAt  time.Time
+	StartedAt  *time.Time `json:",omitempty"`
+	FinishedAt *time.Time `json:",omitempty"`
+
+	scope *leagueScope // the league the job was submitted for
+}
+
+// JobRun is the work behind a job. It runs with the job's league in ctx, reports
+// progress through the callback and returns the job result.
+type JobRun func(ctx context.Context, progress func(done, total int)) (interface{}, error)
+
+// JobQueue runs submitted jobs one at a time on a single worker goroutine, so
+// heavy operations never run concurrently with each other
+type JobQueue struct {
+	mu     sync.Mutex
+	jobs   map[int]*Job
+	nextId int
+	queue  chan queuedJob
+}
+
+type queuedJob struct {
+	job *Job
+	run JobRun
+}
+
+var jobQueue = NewJobQueue(100)
+
+// NewJobQueue creates a queue holding up to capacity pending jobs and starts its worker
+func NewJobQueue(capacity int) *JobQueue {
+	q := &JobQueue{
+		jobs:   make(map[int]*Job),
+		nextId: 1,
+		queue:  make(chan queuedJob, capacity),
+	}
+	go q.work()
+	return q
+}
+
+// Submit queues run on scope's league and returns a snapshot of the new job
+func (q *JobQueue) Submit(scope *leagueScope, jobType string, run JobRun) (Job, error) {
+	q.mu.Lock()
+	job := &Job{
+		JobId:     q.nextId,
+		Type:      jobType,
+		Status:    JobQueued,
+		CreatedAt: time.Now().UTC(),
+		scope:     scope,
+	}
+	q.nextId++
+	q.jobs[job.JobId] = job
+	snapshot := *job
+	q.mu.Unlock()
+
+	select {
+	case q.queue <- queuedJob{job: job, run: run}:
+		return snapshot, nil
+	default:
+		q.mu.Lock()
+		delete(q.jobs, job.JobId)
+		q.mu.Unlock()
+		return Job{}, fmt.Errorf("job queue is full")
+	}
+}
+
+// Get returns a snapshot of the job with the given ID
+func (q *JobQueue) Get(jobId int) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[jobId]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func (q *JobQueue) work() {
+	for item := range q.queue {
+		q.update(item.job, func(job *Job) {
+			now := time.Now().UTC()
+			job.Status = JobRunning
+			job.StartedAt = &now
+		})
+
+		result, err := q.run(item)
+
+		q.update(item.job, func(job *Job) {
+			now := time.Now().UTC()
+			job.FinishedAt = &now
+			if err != nil {
+				job.Status = JobFailed
+				job.Error = err.Error()
+				return
+			}
+			job.Status = JobCompleted
+			job.Result = result
+		})
+	}
+}
+
+// run executes a job, turning a panic into a job failure so the worker keeps going
+func (q *JobQueue) run(item queuedJob) (result interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			report := newPanicReport(context.Background(), recovered)
+			report.Job = fmt.Sprintf("%s #%d", item.job.Type, item.job.JobId)
+			reportPanic(context.Background(), report)
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	// A tenant's job keeps the tenant from being closed while it runs
+	if tenant := item.job.scope.tenant; tenant != nil {
+		leave, err := tenant.hold()
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+	}
+
+	return item.run(withScope(context.Background(), item.job.scope), func(done, total int) {
+		q.update(item.job, func(job *Job) {
+			job.Progress = done
+			job.Total = total
+		})
+	})
+}
+
+func (q *JobQueue) update(job *Job, fn func(job *Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	fn(job)
+}
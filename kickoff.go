@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zones must resolve on hosts without a zoneinfo database
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// errPlayerProfileNotFound is returned for a player without a saved profile
+var errPlayerProfileNotFound = errors.New("player profile not found")
+
+// requestLocation picks the time zone a response renders kickoffs in: the tz query
+// parameter, else the time zone in the profile of the player parameter, else the league's
+func requestLocation(r *http.Request) (*time.Location, error) {
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		location, err := time.LoadLocation(tz)
+		if err != nil || tz == "Local" {
+			return nil, fmt.Errorf("tz %q is not an IANA time zone like \"Europe/Istanbul\"", tz)
+		}
+		return location, nil
+	}
+	if player := r.URL.Query().Get("player"); player != "" {
+		profile, err := requestScope(r).readStorage().GetPlayerProfile(strings.TrimSpace(player))
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			return nil, errPlayerProfileNotFound
+		}
+		return time.LoadLocation(profile.TimeZone)
+	}
+	return sim.LeagueLocation(), nil
+}
+
+// kickoffLocation resolves the request's time zone, writing the error response and
+// returning false when it cannot
+func kickoffLocation(w http.ResponseWriter, r *http.Request) (*time.Location, bool) {
+	location, err := requestLocation(r)
+	switch {
+	case errors.Is(err, errPlayerProfileNotFound):
+		http.Error(w, "Player profile not found", http.StatusNotFound)
+		return nil, false
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return location, true
+}
+
+// formatLocalKickoff renders a kickoff in a time zone with its UTC offset
+func formatLocalKickoff(kickoff *time.Time, location *time.Location) string {
+	if kickoff == nil {
+		return ""
+	}
+	return kickoff.In(location).Format(time.RFC3339)
+}
+
+// localizeKickoff copies a match with its LocalKickoff set for the time zone
+func localizeKickoff(match *sim.Match, location *time.Location) *sim.Match {
+	copied := *match
+	copied.LocalKickoff = formatLocalKickoff(match.Kickoff, location)
+	return &copied
+}
+
+// localizeKickoffs copies matches with their LocalKickoff set for the time zone
+func localizeKickoffs(matches []*sim.Match, location *time.Location) []*sim.Match {
+	localized := make([]*sim.Match, len(matches))
+	for i, match := range matches {
+		localized[i] = localizeKickoff(match, location)
+	}
+	return localized
+}
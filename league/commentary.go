@@ -0,0 +1,161 @@
+package league
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lateGoalMinute is the minute from which a goal counts as late in commentary
+const lateGoalMinute = 85
+
+// CommentaryGenerator writes a short commentary paragraph for a played match
+type CommentaryGenerator interface {
+	Commentary(match *Match) string
+}
+
+// commentaryGenerator writes each simulated match's commentary; nil stores none.
+// Assign another implementation, e.g. one backed by a language model, to change the text.
+var commentaryGenerator CommentaryGenerator = timelineCommentary{}
+
+// GenerateCommentary sets a played match's commentary with the configured generator
+func GenerateCommentary(match *Match) {
+	if commentaryGenerator == nil || !match.Played {
+		match.Commentary = ""
+		return
+	}
+	match.Commentary = commentaryGenerator.Commentary(match)
+}
+
+// timelineCommentary narrates a match from its event timeline: who scored when, lead
+// changes, comebacks, red cards and VAR decisions. Matches without a timeline (the
+// classic engine, edited results) get a summary of the score and expected goals.
+type timelineCommentary struct{}
+
+func (timelineCommentary) Commentary(match *Match) string {
+	home, away := match.HomeTeam.TeamName, match.AwayTeam.TeamName
+	sentences := []string{commentaryOpening(match)}
+
+	goals := 0
+	for _, event := range match.Events {
+		if event.Type == EventGoal {
+			goals++
+		}
+	}
+	if goals == 0 && match.HomeTeamScore+match.AwayTeamScore > 0 {
+		// No timeline to follow, so describe the result as a whole
+		sentences = append(sentences, fmt.Sprintf("The chances fell %.1f to %.1f on expected goals.", match.HomeXG, match.AwayXG))
+		sentences = append(sentences, commentaryResult(match))
+		return strings.Join(sentences, " ")
+	}
+
+	homeScore, awayScore := 0, 0
+	run, runTeam := 0, "" // goals scored without reply
+	for i, event := range match.Events {
+		minute := commentaryMinute(event)
+		switch event.Type {
+		case EventGoal:
+			scorer, opponent := home, away
+			before := homeScore - awayScore
+			if event.TeamName == home {
+				homeScore++
+			} else {
+				scorer, opponent = away, home
+				awayScore++
+				before = -before
+			}
+			if runTeam == scorer {
+				run++
+			} else {
+				run, runTeam = 1, scorer
+			}
+			penalty := i > 0 && match.Events[i-1].Type == EventPenalty && match.Events[i-1].TeamName == scorer
+			sentences = append(sentences, commentaryGoal(scorer, opponent, minute, before, run, penalty, event.Minute >= lateGoalMinute, homeScore, awayScore))
+		case EventPenalty:
+			scored := i+1 < len(match.Events) && match.Events[i+1].Type == EventGoal && match.Events[i+1].TeamName == event.TeamName
+			if !scored {
+				sentences = append(sentences, fmt.Sprintf("%s missed a penalty %s.", event.TeamName, minute))
+			}
+		case EventRedCard:
+			sentences = append(sentences, fmt.Sprintf("%s were reduced to ten men %s.", event.TeamName, minute))
+		case EventVARGoalDisallowed:
+			sentences = append(sentences, fmt.Sprintf("VAR ruled out a %s goal %s.", event.TeamName, minute))
+		case EventVARPenalty:
+			sentences = append(sentences, fmt.Sprintf("VAR awarded %s a penalty %s.", event.TeamName, minute))
+		}
+	}
+
+	sentences = append(sentences, commentaryResult(match))
+	return strings.Join(sentences, " ")
+}
+
+// commentaryOpening sets the scene: who hosts whom, and where when it isn't the home ground
+func commentaryOpening(match *Match) string {
+	home, away := match.HomeTeam.TeamName, match.AwayTeam.TeamName
+	switch match.Venue {
+	case VenueNeutral:
+		return fmt.Sprintf("%s met %s at a neutral ground.", home, away)
+	case VenueClosedDoors:
+		return fmt.Sprintf("%s hosted %s behind closed doors.", home, away)
+	}
+	return fmt.Sprintf("%s hosted %s.", home, away)
+}
+
+// commentaryGoal describes a goal by what it did to the match: before is the
+// scorer's lead before the goal (negative when trailing) and run the goals the
+// scorer has now scored without reply
+func commentaryGoal(scorer, opponent, minute string, before, run int, penalty, late bool, homeScore, awayScore int) string {
+	score := fmt.Sprintf("%d-%d", homeScore, awayScore)
+	how := ""
+	if penalty {
+		how = " from the spot"
+	}
+
+	sentence := ""
+	switch {
+	case homeScore+awayScore == 1:
+		sentence = fmt.Sprintf("%s opened the scoring%s %s.", scorer, how, minute)
+	case before == -1:
+		sentence = fmt.Sprintf("%s levelled at %s%s %s.", scorer, score, how, minute)
+	case before == 0 && run >= 2:
+		sentence = fmt.Sprintf("Momentum swung to %s, who turned the match around to lead %s%s %s.", scorer, score, how, minute)
+	case before == 0:
+		sentence = fmt.Sprintf("%s went back in front at %s%s %s.", scorer, score, how, minute)
+	case before < -1 && run >= 2:
+		sentence = fmt.Sprintf("%s pulled another back%s %s to make it %s.", scorer, how, minute, score)
+	case before < -1:
+		sentence = fmt.Sprintf("%s pulled one back%s %s to make it %s.", scorer, how, minute, score)
+	case run >= 3:
+		sentence = fmt.Sprintf("%s made it %s%s %s, %d goals without reply from %s.", scorer, score, how, minute, run, opponent)
+	default:
+		sentence = fmt.Sprintf("%s extended their lead to %s%s %s.", scorer, score, how, minute)
+	}
+	if late {
+		sentence = "Late on, " + sentence
+	}
+	return sentence
+}
+
+// commentaryResult closes the commentary with the final score
+func commentaryResult(match *Match) string {
+	home, away := match.HomeTeam.TeamName, match.AwayTeam.TeamName
+	switch {
+	case match.HomeTeamScore == 0 && match.AwayTeamScore == 0:
+		return "It finished goalless."
+	case match.HomeTeamScore == match.AwayTeamScore:
+		return fmt.Sprintf("The points were shared at %d-%d.", match.HomeTeamScore, match.AwayTeamScore)
+	case match.HomeTeamScore > match.AwayTeamScore:
+		return fmt.Sprintf("%s won %d-%d.", home, match.HomeTeamScore, match.AwayTeamScore)
+	}
+	if match.Venue == VenueNeutral {
+		return fmt.Sprintf("%s won %d-%d.", away, match.AwayTeamScore, match.HomeTeamScore)
+	}
+	return fmt.Sprintf("%s won %d-%d away from home.", away, match.AwayTeamScore, match.HomeTeamScore)
+}
+
+// commentaryMinute phrases an event's time, e.g. "on 23 minutes" or "in added time (90+3)"
+func commentaryMinute(event MatchEvent) string {
+	if event.AddedTime > 0 {
+		return fmt.Sprintf("in added time (%d+%d)", event.Minute, event.AddedTime)
+	}
+	return fmt.Sprintf("on %d minutes", event.Minute)
+}
@@ -0,0 +1,96 @@
+package league
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// EffectiveConfig is the configuration the server is currently simulating with
+type EffectiveConfig struct {
+	Path        string
+	LoadedAt    time.Time
+	Fingerprint string // changes whenever the simulation parameters change
+	Simulation  SimulationConfig
+}
+
+var (
+	configMu sync.RWMutex
+	// configLoadedAt and configFingerprint describe the active simulationConfig
+	configLoadedAt    time.Time
+	configFingerprint string
+)
+
+// SetConfig makes config the active configuration for subsequent simulations
+func SetConfig(config SimulationConfig) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	simulationConfig = config
+	configLoadedAt = time.Now().UTC()
+	configFingerprint = simulationConfigFingerprint(config)
+}
+
+// Config returns the active simulation parameters
+func Config() SimulationConfig {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return simulationConfig
+}
+
+// LeagueConfig returns a snapshot of the parameters a league is simulated with: an
+// engine's own, or else the active config, with the league's feature flags over the
+// config's. A week is played on one snapshot, so a reload never changes them
+// halfway through.
+func LeagueConfig(league *League) *SimulationConfig {
+	var config SimulationConfig
+	if league.config != nil {
+		config = *league.config
+	} else {
+		config = Config()
+	}
+	if len(league.Features) > 0 {
+		features := make(map[string]float64, len(config.Features)+len(league.Features))
+		for name, rollout := range config.Features {
+			features[name] = rollout
+		}
+		for name, rollout := range league.Features {
+			features[name] = rollout
+		}
+		config.Features = features
+	}
+	return &config
+}
+
+// MatchConfig returns the config a match is simulated with, set by PlayFixture for
+// league fixtures and by SimulateLogged for the rest; a match without one takes a
+// snapshot of the active config
+func MatchConfig(match *Match) *SimulationConfig {
+	if match.config == nil {
+		config := Config()
+		return &config
+	}
+	return match.config
+}
+
+// simulationConfigFingerprint hashes the config so cached responses that depend on
+// it, such as predictions, are not served across a reload that changed it
+func simulationConfigFingerprint(config SimulationConfig) string {
+	data, _ := json.Marshal(config)
+	hash := fnv.New32a()
+	hash.Write(data)
+	return fmt.Sprintf("%08x", hash.Sum32())
+}
+
+// CurrentConfig returns a snapshot of the active configuration
+func CurrentConfig() EffectiveConfig {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return EffectiveConfig{
+		Path:        SimulationConfigPath(),
+		LoadedAt:    configLoadedAt,
+		Fingerprint: configFingerprint,
+		Simulation:  simulationConfig,
+	}
+}
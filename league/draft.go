@@ -0,0 +1,403 @@
+package league
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Player positions in the draft pool
+const (
+	PositionGoalkeeper = "GK"
+	PositionDefender   = "DEF"
+	PositionMidfielder = "MID"
+	PositionForward    = "FWD"
+)
+
+// draftPositions are the positions in squad order
+var draftPositions = []string{PositionGoalkeeper, PositionDefender, PositionMidfielder, PositionForward}
+
+// DraftFormation is how many players of each position a drafted squad has; a squad
+// is complete after one round per player
+var DraftFormation = map[string]int{
+	PositionGoalkeeper: 1,
+	PositionDefender:   4,
+	PositionMidfielder: 4,
+	PositionForward:    2,
+}
+
+// draftPoolDepth is how many players the pool holds per squad place, so the last
+// picks of each position still have a choice
+const draftPoolDepth = 2
+
+// defaultDraftPickTimeout is how long a player has for a pick unless LEAGUE_DRAFT_PICK_TIMEOUT says otherwise
+const defaultDraftPickTimeout = 2 * time.Minute
+
+// DraftClockInterval is how often the server checks for picks whose time has run out
+const DraftClockInterval = time.Second
+
+// draftChangedBy is recorded in the team audit for the ratings a draft sets
+const draftChangedBy = "draft"
+
+var (
+	ErrDraftExists     = errors.New("this season already has a draft")
+	ErrDraftNotFound   = errors.New("this season has no draft")
+	ErrDraftInProgress = errors.New("the draft is still in progress")
+	ErrDraftComplete   = errors.New("the draft is complete")
+	ErrNotOnTheClock   = errors.New("it is not this team's pick")
+	ErrInvalidPick     = errors.New("invalid pick")
+)
+
+// Draft is a snake draft of the season's squads from a generated player pool. Teams
+// pick in Order in odd rounds and in reverse in even ones.
+type Draft struct {
+	Season        int
+	Order         []int // team IDs in first-round order
+	Rounds        int
+	StartedAt     time.Time
+	PickStartedAt time.Time  // when the team on the clock started its pick
+	CompletedAt   *time.Time `json:",omitempty"`
+	Players       []*DraftPlayer
+}
+
+// DraftPlayer is a player in the draft pool; TeamId and Pick are set once drafted
+type DraftPlayer struct {
+	PlayerId   int
+	Name       string
+	Position   string
+	Rating     int
+	TeamId     int        `json:",omitempty"`
+	Pick       int        `json:",omitempty"` // overall pick number, from 1
+	PickedAt   *time.Time `json:",omitempty"`
+	AutoPicked bool       `json:",omitempty"` // picked by the simulator, for an uncontrolled team or after the timer ran out
+}
+
+// DraftTurn is the pick on the clock
+type DraftTurn struct {
+	Pick     int
+	Round    int
+	TeamId   int
+	TeamName string
+	Player   string     `json:",omitempty"` // the team's controller; the simulator picks for teams without one
+	Deadline *time.Time `json:",omitempty"` // none when LEAGUE_DRAFT_PICK_TIMEOUT is 0
+}
+
+// DraftStatus is a draft as served by GET /league/draft
+type DraftStatus struct {
+	Season      int
+	Status      string // "in_progress" or "complete"
+	Order       []int
+	Rounds      int
+	StartedAt   time.Time
+	CompletedAt *time.Time     `json:",omitempty"`
+	OnTheClock  *DraftTurn     `json:",omitempty"`
+	Picks       []*DraftPlayer // drafted players in pick order
+	Available   int            // players still in the pool
+}
+
+var playerFirstNames = []string{"Aaron", "Bruno", "Callum", "Dani", "Emile", "Felix", "Gabriel", "Hugo", "Ivan", "Jamal", "Kai", "Luca", "Marco", "Nico", "Oscar", "Pedro", "Rafael", "Sami", "Theo", "Youssef"}
+var playerLastNames = []string{"Adeyemi", "Barros", "Carvalho", "Dunne", "Eriksen", "Fofana", "Grealish", "Haaland", "Iwobi", "Jansen", "Kessie", "Lindqvist", "Mbeumo", "Nunez", "Ortega", "Pereira", "Quansah", "Rice", "Silva", "Tielemans"}
+
+// draftPickTimeout reads LEAGUE_DRAFT_PICK_TIMEOUT, e.g. 30s or 1h; 0 waits for every pick forever
+func draftPickTimeout() time.Duration {
+	if value := os.Getenv("LEAGUE_DRAFT_PICK_TIMEOUT"); value != "" {
+		if timeout, err := time.ParseDuration(value); err == nil && timeout >= 0 {
+			return timeout
+		}
+		log.Printf("Invalid LEAGUE_DRAFT_PICK_TIMEOUT %q, using default", value)
+	}
+	return defaultDraftPickTimeout
+}
+
+// SquadSize is how many players a drafted squad has
+func SquadSize() int {
+	size := 0
+	for _, count := range DraftFormation {
+		size += count
+	}
+	return size
+}
+
+// NewPlayerPool generates draftPoolDepth players for every squad place of teams
+// squads. Ratings run from 55 to 95, most of them around 75.
+func NewPlayerPool(rng RandomSource, teams int) []*DraftPlayer {
+	pool := []*DraftPlayer{}
+	taken := make(map[string]bool)
+	for _, position := range draftPositions {
+		for i := 0; i < DraftFormation[position]*teams*draftPoolDepth; i++ {
+			// Prefer a name not yet in the pool; a big pool may have to repeat one
+			name := ""
+			for attempt := 0; attempt < 10 && (name == "" || taken[name]); attempt++ {
+				name = playerFirstNames[rng.Intn(len(playerFirstNames))] + " " + playerLastNames[rng.Intn(len(playerLastNames))]
+			}
+			taken[name] = true
+			pool = append(pool, &DraftPlayer{
+				PlayerId: len(pool) + 1,
+				Name:     name,
+				Position: position,
+				Rating:   55 + rng.Intn(21) + rng.Intn(21),
+			})
+		}
+	}
+	return pool
+}
+
+// DraftInProgress reports whether the league's draft still has picks to make
+func DraftInProgress(league *League) bool {
+	return league.Draft != nil && league.Draft.CompletedAt == nil
+}
+
+// CheckDraft keeps the season from being played while its squads are being drafted
+func CheckDraft(league *League) error {
+	if DraftInProgress(league) {
+		return fmt.Errorf("%w: finish it before playing matches; see GET /league/draft", ErrDraftInProgress)
+	}
+	return nil
+}
+
+// draftPickCount is how many players have been drafted
+func draftPickCount(draft *Draft) int {
+	picks := 0
+	for _, player := range draft.Players {
+		if player.Pick > 0 {
+			picks++
+		}
+	}
+	return picks
+}
+
+// draftSlot returns the round and team of an overall pick number: the order runs
+// forwards in odd rounds and backwards in even ones
+func draftSlot(draft *Draft, pick int) (round, teamId int) {
+	teams := len(draft.Order)
+	round = (pick-1)/teams + 1
+	index := (pick - 1) % teams
+	if round%2 == 0 {
+		index = teams - 1 - index
+	}
+	return round, draft.Order[index]
+}
+
+// CurrentDraftTurn describes the pick on the clock, nil once the draft is complete
+func CurrentDraftTurn(league *League, draft *Draft) *DraftTurn {
+	if draft.CompletedAt != nil {
+		return nil
+	}
+	pick := draftPickCount(draft) + 1
+	round, teamId := draftSlot(draft, pick)
+	turn := &DraftTurn{Pick: pick, Round: round, TeamId: teamId}
+	for _, team := range league.Teams {
+		if team.TeamId == teamId {
+			turn.TeamName = team.TeamName
+		}
+	}
+	if controller := league.Controllers[teamId]; controller != nil {
+		turn.Player = controller.Player
+		if timeout := draftPickTimeout(); timeout > 0 {
+			deadline := draft.PickStartedAt.Add(timeout)
+			turn.Deadline = &deadline
+		}
+	}
+	return turn
+}
+
+// squadNeeds is how many more players of each position a team's squad needs
+func squadNeeds(draft *Draft, teamId int) map[string]int {
+	needs := make(map[string]int)
+	for position, count := range DraftFormation {
+		needs[position] = count
+	}
+	for _, player := range draft.Players {
+		if player.TeamId == teamId {
+			needs[player.Position]--
+		}
+	}
+	return needs
+}
+
+// ValidateDraftPick checks that a player is still available and that the team has
+// room for the player's position
+func ValidateDraftPick(draft *Draft, teamId int, player *DraftPlayer) error {
+	if player.Pick > 0 {
+		return fmt.Errorf("%w: %s has already been drafted", ErrInvalidPick, player.Name)
+	}
+	if squadNeeds(draft, teamId)[player.Position] <= 0 {
+		return fmt.Errorf("%w: the squad already has its %d %s", ErrInvalidPick, DraftFormation[player.Position], player.Position)
+	}
+	return nil
+}
+
+// bestAvailable is the simulator's pick for a team: the highest-rated player in a
+// position the squad still needs, the lowest ID on a tie
+func bestAvailable(draft *Draft, teamId int) *DraftPlayer {
+	needs := squadNeeds(draft, teamId)
+	var best *DraftPlayer
+	for _, player := range draft.Players {
+		if player.Pick > 0 || needs[player.Position] <= 0 {
+			continue
+		}
+		if best == nil || player.Rating > best.Rating {
+			best = player
+		}
+	}
+	return best
+}
+
+// PickDraftPlayer gives a player to the team on the clock and starts the next pick at startNext
+func PickDraftPlayer(draft *Draft, player *DraftPlayer, pickedAt, startNext time.Time, auto bool) {
+	pick := draftPickCount(draft) + 1
+	_, teamId := draftSlot(draft, pick)
+	player.TeamId = teamId
+	player.Pick = pick
+	player.PickedAt = &pickedAt
+	player.AutoPicked = auto
+	draft.PickStartedAt = startNext
+}
+
+// AdvanceDraft makes the picks that are due: every pick of a team without a
+// controller, and a controlled team's pick once its time has run out. Each timed-out
+// pick starts the next one's clock at its own deadline. After the last pick it
+// completes the draft. Returns the players picked and the teams' rating changes.
+func AdvanceDraft(league *League, draft *Draft, now time.Time) ([]*DraftPlayer, []*TeamAuditEntry) {
+	picked := []*DraftPlayer{}
+	total := len(draft.Order) * draft.Rounds
+	timeout := draftPickTimeout()
+	for draft.CompletedAt == nil && draftPickCount(draft) < total {
+		_, teamId := draftSlot(draft, draftPickCount(draft)+1)
+		started := draft.PickStartedAt
+		player := bestAvailable(draft, teamId)
+		switch {
+		case league.Controllers[teamId] == nil:
+			PickDraftPlayer(draft, player, started, started, true)
+		case timeout > 0 && !now.Before(started.Add(timeout)):
+			deadline := started.Add(timeout)
+			PickDraftPlayer(draft, player, deadline, deadline, true)
+		default:
+			return picked, nil
+		}
+		picked = append(picked, player)
+	}
+	if draft.CompletedAt != nil {
+		return picked, nil
+	}
+	return picked, completeDraft(league, draft, now)
+}
+
+// ValidateDraftOrder checks that a draft order names every team exactly once
+func ValidateDraftOrder(league *League, order []int) error {
+	if len(order) != len(league.Teams) {
+		return fmt.Errorf("order must list all %d teams", len(league.Teams))
+	}
+	listed := make(map[int]bool)
+	for _, teamId := range order {
+		listed[teamId] = true
+	}
+	for _, team := range league.Teams {
+		if !listed[team.TeamId] {
+			return fmt.Errorf("order must list every team once; team %d is missing", team.TeamId)
+		}
+	}
+	return nil
+}
+
+// RandomDraftOrder shuffles the teams into a first-round order
+func RandomDraftOrder(league *League) []int {
+	order := make([]int, len(league.Teams))
+	for i, team := range league.Teams {
+		order[i] = team.TeamId
+	}
+	rng := LeagueRandom(league)
+	for i := len(order) - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+// squadRatings derives a team's ratings from its drafted squad: strength from the
+// whole squad, attack mostly from the forwards and midfield, defense mostly from the
+// goalkeeper and the back four
+func squadRatings(draft *Draft, teamId int) TeamRatingsUpdate {
+	totals := make(map[string]float64)
+	counts := make(map[string]int)
+	sum := 0.0
+	for _, player := range draft.Players {
+		if player.TeamId == teamId {
+			totals[player.Position] += float64(player.Rating)
+			counts[player.Position]++
+			sum += float64(player.Rating)
+		}
+	}
+	average := func(position string) float64 {
+		return totals[position] / float64(counts[position])
+	}
+	strength := int(math.Round(sum / float64(SquadSize())))
+	attack := int(math.Round(0.6*average(PositionForward) + 0.4*average(PositionMidfielder)))
+	defense := int(math.Round(0.3*average(PositionGoalkeeper) + 0.5*average(PositionDefender) + 0.2*average(PositionMidfielder)))
+	return TeamRatingsUpdate{Strength: &strength, Attack: &attack, Defense: &defense}
+}
+
+// completeDraft closes a draft with every pick made, rates each team by its squad and
+// works the handicaps out again from the new ratings. Returns the rating changes for
+// the team audit.
+func completeDraft(league *League, draft *Draft, now time.Time) []*TeamAuditEntry {
+	draft.CompletedAt = &now
+	changes := []*TeamAuditEntry{}
+	for _, team := range league.Teams {
+		changes = append(changes, ApplyRatingsUpdate(team, squadRatings(draft, team.TeamId), draftChangedBy)...)
+	}
+	// Handicaps follow the drafted strengths
+	league.Handicaps = TeamHandicaps(league.Teams, league.Handicap)
+	UpdateLeagueTable(league)
+	return changes
+}
+
+// BuildDraftStatus describes a draft for GET /league/draft
+func BuildDraftStatus(league *League, draft *Draft) *DraftStatus {
+	status := &DraftStatus{
+		Season:      draft.Season,
+		Status:      "in_progress",
+		Order:       draft.Order,
+		Rounds:      draft.Rounds,
+		StartedAt:   draft.StartedAt,
+		CompletedAt: draft.CompletedAt,
+		OnTheClock:  CurrentDraftTurn(league, draft),
+		Picks:       []*DraftPlayer{},
+	}
+	if draft.CompletedAt != nil {
+		status.Status = "complete"
+	}
+	for _, player := range draft.Players {
+		if player.Pick > 0 {
+			status.Picks = append(status.Picks, player)
+		} else {
+			status.Available++
+		}
+	}
+	sort.Slice(status.Picks, func(i, j int) bool { return status.Picks[i].Pick < status.Picks[j].Pick })
+	return status
+}
+
+// FilterDraftPlayers lists the pool's players best first, optionally only those of a
+// position, those still available or those a team has drafted
+func FilterDraftPlayers(draft *Draft, position string, available bool, teamId int) []*DraftPlayer {
+	players := []*DraftPlayer{}
+	for _, player := range draft.Players {
+		if position != "" && player.Position != position {
+			continue
+		}
+		if available && player.Pick > 0 {
+			continue
+		}
+		if teamId != 0 && player.TeamId != teamId {
+			continue
+		}
+		players = append(players, player)
+	}
+	sort.SliceStable(players, func(i, j int) bool { return players[i].Rating > players[j].Rating })
+	return players
+}
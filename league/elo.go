@@ -0,0 +1,143 @@
+package league
+
+import (
+	"math"
+	"sort"
+)
+
+// Elo parameters for the rating model behind the power rankings
+const (
+	EloBaseRating        = 1500.0
+	EloPointsPerStrength = 20.0 // each strength point above 85 is worth 20 rating points
+	eloKFactor           = 20.0
+	eloHomeAdvantage     = 60.0
+)
+
+// PowerRankingEntry is a team's position in the rating-based power rankings
+type PowerRankingEntry struct {
+	Rank          int
+	TeamName      string
+	Rating        float64
+	RatingChange  float64
+	PreviousRank  int
+	Movement      int // positive when climbing since the previous week
+	TablePosition int
+}
+
+// initialEloRating seeds a rating from the team's configured strength
+func initialEloRating(team *Team) float64 {
+	return EloBaseRating + float64((float64(team.TeamStrength)-85.0)*EloPointsPerStrength)
+}
+
+// EloExpectedScore is the expected result (1 win, 0.5 draw) for the home side, which
+// gets the given share of the home advantage (see HomeAdvantageFactor)
+func EloExpectedScore(homeRating, awayRating, advantageFactor float64) float64 {
+	return 1.0 / (1.0 + portablePow10((awayRating-(homeRating+float64(eloHomeAdvantage*advantageFactor)))/400.0))
+}
+
+// EloActualScore is the home side's result: 1 for a win, 0.5 for a draw, 0 for a loss
+func EloActualScore(homeScore, awayScore int) float64 {
+	switch {
+	case homeScore > awayScore:
+		return 1
+	case homeScore < awayScore:
+		return 0
+	}
+	return 0.5
+}
+
+// EloMarginMultiplier weighs a result by its goal margin: larger margins move ratings further
+func EloMarginMultiplier(homeScore, awayScore int) float64 {
+	margin := math.Abs(float64(homeScore - awayScore))
+	if margin > 1 {
+		return portableLog(margin) + 1
+	}
+	return 1.0
+}
+
+// eloChange is the rating the home side gains from a result, and the away side loses
+func eloChange(homeRating, awayRating float64, homeScore, awayScore int, advantageFactor float64) float64 {
+	actual := EloActualScore(homeScore, awayScore)
+	multiplier := EloMarginMultiplier(homeScore, awayScore)
+	return float64(eloKFactor * multiplier * (actual - EloExpectedScore(homeRating, awayRating, advantageFactor)))
+}
+
+// ComputeEloRatings replays played matches up to the given week in order and returns ratings by team name
+func ComputeEloRatings(league *League, uptoWeek int) map[string]float64 {
+	ratings := make(map[string]float64)
+	for _, team := range league.Teams {
+		ratings[team.TeamName] = initialEloRating(team)
+	}
+
+	config := LeagueConfig(league)
+	played := []*Match{}
+	for _, match := range league.Matches {
+		if match.Played && match.Week <= uptoWeek {
+			played = append(played, match)
+		}
+	}
+	sort.SliceStable(played, func(i, j int) bool {
+		if played[i].Week == played[j].Week {
+			return played[i].MatchId < played[j].MatchId
+		}
+		return played[i].Week < played[j].Week
+	})
+
+	for _, match := range played {
+		home := match.HomeTeam.TeamName
+		away := match.AwayTeam.TeamName
+
+		change := eloChange(ratings[home], ratings[away], match.HomeTeamScore, match.AwayTeamScore, HomeAdvantageFactor(match, config))
+		ratings[home] += change
+		ratings[away] -= change
+	}
+
+	return ratings
+}
+
+// rankByRating orders team names by rating, highest first
+func rankByRating(ratings map[string]float64) []string {
+	names := make([]string, 0, len(ratings))
+	for name := range ratings {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if ratings[names[i]] == ratings[names[j]] {
+			return names[i] < names[j]
+		}
+		return ratings[names[i]] > ratings[names[j]]
+	})
+	return names
+}
+
+// BuildPowerRankings ranks teams by Elo rating with movement since the previous week
+func BuildPowerRankings(league *League) []*PowerRankingEntry {
+	current := ComputeEloRatings(league, league.CurrentWeek)
+	previous := ComputeEloRatings(league, league.CurrentWeek-1)
+
+	previousRanks := make(map[string]int)
+	for i, name := range rankByRating(previous) {
+		previousRanks[name] = i + 1
+	}
+
+	tablePositions := make(map[string]int)
+	for _, entry := range league.LeagueTable {
+		tablePositions[entry.TeamName] = entry.Position
+	}
+
+	rankings := []*PowerRankingEntry{}
+	for i, name := range rankByRating(current) {
+		rank := i + 1
+		rankings = append(rankings, &PowerRankingEntry{
+			Rank:          rank,
+			TeamName:      name,
+			Rating:        math.Round(current[name]*10) / 10,
+			RatingChange:  math.Round((current[name]-previous[name])*10) / 10,
+			PreviousRank:  previousRanks[name],
+			Movement:      previousRanks[name] - rank,
+			TablePosition: tablePositions[name],
+		})
+	}
+
+	return rankings
+}
@@ -0,0 +1,225 @@
+package league
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errEngineTeamsAndStorage is returned by NewEngine when given both teams and storage:
+// a stored league already has its teams
+var errEngineTeamsAndStorage = errors.New("teams cannot be given with storage; the stored league's teams are used")
+
+// errEngineSportAndStorage is returned by NewEngine when given both a sport and storage:
+// a stored league keeps the sport it was created with
+var errEngineSportAndStorage = errors.New("a sport cannot be given with storage; the stored league's sport is used")
+
+// Engine is a league simulation for use from Go code rather than over HTTP. Weeks
+// are played by the same simulation as the server's, so they follow the same rules.
+type Engine struct {
+	league  *League
+	storage Storage
+	ctx     context.Context
+}
+
+// Storage keeps an Engine's league: the league is loaded from it when the engine is
+// created and saved to it after every simulated week
+type Storage interface {
+	// LoadLeague returns the stored league with its table built
+	LoadLeague() (*League, error)
+	// SaveWeek saves the league after a week was played, given the week's results
+	SaveWeek(league *League, played []*Match) error
+}
+
+// engineOptions collects the settings given to NewEngine
+type engineOptions struct {
+	teams   []*Team
+	config  *SimulationConfig
+	storage Storage
+	sport   *Sport
+	seed    *int64
+	ctx     context.Context
+}
+
+// EngineOption configures an Engine created by NewEngine
+type EngineOption func(*engineOptions) error
+
+// WithTeams plays a league between the given teams, with fixtures drawn up under
+// the competition rules. Without it the four default Premier League teams play.
+func WithTeams(teams ...*Team) EngineOption {
+	return func(options *engineOptions) error {
+		if len(teams) < 2 {
+			return fmt.Errorf("a league needs at least 2 teams, got %d", len(teams))
+		}
+		options.teams = teams
+		return nil
+	}
+}
+
+// WithSport plays the league as one of the sport presets. Without it the league
+// plays LEAGUE_SPORT, football when that is unset.
+func WithSport(name string) EngineOption {
+	return func(options *engineOptions) error {
+		sport, err := LookupSport(name)
+		if err != nil {
+			return err
+		}
+		options.sport = sport
+		return nil
+	}
+}
+
+// WithSimulator gives the engine its own simulation parameters. Without it the
+// engine plays with the process-wide ones, see SetConfig.
+func WithSimulator(config SimulationConfig) EngineOption {
+	return func(options *engineOptions) error {
+		if err := validateSimulationConfig(config); err != nil {
+			return err
+		}
+		options.config = &config
+		return nil
+	}
+}
+
+// WithStorage loads the league from storage and saves every simulated week to it.
+// Without it the league is kept in memory only.
+func WithStorage(storage Storage) EngineOption {
+	return func(options *engineOptions) error {
+		if storage == nil {
+			return fmt.Errorf("storage must not be nil")
+		}
+		options.storage = storage
+		return nil
+	}
+}
+
+// WithSeed draws every simulated score from a PCG generator seeded with seed, so the
+// same seed plays the same season on any Go version and platform
+func WithSeed(seed int64) EngineOption {
+	return func(options *engineOptions) error {
+		options.seed = &seed
+		return nil
+	}
+}
+
+// WithEngineContext stops simulation once ctx is done
+func WithEngineContext(ctx context.Context) EngineOption {
+	return func(options *engineOptions) error {
+		options.ctx = ctx
+		return nil
+	}
+}
+
+// NewEngine creates a league simulation configured by options
+func NewEngine(options ...EngineOption) (*Engine, error) {
+	settings := &engineOptions{ctx: context.Background()}
+	for _, option := range options {
+		if err := option(settings); err != nil {
+			return nil, err
+		}
+	}
+	if settings.teams != nil && settings.storage != nil {
+		return nil, errEngineTeamsAndStorage
+	}
+	if settings.sport != nil && settings.storage != nil {
+		return nil, errEngineSportAndStorage
+	}
+
+	var league *League
+	if settings.storage != nil {
+		var err error
+		if league, err = settings.storage.LoadLeague(); err != nil {
+			return nil, err
+		}
+		league.config = settings.config
+	} else {
+		teams := settings.teams
+		if teams == nil {
+			teams = CreatePremierLeagueTeams()
+		}
+		sport := settings.sport
+		if sport == nil {
+			var err error
+			if sport, err = DefaultSport(); err != nil {
+				return nil, err
+			}
+		}
+		AssignSlugs(teams)
+		league = &League{Teams: teams, Sport: sport.Name, config: settings.config}
+		league.Matches = leagueMatches(teams, LeagueConfig(league).Breaks)
+		UpdateLeagueTable(league)
+	}
+	if settings.seed != nil {
+		league.Random = NewPCG(*settings.seed)
+	}
+
+	return &Engine{league: league, storage: settings.storage, ctx: settings.ctx}, nil
+}
+
+// New creates a league simulation configured by options; it is NewEngine under the
+// name callers outside the package use, as in league.New(league.WithSeed(1))
+func New(options ...EngineOption) (*Engine, error) {
+	return NewEngine(options...)
+}
+
+// SimulateWeek plays the next week with fixtures and returns its results
+func (e *Engine) SimulateWeek() ([]*Match, error) {
+	week, err := NextWeek(e.league)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("simulation cancelled: %v", err)
+	}
+	if err := CheckDraft(e.league); err != nil {
+		return nil, err
+	}
+	if err := CheckOrders(e.league, week, time.Now()); err != nil {
+		return nil, err
+	}
+
+	played := []*Match{}
+	for e.league.CurrentWeek < week {
+		played = append(played, WeeklySimulator(e.league)...)
+	}
+	if e.storage != nil {
+		if err := e.storage.SaveWeek(e.league, played); err != nil {
+			return nil, err
+		}
+	}
+	return played, nil
+}
+
+// SimulateSeason plays every remaining week
+func (e *Engine) SimulateSeason() error {
+	if SeasonStateOf(e.league) == SeasonFinished {
+		return ErrSeasonFinished
+	}
+	for HasUnplayedMatches(e.league) {
+		if _, err := e.SimulateWeek(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finished reports whether every fixture has been played
+func (e *Engine) Finished() bool {
+	return !HasUnplayedMatches(e.league)
+}
+
+// Table returns the current league table, leader first
+func (e *Engine) Table() []*LeagueTableEntry {
+	return e.league.LeagueTable
+}
+
+// Predict returns each team's chance of winning the league, in percent
+func (e *Engine) Predict() map[string]float64 {
+	return PredictChampionship(e.league)
+}
+
+// League returns the simulated league
+func (e *Engine) League() *League {
+	return e.league
+}
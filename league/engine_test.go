@@ -0,0 +1,82 @@
+package league
+
+import (
+	"testing"
+)
+
+func TestEngineKeepsItsOwnConfig(t *testing.T) {
+	active := Config()
+
+	capped := DefaultSimulationConfig()
+	capped.MaxGoals = 1
+	first, err := New(WithSeed(1), WithSimulator(capped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncapped := DefaultSimulationConfig()
+	uncapped.MaxGoals = 10
+	if _, err := New(WithSeed(2), WithSimulator(uncapped)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := LeagueConfig(first.League()).MaxGoals; got != 1 {
+		t.Errorf("first engine's max_goals = %d after a second engine was created, want 1", got)
+	}
+	if got := Config().MaxGoals; got != active.MaxGoals {
+		t.Errorf("process-wide max_goals = %d after creating engines, want %d", got, active.MaxGoals)
+	}
+
+	if err := first.SimulateSeason(); err != nil {
+		t.Fatal(err)
+	}
+	for _, match := range first.League().Matches {
+		if match.HomeTeamScore > 1 || match.AwayTeamScore > 1 {
+			t.Fatalf("match %d finished %d-%d, above the engine's max_goals of 1", match.MatchId, match.HomeTeamScore, match.AwayTeamScore)
+		}
+	}
+}
+
+func TestEngineRejectsSettingsOutOfRange(t *testing.T) {
+	// A one-minute period would leave the minute engine's goal timing no room
+	sportPresets["blitz"] = &Sport{Name: "blitz", Periods: 2, PeriodMinutes: 1, GoalRate: 1}
+	defer delete(sportPresets, "blitz")
+
+	simulator := func(change func(config *SimulationConfig)) EngineOption {
+		config := DefaultSimulationConfig()
+		change(&config)
+		return WithSimulator(config)
+	}
+	tests := []struct {
+		name    string
+		option  EngineOption
+		wantErr bool
+	}{
+		{name: "defaults", option: simulator(func(config *SimulationConfig) {})},
+		{name: "every preset sport", option: func(options *engineOptions) error {
+			for _, name := range sportNames() {
+				if name == "blitz" {
+					continue
+				}
+				if err := WithSport(name)(options); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{name: "dixon_coles_rho", option: simulator(func(config *SimulationConfig) { config.DixonColesRho = 1.5 }), wantErr: true},
+		{name: "red_card_chance_per_match", option: simulator(func(config *SimulationConfig) { config.RedCardChancePerMatch = -0.1 }), wantErr: true},
+		{name: "red_card_attack_penalty", option: simulator(func(config *SimulationConfig) { config.RedCardAttackPenalty = 1.2 }), wantErr: true},
+		{name: "red_card_opponent_boost", option: simulator(func(config *SimulationConfig) { config.RedCardOpponentBoost = -0.5 }), wantErr: true},
+		{name: "max_stoppage_minutes", option: simulator(func(config *SimulationConfig) { config.MaxStoppageMinutes = 1000000 }), wantErr: true},
+		{name: "max_first_half_stoppage_minutes", option: simulator(func(config *SimulationConfig) { config.MaxFirstHalfStoppageMinutes = -1 }), wantErr: true},
+		{name: "one-minute periods", option: WithSport("blitz"), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(WithSeed(1), test.option)
+			if (err != nil) != test.wantErr {
+				t.Errorf("New returned %v, want an error: %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package league
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// HomeAdvantage is the strength bonus the home side gets in every engine
+const HomeAdvantage = 5.0
+
+// MatchExplanation records the inputs the simulator used for a match, so a result
+// can be traced back to strengths, form and luck
+type MatchExplanation struct {
+	Engine        string
+	Features      []string `json:",omitempty"`
+	HomeRating    float64  // attack rating against this opponent's defense
+	AwayRating    float64
+	HomeAdvantage float64 // reduced or removed by the match's venue
+	HomeForm      float64 // Elo form applied by the elo_updates model
+	AwayForm      float64
+	HomeBounce    float64 `json:",omitempty"` // new-manager bounce
+	AwayBounce    float64 `json:",omitempty"`
+	HomeFatigue   float64 `json:",omitempty"` // lost after extra time in a recent cup match
+	AwayFatigue   float64 `json:",omitempty"`
+	HomeTactic    string  `json:",omitempty"` // picked by the team's controller in multiplayer mode
+	AwayTactic    string  `json:",omitempty"`
+	// Added to both sides by the two tactics, see tacticAdjustments
+	TacticAdjustment float64 `json:",omitempty"`
+	HomeMorale       float64 // morale multiplier, 1 for neutral morale
+	AwayMorale       float64
+	HomeStrength     float64 // (rating + home advantage + form + bounce - fatigue + tactics) × morale
+	AwayStrength     float64
+	HomeXG           float64
+	AwayXG           float64
+	// Noise added to each side's expected goals by the classic engine (-1 to +1).
+	// The minute engine and the Dixon-Coles model draw goals directly instead.
+	HomeRandomDraw  *float64 `json:",omitempty"`
+	AwayRandomDraw  *float64 `json:",omitempty"`
+	DrawBiasApplied bool     // draw_bias changed the simulated score
+	ResultEdited    bool     // the score was changed by hand after the simulation
+	ResultReported  bool     `json:",omitempty"` // the match was played by its controllers, who reported the score
+	Summary         string
+}
+
+// explainStrengths breaks down both sides' effective strength for a match under config
+func explainStrengths(match *Match, config *SimulationConfig) *MatchExplanation {
+	explanation := &MatchExplanation{
+		HomeRating:    MatchupStrength(match.HomeTeam, match.AwayTeam),
+		AwayRating:    MatchupStrength(match.AwayTeam, match.HomeTeam),
+		HomeAdvantage: float64(HomeAdvantage * HomeAdvantageFactor(match, config)),
+	}
+	if match.hasFeature(FeatureEloUpdates) {
+		explanation.HomeForm = match.HomeTeam.eloAdjustment
+		explanation.AwayForm = match.AwayTeam.eloAdjustment
+	}
+	explanation.HomeBounce = match.HomeTeam.managerBounce
+	explanation.AwayBounce = match.AwayTeam.managerBounce
+	explanation.HomeFatigue = match.HomeTeam.fatigue
+	explanation.AwayFatigue = match.AwayTeam.fatigue
+	explanation.HomeTactic = match.homeTactic
+	explanation.AwayTactic = match.awayTactic
+	explanation.TacticAdjustment = tacticAdjustments[match.homeTactic] + tacticAdjustments[match.awayTactic]
+	explanation.HomeMorale = moraleMultiplier(match.HomeTeam.morale, config)
+	explanation.AwayMorale = moraleMultiplier(match.AwayTeam.morale, config)
+	explanation.HomeStrength = (explanation.HomeRating + explanation.HomeAdvantage + explanation.HomeForm + explanation.HomeBounce - explanation.HomeFatigue + explanation.TacticAdjustment) * explanation.HomeMorale
+	explanation.AwayStrength = (explanation.AwayRating + explanation.AwayForm + explanation.AwayBounce - explanation.AwayFatigue + explanation.TacticAdjustment) * explanation.AwayMorale
+	return explanation
+}
+
+// SummarizeExplanation describes in one sentence how the result relates to the inputs
+func SummarizeExplanation(match *Match) string {
+	e := match.Explanation
+	expected := fmt.Sprintf("%s were expected to score %.1f and %s %.1f", match.HomeTeam.TeamName, e.HomeXG, match.AwayTeam.TeamName, e.AwayXG)
+
+	favourite, underdog := match.HomeTeam.TeamName, match.AwayTeam.TeamName
+	favouriteGoals, underdogGoals := match.HomeTeamScore, match.AwayTeamScore
+	if e.AwayXG > e.HomeXG {
+		favourite, underdog = underdog, favourite
+		favouriteGoals, underdogGoals = underdogGoals, favouriteGoals
+	}
+
+	switch {
+	case e.ResultEdited:
+		return fmt.Sprintf("%s; the score was edited by hand afterwards, so it does not come from the simulation.", expected)
+	case e.ResultReported:
+		return fmt.Sprintf("%s; the match was played by its controllers, who reported the score.", expected)
+	case favouriteGoals > underdogGoals:
+		return fmt.Sprintf("%s; %s won as the model expected.", expected, favourite)
+	case e.DrawBiasApplied && favouriteGoals == underdogGoals:
+		return fmt.Sprintf("%s; the close matchup was pulled to a draw by draw_bias.", expected)
+	case favouriteGoals == underdogGoals:
+		return fmt.Sprintf("%s; the random draw levelled the score.", expected)
+	}
+	return fmt.Sprintf("%s; the random draw let %s beat the favourite %s.", expected, underdog, favourite)
+}
+
+// EncodeExplanation stores a match's explanation as JSON; an empty string means none
+func EncodeExplanation(explanation *MatchExplanation) (string, error) {
+	if explanation == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(explanation)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode match explanation: %v", err)
+	}
+	return string(data), nil
+}
+
+// DecodeExplanation reverses EncodeExplanation
+func DecodeExplanation(value string) (*MatchExplanation, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var explanation MatchExplanation
+	if err := json.Unmarshal([]byte(value), &explanation); err != nil {
+		return nil, fmt.Errorf("failed to decode match explanation: %v", err)
+	}
+	return &explanation, nil
+}
@@ -0,0 +1,46 @@
+package league
+
+// computeFatigue returns the strength each team loses in the given league week. A
+// team that went to extra time in a cup match is tired for its first league match
+// after it, when that match is no more than fatigue_window_weeks later. Only cup
+// matches of the current season count, keyed by team ID so renames keep them.
+func computeFatigue(league *League, config *SimulationConfig, week int) map[int]float64 {
+	fatigue := make(map[int]float64)
+	if config.ExtraTimeFatigue <= 0 {
+		return fatigue
+	}
+
+	for _, cupMatch := range league.ExtraTimeMatches {
+		if cupMatch.Season != league.Season || cupMatch.LeagueWeek >= week || week-cupMatch.LeagueWeek > config.FatigueWindowWeeks {
+			continue
+		}
+		for _, team := range []*Team{cupMatch.HomeTeam, cupMatch.AwayTeam} {
+			if nextLeagueWeek(league, team.TeamId, cupMatch.LeagueWeek) == week {
+				fatigue[team.TeamId] = config.ExtraTimeFatigue
+			}
+		}
+	}
+	return fatigue
+}
+
+// nextLeagueWeek is the week of a team's first league fixture after the given week, or 0
+func nextLeagueWeek(league *League, teamId, afterWeek int) int {
+	next := 0
+	for _, match := range league.Matches {
+		if match.Week <= afterWeek || (next != 0 && match.Week >= next) {
+			continue
+		}
+		if match.HomeTeam.TeamId == teamId || match.AwayTeam.TeamId == teamId {
+			next = match.Week
+		}
+	}
+	return next
+}
+
+// RefreshFatigue sets each team's fatigue for its league match in the given week
+func RefreshFatigue(league *League, config *SimulationConfig, week int) {
+	fatigue := computeFatigue(league, config, week)
+	for _, team := range league.Teams {
+		team.fatigue = fatigue[team.TeamId]
+	}
+}
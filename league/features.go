@@ -0,0 +1,220 @@
+package league
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Experimental simulation models that can be switched on through SimulationConfig.Features,
+// or for one league through League.Features
+const (
+	FeatureMinuteEngine = "minute_engine" // play matches minute by minute regardless of Engine
+	FeatureDixonColes   = "dixon_coles"   // draw classic-engine scores from a Dixon-Coles Poisson model
+	FeatureEloUpdates   = "elo_updates"   // shift team strength by the Elo rating earned so far
+)
+
+// knownFeatures lists every flag accepted in the config
+var knownFeatures = []string{FeatureMinuteEngine, FeatureDixonColes, FeatureEloUpdates}
+
+// defaultDixonColesRho is the low-score correlation of the Dixon-Coles model; a
+// negative value makes 0-0 and 1-1 more likely and 1-0 and 0-1 less likely
+const defaultDixonColesRho = -0.13
+
+// ValidateFeatures checks that every flag is known and its rollout is a fraction
+func ValidateFeatures(features map[string]float64) error {
+	for name, rollout := range features {
+		known := false
+		for _, feature := range knownFeatures {
+			if feature == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown feature flag %q", name)
+		}
+		if rollout < 0 || rollout > 1 {
+			return fmt.Errorf("feature flag %q rollout must be between 0 and 1", name)
+		}
+	}
+	return nil
+}
+
+// featureBucket places a match in [0, 1) for a flag. The same fixture always lands in
+// the same bucket, and each flag splits matches independently of the others.
+func featureBucket(feature string, matchId int) float64 {
+	hash := fnv.New32a()
+	hash.Write([]byte(feature + ":" + strconv.Itoa(matchId)))
+	return float64(hash.Sum32()) / float64(math.MaxUint32+1)
+}
+
+// featureEnabled reports whether a flag applies to a match. A rollout of 1 enables it
+// for every match, 0 for none, and anything in between runs an A/B split.
+func featureEnabled(config *SimulationConfig, feature string, matchId int) bool {
+	rollout := config.Features[feature]
+	if rollout <= 0 {
+		return false
+	}
+	return rollout >= 1 || featureBucket(feature, matchId) < rollout
+}
+
+// ActiveFeatures returns the config's flags with a non-zero rollout, e.g. "dixon_coles=1"
+func ActiveFeatures(config *SimulationConfig) []string {
+	active := []string{}
+	for _, name := range knownFeatures {
+		if rollout := config.Features[name]; rollout > 0 {
+			active = append(active, fmt.Sprintf("%s=%g", name, rollout))
+		}
+	}
+	return active
+}
+
+// RefreshEloAdjustments converts each team's Elo rating into strength points so the
+// elo_updates model can feed form back into the simulation
+func RefreshEloAdjustments(league *League, config *SimulationConfig) {
+	if config.Features[FeatureEloUpdates] <= 0 {
+		return
+	}
+	ratings := ComputeEloRatings(league, league.CurrentWeek)
+	for _, team := range league.Teams {
+		team.eloAdjustment = (ratings[team.TeamName] - initialEloRating(team)) / EloPointsPerStrength
+	}
+}
+
+// PoissonSample draws a goal count with the given mean
+func PoissonSample(mean float64, rng RandomSource) int {
+	limit := portableExp(-mean)
+	goals, product := 0, rng.Float64()
+	for product > limit {
+		goals++
+		product *= rng.Float64()
+	}
+	return goals
+}
+
+// dixonColesTau is the Dixon-Coles correction for low scorelines
+func dixonColesTau(home, away int, homeMean, awayMean, rho float64) float64 {
+	switch {
+	case home == 0 && away == 0:
+		return 1 - float64(homeMean*awayMean*rho)
+	case home == 0 && away == 1:
+		return 1 + float64(homeMean*rho)
+	case home == 1 && away == 0:
+		return 1 + float64(awayMean*rho)
+	case home == 1 && away == 1:
+		return 1 - rho
+	}
+	return 1
+}
+
+// dixonColesScore draws a scoreline from independent Poisson goals, reweighted by
+// the Dixon-Coles correction through rejection sampling
+func dixonColesScore(homeMean, awayMean, rho float64, rng RandomSource) (int, int) {
+	maxTau := 1.0
+	for _, scoreline := range [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}} {
+		maxTau = math.Max(maxTau, dixonColesTau(scoreline[0], scoreline[1], homeMean, awayMean, rho))
+	}
+	for {
+		home, away := PoissonSample(homeMean, rng), PoissonSample(awayMean, rng)
+		tau := dixonColesTau(home, away, homeMean, awayMean, rho)
+		if tau > 0 && rng.Float64()*maxTau < tau {
+			return home, away
+		}
+	}
+}
+
+// FeatureVariant summarizes played matches with or without an experimental model
+type FeatureVariant struct {
+	Feature      string
+	Variant      string // "on" or "off"
+	Matches      int
+	AverageGoals float64
+	HomeWinPct   float64
+	DrawPct      float64
+	AwayWinPct   float64
+}
+
+// FeatureReport lists the flags the league plays with and the A/B comparison of played matches
+type FeatureReport struct {
+	Flags      map[string]float64 // the config's rollouts with the league's own over them
+	League     map[string]float64 // the rollouts set for this league, see PUT /league/features
+	Active     []string
+	Comparison []*FeatureVariant
+}
+
+// BuildFeatureReport compares played matches simulated with each flag against those
+// simulated without it. Edited results keep the flags of their original simulation.
+func BuildFeatureReport(league *League) FeatureReport {
+	config := LeagueConfig(league)
+	report := FeatureReport{Flags: make(map[string]float64), League: make(map[string]float64), Active: ActiveFeatures(config), Comparison: []*FeatureVariant{}}
+	for name, rollout := range league.Features {
+		report.League[name] = rollout
+	}
+	for _, name := range knownFeatures {
+		report.Flags[name] = config.Features[name]
+	}
+
+	for _, name := range knownFeatures {
+		on := &FeatureVariant{Feature: name, Variant: "on"}
+		off := &FeatureVariant{Feature: name, Variant: "off"}
+		for _, match := range league.Matches {
+			if !match.Played {
+				continue
+			}
+			variant := off
+			if match.hasFeature(name) {
+				variant = on
+			}
+			variant.Matches++
+			variant.AverageGoals += float64(match.HomeTeamScore + match.AwayTeamScore)
+			switch {
+			case match.HomeTeamScore > match.AwayTeamScore:
+				variant.HomeWinPct++
+			case match.HomeTeamScore < match.AwayTeamScore:
+				variant.AwayWinPct++
+			default:
+				variant.DrawPct++
+			}
+		}
+		for _, variant := range []*FeatureVariant{on, off} {
+			if variant.Matches > 0 {
+				count := float64(variant.Matches)
+				variant.AverageGoals = math.Round(variant.AverageGoals/count*100) / 100
+				variant.HomeWinPct = math.Round(variant.HomeWinPct/count*1000) / 10
+				variant.DrawPct = math.Round(variant.DrawPct/count*1000) / 10
+				variant.AwayWinPct = math.Round(variant.AwayWinPct/count*1000) / 10
+			}
+			report.Comparison = append(report.Comparison, variant)
+		}
+	}
+	return report
+}
+
+// hasFeature reports whether the match was simulated with an experimental model
+func (m *Match) hasFeature(feature string) bool {
+	for _, name := range m.Features {
+		if name == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeFeatures stores a match's flags as a sorted comma-separated list
+func EncodeFeatures(features []string) string {
+	sorted := append([]string(nil), features...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// DecodeFeatures reverses EncodeFeatures
+func DecodeFeatures(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,35 @@
+package league
+
+import (
+	"testing"
+)
+
+func TestLeagueFeaturesOverrideConfig(t *testing.T) {
+	config := DefaultSimulationConfig()
+	config.Features = map[string]float64{FeatureDixonColes: 1, FeatureEloUpdates: 0.5}
+	engine, err := New(WithSimulator(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	league := engine.League()
+	league.Features = map[string]float64{FeatureDixonColes: 0, FeatureMinuteEngine: 1}
+
+	flags := LeagueConfig(league).Features
+	for name, want := range map[string]float64{FeatureDixonColes: 0, FeatureEloUpdates: 0.5, FeatureMinuteEngine: 1} {
+		if flags[name] != want {
+			t.Errorf("%s rollout = %v, want %v", name, flags[name], want)
+		}
+	}
+	if config.Features[FeatureDixonColes] != 1 {
+		t.Errorf("the league's flags changed the config it was given")
+	}
+
+	if err := engine.SimulateSeason(); err != nil {
+		t.Fatal(err)
+	}
+	for _, match := range league.Matches {
+		if match.hasFeature(FeatureDixonColes) || !match.hasFeature(FeatureMinuteEngine) {
+			t.Fatalf("match %d was simulated with %v, want the league's flags", match.MatchId, match.Features)
+		}
+	}
+}
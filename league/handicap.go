@@ -0,0 +1,137 @@
+package league
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Handicap modes, which decide how weaker teams are helped along
+const (
+	HandicapNone   = ""       // every team starts level and results stand as played
+	HandicapPoints = "points" // weaker teams start the season with bonus points
+	HandicapLine   = "line"   // weaker teams get a goal start in every match the table counts
+)
+
+// Handicap is a league's handicap: each team's handicap is Rate for every strength
+// point it is behind the strongest team, up to Max, fixed at the start of the season
+type Handicap struct {
+	Mode string
+	Rate float64 // bonus points, or goals of start, per strength point
+	Max  float64 `json:",omitempty"` // the largest handicap a team can get; 0 for no limit
+}
+
+// TeamHandicap is a team's handicap for the season
+type TeamHandicap struct {
+	TeamId   int
+	TeamName string
+	Strength int     // the team's current strength
+	Handicap float64 // bonus points in points mode, goals of start in line mode
+}
+
+// HandicapStatus describes the league's handicap for GET /league/handicap
+type HandicapStatus struct {
+	Handicap
+	Teams []*TeamHandicap // most help first
+}
+
+// ValidateHandicap checks a handicap before it is stored; "none" is read as no handicap
+func ValidateHandicap(handicap *Handicap) error {
+	handicap.Mode = strings.ToLower(strings.TrimSpace(handicap.Mode))
+	if handicap.Mode == "none" {
+		handicap.Mode = HandicapNone
+	}
+	switch {
+	case handicap.Mode != HandicapNone && handicap.Mode != HandicapPoints && handicap.Mode != HandicapLine:
+		return fmt.Errorf("mode must be %s, %s or none", HandicapPoints, HandicapLine)
+	case handicap.Rate < 0 || math.IsNaN(handicap.Rate) || math.IsInf(handicap.Rate, 0):
+		return fmt.Errorf("rate must be a number of at least 0")
+	case handicap.Max < 0 || math.IsNaN(handicap.Max) || math.IsInf(handicap.Max, 0):
+		return fmt.Errorf("max must be a number of at least 0")
+	}
+	if handicap.Mode == HandicapNone {
+		handicap.Rate, handicap.Max = 0, 0
+	}
+	return nil
+}
+
+// TeamHandicaps works out each team's handicap from the current strengths, by team ID.
+// Bonus points are rounded to whole points and goal starts to half goals, so a half
+// goal line rules out a draw and a whole one keeps it.
+func TeamHandicaps(teams []*Team, handicap Handicap) map[int]float64 {
+	handicaps := make(map[int]float64)
+	if handicap.Mode == HandicapNone {
+		return handicaps
+	}
+
+	strongest := 0
+	for _, team := range teams {
+		if team.TeamStrength > strongest {
+			strongest = team.TeamStrength
+		}
+	}
+	for _, team := range teams {
+		value := handicap.Rate * float64(strongest-team.TeamStrength)
+		if handicap.Max > 0 && value > handicap.Max {
+			value = handicap.Max
+		}
+		if handicap.Mode == HandicapPoints {
+			value = math.Round(value)
+		} else {
+			value = math.Round(value*2) / 2
+		}
+		if value > 0 {
+			handicaps[team.TeamId] = value
+		}
+	}
+	return handicaps
+}
+
+// startingPoints are the bonus points a team starts the season with
+func startingPoints(league *League, team *Team) int {
+	if league.Handicap.Mode != HandicapPoints {
+		return 0
+	}
+	return int(league.Handicaps[team.TeamId])
+}
+
+// MatchHandicapLine is the goals the away side is given over the home side in line mode,
+// negative when the home side is the one given a start
+func MatchHandicapLine(league *League, match *Match) float64 {
+	if league.Handicap.Mode != HandicapLine {
+		return 0
+	}
+	return league.Handicaps[match.AwayTeam.TeamId] - league.Handicaps[match.HomeTeam.TeamId]
+}
+
+// handicapPoints are the points the handicap line adds to or takes from each side's
+// points for a played match: the result the table counts is the score with each
+// side's goal start, while the team records keep the score as played. A match won
+// after regulation time stays worth the sport's overtime points.
+func handicapPoints(league *League, match *Match) (int, int) {
+	line := MatchHandicapLine(league, match)
+	if line == 0 {
+		return 0, 0
+	}
+	sport := LeagueSport(league)
+	margin := float64(match.HomeTeamScore - match.AwayTeamScore)
+	homeActual, awayActual := sport.MatchPoints(match)
+	homeAdjusted, awayAdjusted := sport.ResultPoints(margin-line, match.DecidedBy != "")
+	return homeAdjusted - homeActual, awayAdjusted - awayActual
+}
+
+// BuildHandicapStatus describes the league's handicap and the teams it helps
+func BuildHandicapStatus(league *League) *HandicapStatus {
+	status := &HandicapStatus{Handicap: league.Handicap, Teams: []*TeamHandicap{}}
+	for _, team := range league.Teams {
+		status.Teams = append(status.Teams, &TeamHandicap{
+			TeamId:   team.TeamId,
+			TeamName: team.TeamName,
+			Strength: team.TeamStrength,
+			Handicap: league.Handicaps[team.TeamId],
+		})
+	}
+	sort.SliceStable(status.Teams, func(i, j int) bool { return status.Teams[i].Handicap > status.Teams[j].Handicap })
+	return status
+}
@@ -0,0 +1,118 @@
+package league
+
+import (
+	"fmt"
+	"os"
+	"plugin"
+	"strings"
+)
+
+// House rules are injected through Go plugins listed in LEAGUE_RULES_PLUGINS. Each
+// plugin exports a variable named Hooks implementing one or more of the hook interfaces
+// below. Their signatures use only built-in types, so a plugin does not need to import
+// this package. Hooks may be called concurrently by batch runs and must be deterministic:
+// tables are rebuilt from the match results at any time.
+
+// MatchHook runs on every played result and returns bonus points for each side,
+// e.g. a bonus point for winning by three goals; negative values deduct points
+type MatchHook interface {
+	AfterMatch(week int, homeTeam, awayTeam string, homeGoals, awayGoals int) (homeBonus, awayBonus int)
+}
+
+// TableHook runs on each team's standing before the table is sorted and returns points
+// to add or deduct, e.g. a penalty for a team in administration
+type TableHook interface {
+	BeforeTableSort(team string, played, wins, draws, losses, goalsFor, goalsAgainst, points int) (adjustment int)
+}
+
+// SeasonHook runs when a finished season is rolled over, with the final table order and
+// points; the notes it returns are kept in the end-of-season report
+type SeasonHook interface {
+	SeasonEnd(season int, teams []string, points []int) (notes []string)
+}
+
+// rulesHooks holds the loaded hooks in the order their plugins were listed
+var rulesHooks []interface{}
+
+// RulesHooks returns the loaded house rules hooks
+func RulesHooks() []interface{} {
+	return rulesHooks
+}
+
+// SetRulesHooks replaces the house rules hooks, e.g. with none to play by the plain rules
+func SetRulesHooks(hooks []interface{}) {
+	rulesHooks = hooks
+}
+
+// LoadRulesHooks opens the plugins listed in LEAGUE_RULES_PLUGINS, separated by commas
+func LoadRulesHooks() error {
+	value := os.Getenv("LEAGUE_RULES_PLUGINS")
+	if value == "" {
+		return nil
+	}
+
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		p, err := plugin.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open rules plugin %s: %v", path, err)
+		}
+		symbol, err := p.Lookup("Hooks")
+		if err != nil {
+			return fmt.Errorf("rules plugin %s does not export Hooks: %v", path, err)
+		}
+
+		_, matchHook := symbol.(MatchHook)
+		_, tableHook := symbol.(TableHook)
+		_, seasonHook := symbol.(SeasonHook)
+		if !matchHook && !tableHook && !seasonHook {
+			return fmt.Errorf("Hooks in rules plugin %s implements no hook (AfterMatch, BeforeTableSort or SeasonEnd)", path)
+		}
+		rulesHooks = append(rulesHooks, symbol)
+	}
+	return nil
+}
+
+// matchBonus totals the bonus points the hooks award each side of a played match
+func matchBonus(match *Match) (homeBonus, awayBonus int) {
+	for _, hook := range rulesHooks {
+		if h, ok := hook.(MatchHook); ok {
+			home, away := h.AfterMatch(match.Week, match.HomeTeam.TeamName, match.AwayTeam.TeamName, match.HomeTeamScore, match.AwayTeamScore)
+			homeBonus += home
+			awayBonus += away
+		}
+	}
+	return homeBonus, awayBonus
+}
+
+// standingAdjustment totals the points the hooks add to or deduct from a standing
+func standingAdjustment(entry *LeagueTableEntry) int {
+	adjustment := 0
+	for _, hook := range rulesHooks {
+		if h, ok := hook.(TableHook); ok {
+			adjustment += h.BeforeTableSort(entry.TeamName, entry.Played, entry.Wins, entry.Draws, entry.Losses, entry.GoalsFor, entry.GoalsAgainst, entry.Points)
+		}
+	}
+	return adjustment
+}
+
+// SeasonEndNotes collects the hooks' notes on a finished season's final table
+func SeasonEndNotes(season int, table []*LeagueTableEntry) []string {
+	teams := make([]string, len(table))
+	points := make([]int, len(table))
+	for i, entry := range table {
+		teams[i] = entry.TeamName
+		points[i] = entry.Points
+	}
+
+	notes := []string{}
+	for _, hook := range rulesHooks {
+		if h, ok := hook.(SeasonHook); ok {
+			notes = append(notes, h.SeasonEnd(season, teams, points)...)
+		}
+	}
+	return notes
+}
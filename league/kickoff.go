@@ -0,0 +1,96 @@
+package league
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Layouts of the season_start and kickoff_times settings
+const (
+	seasonStartLayout = "01-02"
+	kickoffTimeLayout = "15:04"
+)
+
+// matchDays maps match_day settings to weekdays
+var matchDays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// validateKickoffSchedule checks the time zone, season start, match day and kickoff times
+func validateKickoffSchedule(config SimulationConfig) error {
+	if _, err := time.LoadLocation(config.TimeZone); err != nil || config.TimeZone == "Local" {
+		return fmt.Errorf("timezone %q is not an IANA time zone like \"Europe/London\"", config.TimeZone)
+	}
+	if _, err := time.Parse(seasonStartLayout, config.SeasonStart); err != nil {
+		return fmt.Errorf("season_start must be a month and day like \"08-15\"")
+	}
+	if _, ok := matchDays[strings.ToLower(config.MatchDay)]; !ok {
+		return fmt.Errorf("match_day must be a weekday like \"saturday\"")
+	}
+	if len(config.KickoffTimes) == 0 {
+		return fmt.Errorf("kickoff_times must list at least one time")
+	}
+	for _, kickoff := range config.KickoffTimes {
+		if _, err := time.Parse(kickoffTimeLayout, kickoff); err != nil {
+			return fmt.Errorf("kickoff time %q must be a local time like \"15:00\"", kickoff)
+		}
+	}
+	return nil
+}
+
+// LeagueLocation returns the league's configured time zone
+func LeagueLocation() *time.Location {
+	location, err := time.LoadLocation(Config().TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// kickoffTime returns the UTC kickoff of a week's slot-th fixture. Week 1 is played on
+// the first match day on or after the season start; the kickoff times are used in turn.
+func kickoffTime(season, week, slot int, config SimulationConfig) time.Time {
+	location, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+	start, _ := time.Parse(seasonStartLayout, config.SeasonStart)
+	clock, _ := time.Parse(kickoffTimeLayout, config.KickoffTimes[slot%len(config.KickoffTimes)])
+
+	first := time.Date(season, start.Month(), start.Day(), 0, 0, 0, 0, location)
+	offset := (int(matchDays[strings.ToLower(config.MatchDay)]) - int(first.Weekday()) + 7) % 7
+
+	// Weeks are added to the local date rather than as 168 hours to the instant, so a
+	// 15:00 kickoff stays at 15:00 on the local clock across daylight saving changes
+	kickoff := time.Date(season, start.Month(), start.Day()+offset+7*(week-1), clock.Hour(), clock.Minute(), 0, 0, location)
+	return kickoff.UTC()
+}
+
+// EnsureKickoffs schedules the fixtures that have no kickoff yet, such as those of a
+// league created before kickoff times were stored, and reports whether any were
+func EnsureKickoffs(league *League) bool {
+	config := Config()
+	changed := false
+	slots := make(map[int]int)
+	for _, match := range league.Matches {
+		slot := slots[match.Week]
+		slots[match.Week]++
+		if match.Kickoff == nil {
+			kickoff := kickoffTime(league.Season, match.Week, slot, config)
+			match.Kickoff = &kickoff
+			changed = true
+		}
+	}
+	return changed
+}
+
+// ScheduleKickoffs sets every fixture's kickoff for the league's season, e.g. after the
+// fixtures are regenerated or a new season starts
+func ScheduleKickoffs(league *League) {
+	for _, match := range league.Matches {
+		match.Kickoff = nil
+	}
+	EnsureKickoffs(league)
+}
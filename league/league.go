@@ -0,0 +1,646 @@
+// Package league simulates football leagues: fixtures, matches, tables and
+// whole seasons. Engine runs a league from Go code; New creates one.
+package league
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// HasUnplayedMatches reports whether any fixture is still to be played
+func HasUnplayedMatches(league *League) bool {
+	for _, match := range league.Matches {
+		if !match.Played {
+			return true
+		}
+	}
+	return false
+}
+
+// Team is a club in the league with its season record
+type Team struct {
+	TeamName       string
+	TeamId         int
+	Slug           string // URL-friendly name, see Slugify
+	TeamStrength   int
+	AttackRating   int               `json:",omitempty"` // 0 uses TeamStrength
+	DefenseRating  int               `json:",omitempty"` // 0 uses TeamStrength
+	Stadium        string            `json:",omitempty"`
+	City           string            `json:",omitempty"`
+	LocalizedNames map[string]string `json:",omitempty"` // names by language tag, e.g. "tr"
+	DisplayName    string            `json:",omitempty"` // name for the request's Accept-Language, set on responses
+	TeamBranding
+	eloAdjustment   float64 // strength points earned through Elo, used by the elo_updates model
+	managerBounce   float64 // temporary strength boost after a new manager is appointed
+	morale          float64 // confidence from recent results, see computeMorale
+	fatigue         float64 // strength lost after extra time in a recent cup match, see computeFatigue
+	GoalsFor        int
+	GoalsAgainst    int
+	Wins            int
+	Draws           int
+	Losses          int
+	Points          int
+	GoalsDifference int
+}
+
+// Match is a league fixture and, once played, its result
+type Match struct {
+	MatchId       int
+	Week          int
+	HomeTeam      *Team
+	AwayTeam      *Team
+	HomeTeamScore int
+	AwayTeamScore int
+	Played        bool
+	DecidedBy     string  `json:",omitempty"` // DecidedByOvertime or DecidedByShootout for a match level after regulation time
+	HomeXG        float64 // expected goals the simulation assigned to each side
+	AwayXG        float64
+	Events        []MatchEvent      `json:",omitempty"`
+	Features      []string          `json:",omitempty"` // experimental models the match was simulated with
+	Venue         string            `json:",omitempty"` // VenueNeutral or VenueClosedDoors; empty for the home ground
+	Referee       *Referee          `json:",omitempty"`
+	Explanation   *MatchExplanation `json:"-"`                // simulator inputs, served by /league/matches/{id}/explanation
+	Commentary    string            `json:"-"`                // generated text, served by /league/matches/{id}
+	Kickoff       *time.Time        `json:",omitempty"`       // in UTC
+	LocalKickoff  string            `json:",omitempty"`       // Kickoff in the client's time zone, set on responses
+	SixPointer    *SixPointer       `json:",omitempty"`       // why an upcoming fixture is a six-pointer, set on responses
+	Links         Links             `json:"_links,omitempty"` // related resources, set on responses
+	homeTactic    string            // tactics chosen by human controllers, set just before the simulation
+	awayTactic    string
+	sport         *Sport            // the league's sport, set just before the simulation; nil plays football
+	config        *SimulationConfig // the week's config, set just before the simulation; nil takes the active one
+}
+
+// LeagueTableEntry is one team's row in a league table
+type LeagueTableEntry struct {
+	TeamName        string
+	Played          int
+	Wins            int
+	Draws           int
+	Losses          int
+	GoalsFor        int
+	GoalsAgainst    int
+	GoalsDifference int
+	Points          int
+	Position        int
+	RulePoints      int    `json:",omitempty"` // added or deducted by house rules hooks, included in Points
+	HandicapPoints  int    `json:",omitempty"` // added or deducted by the league's handicap, included in Points
+	OvertimeWins    int    `json:",omitempty"` // wins after regulation time, included in Wins
+	OvertimeLosses  int    `json:",omitempty"` // losses after regulation time, included in Losses
+	Zone            string `json:",omitempty"` // the league zone the position falls in, see GET /league/zones
+	ZoneColor       string `json:",omitempty"` // the zone's colour, when it has one
+	DisplayName     string `json:",omitempty"` // name for the request's Accept-Language, set on responses
+	TeamBranding
+	Links Links `json:"_links,omitempty"` // related resources, set on responses
+}
+
+// League is a season of the league: its teams, fixtures and table
+type League struct {
+	Teams            []*Team
+	Matches          []*Match
+	CurrentWeek      int
+	Season           int // season number, e.g. 2024; advanced when a finished season is rolled over
+	LeagueTable      []*LeagueTableEntry
+	ArchivedAt       *time.Time              // set when the league is soft-deleted (read-only)
+	Version          int                     // bumped whenever the table is recomputed, used for cache invalidation
+	Revision         int                     // persisted state revision shared by every instance using the same database
+	ModifiedAt       *time.Time              // when the revision was last bumped; nil for a league never written
+	Managers         []*Manager              // every manager spell, current and past
+	Referees         []*Referee              // the pool of match officials
+	ExtraTimeMatches []*ShowpieceMatch       // cup matches that went to extra time, for fatigue
+	Random           RandomSource            // seeded source for reproducible runs; nil draws from the shared generator
+	LockedWeeks      map[int]time.Time       // weeks of this season locked by hand against result edits
+	Zones            []*Zone                 // named table zones stored with the league; empty uses the rules' qualification spots
+	Controllers      map[int]*TeamController // human-controlled teams by team ID, see multiplayer.go
+	Orders           []*MatchOrders          // controllers' orders for this season's matches
+	OrdersOpenedAt   *time.Time              // when the next week started taking orders; its deadline runs from here
+	Draft            *Draft                  // this season's squad draft, nil without one; see draft.go
+	Handicap         Handicap                // how weaker teams are helped along, see handicap.go
+	Handicaps        map[int]float64         // each team's handicap for the season by team ID; teams without one are left out
+	Sport            string                  // the sport preset the league was created with, see sport.go; empty is football
+	Features         map[string]float64      // feature flag rollouts set for this league over the config's, see features.go
+	Tenant           string                  // the tenant hosting the league in the server's tenancy mode, for the season log
+	config           *SimulationConfig       // an engine's own simulation parameters, see WithSimulator; nil uses the active config
+}
+
+// CreatePremierLeagueTeams returns the four Premier League teams a new league plays with
+func CreatePremierLeagueTeams() []*Team {
+	teams := []*Team{
+		{TeamName: "Manchester United", TeamId: 1, TeamStrength: 80, City: "Manchester"},
+		{TeamName: "Liverpool", TeamId: 2, TeamStrength: 85, City: "Liverpool"},
+		{TeamName: "Manchester City", TeamId: 3, TeamStrength: 90, City: "Manchester"},
+		{TeamName: "Chelsea", TeamId: 4, TeamStrength: 88, City: "London"},
+	}
+	return teams
+}
+
+// CreatePremierLeagueMatches creates all matches for the league: every team pair
+// meets once per leg, with home and away swapped in alternate legs (see the
+// competition rules for legs and groups)
+func CreatePremierLeagueMatches(teams []*Team) []*Match {
+	return leagueMatches(teams, Config().Breaks)
+}
+
+// leagueMatches creates the matches of CreatePremierLeagueMatches around the given breaks
+func leagueMatches(teams []*Team, breaks []ScheduleBreak) []*Match {
+	matches := []*Match{}
+	matchId := 1
+
+	// Each team plays once per week; for 4 teams the rounds are
+	// Week 1: Team 0 vs Team 1, Team 2 vs Team 3
+	// Week 2: Team 0 vs Team 2, Team 1 vs Team 3
+	// Week 3: Team 0 vs Team 3, Team 1 vs Team 2
+	// Then repeat with reversed home/away for the second leg
+
+	weekFixtures := fixtureRounds(len(teams))
+
+	round := 0
+
+	for leg := 0; leg < competitionRules.Legs; leg++ {
+		for _, fixtures := range weekFixtures {
+			round++
+			week := roundWeek(round, breaks)
+			for _, fixture := range fixtures {
+				home, away := fixture[0], fixture[1]
+				if leg%2 == 1 {
+					home, away = away, home // Reversed
+				}
+				match := &Match{
+					MatchId:       matchId,
+					Week:          week,
+					HomeTeam:      teams[home],
+					AwayTeam:      teams[away],
+					HomeTeamScore: 0,
+					AwayTeamScore: 0,
+					Played:        false,
+				}
+				matches = append(matches, match)
+				matchId++
+			}
+		}
+	}
+
+	return matches
+}
+
+// RoundRobinRounds pairs teams by index so every team meets every other team once.
+// Team 0 stays fixed while the others rotate; with an odd number of teams one team
+// sits out each round.
+func RoundRobinRounds(teamCount int) [][][2]int {
+	slots := teamCount
+	if slots%2 == 1 {
+		slots++ // the extra slot is a bye
+	}
+	rotating := slots - 1
+
+	rounds := [][][2]int{}
+	for k := 0; k < rotating; k++ {
+		fixtures := [][2]int{}
+		pair := func(a, b int) {
+			if a >= teamCount || b >= teamCount {
+				return
+			}
+			if a > b {
+				a, b = b, a
+			}
+			fixtures = append(fixtures, [2]int{a, b})
+		}
+
+		pair(0, 1+k)
+		for i := 1; i <= (rotating-1)/2; i++ {
+			pair(1+(k+i)%rotating, 1+(k-i+rotating)%rotating)
+		}
+		rounds = append(rounds, fixtures)
+	}
+	return rounds
+}
+
+// roundWeek maps a fixture round to its calendar week, shifted by any breaks before it
+func roundWeek(round int, breaks []ScheduleBreak) int {
+	week := round
+	for _, b := range breaks {
+		if b.AfterRound < round {
+			week++
+		}
+	}
+	return week
+}
+
+// WeekHasMatches reports whether any fixture is scheduled in the given week
+func WeekHasMatches(league *League, week int) bool {
+	for _, match := range league.Matches {
+		if match.Week == week {
+			return true
+		}
+	}
+	return false
+}
+
+// simulateMatch simulates a single match with the configured engine and applies the result
+func simulateMatch(match *Match, rng RandomSource) {
+	if match.Played {
+		return
+	}
+
+	SimulateScore(match, rng)
+	applyMatchResult(match)
+	GenerateCommentary(match)
+}
+
+// SimulateScore simulates the score with the configured engine without touching team
+// statistics. Feature flags may swap in experimental models; the ones used are
+// recorded on the match.
+func SimulateScore(match *Match, rng RandomSource) {
+	config := MatchConfig(match)
+	match.Features = nil
+	if featureEnabled(config, FeatureEloUpdates, match.MatchId) {
+		match.Features = append(match.Features, FeatureEloUpdates)
+	}
+
+	engine := config.Engine
+	if engine != EngineMinute && featureEnabled(config, FeatureMinuteEngine, match.MatchId) {
+		engine = EngineMinute
+		match.Features = append(match.Features, FeatureMinuteEngine)
+	}
+
+	match.Explanation = explainStrengths(match, config)
+	match.Explanation.Engine = engine
+	match.DecidedBy = ""
+
+	switch engine {
+	case EngineMinute:
+		simulateMatchByMinute(match, config, rng)
+	default:
+		simulateMatchClassic(match, config, rng)
+		// The minute engine's timeline would no longer match a reweighted score
+		match.Explanation.DrawBiasApplied = applyDrawBias(match, config, rng)
+		settleLevelMatch(match, MatchSport(match), rng)
+	}
+
+	match.Explanation.Features = match.Features
+	match.Explanation.HomeXG = match.HomeXG
+	match.Explanation.AwayXG = match.AwayXG
+	match.Explanation.Summary = SummarizeExplanation(match)
+}
+
+// simulateMatchClassic simulates the final score in one step based on team strength
+func simulateMatchClassic(match *Match, config *SimulationConfig, rng RandomSource) {
+	// Calculate team strength difference and home advantage
+	homeStrength, awayStrength := MatchStrengths(match, config)
+
+	// Calculate attack potential based on strength (0.5 to 4.5 goals expected in football)
+	sport := MatchSport(match)
+	homeAttack := sport.ExpectedGoals(homeStrength)
+	awayAttack := sport.ExpectedGoals(awayStrength)
+
+	// The strength-based attack is the model's expectation before randomness
+	match.HomeXG = homeAttack
+	match.AwayXG = awayAttack
+
+	// Dixon-Coles draws goals from Poisson means instead of rounding a noisy expectation
+	if featureEnabled(config, FeatureDixonColes, match.MatchId) {
+		match.Features = append(match.Features, FeatureDixonColes)
+		homeGoals, awayGoals := dixonColesScore(homeAttack, awayAttack, config.DixonColesRho, rng)
+		match.HomeTeamScore = min(homeGoals, sport.MaxGoals(config))
+		match.AwayTeamScore = min(awayGoals, sport.MaxGoals(config))
+		return
+	}
+
+	// Add some randomness but weighted by strength
+	homeRandomFactor := float64(rng.Float64()*2.0) - 1.0 // -1 to +1
+	awayRandomFactor := float64(rng.Float64()*2.0) - 1.0 // -1 to +1
+
+	homeExpected := homeAttack + homeRandomFactor
+	awayExpected := awayAttack + awayRandomFactor
+	if match.Explanation != nil {
+		match.Explanation.HomeRandomDraw = &homeRandomFactor
+		match.Explanation.AwayRandomDraw = &awayRandomFactor
+	}
+
+	// Ensure minimum 0 goals
+	if homeExpected < 0 {
+		homeExpected = 0
+	}
+	if awayExpected < 0 {
+		awayExpected = 0
+	}
+
+	// Convert to actual goals (Poisson-like distribution simulation)
+	homeTeamScore := int(homeExpected + 0.5) // Round to nearest int
+	awayTeamScore := int(awayExpected + 0.5)
+
+	// Cap maximum goals per team
+	if homeTeamScore > sport.MaxGoals(config) {
+		homeTeamScore = sport.MaxGoals(config)
+	}
+	if awayTeamScore > sport.MaxGoals(config) {
+		awayTeamScore = sport.MaxGoals(config)
+	}
+
+	match.HomeTeamScore = homeTeamScore
+	match.AwayTeamScore = awayTeamScore
+}
+
+// applyMatchResult applies a simulated score to both teams' statistics
+func applyMatchResult(match *Match) {
+	homeTeam := match.HomeTeam
+	awayTeam := match.AwayTeam
+	homeTeamScore := match.HomeTeamScore
+	awayTeamScore := match.AwayTeamScore
+
+	// Update team stats
+	homeTeam.GoalsFor += homeTeamScore
+	awayTeam.GoalsFor += awayTeamScore
+	homeTeam.GoalsAgainst += awayTeamScore
+	awayTeam.GoalsAgainst += homeTeamScore
+
+	// Update points and match results
+	if homeTeamScore > awayTeamScore {
+		homeTeam.Wins++
+		awayTeam.Losses++
+	} else if homeTeamScore < awayTeamScore {
+		awayTeam.Wins++
+		homeTeam.Losses++
+	} else {
+		homeTeam.Draws++
+		awayTeam.Draws++
+	}
+	homePoints, awayPoints := MatchSport(match).MatchPoints(match)
+	homeTeam.Points += homePoints
+	awayTeam.Points += awayPoints
+
+	homeTeam.GoalsDifference = homeTeam.GoalsFor - homeTeam.GoalsAgainst
+	awayTeam.GoalsDifference = awayTeam.GoalsFor - awayTeam.GoalsAgainst
+
+	match.Played = true
+}
+
+// UpdateLeagueTable rebuilds the league table from every played match, after each match
+func UpdateLeagueTable(league *League) {
+	// at each week, the league table is deleted and recreated
+	league.LeagueTable = BuildLeagueTable(league, func(match *Match) bool { return true })
+
+	// Group tables carry their own zones, see GET /league/standings
+	if competitionRules.Format != FormatGroups {
+		ApplyZones(LeagueZones(league).Zones, league.LeagueTable)
+	}
+
+	league.Version++
+}
+
+// BuildLeagueTable builds a sorted league table from the played matches accepted by include
+func BuildLeagueTable(league *League, include func(match *Match) bool) []*LeagueTableEntry {
+	table := []*LeagueTableEntry{}
+
+	// Collect stats from matches instead of team objects
+	teamStats := make(map[string]*LeagueTableEntry)
+	sport := LeagueSport(league)
+
+	// Initialize with team names
+	for _, team := range league.Teams {
+		// A points handicap gives weaker teams a head start
+		bonus := startingPoints(league, team)
+		teamStats[team.TeamName] = &LeagueTableEntry{
+			TeamName:        team.TeamName,
+			TeamBranding:    team.TeamBranding,
+			Played:          0,
+			Wins:            0,
+			Draws:           0,
+			Losses:          0,
+			GoalsFor:        0,
+			GoalsAgainst:    0,
+			GoalsDifference: 0,
+			Points:          bonus,
+			HandicapPoints:  bonus,
+		}
+	}
+
+	// Calculate stats from played matches
+	for _, match := range league.Matches {
+		if match.Played && include(match) {
+			homeEntry := teamStats[match.HomeTeam.TeamName]
+			awayEntry := teamStats[match.AwayTeam.TeamName]
+
+			homeEntry.Played++
+			awayEntry.Played++
+			homeEntry.GoalsFor += match.HomeTeamScore
+			homeEntry.GoalsAgainst += match.AwayTeamScore
+			awayEntry.GoalsFor += match.AwayTeamScore
+			awayEntry.GoalsAgainst += match.HomeTeamScore
+
+			if match.HomeTeamScore > match.AwayTeamScore {
+				homeEntry.Wins++
+				awayEntry.Losses++
+			} else if match.HomeTeamScore < match.AwayTeamScore {
+				awayEntry.Wins++
+				homeEntry.Losses++
+			} else {
+				homeEntry.Draws++
+				awayEntry.Draws++
+			}
+			homePoints, awayPoints := sport.MatchPoints(match)
+			homeEntry.Points += homePoints
+			awayEntry.Points += awayPoints
+
+			// Wins after regulation time are worth less in sports with overtime
+			if match.DecidedBy != "" {
+				winner, loser := homeEntry, awayEntry
+				if match.HomeTeamScore < match.AwayTeamScore {
+					winner, loser = awayEntry, homeEntry
+				}
+				winner.OvertimeWins++
+				loser.OvertimeLosses++
+			}
+
+			// House rules hooks may award bonus points for the result
+			homeBonus, awayBonus := matchBonus(match)
+			homeEntry.RulePoints += homeBonus
+			homeEntry.Points += homeBonus
+			awayEntry.RulePoints += awayBonus
+			awayEntry.Points += awayBonus
+
+			// A handicap line can turn the result the table counts
+			homeHandicap, awayHandicap := handicapPoints(league, match)
+			homeEntry.HandicapPoints += homeHandicap
+			homeEntry.Points += homeHandicap
+			awayEntry.HandicapPoints += awayHandicap
+			awayEntry.Points += awayHandicap
+
+			homeEntry.GoalsDifference = homeEntry.GoalsFor - homeEntry.GoalsAgainst
+			awayEntry.GoalsDifference = awayEntry.GoalsFor - awayEntry.GoalsAgainst
+		}
+	}
+
+	// Convert map to slice
+	for _, entry := range teamStats {
+		table = append(table, entry)
+	}
+
+	// House rules hooks may adjust each standing before the table is sorted
+	for _, entry := range table {
+		adjustment := standingAdjustment(entry)
+		entry.RulePoints += adjustment
+		entry.Points += adjustment
+	}
+
+	// Sort by points (descending), then by the competition rules' tiebreakers
+	sortLeagueTable(league, table, include)
+
+	// Assign positions
+	for i, entry := range table {
+		entry.Position = i + 1
+	}
+
+	return table
+}
+
+// WeeklySimulator simulates every unresolved fixture of the next week and returns the
+// matches played
+func WeeklySimulator(league *League) []*Match {
+	week := league.CurrentWeek + 1
+	config := LeagueConfig(league)
+	simulated := []*Match{}
+	EnsureManagers(league)
+	RefreshEloAdjustments(league, config)
+	RefreshManagerBounces(league, config)
+	RefreshMorale(league)
+	RefreshFatigue(league, config, week)
+	for _, match := range league.Matches {
+		if match.Week == week && !match.Played {
+			PlayFixture(league, match, config)
+			simulated = append(simulated, match)
+		}
+	}
+	league.CurrentWeek = week
+
+	// Later weeks may already be resolved by single-match simulations or entered results
+	AdvanceCurrentWeek(league)
+	UpdateLeagueTable(league)
+
+	// Boards judge their managers once the week's results are in
+	reviewManagers(league, config)
+	return simulated
+}
+
+// ErrSeasonFinished is returned when a week is asked of a season already played out
+var ErrSeasonFinished = errors.New("season is finished")
+
+// NextWeek returns the next week with fixtures still to play, skipping blank break weeks
+func NextWeek(league *League) (int, error) {
+	week := 0
+	for _, match := range league.Matches {
+		if match.Week > league.CurrentWeek && !match.Played && (week == 0 || match.Week < week) {
+			week = match.Week
+		}
+	}
+	if week == 0 {
+		if SeasonStateOf(league) == SeasonFinished {
+			return 0, ErrSeasonFinished
+		}
+		return 0, fmt.Errorf("no more matches to simulate")
+	}
+	return week, nil
+}
+
+// AdvanceCurrentWeek moves CurrentWeek forward over every week whose fixtures are all played
+func AdvanceCurrentWeek(league *League) {
+	totalWeeks := 0
+	pending := make(map[int]bool)
+	for _, match := range league.Matches {
+		if match.Week > totalWeeks {
+			totalWeeks = match.Week
+		}
+		if !match.Played {
+			pending[match.Week] = true
+		}
+	}
+
+	for league.CurrentWeek < totalWeeks && !pending[league.CurrentWeek+1] {
+		league.CurrentWeek++
+	}
+}
+
+// PredictChampionship predicts each team's chance of winning the championship, in percent
+func PredictChampionship(league *League) map[string]float64 {
+	predictions := make(map[string]float64) // map of team name to prediction percentage
+
+	// Calculate remaining matches for each team
+	remainingMatches := make(map[string]int)
+	for _, team := range league.Teams {
+		remainingMatches[team.TeamName] = 0
+	}
+
+	for _, match := range league.Matches {
+		if !match.Played {
+			remainingMatches[match.HomeTeam.TeamName]++
+			remainingMatches[match.AwayTeam.TeamName]++
+		}
+	}
+
+	// Calculate maximum possible points for each team
+	maxPossiblePoints := make(map[string]int)
+	for _, entry := range league.LeagueTable {
+		maxPossiblePoints[entry.TeamName] = entry.Points + (remainingMatches[entry.TeamName] * LeagueSport(league).Points.Win)
+	}
+
+	// Simple prediction algorithm based on:
+	// 1. Current points (40%)
+	// 2. Team strength (30%)
+	// 3. Goal difference (20%)
+	// 4. Recent form/momentum (10%)
+
+	totalWeight := 0.0
+	teamWeights := make(map[string]float64)
+
+	// Optionally regress points towards what the underlying xG deserved
+	config := LeagueConfig(league)
+	luckIndex := make(map[string]float64)
+	if config.RegressLuck {
+		for _, luck := range BuildLuckIndex(league, league.CurrentWeek) {
+			luckIndex[luck.TeamName] = luck.LuckIndex
+		}
+	}
+
+	for _, entry := range league.LeagueTable {
+		// Find team strength
+		var teamStrength float64 = 75 // default
+		for _, team := range league.Teams {
+			if team.TeamName == entry.TeamName {
+				teamStrength = float64(team.TeamStrength)
+				break
+			}
+		}
+
+		// Calculate weighted score
+		points := float64(entry.Points) - config.LuckRegression*luckIndex[entry.TeamName]
+		pointsWeight := math.Max(points, 0) * 0.4
+		strengthWeight := (teamStrength / 100.0) * 30.0
+		gdWeight := math.Max(float64(entry.GoalsDifference)*0.2, 0)
+		formWeight := float64(entry.Wins) * 1.0 // recent form approximation
+
+		weight := pointsWeight + strengthWeight + gdWeight + formWeight
+
+		// Bonus for being in top position
+		if entry.Position == 1 {
+			weight *= 1.2
+		} else if entry.Position == 2 {
+			weight *= 1.1
+		}
+
+		teamWeights[entry.TeamName] = weight
+		totalWeight += weight
+	}
+
+	// Convert to percentages
+	for teamName, weight := range teamWeights {
+		if totalWeight > 0 {
+			predictions[teamName] = (weight / totalWeight) * 100
+		} else {
+			predictions[teamName] = 25.0 // equal chance if no data
+		}
+	}
+
+	return predictions
+}
@@ -0,0 +1,118 @@
+package league
+
+import (
+	"testing"
+)
+
+// bigWinBonus is a house rules match hook: a home win by two or more earns the home
+// side two bonus points
+type bigWinBonus struct{}
+
+func (bigWinBonus) AfterMatch(week int, homeTeam, awayTeam string, homeGoals, awayGoals int) (int, int) {
+	if homeGoals-awayGoals >= 2 {
+		return 2, 0
+	}
+	return 0, 0
+}
+
+// administration is a house rules table hook: the named team loses three points
+type administration string
+
+func (a administration) BeforeTableSort(team string, played, wins, draws, losses, goalsFor, goalsAgainst, points int) int {
+	if team == string(a) {
+		return -3
+	}
+	return 0
+}
+
+func TestBuildLeagueTable(t *testing.T) {
+	defer SetRulesHooks(RulesHooks())
+
+	// A beats B 2-0, B and C draw 1-1 and C beats A 1-0: C 4, A 3, B 1 as played
+	teams := []*Team{
+		{TeamName: "A", TeamId: 1, TeamStrength: 80},
+		{TeamName: "B", TeamId: 2, TeamStrength: 60},
+		{TeamName: "C", TeamId: 3, TeamStrength: 70},
+	}
+	newLeague := func() *League {
+		return &League{Teams: teams, Matches: []*Match{
+			{MatchId: 1, Week: 1, HomeTeam: teams[0], AwayTeam: teams[1], HomeTeamScore: 2, AwayTeamScore: 0, Played: true},
+			{MatchId: 2, Week: 2, HomeTeam: teams[1], AwayTeam: teams[2], HomeTeamScore: 1, AwayTeamScore: 1, Played: true},
+			{MatchId: 3, Week: 3, HomeTeam: teams[2], AwayTeam: teams[0], HomeTeamScore: 1, AwayTeamScore: 0, Played: true},
+		}}
+	}
+
+	// want is a team's standing: its points and the parts of them from house rules and the handicap
+	type want struct {
+		name                               string
+		points, rulePoints, handicapPoints int
+	}
+	tests := []struct {
+		name      string
+		handicap  Handicap
+		handicaps map[int]float64
+		hooks     []interface{}
+		want      []want // in table order
+	}{
+		{
+			name: "as played",
+			want: []want{{"C", 4, 0, 0}, {"A", 3, 0, 0}, {"B", 1, 0, 0}},
+		},
+		{
+			name:     "points handicap",
+			handicap: Handicap{Mode: HandicapPoints, Rate: 0.25}, handicaps: map[int]float64{2: 5, 3: 3},
+			want: []want{{"C", 7, 0, 3}, {"B", 6, 0, 5}, {"A", 3, 0, 0}},
+		},
+		{
+			// A two-goal start for B turns A's 2-0 into a draw and B's draw with C into a win
+			name:     "handicap line",
+			handicap: Handicap{Mode: HandicapLine, Rate: 0.1}, handicaps: map[int]float64{2: 2},
+			want: []want{{"B", 4, 0, 3}, {"C", 3, 0, -1}, {"A", 1, 0, -2}},
+		},
+		{
+			name:  "match hook",
+			hooks: []interface{}{bigWinBonus{}},
+			want:  []want{{"A", 5, 2, 0}, {"C", 4, 0, 0}, {"B", 1, 0, 0}},
+		},
+		{
+			name:  "standing adjustment",
+			hooks: []interface{}{administration("C")},
+			want:  []want{{"A", 3, 0, 0}, {"C", 1, -3, 0}, {"B", 1, 0, 0}},
+		},
+		{
+			name:     "handicap, bonus and adjustment together",
+			handicap: Handicap{Mode: HandicapPoints, Rate: 0.25}, handicaps: map[int]float64{2: 5, 3: 3},
+			hooks: []interface{}{bigWinBonus{}, administration("B")},
+			want:  []want{{"C", 7, 0, 3}, {"A", 5, 2, 0}, {"B", 3, -3, 5}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetRulesHooks(test.hooks)
+			league := newLeague()
+			league.Handicap, league.Handicaps = test.handicap, test.handicaps
+
+			table := BuildLeagueTable(league, func(match *Match) bool { return true })
+			if len(table) != len(test.want) {
+				t.Fatalf("table has %d entries, want %d", len(table), len(test.want))
+			}
+			for i, entry := range table {
+				want := test.want[i]
+				if entry.TeamName != want.name || entry.Points != want.points || entry.RulePoints != want.rulePoints || entry.HandicapPoints != want.handicapPoints {
+					t.Errorf("position %d is %s on %d points (%d rule, %d handicap), want %s on %d (%d rule, %d handicap)",
+						i+1, entry.TeamName, entry.Points, entry.RulePoints, entry.HandicapPoints, want.name, want.points, want.rulePoints, want.handicapPoints)
+				}
+				if entry.Position != i+1 {
+					t.Errorf("%s is in position %d, want %d", entry.TeamName, entry.Position, i+1)
+				}
+			}
+
+			// The records keep the results as played whatever the points
+			for _, entry := range table {
+				if entry.Wins+entry.Draws+entry.Losses != 2 || entry.Played != 2 {
+					t.Errorf("%s has a record of %d-%d-%d from %d played", entry.TeamName, entry.Wins, entry.Draws, entry.Losses, entry.Played)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package league
+
+// Link is a HAL-style link to a related resource
+type Link struct {
+	Href string `json:"href"`
+}
+
+// Links maps relation names to links; responses serve them as _links
+type Links map[string]Link
@@ -0,0 +1,184 @@
+package league
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// sackingWindow is how many of a manager's most recent matches are judged
+const sackingWindow = 4
+
+// Manager is one spell in charge of a team; a team's current manager has no DepartedWeek
+type Manager struct {
+	Id              int
+	TeamName        string
+	Name            string
+	Season          int    // season the manager was appointed in
+	AppointedWeek   int    // 0 when appointed before the first match of the season
+	DepartedSeason  int    `json:",omitempty"`
+	DepartedWeek    int    `json:",omitempty"`
+	DepartureReason string `json:",omitempty"`
+}
+
+// TeamDetail is a team with its current manager, every earlier spell and its morale
+type TeamDetail struct {
+	*Team
+	Manager     *Manager   `json:",omitempty"`
+	Managers    []*Manager `json:",omitempty"`
+	Morale      float64
+	MoraleState string
+}
+
+var managerFirstNames = []string{"Carlo", "Diego", "Eddie", "Graham", "Jürgen", "Mikel", "Nuno", "Roberto", "Sean", "Thomas", "Unai", "Xabi"}
+var managerLastNames = []string{"Alvarez", "Brennan", "Costa", "Doyle", "Fischer", "Garrido", "Hartley", "Kowalski", "Moreau", "Okafor", "Russo", "Whitfield"}
+
+// randomManagerName invents a name for a newly appointed manager
+func randomManagerName() string {
+	return managerFirstNames[rand.Intn(len(managerFirstNames))] + " " + managerLastNames[rand.Intn(len(managerLastNames))]
+}
+
+// currentManager returns the manager in charge of a team, or nil
+func currentManager(league *League, teamName string) *Manager {
+	for _, manager := range league.Managers {
+		if manager.TeamName == teamName && manager.DepartedWeek == 0 {
+			return manager
+		}
+	}
+	return nil
+}
+
+// appointManager puts a new manager in charge of a team from the next match
+func appointManager(league *League, teamName string) *Manager {
+	nextId := 1
+	for _, manager := range league.Managers {
+		if manager.Id >= nextId {
+			nextId = manager.Id + 1
+		}
+	}
+	manager := &Manager{
+		Id:            nextId,
+		TeamName:      teamName,
+		Name:          randomManagerName(),
+		Season:        league.Season,
+		AppointedWeek: league.CurrentWeek,
+	}
+	league.Managers = append(league.Managers, manager)
+	return manager
+}
+
+// EnsureManagers appoints a manager for every team without one, e.g. after an import
+func EnsureManagers(league *League) {
+	for _, team := range league.Teams {
+		if currentManager(league, team.TeamName) == nil {
+			appointManager(league, team.TeamName)
+		}
+	}
+}
+
+// reviewManagers sacks every manager whose last sackingWindow matches this season
+// fell short of the expected points by sacking_threshold or more, and appoints a
+// successor. Returns the managers who were sacked.
+func reviewManagers(league *League, config *SimulationConfig) []*Manager {
+	if config.SackingThreshold <= 0 {
+		return nil
+	}
+
+	sport := LeagueSport(league)
+	sacked := []*Manager{}
+	for _, team := range league.Teams {
+		manager := currentManager(league, team.TeamName)
+		if manager == nil {
+			continue
+		}
+
+		// Matches under this manager, most recent last
+		matches := []*Match{}
+		for _, match := range league.Matches {
+			if !match.Played || match.Week > league.CurrentWeek {
+				continue
+			}
+			if manager.Season == league.Season && match.Week <= manager.AppointedWeek {
+				continue
+			}
+			if match.HomeTeam == team || match.AwayTeam == team {
+				matches = append(matches, match)
+			}
+		}
+		if len(matches) < sackingWindow {
+			continue
+		}
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].Week < matches[j].Week })
+		matches = matches[len(matches)-sackingWindow:]
+
+		points, expected := 0, 0.0
+		for _, match := range matches {
+			homeExpected, awayExpected := expectedPoints(sport, match.HomeXG, match.AwayXG)
+			homePoints, awayPoints := sport.MatchPoints(match)
+			if match.HomeTeam == team {
+				expected += homeExpected
+				points += homePoints
+			} else {
+				expected += awayExpected
+				points += awayPoints
+			}
+		}
+		if expected-float64(points) < config.SackingThreshold {
+			continue
+		}
+
+		manager.DepartedSeason = league.Season
+		manager.DepartedWeek = league.CurrentWeek
+		manager.DepartureReason = fmt.Sprintf("Sacked after %d points from the last %d matches (%.1f expected)", points, sackingWindow, expected)
+		appointManager(league, team.TeamName)
+		sacked = append(sacked, manager)
+	}
+	return sacked
+}
+
+// RefreshManagerBounces gives each team the new-manager bounce it is due for its
+// next match: the full new_manager_bounce at the first match, fading linearly to
+// nothing after new_manager_bounce_weeks
+func RefreshManagerBounces(league *League, config *SimulationConfig) {
+	for _, team := range league.Teams {
+		team.managerBounce = 0
+		manager := currentManager(league, team.TeamName)
+		if manager == nil || manager.Season != league.Season || manager.AppointedWeek == 0 {
+			continue
+		}
+		weeks := config.NewManagerBounceWeeks
+		elapsed := league.CurrentWeek - manager.AppointedWeek
+		if weeks > 0 && elapsed < weeks {
+			team.managerBounce = config.NewManagerBounce * float64(weeks-elapsed) / float64(weeks)
+		}
+	}
+}
+
+// ManagerSackings lists the managers sacked during a season, in order
+func ManagerSackings(league *League, season int) []*Manager {
+	sackings := []*Manager{}
+	for _, manager := range league.Managers {
+		if manager.DepartedWeek > 0 && manager.DepartedSeason == season {
+			sackings = append(sackings, manager)
+		}
+	}
+	return sackings
+}
+
+// BuildTeamDetail gathers a team's managers and current morale for the team endpoint
+func BuildTeamDetail(league *League, team *Team) *TeamDetail {
+	morale := math.Round(computeMorale(league, league.CurrentWeek)[team.TeamName]*10) / 10
+	detail := &TeamDetail{
+		Team:        team,
+		Manager:     currentManager(league, team.TeamName),
+		Morale:      morale,
+		MoraleState: moraleState(morale),
+	}
+	for _, manager := range league.Managers {
+		if manager.TeamName == team.TeamName && manager != detail.Manager {
+			detail.Managers = append(detail.Managers, manager)
+		}
+	}
+	return detail
+}
@@ -0,0 +1,112 @@
+package league
+
+import (
+	"math"
+	"sort"
+)
+
+// Morale swings per result, before the derby multiplier
+const (
+	moraleWin       = 2.0
+	moraleBigWin    = 4.0 // won by three goals or more
+	moraleLoss      = -2.0
+	moraleHeavyLoss = -4.0 // lost by three goals or more
+	moraleDerby     = 2.0  // results against a same-city rival count double
+	moraleDecay     = 0.8  // share of morale carried into the next match
+	moraleLimit     = 10.0
+)
+
+// Morale states, from the team's morale value
+const (
+	MoraleBuoyant   = "buoyant"
+	MoraleConfident = "confident"
+	MoraleSteady    = "steady"
+	MoraleLow       = "low"
+	MoraleCrisis    = "crisis"
+)
+
+// isDerby reports whether two teams share a city
+func isDerby(match *Match) bool {
+	return match.HomeTeam.City != "" && match.HomeTeam.City == match.AwayTeam.City
+}
+
+// moraleChange is how one result moves a team's morale
+func moraleChange(goalsFor, goalsAgainst int, derby bool) float64 {
+	change := 0.0
+	switch margin := goalsFor - goalsAgainst; {
+	case margin >= 3:
+		change = moraleBigWin
+	case margin > 0:
+		change = moraleWin
+	case margin <= -3:
+		change = moraleHeavyLoss
+	case margin < 0:
+		change = moraleLoss
+	}
+	if derby {
+		change = float64(change * moraleDerby)
+	}
+	return change
+}
+
+// computeMorale replays the season's results up to the given week in order. Morale
+// starts at 0, fades towards 0 before each match and is kept between -10 and 10,
+// so it starts afresh every season and follows edited results.
+func computeMorale(league *League, uptoWeek int) map[string]float64 {
+	morale := make(map[string]float64)
+	for _, team := range league.Teams {
+		morale[team.TeamName] = 0
+	}
+
+	played := []*Match{}
+	for _, match := range league.Matches {
+		if match.Played && match.Week <= uptoWeek {
+			played = append(played, match)
+		}
+	}
+	sort.SliceStable(played, func(i, j int) bool {
+		if played[i].Week == played[j].Week {
+			return played[i].MatchId < played[j].MatchId
+		}
+		return played[i].Week < played[j].Week
+	})
+
+	update := func(name string, goalsFor, goalsAgainst int, derby bool) {
+		value := float64(morale[name]*moraleDecay) + moraleChange(goalsFor, goalsAgainst, derby)
+		morale[name] = math.Max(-moraleLimit, math.Min(moraleLimit, value))
+	}
+	for _, match := range played {
+		derby := isDerby(match)
+		update(match.HomeTeam.TeamName, match.HomeTeamScore, match.AwayTeamScore, derby)
+		update(match.AwayTeam.TeamName, match.AwayTeamScore, match.HomeTeamScore, derby)
+	}
+	return morale
+}
+
+// moraleState names a morale value
+func moraleState(morale float64) string {
+	switch {
+	case morale >= 5:
+		return MoraleBuoyant
+	case morale >= 2:
+		return MoraleConfident
+	case morale <= -5:
+		return MoraleCrisis
+	case morale <= -2:
+		return MoraleLow
+	}
+	return MoraleSteady
+}
+
+// moraleMultiplier scales a team's strength by morale_effect per morale point
+func moraleMultiplier(morale float64, config *SimulationConfig) float64 {
+	return 1 + float64(morale*config.MoraleEffect)
+}
+
+// RefreshMorale sets each team's morale going into the next week
+func RefreshMorale(league *League) {
+	morale := computeMorale(league, league.CurrentWeek)
+	for _, team := range league.Teams {
+		team.morale = morale[team.TeamName]
+	}
+}
@@ -0,0 +1,325 @@
+package league
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Ways a player can control a team
+const (
+	ControlTactics = "tactics" // the player picks a tactic for each match and the simulator plays it
+	ControlHotseat = "hotseat" // the players play the match themselves and report the result
+)
+
+// Tactics a controller can pick for a match. A tactic moves both sides' strength by
+// the same amount, so it changes how open the match is rather than who is favoured:
+// attacking makes for more goals at both ends, defensive for a tight match that
+// suits an underdog playing for a draw. The two sides' tactics add up.
+const (
+	TacticBalanced  = "balanced"
+	TacticAttacking = "attacking"
+	TacticDefensive = "defensive"
+)
+
+// tacticAdjustments are the strength points each tactic adds to both sides
+var tacticAdjustments = map[string]float64{
+	TacticBalanced:  0,
+	TacticAttacking: 4,
+	TacticDefensive: -4,
+}
+
+// defaultOrdersTimeout is how long a week waits for its orders unless LEAGUE_ORDERS_TIMEOUT says otherwise
+const defaultOrdersTimeout = 24 * time.Hour
+
+var (
+	ErrAwaitingOrders = errors.New("waiting for orders")
+	ErrTeamControlled = errors.New("team is already controlled by a player")
+	ErrNotControlled  = errors.New("team is not controlled by a player")
+	ErrNotController  = errors.New("a valid X-Controller-Token for a team in this match is required")
+	ErrInvalidOrders  = errors.New("invalid orders")
+	ErrResultDisputed = errors.New("the other side reported a different result")
+)
+
+// TeamController is a player controlling a team in multiplayer mode
+type TeamController struct {
+	TeamId    int
+	TeamName  string `json:",omitempty"` // set on responses
+	Player    string
+	Mode      string
+	ClaimedAt time.Time
+	Token     string `json:",omitempty"` // only set on the response claiming the team
+
+	TokenHash string `json:"-"` // SHA-256 of the token, the only form of it stored
+}
+
+// MatchOrders are a controller's orders for one of its team's matches
+type MatchOrders struct {
+	Season      int
+	MatchId     int
+	TeamId      int
+	Player      string
+	Tactic      string `json:",omitempty"`
+	HomeScore   *int   `json:",omitempty"` // the result reported in hotseat mode
+	AwayScore   *int   `json:",omitempty"`
+	SubmittedAt time.Time
+}
+
+// PendingOrders is a controlled team's match of the next week still waiting for orders
+type PendingOrders struct {
+	MatchId  int
+	TeamId   int
+	TeamName string
+	Player   string
+	Mode     string
+}
+
+// OrdersStatus is the state of the next week's orders, see GET /league/orders
+type OrdersStatus struct {
+	Week     int        // the next week to be played; 0 once the season is over
+	OpenedAt *time.Time `json:",omitempty"`
+	Deadline *time.Time `json:",omitempty"` // none when LEAGUE_ORDERS_TIMEOUT is 0
+	Ready    bool       // the week can be played: every order is in or the deadline has passed
+	Pending  []*PendingOrders
+	Orders   []*MatchOrders // the orders submitted for the week's matches
+}
+
+// ordersTimeout reads LEAGUE_ORDERS_TIMEOUT, e.g. 30m or 48h; 0 waits for orders forever
+func ordersTimeout() time.Duration {
+	if value := os.Getenv("LEAGUE_ORDERS_TIMEOUT"); value != "" {
+		if timeout, err := time.ParseDuration(value); err == nil && timeout >= 0 {
+			return timeout
+		}
+		log.Printf("Invalid LEAGUE_ORDERS_TIMEOUT %q, using default", value)
+	}
+	return defaultOrdersTimeout
+}
+
+// ControllersList returns the league's controllers ordered by team ID, with team names
+func ControllersList(league *League) []*TeamController {
+	names := make(map[int]string)
+	for _, team := range league.Teams {
+		names[team.TeamId] = team.TeamName
+	}
+	controllers := []*TeamController{}
+	for _, controller := range league.Controllers {
+		if name, ok := names[controller.TeamId]; ok {
+			named := *controller
+			named.TeamName = name
+			controllers = append(controllers, &named)
+		}
+	}
+	sort.Slice(controllers, func(i, j int) bool { return controllers[i].TeamId < controllers[j].TeamId })
+	return controllers
+}
+
+// ValidateMatchOrders checks a controller's orders against its mode: a known tactic in
+// tactics mode, a result of two scores from 0 up in hotseat mode
+func ValidateMatchOrders(mode string, orders *MatchOrders) error {
+	if mode == ControlTactics {
+		if orders.HomeScore != nil || orders.AwayScore != nil {
+			return fmt.Errorf("%w: results are only reported in hotseat mode", ErrInvalidOrders)
+		}
+		if _, ok := tacticAdjustments[orders.Tactic]; !ok {
+			return fmt.Errorf("%w: tactic must be %s, %s or %s", ErrInvalidOrders, TacticBalanced, TacticAttacking, TacticDefensive)
+		}
+		return nil
+	}
+	if orders.Tactic != "" {
+		return fmt.Errorf("%w: tactics are only picked in tactics mode", ErrInvalidOrders)
+	}
+	if orders.HomeScore == nil || orders.AwayScore == nil || *orders.HomeScore < 0 || *orders.AwayScore < 0 {
+		return fmt.Errorf("%w: a hotseat result needs home_score and away_score from 0 up", ErrInvalidOrders)
+	}
+	return nil
+}
+
+// MatchOrdersFor returns a team's orders for a match, or nil
+func MatchOrdersFor(league *League, matchId, teamId int) *MatchOrders {
+	for _, orders := range league.Orders {
+		if orders.MatchId == matchId && orders.TeamId == teamId {
+			return orders
+		}
+	}
+	return nil
+}
+
+// ReportedResult returns the result a hotseat controller of either side reported for
+// a match, or nil
+func ReportedResult(league *League, match *Match) *MatchOrders {
+	for _, team := range []*Team{match.HomeTeam, match.AwayTeam} {
+		controller := league.Controllers[team.TeamId]
+		if controller == nil || controller.Mode != ControlHotseat {
+			continue
+		}
+		if orders := MatchOrdersFor(league, match.MatchId, team.TeamId); orders != nil && orders.HomeScore != nil {
+			return orders
+		}
+	}
+	return nil
+}
+
+// orderedTactic is the tactic a tactics controller picked for its team's match; ""
+// when the team is the simulator's or its controller sent no orders
+func orderedTactic(league *League, match *Match, team *Team) string {
+	controller := league.Controllers[team.TeamId]
+	if controller == nil || controller.Mode != ControlTactics {
+		return ""
+	}
+	if orders := MatchOrdersFor(league, match.MatchId, team.TeamId); orders != nil {
+		return orders.Tactic
+	}
+	return ""
+}
+
+// PlayFixture plays an unplayed league fixture: with the result reported for it in
+// hotseat mode, or simulated with the tactics its controllers picked. Without orders,
+// e.g. after the deadline, the simulator plays for the team as for any other. The
+// match is simulated under config, the snapshot its week is played with.
+func PlayFixture(league *League, match *Match, config *SimulationConfig) {
+	match.sport = LeagueSport(league)
+	match.config = config
+	if reported := ReportedResult(league, match); reported != nil {
+		playReportedResult(match, *reported.HomeScore, *reported.AwayScore)
+		return
+	}
+	match.homeTactic = orderedTactic(league, match, match.HomeTeam)
+	match.awayTactic = orderedTactic(league, match, match.AwayTeam)
+	SimulateLogged(league, match, CompetitionLeague, simulateMatch)
+}
+
+// playReportedResult records a result played outside the simulator. The explanation
+// keeps the strengths and expected goals the simulator would have used.
+func playReportedResult(match *Match, homeScore, awayScore int) {
+	match.Features = nil
+	match.Events = nil
+	match.Explanation = explainStrengths(match, MatchConfig(match))
+	match.Explanation.Engine = ControlHotseat
+	match.Explanation.ResultReported = true
+	match.HomeXG = MatchSport(match).ExpectedGoals(match.Explanation.HomeStrength)
+	match.AwayXG = MatchSport(match).ExpectedGoals(match.Explanation.AwayStrength)
+	match.Explanation.HomeXG, match.Explanation.AwayXG = match.HomeXG, match.AwayXG
+	match.HomeTeamScore, match.AwayTeamScore = homeScore, awayScore
+	match.Explanation.Summary = SummarizeExplanation(match)
+	applyMatchResult(match)
+	GenerateCommentary(match)
+}
+
+// nextOrdersWeek is the next week with an unplayed fixture, 0 when there is none
+func nextOrdersWeek(league *League) int {
+	week := 0
+	for _, match := range league.Matches {
+		if match.Week > league.CurrentWeek && !match.Played && (week == 0 || match.Week < week) {
+			week = match.Week
+		}
+	}
+	return week
+}
+
+// pendingOrders lists the controlled teams' unplayed matches up to week that still
+// wait for orders
+func pendingOrders(league *League, week int) []*PendingOrders {
+	pending := []*PendingOrders{}
+	for _, match := range league.Matches {
+		if !match.Played && match.Week > league.CurrentWeek && match.Week <= week {
+			pending = append(pending, pendingMatchOrders(league, match)...)
+		}
+	}
+	return pending
+}
+
+// pendingMatchOrders lists the controlled sides of a match still to send orders: a
+// tactic from a tactics controller, a result from a hotseat one. One hotseat result
+// settles the match for both sides.
+func pendingMatchOrders(league *League, match *Match) []*PendingOrders {
+	if match.Played || ReportedResult(league, match) != nil {
+		return nil
+	}
+	pending := []*PendingOrders{}
+	for _, team := range []*Team{match.HomeTeam, match.AwayTeam} {
+		controller := league.Controllers[team.TeamId]
+		if controller == nil || controller.Mode == ControlTactics && MatchOrdersFor(league, match.MatchId, team.TeamId) != nil {
+			continue
+		}
+		pending = append(pending, &PendingOrders{
+			MatchId:  match.MatchId,
+			TeamId:   team.TeamId,
+			TeamName: team.TeamName,
+			Player:   controller.Player,
+			Mode:     controller.Mode,
+		})
+	}
+	return pending
+}
+
+// ordersDeadline is when the next week stops waiting for orders, nil without a timeout
+func ordersDeadline(league *League) *time.Time {
+	timeout := ordersTimeout()
+	if timeout == 0 || league.OrdersOpenedAt == nil {
+		return nil
+	}
+	deadline := league.OrdersOpenedAt.Add(timeout)
+	return &deadline
+}
+
+// CheckOrders returns ErrAwaitingOrders while a controlled team's match up to week
+// waits for orders and the deadline has not passed
+func CheckOrders(league *League, week int, now time.Time) error {
+	return awaitOrders(league, fmt.Sprintf("week %d", week), pendingOrders(league, week), now)
+}
+
+// CheckMatchOrders is CheckOrders for a single match played on demand
+func CheckMatchOrders(league *League, match *Match, now time.Time) error {
+	return awaitOrders(league, fmt.Sprintf("match %d", match.MatchId), pendingMatchOrders(league, match), now)
+}
+
+// awaitOrders returns ErrAwaitingOrders naming what waits for the pending orders,
+// or nil when there are none or the deadline has passed
+func awaitOrders(league *League, waiter string, pending []*PendingOrders, now time.Time) error {
+	if len(pending) == 0 {
+		return nil
+	}
+	deadline := ordersDeadline(league)
+	if deadline != nil && !now.Before(*deadline) {
+		return nil
+	}
+
+	waiting := []string{}
+	for _, orders := range pending {
+		waiting = append(waiting, fmt.Sprintf("%s (%s) in match %d", orders.TeamName, orders.Player, orders.MatchId))
+	}
+	until := ""
+	if deadline != nil {
+		until = fmt.Sprintf(" until %s", deadline.Format(time.RFC3339))
+	}
+	return fmt.Errorf("%w: %s waits for %s%s; see GET /league/orders", ErrAwaitingOrders, waiter, strings.Join(waiting, ", "), until)
+}
+
+// BuildOrdersStatus describes the next week's orders
+func BuildOrdersStatus(league *League, now time.Time) *OrdersStatus {
+	status := &OrdersStatus{Week: nextOrdersWeek(league), Pending: []*PendingOrders{}, Orders: []*MatchOrders{}}
+	if status.Week == 0 {
+		return status
+	}
+	status.OpenedAt = league.OrdersOpenedAt
+	status.Deadline = ordersDeadline(league)
+	status.Pending = pendingOrders(league, status.Week)
+	status.Ready = CheckOrders(league, status.Week, now) == nil
+
+	weekMatches := make(map[int]bool)
+	for _, match := range league.Matches {
+		if !match.Played && match.Week > league.CurrentWeek && match.Week <= status.Week {
+			weekMatches[match.MatchId] = true
+		}
+	}
+	for _, orders := range league.Orders {
+		if weekMatches[orders.MatchId] {
+			status.Orders = append(status.Orders, orders)
+		}
+	}
+	return status
+}
@@ -0,0 +1,114 @@
+package league
+
+import (
+	"math"
+)
+
+// The scoring pipeline must give bit-identical results on every architecture, so that
+// a seed replays the same season anywhere (see PCG). Two things get in the way:
+//
+//   - The Go spec lets the compiler fuse x*y + z into one fused multiply-add, which
+//     rounds once instead of twice. arm64, ppc64le, s390x and riscv64 do so, amd64
+//     does not. An explicit float64(x*y) conversion forces the rounding and prevents
+//     the fusion, so every product that feeds an addition in the pipeline is wrapped
+//     in one, including products stored in a variable or field and added later.
+//   - math.Exp and math.Log are assembly on some architectures and pure Go on others,
+//     and the two need not agree in the last bit. portableExp and portableLog below
+//     are the fdlibm algorithms behind the pure Go versions, written with the same
+//     conversions, and only use operations IEEE 754 defines exactly.
+//
+// Only +, -, *, /, comparisons, math.Abs, math.Min, math.Max, math.Ldexp and
+// math.Frexp are used besides these; all of them are exact on every platform.
+
+// portableExp returns e**x, computed the same way on every architecture
+func portableExp(x float64) float64 {
+	const (
+		ln2Hi     = 6.93147180369123816490e-01
+		ln2Lo     = 1.90821492927058770002e-10
+		log2e     = 1.44269504088896338700e+00
+		overflow  = 7.09782712893383973096e+02
+		underflow = -7.45133219101941108420e+02
+		nearZero  = 1.0 / (1 << 28)
+	)
+	switch {
+	case math.IsNaN(x) || math.IsInf(x, 1):
+		return x
+	case math.IsInf(x, -1), x < underflow:
+		return 0
+	case x > overflow:
+		return math.Inf(1)
+	case -nearZero < x && x < nearZero:
+		return 1 + x
+	}
+
+	// Reduce x to r = hi - lo with |r| <= ln2/2 and x = k*ln2 + r
+	var k int
+	switch {
+	case x < 0:
+		k = int(float64(log2e*x) - 0.5)
+	case x > 0:
+		k = int(float64(log2e*x) + 0.5)
+	}
+	hi := x - float64(float64(k)*ln2Hi)
+	lo := float64(float64(k) * ln2Lo)
+
+	const (
+		p1 = 1.66666666666666657415e-01
+		p2 = -2.77777777770155933842e-03
+		p3 = 6.61375632143793436117e-05
+		p4 = -1.65339022054652515390e-06
+		p5 = 4.13813679705723846039e-08
+	)
+	r := hi - lo
+	t := float64(r * r)
+	polynomial := p1 + float64(t*(p2+float64(t*(p3+float64(t*(p4+float64(t*p5)))))))
+	c := r - float64(t*polynomial)
+	y := 1 - ((lo - float64(r*c)/(2-c)) - hi)
+	return math.Ldexp(y, k)
+}
+
+// portableLog returns the natural logarithm of x, computed the same way on every architecture
+func portableLog(x float64) float64 {
+	const (
+		ln2Hi = 6.93147180369123816490e-01
+		ln2Lo = 1.90821492927058770002e-10
+		l1    = 6.666666666666735130e-01
+		l2    = 3.999999999940941908e-01
+		l3    = 2.857142874366239149e-01
+		l4    = 2.222219843214978396e-01
+		l5    = 1.818357216161805012e-01
+		l6    = 1.531383769920937332e-01
+		l7    = 1.479819860511658591e-01
+	)
+	switch {
+	case math.IsNaN(x) || math.IsInf(x, 1):
+		return x
+	case x < 0:
+		return math.NaN()
+	case x == 0:
+		return math.Inf(-1)
+	}
+
+	// Reduce x to f1 * 2**ki with sqrt(2)/2 <= f1 < sqrt(2)
+	f1, ki := math.Frexp(x)
+	if f1 < math.Sqrt2/2 {
+		f1 *= 2
+		ki--
+	}
+	f := f1 - 1
+	k := float64(ki)
+
+	s := f / (2 + f)
+	s2 := float64(s * s)
+	s4 := float64(s2 * s2)
+	t1 := float64(s2 * (l1 + float64(s4*(l3+float64(s4*(l5+float64(s4*l7)))))))
+	t2 := float64(s4 * (l2 + float64(s4*(l4+float64(s4*l6)))))
+	r := t1 + t2
+	hfsq := float64(float64(0.5*f) * f)
+	return float64(k*ln2Hi) - ((hfsq - (float64(s*(hfsq+r)) + float64(k*ln2Lo))) - f)
+}
+
+// portablePow10 returns 10**x through portableExp
+func portablePow10(x float64) float64 {
+	return portableExp(float64(x * math.Ln10))
+}
@@ -0,0 +1,161 @@
+package league
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// refereePoolSize is how many referees a league's pool starts with
+const refereePoolSize = 6
+
+// Referee officiates matches; tendencies scale the minute engine's base rates, so 1
+// is an average referee, above 1 stricter and below 1 more lenient
+type Referee struct {
+	Id              int
+	Name            string
+	CardTendency    float64 // yellow and red cards
+	PenaltyTendency float64
+}
+
+// RefereeStats summarizes a referee's matches for GET /league/referees
+type RefereeStats struct {
+	*Referee
+	Matches           int
+	TimedMatches      int // minute-engine matches, the only ones with card and penalty events
+	YellowCards       int
+	RedCards          int
+	Penalties         int
+	CardsPerMatch     float64
+	PenaltiesPerMatch float64
+	HomeWinPercentage float64
+	UpcomingMatches   int
+}
+
+var refereeFirstNames = []string{"Andy", "Anthony", "Chris", "Craig", "Darren", "Jarred", "Michael", "Paul", "Robert", "Simon", "Stuart", "Tim"}
+var refereeLastNames = []string{"Attwell", "Bankes", "Coote", "Dean", "England", "Gillett", "Hooper", "Jones", "Kavanagh", "Madley", "Pawson", "Tierney"}
+
+// newRefereePool generates referees with tendencies spread around the average
+func newRefereePool(size int) []*Referee {
+	pool := make([]*Referee, 0, size)
+	for i := 1; i <= size; i++ {
+		pool = append(pool, &Referee{
+			Id:              i,
+			Name:            refereeFirstNames[rand.Intn(len(refereeFirstNames))] + " " + refereeLastNames[rand.Intn(len(refereeLastNames))],
+			CardTendency:    math.Round((0.6+rand.Float64()*0.9)*100) / 100, // 0.6 to 1.5
+			PenaltyTendency: math.Round((0.5+rand.Float64()*1.1)*100) / 100, // 0.5 to 1.6
+		})
+	}
+	return pool
+}
+
+// EnsureReferees fills an empty pool and appoints a referee to every fixture without
+// one, spreading each week's matches across different referees. Reports whether
+// anything changed.
+func EnsureReferees(league *League) bool {
+	changed := false
+	if len(league.Referees) == 0 {
+		league.Referees = newRefereePool(refereePoolSize)
+		changed = true
+	}
+
+	byWeek := make(map[int][]*Match)
+	for _, match := range league.Matches {
+		byWeek[match.Week] = append(byWeek[match.Week], match)
+	}
+	for _, matches := range byWeek {
+		taken := make(map[int]bool)
+		for _, match := range matches {
+			if match.Referee != nil {
+				taken[match.Referee.Id] = true
+			}
+		}
+		for _, match := range matches {
+			if match.Referee != nil {
+				continue
+			}
+			free := []*Referee{}
+			for _, referee := range league.Referees {
+				if !taken[referee.Id] {
+					free = append(free, referee)
+				}
+			}
+			if len(free) == 0 {
+				free = league.Referees
+			}
+			match.Referee = free[rand.Intn(len(free))]
+			taken[match.Referee.Id] = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// refereeTendencies returns a match's card and penalty multipliers
+func refereeTendencies(match *Match) (float64, float64) {
+	if match.Referee == nil {
+		return 1, 1
+	}
+	return match.Referee.CardTendency, match.Referee.PenaltyTendency
+}
+
+// BuildRefereeStats counts each referee's matches and disciplinary events, strictest first
+func BuildRefereeStats(league *League) []*RefereeStats {
+	entries := make(map[int]*RefereeStats)
+	stats := []*RefereeStats{}
+	for _, referee := range league.Referees {
+		entry := &RefereeStats{Referee: referee}
+		entries[referee.Id] = entry
+		stats = append(stats, entry)
+	}
+
+	homeWins := make(map[int]int)
+	for _, match := range league.Matches {
+		if match.Referee == nil {
+			continue
+		}
+		entry := entries[match.Referee.Id]
+		if entry == nil {
+			continue
+		}
+		if !match.Played {
+			entry.UpcomingMatches++
+			continue
+		}
+		entry.Matches++
+		if match.HomeTeamScore > match.AwayTeamScore {
+			homeWins[match.Referee.Id]++
+		}
+		if len(match.Events) > 0 {
+			entry.TimedMatches++
+		}
+		for _, event := range match.Events {
+			switch event.Type {
+			case EventYellowCard:
+				entry.YellowCards++
+			case EventRedCard:
+				entry.RedCards++
+			case EventPenalty:
+				entry.Penalties++
+			}
+		}
+	}
+
+	for _, entry := range stats {
+		if entry.TimedMatches > 0 {
+			entry.CardsPerMatch = math.Round(float64(entry.YellowCards+entry.RedCards)/float64(entry.TimedMatches)*100) / 100
+			entry.PenaltiesPerMatch = math.Round(float64(entry.Penalties)/float64(entry.TimedMatches)*100) / 100
+		}
+		if entry.Matches > 0 {
+			entry.HomeWinPercentage = math.Round(float64(homeWins[entry.Id])/float64(entry.Matches)*1000) / 10
+		}
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].CardsPerMatch != stats[j].CardsPerMatch {
+			return stats[i].CardsPerMatch > stats[j].CardsPerMatch
+		}
+		return stats[i].CardTendency > stats[j].CardTendency
+	})
+	return stats
+}
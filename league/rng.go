@@ -0,0 +1,124 @@
+package league
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// pcgMultiplier is the 64-bit LCG multiplier of the PCG reference implementation
+const pcgMultiplier = 6364136223846793005
+
+// pcgStream selects the PCG stream every seeded generator uses. It is the sequence
+// constant of the reference pcg32 demo, so NewPCG(42) matches its published output.
+const pcgStream = 54
+
+// PCG is a self-contained PCG32 (XSH RR 64/32) generator. Seeded runs draw from it
+// instead of math/rand, whose source and derived methods are not promised to stay the
+// same across Go releases; every step uses integer arithmetic and exact conversions,
+// so a seed produces the same sequence on every Go version and platform.
+// A PCG is not safe for concurrent use.
+type PCG struct {
+	state     uint64
+	increment uint64
+}
+
+// NewPCG returns a generator seeded as the reference pcg32_srandom(seed, pcgStream)
+func NewPCG(seed int64) *PCG {
+	rng := &PCG{increment: pcgStream<<1 | 1}
+	rng.Uint32()
+	rng.state += uint64(seed)
+	rng.Uint32()
+	return rng
+}
+
+// Uint32 advances the generator and returns the next 32 random bits
+func (rng *PCG) Uint32() uint32 {
+	old := rng.state
+	rng.state = old*pcgMultiplier + rng.increment
+	xorShifted := uint32((old>>18 ^ old) >> 27)
+	return bits.RotateLeft32(xorShifted, -int(old>>59))
+}
+
+// Uint64 joins the next two 32-bit outputs, the first in the high half
+func (rng *PCG) Uint64() uint64 {
+	high := uint64(rng.Uint32())
+	return high<<32 | uint64(rng.Uint32())
+}
+
+// Float64 returns a number in [0, 1) from the top 53 bits of Uint64
+func (rng *PCG) Float64() float64 {
+	return float64(rng.Uint64()>>11) / (1 << 53)
+}
+
+// Intn returns an unbiased number in [0, n), rejecting the draws that would favour
+// low values (Lemire's multiply-and-shift). It panics if n <= 0.
+func (rng *PCG) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+	bound := uint64(n)
+	threshold := -bound % bound
+	for {
+		high, low := bits.Mul64(rng.Uint64(), bound)
+		if low >= threshold {
+			return int(high)
+		}
+	}
+}
+
+// Shuffle permutes n elements with a Fisher-Yates shuffle, calling swap to exchange them
+func (rng *PCG) Shuffle(n int, swap func(i, j int)) {
+	for i := n - 1; i > 0; i-- {
+		swap(i, rng.Intn(i+1))
+	}
+}
+
+// pcgReferenceSeed and pcgReferenceOutputs are the first outputs of the reference
+// pcg32 demo (seed 42, stream 54), published with the PCG C implementation
+const pcgReferenceSeed = 42
+
+var pcgReferenceOutputs = []uint32{0xa15c02b7, 0x7b47f409, 0xba1d3330, 0x83d2f293, 0xbfa4784b, 0xcbed606e}
+
+// CheckPCGReference compares NewPCG with the published reference outputs and
+// describes the first mismatch; an empty string means it matches
+func CheckPCGReference() string {
+	rng := NewPCG(pcgReferenceSeed)
+	for i, want := range pcgReferenceOutputs {
+		if got := rng.Uint32(); got != want {
+			return fmt.Sprintf("output %d of seed %d is %#08x, the PCG reference gives %#08x", i+1, pcgReferenceSeed, got, want)
+		}
+	}
+	return ""
+}
+
+// RandomSequence is the recorded start of a seeded generator's Float64, Intn and
+// Shuffle outputs, kept as a golden file so any change to the derived methods shows
+type RandomSequence struct {
+	Seed    int64
+	Uint32  []uint32
+	Float64 []float64
+	Intn    []int // Intn(n) for n = 1, 2, ... in turn
+	Shuffle []int // a shuffle of 0..19
+}
+
+// RecordRandomSequence draws the recorded outputs from a generator seeded with seed
+func RecordRandomSequence(seed int64) *RandomSequence {
+	rng := NewPCG(seed)
+	sequence := &RandomSequence{Seed: seed}
+	for i := 0; i < 8; i++ {
+		sequence.Uint32 = append(sequence.Uint32, rng.Uint32())
+	}
+	for i := 0; i < 8; i++ {
+		sequence.Float64 = append(sequence.Float64, rng.Float64())
+	}
+	for n := 1; n <= 16; n++ {
+		sequence.Intn = append(sequence.Intn, rng.Intn(n))
+	}
+	for i := 0; i < 20; i++ {
+		sequence.Shuffle = append(sequence.Shuffle, i)
+	}
+	rng.Shuffle(len(sequence.Shuffle), func(i, j int) {
+		sequence.Shuffle[i], sequence.Shuffle[j] = sequence.Shuffle[j], sequence.Shuffle[i]
+	})
+	return sequence
+}
@@ -0,0 +1,39 @@
+package league
+
+import (
+	"testing"
+)
+
+func TestPCGReference(t *testing.T) {
+	rng := NewPCG(pcgReferenceSeed)
+	for i, want := range pcgReferenceOutputs {
+		if got := rng.Uint32(); got != want {
+			t.Fatalf("output %d of seed %d is %#08x, want %#08x", i+1, pcgReferenceSeed, got, want)
+		}
+	}
+}
+
+func TestPCGReplaysSeed(t *testing.T) {
+	first, second := NewPCG(pcgReferenceSeed), NewPCG(pcgReferenceSeed)
+	for i := 0; i < 1000; i++ {
+		if a, b := first.Float64(), second.Float64(); a != b {
+			t.Fatalf("draw %d: Float64 gave %v and %v for the same seed", i, a, b)
+		}
+		if a, b := first.Intn(i+1), second.Intn(i+1); a != b {
+			t.Fatalf("draw %d: Intn gave %d and %d for the same seed", i, a, b)
+		}
+	}
+}
+
+func TestPCGRanges(t *testing.T) {
+	rng := NewPCG(pcgReferenceSeed)
+	for i := 0; i < 10000; i++ {
+		if f := rng.Float64(); f < 0 || f >= 1 {
+			t.Fatalf("Float64 gave %v, outside [0, 1)", f)
+		}
+		n := i%50 + 1
+		if k := rng.Intn(n); k < 0 || k >= n {
+			t.Fatalf("Intn(%d) gave %d", n, k)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package league
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Competition formats
+const (
+	FormatRoundRobin = "round_robin" // one table over every leg
+	FormatSplit      = "split"       // apertura and clausura stages, each with its own table
+	FormatGroups     = "groups"      // teams only meet the others in their group
+)
+
+// Tiebreakers, applied in order to teams level on points
+const (
+	TiebreakGoalDifference = "goal_difference"
+	TiebreakGoalsFor       = "goals_for"
+	TiebreakWins           = "wins"
+	TiebreakHeadToHead     = "head_to_head" // points in the matches between the tied teams
+)
+
+// Play-off triggers
+const (
+	PlayoffLevelOnPoints = "level_on_points" // the top two finish level on points
+	PlayoffStageWinners  = "stage_winners"   // apertura and clausura are won by different teams
+)
+
+// TitlePlayoffCompetition names the play-off's showpiece match and honours
+const TitlePlayoffCompetition = "Title play-off"
+
+// QualificationSpot labels a range of table positions, e.g. Champions League places.
+// Teams finishing in a spot with a continental competition enter it next season.
+type QualificationSpot struct {
+	Label       string `json:"label"`
+	From        int    `json:"from"`
+	To          int    `json:"to"`
+	Continental string `json:"continental,omitempty"`
+}
+
+// PlayoffRule decides when the title goes to a play-off and how it is played
+type PlayoffRule struct {
+	Trigger string `json:"trigger"` // empty for no play-off
+	Neutral bool   `json:"neutral"` // play at a neutral venue instead of the higher-placed side's ground
+	Legs    int    `json:"legs"`    // 2 plays it home and away, the higher-placed side at home second; 0 or 1 is a one-off match
+}
+
+// CompetitionRules describes the league's format declaratively
+type CompetitionRules struct {
+	Format        string              `json:"format"`
+	Legs          int                 `json:"legs"`
+	Groups        int                 `json:"groups"`
+	Tiebreakers   []string            `json:"tiebreakers"`
+	Qualification []QualificationSpot `json:"qualification"`
+	Playoff       PlayoffRule         `json:"playoff"`
+}
+
+// StandingsTable is one table of the league's standings
+type StandingsTable struct {
+	Name    string
+	Entries []*LeagueTableEntry
+}
+
+// PlayoffStatus reports whether the rules call for a title play-off
+type PlayoffStatus struct {
+	Trigger  string
+	Required bool
+	Played   bool
+	HomeTeam string `json:",omitempty"`
+	AwayTeam string `json:",omitempty"`
+	Reason   string `json:",omitempty"`
+}
+
+// Standings are the league's tables as its rules define them
+type Standings struct {
+	Format  string
+	Tables  []*StandingsTable
+	Playoff *PlayoffStatus `json:",omitempty"`
+}
+
+// competitionRules holds the rules loaded at startup
+var competitionRules = DefaultCompetitionRules()
+
+// Rules returns the competition rules in use
+func Rules() CompetitionRules {
+	return competitionRules
+}
+
+// SetRules makes rules the competition rules for leagues created and played from now on
+func SetRules(rules CompetitionRules) {
+	competitionRules = rules
+}
+
+// DefaultCompetitionRules is a double round robin decided on points then goal difference
+func DefaultCompetitionRules() CompetitionRules {
+	return CompetitionRules{
+		Format:      FormatRoundRobin,
+		Legs:        2,
+		Tiebreakers: []string{TiebreakGoalDifference},
+	}
+}
+
+// LoadCompetitionRules reads a JSON rules file over the defaults; a missing file keeps the defaults
+func LoadCompetitionRules(path string) (CompetitionRules, error) {
+	rules := DefaultCompetitionRules()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return rules, nil
+	}
+	if err != nil {
+		return rules, fmt.Errorf("failed to read competition rules: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("failed to parse competition rules: %v", err)
+	}
+
+	switch rules.Format {
+	case FormatRoundRobin:
+	case FormatSplit:
+		if rules.Legs%2 != 0 {
+			return rules, fmt.Errorf("the split format needs an even number of legs")
+		}
+	case FormatGroups:
+		if rules.Groups < 2 {
+			return rules, fmt.Errorf("the groups format needs at least 2 groups")
+		}
+	default:
+		return rules, fmt.Errorf("unknown competition format %q", rules.Format)
+	}
+
+	if rules.Legs < 1 {
+		return rules, fmt.Errorf("legs must be at least 1")
+	}
+
+	for _, tiebreaker := range rules.Tiebreakers {
+		switch tiebreaker {
+		case TiebreakGoalDifference, TiebreakGoalsFor, TiebreakWins, TiebreakHeadToHead:
+		default:
+			return rules, fmt.Errorf("unknown tiebreaker %q", tiebreaker)
+		}
+	}
+
+	for _, spot := range rules.Qualification {
+		if spot.Label == "" || spot.From < 1 || spot.To < spot.From {
+			return rules, fmt.Errorf("qualification spots need a label and positions from 1 with to >= from")
+		}
+	}
+
+	switch rules.Playoff.Trigger {
+	case "", PlayoffLevelOnPoints:
+		if rules.Playoff.Trigger != "" && rules.Format == FormatGroups {
+			return rules, fmt.Errorf("the groups format has no single table to trigger a play-off")
+		}
+	case PlayoffStageWinners:
+		if rules.Format != FormatSplit {
+			return rules, fmt.Errorf("the %q play-off trigger needs the split format", PlayoffStageWinners)
+		}
+	default:
+		return rules, fmt.Errorf("unknown play-off trigger %q", rules.Playoff.Trigger)
+	}
+	if rules.Playoff.Legs < 0 || rules.Playoff.Legs > 2 {
+		return rules, fmt.Errorf("a play-off is played over 1 or 2 legs, got %d", rules.Playoff.Legs)
+	}
+	if rules.Playoff.Legs == 2 && rules.Playoff.Neutral {
+		return rules, fmt.Errorf("a two-legged play-off cannot be played at a neutral venue")
+	}
+
+	return rules, nil
+}
+
+// CompetitionRulesPath returns the rules file location, overridable via LEAGUE_RULES
+func CompetitionRulesPath() string {
+	if path := os.Getenv("LEAGUE_RULES"); path != "" {
+		return path
+	}
+	return "./rules.json"
+}
+
+// fixtureRounds pairs team indices round by round for a single leg. In the groups
+// format teams are dealt into groups in order and each group plays its own round
+// robin, with the groups' rounds played in the same weeks.
+func fixtureRounds(teamCount int) [][][2]int {
+	if competitionRules.Format != FormatGroups {
+		return RoundRobinRounds(teamCount)
+	}
+
+	rounds := [][][2]int{}
+	for group := 0; group < competitionRules.Groups; group++ {
+		members := []int{}
+		for i := group; i < teamCount; i += competitionRules.Groups {
+			members = append(members, i)
+		}
+		for k, fixtures := range RoundRobinRounds(len(members)) {
+			if k == len(rounds) {
+				rounds = append(rounds, [][2]int{})
+			}
+			for _, fixture := range fixtures {
+				rounds[k] = append(rounds[k], [2]int{members[fixture[0]], members[fixture[1]]})
+			}
+		}
+	}
+	return rounds
+}
+
+// sortLeagueTable orders a table by points and then the rules' tiebreakers, falling
+// back to team name so the order is stable. Head-to-head compares the points each
+// team took from the matches among everyone level with it on points.
+func sortLeagueTable(league *League, table []*LeagueTableEntry, include func(match *Match) bool) {
+	headToHead := make(map[string]int)
+	for _, tiebreaker := range competitionRules.Tiebreakers {
+		if tiebreaker == TiebreakHeadToHead {
+			headToHead = headToHeadPoints(league, table, include)
+		}
+	}
+
+	sort.SliceStable(table, func(i, j int) bool {
+		a, b := table[i], table[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		for _, tiebreaker := range competitionRules.Tiebreakers {
+			var x, y int
+			switch tiebreaker {
+			case TiebreakGoalDifference:
+				x, y = a.GoalsDifference, b.GoalsDifference
+			case TiebreakGoalsFor:
+				x, y = a.GoalsFor, b.GoalsFor
+			case TiebreakWins:
+				x, y = a.Wins, b.Wins
+			case TiebreakHeadToHead:
+				x, y = headToHead[a.TeamName], headToHead[b.TeamName]
+			}
+			if x != y {
+				return x > y
+			}
+		}
+		return a.TeamName < b.TeamName
+	})
+}
+
+// headToHeadPoints counts each team's points against the teams level with it on points
+func headToHeadPoints(league *League, table []*LeagueTableEntry, include func(match *Match) bool) map[string]int {
+	sport := LeagueSport(league)
+	points := make(map[string]int)
+	level := make(map[string]int)
+	for _, entry := range table {
+		level[entry.TeamName] = entry.Points
+	}
+	for _, match := range league.Matches {
+		if !match.Played || !include(match) {
+			continue
+		}
+		home, away := match.HomeTeam.TeamName, match.AwayTeam.TeamName
+		homePoints, okHome := level[home]
+		awayPoints, okAway := level[away]
+		if !okHome || !okAway || homePoints != awayPoints {
+			continue
+		}
+		homeResult, awayResult := sport.MatchPoints(match)
+		points[home] += homeResult
+		points[away] += awayResult
+	}
+	return points
+}
+
+// BuildStandings builds the tables the rules call for: the full table, one per stage
+// in the split format, or one per group, each group being the teams that play each
+// other. Every table gets the league's zones.
+func BuildStandings(league *League, playoffPlayed bool) *Standings {
+	standings := &Standings{Format: competitionRules.Format}
+	all := func(match *Match) bool { return true }
+
+	switch competitionRules.Format {
+	case FormatGroups:
+		for i, members := range fixtureGroups(league) {
+			inGroup := func(match *Match) bool { return members[match.HomeTeam.TeamName] }
+			entries := []*LeagueTableEntry{}
+			for _, entry := range BuildLeagueTable(league, inGroup) {
+				if members[entry.TeamName] {
+					entries = append(entries, entry)
+				}
+			}
+			for position, entry := range entries {
+				entry.Position = position + 1
+			}
+			standings.Tables = append(standings.Tables, &StandingsTable{Name: fmt.Sprintf("Group %c", 'A'+i), Entries: entries})
+		}
+	case FormatSplit:
+		standings.Tables = append(standings.Tables, &StandingsTable{Name: "Overall", Entries: BuildLeagueTable(league, all)})
+		for stage, name := range []string{"Apertura", "Clausura"} {
+			stage := stage
+			standings.Tables = append(standings.Tables, &StandingsTable{
+				Name:    name,
+				Entries: BuildLeagueTable(league, func(match *Match) bool { return splitStage(league, match) == stage }),
+			})
+		}
+	default:
+		standings.Tables = append(standings.Tables, &StandingsTable{Name: "League", Entries: BuildLeagueTable(league, all)})
+	}
+
+	zones := LeagueZones(league).Zones
+	for _, table := range standings.Tables {
+		ApplyZones(zones, table.Entries)
+	}
+
+	if competitionRules.Playoff.Trigger != "" {
+		standings.Playoff = playoffStatus(league, standings)
+		standings.Playoff.Played = playoffPlayed
+	}
+	return standings
+}
+
+// fixtureGroups finds the groups from the fixtures: teams connected by a match share a group
+func fixtureGroups(league *League) []map[string]bool {
+	parent := make(map[string]string)
+	var find func(name string) string
+	find = func(name string) string {
+		if parent[name] == name {
+			return name
+		}
+		parent[name] = find(parent[name])
+		return parent[name]
+	}
+	for _, team := range league.Teams {
+		parent[team.TeamName] = team.TeamName
+	}
+	for _, match := range league.Matches {
+		parent[find(match.HomeTeam.TeamName)] = find(match.AwayTeam.TeamName)
+	}
+
+	groups := []map[string]bool{}
+	index := make(map[string]int)
+	for _, team := range league.Teams {
+		root := find(team.TeamName)
+		i, ok := index[root]
+		if !ok {
+			i = len(groups)
+			index[root] = i
+			groups = append(groups, make(map[string]bool))
+		}
+		groups[i][team.TeamName] = true
+	}
+	return groups
+}
+
+// splitStage returns 0 for an apertura fixture and 1 for a clausura one: the first
+// half of the fixture list by match ID is the apertura
+func splitStage(league *League, match *Match) int {
+	earlier := 0
+	for _, other := range league.Matches {
+		if other.MatchId < match.MatchId {
+			earlier++
+		}
+	}
+	if earlier < len(league.Matches)/2 {
+		return 0
+	}
+	return 1
+}
+
+// playoffStatus applies the play-off trigger once the fixtures it depends on are
+// played. The higher-placed team in the overall table is the home side.
+func playoffStatus(league *League, standings *Standings) *PlayoffStatus {
+	status := &PlayoffStatus{Trigger: competitionRules.Playoff.Trigger}
+	overall := standings.Tables[0].Entries
+	if len(overall) < 2 {
+		return status
+	}
+	for _, match := range league.Matches {
+		if !match.Played {
+			return status
+		}
+	}
+
+	var first, second string
+	switch status.Trigger {
+	case PlayoffLevelOnPoints:
+		if overall[0].Points != overall[1].Points {
+			return status
+		}
+		first, second = overall[0].TeamName, overall[1].TeamName
+		status.Reason = fmt.Sprintf("%s and %s finished level on %d points", first, second, overall[0].Points)
+	case PlayoffStageWinners:
+		apertura, clausura := standings.Tables[1].Entries[0].TeamName, standings.Tables[2].Entries[0].TeamName
+		if apertura == clausura {
+			return status
+		}
+		first, second = apertura, clausura
+		status.Reason = fmt.Sprintf("%s won the apertura and %s the clausura", apertura, clausura)
+	}
+
+	for _, entry := range overall {
+		if entry.TeamName == second {
+			first, second = second, first
+			break
+		}
+		if entry.TeamName == first {
+			break
+		}
+	}
+	status.Required = true
+	status.HomeTeam, status.AwayTeam = first, second
+	return status
+}
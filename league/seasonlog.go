@@ -0,0 +1,172 @@
+package league
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// seasonLogQueue is how many records may wait for the season log writer; a
+// simulation only waits for the writer when it falls further behind than that
+const seasonLogQueue = 1024
+
+// Competitions a season log record can come from
+const (
+	CompetitionLeague    = "league"
+	CompetitionShowpiece = "showpiece"
+)
+
+// SeasonLogRecord is one simulated match in the season log: everything the score was
+// computed from, so a disputed result can be replayed by hand
+type SeasonLogRecord struct {
+	Time        time.Time
+	Tenant      string `json:",omitempty"` // in tenancy mode
+	Season      int
+	Week        int // the fixture's week; for a showpiece, the league week it followed
+	MatchId     int `json:",omitempty"` // showpieces are not fixtures and have none
+	Competition string
+	HomeTeam    string
+	AwayTeam    string
+	Venue       string `json:",omitempty"`
+	Config      string // fingerprint of the simulation config, as in GET /admin/config
+	Seeded      bool   // drawn from the league's seeded generator rather than the shared one
+	Inputs      *MatchExplanation
+	Draws       []RandomDraw // in the order the simulation took them
+	HomeScore   int
+	AwayScore   int
+}
+
+// RandomDraw is one number the simulation drew: a Float64 in [0, 1), or for Intn, a
+// whole number below Intn
+type RandomDraw struct {
+	Intn  int `json:",omitempty"`
+	Value float64
+}
+
+// recordingRandom passes draws through from source and keeps a copy of each
+type recordingRandom struct {
+	source RandomSource
+	draws  []RandomDraw
+}
+
+func (r *recordingRandom) Float64() float64 {
+	value := r.source.Float64()
+	r.draws = append(r.draws, RandomDraw{Value: value})
+	return value
+}
+
+func (r *recordingRandom) Intn(n int) int {
+	value := r.source.Intn(n)
+	r.draws = append(r.draws, RandomDraw{Intn: n, Value: float64(value)})
+	return value
+}
+
+// seasonLog appends records to one JSONL file per season, season-<n>.jsonl, under dir
+// (under dir/<tenant> in tenancy mode). Records are written in the background, in the
+// order the matches were simulated.
+type seasonLog struct {
+	dir     string
+	records chan *SeasonLogRecord
+}
+
+// seasonLogger is the server's season log, nil unless LEAGUE_SEASON_LOG_DIR is set
+var seasonLogger *seasonLog
+
+// OpenSeasonLog starts logging every simulated match when LEAGUE_SEASON_LOG_DIR is set
+func OpenSeasonLog() error {
+	logger, err := newSeasonLog()
+	if err != nil {
+		return err
+	}
+	seasonLogger = logger
+	return nil
+}
+
+// newSeasonLog starts the season log in LEAGUE_SEASON_LOG_DIR, or returns nil when it is unset
+func newSeasonLog() (*seasonLog, error) {
+	dir := os.Getenv("LEAGUE_SEASON_LOG_DIR")
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	l := &seasonLog{dir: dir, records: make(chan *SeasonLogRecord, seasonLogQueue)}
+	go l.run()
+	return l, nil
+}
+
+// run writes queued records until the process exits
+func (l *seasonLog) run() {
+	for record := range l.records {
+		if err := l.write(record); err != nil {
+			log.Printf("Failed to write match %d of season %d to the season log: %v", record.MatchId, record.Season, err)
+		}
+	}
+}
+
+// write appends a record as one line. The file is opened for every record, so it can
+// be moved away or rotated while the server runs.
+func (l *seasonLog) write(record *SeasonLogRecord) error {
+	dir := l.dir
+	if record.Tenant != "" {
+		dir = filepath.Join(dir, record.Tenant)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("season-%d.jsonl", record.Season)), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// SimulateLogged runs simulate on match with the league's random source and config
+// and, when the season log is on, queues the match's inputs and the draws it took
+func SimulateLogged(league *League, match *Match, competition string, simulate func(match *Match, rng RandomSource)) {
+	if match.config == nil {
+		match.config = LeagueConfig(league)
+	}
+	if seasonLogger == nil || match.Played {
+		simulate(match, LeagueRandom(league))
+		return
+	}
+	rng := &recordingRandom{source: LeagueRandom(league)}
+	simulate(match, rng)
+	// Copied, as an edited result later marks the match's own explanation
+	inputs := *match.Explanation
+
+	record := &SeasonLogRecord{
+		Time:        time.Now().UTC(),
+		Season:      league.Season,
+		Tenant:      league.Tenant,
+		Week:        match.Week,
+		MatchId:     match.MatchId,
+		Competition: competition,
+		HomeTeam:    match.HomeTeam.TeamName,
+		AwayTeam:    match.AwayTeam.TeamName,
+		Venue:       match.Venue,
+		Config:      simulationConfigFingerprint(*match.config),
+		Seeded:      league.Random != nil,
+		Inputs:      &inputs,
+		Draws:       rng.draws,
+		HomeScore:   match.HomeTeamScore,
+		AwayScore:   match.AwayTeamScore,
+	}
+	if competition == CompetitionShowpiece {
+		record.Week = league.CurrentWeek
+	}
+	seasonLogger.records <- record
+}
@@ -0,0 +1,63 @@
+package league
+
+// SeasonState is where the season stands; it follows from the match results and is
+// stored in league_state with every change
+type SeasonState string
+
+const (
+	SeasonNotStarted SeasonState = "not_started" // no fixture played yet
+	SeasonInProgress SeasonState = "in_progress" // some fixtures played, some to play
+	SeasonFinished   SeasonState = "finished"    // every fixture played; only a new season can follow
+)
+
+// CheckSeasonState is the startup check that the stored season state agrees with the results
+const CheckSeasonState = "season_state"
+
+// SeasonStateOf works out the state of the league's season from its fixtures
+func SeasonStateOf(league *League) SeasonState {
+	played, unplayed := 0, 0
+	for _, match := range league.Matches {
+		if match.Played {
+			played++
+		} else {
+			unplayed++
+		}
+	}
+	switch {
+	case played == 0:
+		return SeasonNotStarted
+	case unplayed == 0:
+		return SeasonFinished
+	}
+	return SeasonInProgress
+}
+
+// LeagueState is the season's state with the progress behind it
+type LeagueState struct {
+	Season           int
+	State            SeasonState
+	CurrentWeek      int
+	TotalWeeks       int
+	MatchesPlayed    int
+	MatchesRemaining int
+	Archived         bool
+}
+
+// BuildLeagueState describes the league's season for GET /league/state
+func BuildLeagueState(league *League) *LeagueState {
+	state := &LeagueState{
+		Season:      league.Season,
+		State:       SeasonStateOf(league),
+		CurrentWeek: league.CurrentWeek,
+		Archived:    league.ArchivedAt != nil,
+	}
+	for _, match := range league.Matches {
+		state.TotalWeeks = max(state.TotalWeeks, match.Week)
+		if match.Played {
+			state.MatchesPlayed++
+		} else {
+			state.MatchesRemaining++
+		}
+	}
+	return state
+}
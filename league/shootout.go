@@ -0,0 +1,157 @@
+package league
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// shootoutRounds is how many kicks each side takes before sudden death
+const shootoutRounds = 5
+
+// Adjustments to the shootout conversion rate for a single kick
+const (
+	shootoutOrderFade      = 0.01  // per kick after a side's first, as the best takers go first
+	shootoutSuddenDeath    = 0.04  // sudden-death kicks fall to weaker takers
+	shootoutPressure       = 0.05  // a kick that must be scored to stay in the shootout
+	shootoutStrengthFactor = 0.002 // per point of strength the kicker's side has over the keeper's
+)
+
+// Shootout is a penalty shootout's score and its kicks as match events
+type Shootout struct {
+	HomeScore int
+	AwayScore int
+	Kicks     []MatchEvent
+}
+
+// ShootoutRecord is a team's penalty shootout history for GET /showpieces/shootouts
+type ShootoutRecord struct {
+	TeamName             string
+	Shootouts            int
+	Won                  int
+	Lost                 int
+	Kicks                int // only shootouts played with kick-by-kick detail count towards kicks
+	Scored               int
+	ConversionPercentage float64
+}
+
+// shootoutKickProbability is the chance a kick is scored
+func shootoutKickProbability(kicker, keeper *Team, round int, mustScore bool, config *SimulationConfig) float64 {
+	probability := config.ShootoutConversion
+	if round <= shootoutRounds {
+		probability -= float64(round-1) * shootoutOrderFade
+	} else {
+		probability -= float64(shootoutRounds-1)*shootoutOrderFade + shootoutSuddenDeath
+	}
+	if mustScore {
+		probability -= shootoutPressure
+	}
+	probability += float64(kicker.TeamStrength-keeper.TeamStrength) * shootoutStrengthFactor
+	return math.Max(0.05, math.Min(0.98, probability))
+}
+
+// shootoutDecided reports whether one side can no longer be caught, given each
+// side's score and kicks taken with up to limit kicks each
+func shootoutDecided(scoreA, scoreB, takenA, takenB, limit int) bool {
+	return scoreA > scoreB+limit-takenB || scoreB > scoreA+limit-takenA
+}
+
+// SimulateShootout plays a penalty shootout after the given minute: a coin toss
+// decides who kicks first, the sides alternate over five kicks each and stop as
+// soon as one cannot be caught, then go to sudden death.
+func SimulateShootout(home, away *Team, minute int, config *SimulationConfig, rng RandomSource) *Shootout {
+	kickers := [2]*Team{home, away}
+	if rng.Intn(2) == 1 {
+		kickers = [2]*Team{away, home}
+	}
+
+	shootout := &Shootout{}
+	scores, taken := [2]int{}, [2]int{}
+	for round := 1; ; round++ {
+		limit := shootoutRounds
+		if round > shootoutRounds {
+			limit = round
+		}
+		for side := 0; side < 2; side++ {
+			other := 1 - side
+			// A miss that would leave the other side out of reach must be avoided
+			mustScore := scores[other] > scores[side]+limit-(taken[side]+1)
+			scored := rng.Float64() < shootoutKickProbability(kickers[side], kickers[other], round, mustScore, config)
+
+			taken[side]++
+			eventType := EventShootoutMiss
+			if scored {
+				scores[side]++
+				eventType = EventShootoutGoal
+			}
+			if kickers[side] == home {
+				shootout.HomeScore = scores[side]
+			} else {
+				shootout.AwayScore = scores[side]
+			}
+			shootout.Kicks = append(shootout.Kicks, MatchEvent{
+				Minute:     minute,
+				Type:       eventType,
+				TeamId:     kickers[side].TeamId,
+				TeamName:   kickers[side].TeamName,
+				ScoreAfter: fmt.Sprintf("%d-%d", shootout.HomeScore, shootout.AwayScore),
+				Kick:       len(shootout.Kicks) + 1,
+			})
+
+			if shootoutDecided(scores[0], scores[1], taken[0], taken[1], limit) {
+				return shootout
+			}
+		}
+	}
+}
+
+// BuildShootoutStats tallies each team's shootouts from the showpiece matches,
+// most shootouts first
+func BuildShootoutStats(matches []*ShowpieceMatch) []*ShootoutRecord {
+	records := make(map[string]*ShootoutRecord)
+	stats := []*ShootoutRecord{}
+	record := func(name string) *ShootoutRecord {
+		if records[name] == nil {
+			records[name] = &ShootoutRecord{TeamName: name}
+			stats = append(stats, records[name])
+		}
+		return records[name]
+	}
+
+	for _, match := range matches {
+		if match.PenaltiesHome == 0 && match.PenaltiesAway == 0 {
+			continue
+		}
+		for _, team := range []*Team{match.HomeTeam, match.AwayTeam} {
+			entry := record(team.TeamName)
+			entry.Shootouts++
+			if match.WinnerName == team.TeamName {
+				entry.Won++
+			} else {
+				entry.Lost++
+			}
+		}
+		for _, event := range match.Events {
+			switch event.Type {
+			case EventShootoutGoal:
+				record(event.TeamName).Scored++
+				record(event.TeamName).Kicks++
+			case EventShootoutMiss:
+				record(event.TeamName).Kicks++
+			}
+		}
+	}
+
+	for _, entry := range stats {
+		if entry.Kicks > 0 {
+			entry.ConversionPercentage = math.Round(float64(entry.Scored)/float64(entry.Kicks)*1000) / 10
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Shootouts != stats[j].Shootouts {
+			return stats[i].Shootouts > stats[j].Shootouts
+		}
+		return stats[i].Won > stats[j].Won
+	})
+	return stats
+}
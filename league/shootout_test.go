@@ -0,0 +1,131 @@
+package league
+
+import (
+	"math"
+	"testing"
+)
+
+// scriptedKicks plays a shootout out kick by kick: each character of kicks is one
+// kick in order, 'o' scored and 'x' missed, and coin is the toss (1 sends the away
+// side first)
+type scriptedKicks struct {
+	kicks string
+	coin  int
+}
+
+func (r *scriptedKicks) Float64() float64 {
+	kick := r.kicks[0]
+	r.kicks = r.kicks[1:]
+	// Every kick's chance is clamped to 0.05-0.98
+	if kick == 'o' {
+		return 0
+	}
+	return 0.99
+}
+
+func (r *scriptedKicks) Intn(n int) int { return r.coin }
+
+func TestSimulateShootout(t *testing.T) {
+	config := DefaultSimulationConfig()
+	home := &Team{TeamName: "Home", TeamId: 1, TeamStrength: 70}
+	away := &Team{TeamName: "Away", TeamId: 2, TeamStrength: 70}
+
+	tests := []struct {
+		name               string
+		kicks              string
+		coin               int
+		wantHome, wantAway int
+		wantFirst          string // who took the first kick
+	}{
+		{name: "out of reach after three rounds", kicks: "oxoxox", wantHome: 3, wantAway: 0, wantFirst: "Home"},
+		{name: "second side out of reach mid-round", kicks: "ooooooooxo", wantHome: 4, wantAway: 5, wantFirst: "Home"},
+		{name: "away side kicks first", kicks: "oxoxox", coin: 1, wantHome: 0, wantAway: 3, wantFirst: "Away"},
+		{name: "sudden death after a level five", kicks: "oooooooooo" + "oo" + "ox", wantHome: 7, wantAway: 6, wantFirst: "Home"},
+		{name: "sudden death goes both ways", kicks: "oooooooooo" + "xo", wantHome: 5, wantAway: 6, wantFirst: "Home"},
+		{name: "sudden death after all miss", kicks: "xxxxxxxxxx" + "xx" + "xx" + "ox", wantHome: 1, wantAway: 0, wantFirst: "Home"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rng := &scriptedKicks{kicks: test.kicks, coin: test.coin}
+			shootout := SimulateShootout(home, away, 120, &config, rng)
+
+			if shootout.HomeScore != test.wantHome || shootout.AwayScore != test.wantAway {
+				t.Errorf("shootout finished %d-%d, want %d-%d", shootout.HomeScore, shootout.AwayScore, test.wantHome, test.wantAway)
+			}
+			if rng.kicks != "" {
+				t.Errorf("shootout stopped with kicks %q still to take", rng.kicks)
+			}
+			if len(shootout.Kicks) != len(test.kicks) {
+				t.Fatalf("%d kicks recorded, want %d", len(shootout.Kicks), len(test.kicks))
+			}
+			if first := shootout.Kicks[0].TeamName; first != test.wantFirst {
+				t.Errorf("%s kicked first, want %s", first, test.wantFirst)
+			}
+			for i, kick := range shootout.Kicks {
+				if kick.Kick != i+1 || kick.Minute != 120 {
+					t.Errorf("kick %d numbered %d at minute %d", i+1, kick.Kick, kick.Minute)
+				}
+			}
+		})
+	}
+}
+
+func TestShootoutKickProbability(t *testing.T) {
+	config := DefaultSimulationConfig()
+	config.ShootoutConversion = 0.75
+	even := &Team{TeamStrength: 70}
+
+	tests := []struct {
+		name       string
+		kicker     *Team
+		round      int
+		mustScore  bool
+		conversion float64
+		wantChance float64
+	}{
+		{name: "first kick", kicker: even, round: 1, wantChance: 0.75},
+		{name: "fifth kick fades", kicker: even, round: 5, wantChance: 0.71},
+		{name: "sudden death", kicker: even, round: 6, wantChance: 0.67},
+		{name: "sudden death does not fade further", kicker: even, round: 9, wantChance: 0.67},
+		{name: "must score", kicker: even, round: 1, mustScore: true, wantChance: 0.70},
+		{name: "stronger kicker", kicker: &Team{TeamStrength: 80}, round: 1, wantChance: 0.77},
+		{name: "clamped high", kicker: even, round: 1, conversion: 1.2, wantChance: 0.98},
+		{name: "clamped low", kicker: even, round: 6, mustScore: true, conversion: 0.1, wantChance: 0.05},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := config
+			if test.conversion != 0 {
+				config.ShootoutConversion = test.conversion
+			}
+			chance := shootoutKickProbability(test.kicker, even, test.round, test.mustScore, &config)
+			if math.Abs(chance-test.wantChance) > 1e-9 {
+				t.Errorf("chance %v, want %v", chance, test.wantChance)
+			}
+		})
+	}
+}
+
+func TestShootoutDecided(t *testing.T) {
+	tests := []struct {
+		name                           string
+		scoreA, scoreB, takenA, takenB int
+		limit                          int
+		want                           bool
+	}{
+		{name: "level", scoreA: 2, scoreB: 2, takenA: 3, takenB: 3, limit: 5, want: false},
+		{name: "three clear with two left", scoreA: 3, scoreB: 0, takenA: 3, takenB: 3, limit: 5, want: true},
+		{name: "catchable with the last kick", scoreA: 4, scoreB: 3, takenA: 5, takenB: 4, limit: 5, want: false},
+		{name: "three up with the other side a kick behind", scoreA: 3, scoreB: 0, takenA: 3, takenB: 2, limit: 5, want: false},
+		{name: "sudden death first kick scored", scoreA: 6, scoreB: 5, takenA: 6, takenB: 5, limit: 6, want: false},
+		{name: "sudden death reply missed", scoreA: 6, scoreB: 5, takenA: 6, takenB: 6, limit: 6, want: true},
+		{name: "sudden death both missed", scoreA: 5, scoreB: 5, takenA: 6, takenB: 6, limit: 6, want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := shootoutDecided(test.scoreA, test.scoreB, test.takenA, test.takenB, test.limit); got != test.want {
+				t.Errorf("shootoutDecided = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package league
+
+import (
+	"time"
+)
+
+// ShowpieceMatch is a one-off match (super cup, exhibition) outside the league standings
+type ShowpieceMatch struct {
+	Id            int
+	Name          string
+	HomeTeam      *Team
+	AwayTeam      *Team
+	HomeTeamScore int
+	AwayTeamScore int
+	ExtraTime     bool         `json:",omitempty"` // level after 90 minutes; the score includes extra time
+	PenaltiesHome int          `json:",omitempty"`
+	PenaltiesAway int          `json:",omitempty"`
+	WinnerName    string       `json:",omitempty"`
+	Events        []MatchEvent `json:",omitempty"`
+	Season        int
+	LeagueWeek    int // league weeks completed when the match was played
+	PlayedAt      time.Time
+}
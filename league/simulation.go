@@ -0,0 +1,623 @@
+package league
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// Simulation engines selectable via SimulationConfig.Engine
+const (
+	EngineClassic = "classic" // final score drawn in one step
+	EngineMinute  = "minute"  // match played out minute by minute with events
+)
+
+// Match event types produced by the minute engine
+const (
+	EventGoal       = "goal"
+	EventRedCard    = "red_card"
+	EventYellowCard = "yellow_card"
+	EventPenalty    = "penalty" // awarded; a scored penalty is followed by a goal event
+
+	// VAR reviews, with the scoreline before and after the review
+	EventVARGoalDisallowed = "var_goal_disallowed"
+	EventVARPenalty        = "var_penalty" // followed by the penalty it awarded
+
+	// Penalty shootout kicks, with the running shootout score in ScoreAfter
+	EventShootoutGoal = "shootout_goal"
+	EventShootoutMiss = "shootout_miss"
+)
+
+// MatchEvent is a single entry in a match's events timeline
+type MatchEvent struct {
+	Minute    int
+	AddedTime int `json:",omitempty"` // minutes into stoppage time, shown as 45+2 or 90+3
+	Type      string
+	TeamId    int
+	TeamName  string
+	// Set on VAR events: the score as it stood on the pitch and after the review,
+	// including the awarded penalty's outcome
+	ScoreBefore string `json:",omitempty"`
+	ScoreAfter  string `json:",omitempty"`
+	Kick        int    `json:",omitempty"` // order of a shootout kick, counting both sides' kicks
+}
+
+// RandomSource is what the simulation draws from: a seeded *PCG for reproducible
+// runs, or SharedRandom
+type RandomSource interface {
+	Float64() float64
+	Intn(n int) int
+}
+
+// SharedRandom draws from math/rand's shared generator, which is safe for concurrent use
+type SharedRandom struct{}
+
+func (SharedRandom) Float64() float64 { return rand.Float64() }
+func (SharedRandom) Intn(n int) int   { return rand.Intn(n) }
+
+// LeagueRandom returns the league's random source, the shared generator unless seeded
+func LeagueRandom(league *League) RandomSource {
+	if league.Random == nil {
+		return SharedRandom{}
+	}
+	return league.Random
+}
+
+// ScheduleBreak is a blank week inserted into the fixture list after a round
+type ScheduleBreak struct {
+	AfterRound int    `json:"after_round"`
+	Label      string `json:"label"`
+}
+
+// SimulationConfig holds the tunable parameters of the match simulation
+type SimulationConfig struct {
+	Engine string `json:"engine"`
+
+	// Scoreline realism: hard cap per team and the average above which a warning is raised
+	MaxGoals        int     `json:"max_goals"`
+	MaxAverageGoals float64 `json:"max_average_goals"`
+	MinAverageGoals float64 `json:"min_average_goals"`
+
+	// Prediction model: discount points earned above expected points (luck index)
+	RegressLuck    bool    `json:"regress_luck"`
+	LuckRegression float64 `json:"luck_regression"`
+
+	// Experimental models by rollout: 1 enables a flag for every match, 0 disables it,
+	// anything in between applies it to that fraction of fixtures for A/B comparison
+	Features      map[string]float64 `json:"features"`
+	DixonColesRho float64            `json:"dixon_coles_rho"`
+
+	// Draw reweighting for close matchups: positive values turn some narrow wins into
+	// draws, negative values break some draws; -1 to 1, 0 leaves results alone
+	DrawBias float64 `json:"draw_bias"`
+
+	// Managerial changes: a manager is sacked when their last four matches fall this many
+	// points short of expectations (0 disables sackings); the successor's team gets a
+	// strength bounce that fades over the given number of weeks
+	SackingThreshold      float64 `json:"sacking_threshold"`
+	NewManagerBounce      float64 `json:"new_manager_bounce"`
+	NewManagerBounceWeeks int     `json:"new_manager_bounce_weeks"`
+
+	// Share of the home advantage kept by matches played behind closed doors
+	ClosedDoorsHomeFactor float64 `json:"closed_doors_home_factor"`
+
+	// Strength multiplier per morale point (morale runs from -10 to 10); 0 ignores morale
+	MoraleEffect float64 `json:"morale_effect"`
+
+	// Blank weeks (international breaks, winter break) applied when fixtures are generated
+	Breaks []ScheduleBreak `json:"breaks"`
+
+	// Kickoff scheduling: the league's time zone, the month and day the season starts in
+	// its year, the weekday matches are played on and the local kickoff times of each week
+	TimeZone     string   `json:"timezone"`
+	SeasonStart  string   `json:"season_start"`
+	MatchDay     string   `json:"match_day"`
+	KickoffTimes []string `json:"kickoff_times"`
+
+	// Minute engine parameters
+	RedCardChancePerMatch float64 `json:"red_card_chance_per_match"`
+	RedCardAttackPenalty  float64 `json:"red_card_attack_penalty"`
+	RedCardOpponentBoost  float64 `json:"red_card_opponent_boost"`
+	MaxStoppageMinutes    int     `json:"max_stoppage_minutes"`
+
+	// Per-team base rates scaled by the referee's tendencies in the minute engine
+	YellowCardChancePerMatch float64 `json:"yellow_card_chance_per_match"`
+	PenaltyChancePerMatch    float64 `json:"penalty_chance_per_match"`
+	PenaltyConversion        float64 `json:"penalty_conversion"`
+
+	// VAR reviews in the minute engine: the share of goals ruled out and each team's
+	// chance per match of a penalty awarded on review
+	VARReviews          bool    `json:"var_reviews"`
+	VARGoalDisallowRate float64 `json:"var_goal_disallow_rate"`
+	VARPenaltyChance    float64 `json:"var_penalty_chance_per_match"`
+
+	// Goal timing: first-half added time and how strongly scoring rises towards the
+	// end of each half (0 spreads goals evenly, 2 has no goals at kick-off)
+	MaxFirstHalfStoppageMinutes int     `json:"max_first_half_stoppage_minutes"`
+	LateGoalWeight              float64 `json:"late_goal_weight"`
+
+	// Base chance a shootout kick is scored, before order, pressure and strength
+	ShootoutConversion float64 `json:"shootout_conversion"`
+
+	// Strength points a team loses in its next league match after extra time in a
+	// cup match, when that match is within fatigue_window_weeks
+	ExtraTimeFatigue   float64 `json:"extra_time_fatigue"`
+	FatigueWindowWeeks int     `json:"fatigue_window_weeks"`
+}
+
+// simulationConfig is the active configuration. It is swapped by a reload while
+// matches are played, so it is only read through Config and each simulation runs on
+// the snapshot it was given.
+var simulationConfig = DefaultSimulationConfig()
+
+// DefaultSimulationConfig returns the simulation parameters used when no config file sets them
+func DefaultSimulationConfig() SimulationConfig {
+	return SimulationConfig{
+		Engine:                EngineClassic,
+		MaxGoals:              6,
+		MaxAverageGoals:       4.0,
+		MinAverageGoals:       1.5,
+		LuckRegression:        0.5,
+		SackingThreshold:      4,
+		NewManagerBounce:      5,
+		NewManagerBounceWeeks: 4,
+		MoraleEffect:          0.005,
+		ClosedDoorsHomeFactor: 0.4,
+		DixonColesRho:         defaultDixonColesRho,
+		RedCardChancePerMatch: 0.1,
+		RedCardAttackPenalty:  0.3,
+		RedCardOpponentBoost:  0.15,
+		MaxStoppageMinutes:    5,
+
+		YellowCardChancePerMatch: 1.8,
+		PenaltyChancePerMatch:    0.15,
+		PenaltyConversion:        0.78,
+		VARGoalDisallowRate:      0.05,
+		VARPenaltyChance:         0.05,
+
+		MaxFirstHalfStoppageMinutes: 3,
+		LateGoalWeight:              0.6,
+
+		ShootoutConversion: 0.75,
+		ExtraTimeFatigue:   3,
+		FatigueWindowWeeks: 1,
+
+		TimeZone:     "Europe/London",
+		SeasonStart:  "08-15",
+		MatchDay:     "saturday",
+		KickoffTimes: []string{"12:30", "15:00", "17:30"},
+	}
+}
+
+// LoadSimulationConfig reads a JSON config file over the defaults; a missing file keeps the defaults
+func LoadSimulationConfig(path string) (SimulationConfig, error) {
+	config := DefaultSimulationConfig()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read simulation config: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse simulation config: %v", err)
+	}
+
+	if err := validateSimulationConfig(config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// maxStoppageMinutes is the most added time a half can be set to have
+const maxStoppageMinutes = 15
+
+// validateSimulationConfig rejects an unknown engine and settings out of range
+func validateSimulationConfig(config SimulationConfig) error {
+	switch config.Engine {
+	case EngineClassic, EngineMinute:
+	default:
+		return fmt.Errorf("unknown simulation engine %q", config.Engine)
+	}
+
+	if config.MaxGoals < 1 {
+		return fmt.Errorf("max_goals must be at least 1")
+	}
+
+	if err := ValidateFeatures(config.Features); err != nil {
+		return err
+	}
+
+	if config.DrawBias < -1 || config.DrawBias > 1 {
+		return fmt.Errorf("draw_bias must be between -1 and 1")
+	}
+
+	if config.SackingThreshold < 0 || config.NewManagerBounce < 0 || config.NewManagerBounceWeeks < 0 {
+		return fmt.Errorf("sacking_threshold and new manager bounce settings cannot be negative")
+	}
+
+	if config.ClosedDoorsHomeFactor < 0 || config.ClosedDoorsHomeFactor > 1 {
+		return fmt.Errorf("closed_doors_home_factor must be between 0 and 1")
+	}
+
+	if config.MoraleEffect < 0 || config.MoraleEffect > 0.05 {
+		return fmt.Errorf("morale_effect must be between 0 and 0.05")
+	}
+
+	if config.DixonColesRho < -1 || config.DixonColesRho > 1 {
+		return fmt.Errorf("dixon_coles_rho must be between -1 and 1")
+	}
+
+	if config.YellowCardChancePerMatch < 0 || config.RedCardChancePerMatch < 0 || config.PenaltyChancePerMatch < 0 {
+		return fmt.Errorf("card and penalty chances cannot be negative")
+	}
+	if config.RedCardAttackPenalty < 0 || config.RedCardAttackPenalty > 1 || config.RedCardOpponentBoost < 0 || config.RedCardOpponentBoost > 1 {
+		return fmt.Errorf("red_card_attack_penalty and red_card_opponent_boost must be between 0 and 1")
+	}
+	if config.PenaltyConversion < 0 || config.PenaltyConversion > 1 {
+		return fmt.Errorf("penalty_conversion must be between 0 and 1")
+	}
+	if config.ShootoutConversion < 0 || config.ShootoutConversion > 1 {
+		return fmt.Errorf("shootout_conversion must be between 0 and 1")
+	}
+	if config.ExtraTimeFatigue < 0 || config.FatigueWindowWeeks < 0 {
+		return fmt.Errorf("extra_time_fatigue and fatigue_window_weeks cannot be negative")
+	}
+	if config.VARGoalDisallowRate < 0 || config.VARGoalDisallowRate > 1 || config.VARPenaltyChance < 0 {
+		return fmt.Errorf("var_goal_disallow_rate must be between 0 and 1 and var_penalty_chance_per_match cannot be negative")
+	}
+
+	if config.LateGoalWeight < 0 || config.LateGoalWeight > 2 {
+		return fmt.Errorf("late_goal_weight must be between 0 and 2")
+	}
+	if config.MaxStoppageMinutes < 0 || config.MaxStoppageMinutes > maxStoppageMinutes ||
+		config.MaxFirstHalfStoppageMinutes < 0 || config.MaxFirstHalfStoppageMinutes > maxStoppageMinutes {
+		return fmt.Errorf("stoppage minutes must be between 0 and %d", maxStoppageMinutes)
+	}
+
+	if err := validateKickoffSchedule(config); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SimulationConfigPath returns the config file location, overridable via LEAGUE_SIM_CONFIG
+func SimulationConfigPath() string {
+	if path := os.Getenv("LEAGUE_SIM_CONFIG"); path != "" {
+		return path
+	}
+	return "./simulation.json"
+}
+
+// BreakWeeks returns the calendar weeks occupied by the configured breaks and their labels
+func BreakWeeks(breaks []ScheduleBreak) map[int]string {
+	sorted := append([]ScheduleBreak(nil), breaks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].AfterRound < sorted[j].AfterRound
+	})
+
+	weeks := make(map[int]string)
+	for i, b := range sorted {
+		label := b.Label
+		if label == "" {
+			label = "Break"
+		}
+		weeks[b.AfterRound+i+1] = label
+	}
+	return weeks
+}
+
+// BreakLabel names a blank week, falling back to a generic label for gaps not in the config
+func BreakLabel(week int, breaks []ScheduleBreak) string {
+	if label, ok := BreakWeeks(breaks)[week]; ok {
+		return label
+	}
+	return "Break"
+}
+
+// MatchupStrength is the attacker's effective strength against a given defender.
+// Without separate ratings it is simply the attacker's overall strength; a defense
+// rated above the defender's overall strength suppresses it, a weaker one boosts it,
+// but never below 0: a weak attack against a strong defense can do no worse than not
+// threaten at all.
+func MatchupStrength(attacker, defender *Team) float64 {
+	attack := attacker.TeamStrength
+	if attacker.AttackRating > 0 {
+		attack = attacker.AttackRating
+	}
+	defenseAdjustment := 0
+	if defender.DefenseRating > 0 {
+		defenseAdjustment = defender.DefenseRating - defender.TeamStrength
+	}
+	return math.Max(float64(attack-defenseAdjustment), 0)
+}
+
+// MatchStrengths returns both sides' effective strength for a match under config,
+// including the home advantage and, under the elo_updates model, the form earned so far
+func MatchStrengths(match *Match, config *SimulationConfig) (float64, float64) {
+	explanation := explainStrengths(match, config)
+	return explanation.HomeStrength, explanation.AwayStrength
+}
+
+// drawBiasWindow is the expected-goals gap beyond which draw_bias no longer applies
+const drawBiasWindow = 1.0
+
+// applyDrawBias reweights a simulated score towards or away from a draw. The closer
+// the two sides' expected goals, the likelier the adjustment: with a positive bias a
+// decided match becomes a draw sharing its goals, with a negative bias a draw is
+// broken by one goal for the side with the higher xG. It reports whether the score changed.
+func applyDrawBias(match *Match, config *SimulationConfig, rng RandomSource) bool {
+	bias := config.DrawBias
+	closeness := 1 - math.Abs(match.HomeXG-match.AwayXG)/drawBiasWindow
+	if bias == 0 || closeness <= 0 || rng.Float64() >= math.Abs(bias)*closeness {
+		return false
+	}
+
+	isDraw := match.HomeTeamScore == match.AwayTeamScore
+	switch {
+	case bias > 0 && !isDraw:
+		shared := (match.HomeTeamScore + match.AwayTeamScore) / 2
+		match.HomeTeamScore, match.AwayTeamScore = shared, shared
+	case bias < 0 && isDraw:
+		if match.HomeXG >= match.AwayXG {
+			match.HomeTeamScore++
+		} else {
+			match.AwayTeamScore++
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// matchClockMinute is one minute of play, with its position on the match clock
+type matchClockMinute struct {
+	minute    int
+	addedTime int
+	// how far through its period this minute falls, from 0 at kick-off to 1 at its
+	// last minute; added time stays at 1
+	progress float64
+}
+
+// matchClock lists every minute of regulation time in the sport: its periods, in
+// football two halves of 45 minutes. With a running clock each half is followed by
+// a random amount of added time; the last period's is the end-of-match stoppage.
+func matchClock(sport *Sport, config *SimulationConfig, rng RandomSource) []matchClockMinute {
+	stoppage := func(max int) int {
+		if max <= 0 || !sport.AddedTime {
+			return 0
+		}
+		return rng.Intn(max + 1)
+	}
+
+	clock := make([]matchClockMinute, 0, sport.Minutes()+config.MaxFirstHalfStoppageMinutes+config.MaxStoppageMinutes)
+	for period := 0; period < sport.Periods; period++ {
+		start := period * sport.PeriodMinutes
+		limit := config.MaxFirstHalfStoppageMinutes
+		if period == sport.Periods-1 {
+			limit = config.MaxStoppageMinutes
+		}
+		added := stoppage(limit)
+		for minute := 1; minute <= sport.PeriodMinutes; minute++ {
+			clock = append(clock, matchClockMinute{minute: start + minute, progress: float64(minute-1) / float64(sport.PeriodMinutes-1)})
+		}
+		for a := 1; a <= added; a++ {
+			clock = append(clock, matchClockMinute{minute: start + sport.PeriodMinutes, addedTime: a, progress: 1})
+		}
+	}
+	return clock
+}
+
+// goalTimingWeight scales the scoring rate over a period: it rises linearly from
+// 1 - w/2 at kick-off to 1 + w/2 at the end, averaging 1 so expected goals over
+// regulation time are unchanged. Added time is played at the late-half rate.
+func goalTimingWeight(progress float64, config *SimulationConfig) float64 {
+	return 1 + float64(config.LateGoalWeight*(progress-0.5))
+}
+
+// simulateMatchByMinute plays a match as the sport's periods plus any added time
+// with per-minute scoring chances, and in a sport with overtime, a level match on
+// to a winner
+func simulateMatchByMinute(match *Match, config *SimulationConfig, rng RandomSource) {
+	homeTeam := match.HomeTeam
+	awayTeam := match.AwayTeam
+
+	// Same expected goals as the classic engine, spread over regulation time
+	sport := MatchSport(match)
+	minutes := float64(sport.Minutes())
+	homeStrength, awayStrength := MatchStrengths(match, config)
+	homeRate := sport.ExpectedGoals(homeStrength) / minutes
+	awayRate := sport.ExpectedGoals(awayStrength) / minutes
+
+	// The referee scales card and penalty rates. Penalties are part of each side's
+	// expected goals, so open play leaves room for an average referee's share and a
+	// strict referee raises scoring while a lenient one lowers it.
+	cardTendency, penaltyTendency := refereeTendencies(match)
+	redCardRate := config.RedCardChancePerMatch * cardTendency / minutes
+	yellowCardRate := config.YellowCardChancePerMatch * cardTendency / minutes
+	penaltyRate := config.PenaltyChancePerMatch / minutes
+	penaltyGoalRate := float64(penaltyRate * config.PenaltyConversion)
+	varPenaltyRate := 0.0
+	if config.VARReviews {
+		varPenaltyRate = config.VARPenaltyChance / minutes
+	}
+	scoreline := func() string {
+		return fmt.Sprintf("%d-%d", match.HomeTeamScore, match.AwayTeamScore)
+	}
+
+	match.HomeTeamScore = 0
+	match.AwayTeamScore = 0
+	match.HomeXG = 0
+	match.AwayXG = 0
+	match.Events = nil
+	homeSentOff, awaySentOff := false, false
+
+	for _, clock := range matchClock(sport, config, rng) {
+		event := func(eventType string, team *Team) {
+			e := newMatchEvent(clock.minute, eventType, team)
+			e.AddedTime = clock.addedTime
+			match.Events = append(match.Events, e)
+		}
+
+		// xG accumulates the scoring chance of every minute played, plus each penalty awarded
+		weight := goalTimingWeight(clock.progress, config)
+		takePenalty := func(team *Team, score *int, xg *float64) {
+			*xg += config.PenaltyConversion
+			event(EventPenalty, team)
+			if *score < sport.MaxGoals(config) && rng.Float64() < config.PenaltyConversion {
+				*score++
+				event(EventGoal, team)
+			}
+		}
+		attack := func(team *Team, rate float64, score *int, xg *float64) {
+			openPlay := math.Max((rate-penaltyGoalRate)*weight, 0)
+			*xg += openPlay
+			if *score < sport.MaxGoals(config) && rng.Float64() < openPlay {
+				*score++
+				if config.VARReviews && rng.Float64() < config.VARGoalDisallowRate {
+					before := scoreline()
+					*score--
+					event(EventVARGoalDisallowed, team)
+					match.Events[len(match.Events)-1].ScoreBefore = before
+					match.Events[len(match.Events)-1].ScoreAfter = scoreline()
+				} else {
+					event(EventGoal, team)
+				}
+			}
+			if rng.Float64() < penaltyRate*penaltyTendency*weight {
+				takePenalty(team, score, xg)
+			}
+			if rng.Float64() < varPenaltyRate*penaltyTendency*weight {
+				before := scoreline()
+				event(EventVARPenalty, team)
+				review := len(match.Events) - 1
+				takePenalty(team, score, xg)
+				match.Events[review].ScoreBefore = before
+				match.Events[review].ScoreAfter = scoreline()
+			}
+		}
+		attack(homeTeam, homeRate, &match.HomeTeamScore, &match.HomeXG)
+		attack(awayTeam, awayRate, &match.AwayTeamScore, &match.AwayXG)
+
+		if rng.Float64() < yellowCardRate {
+			event(EventYellowCard, homeTeam)
+		}
+		if rng.Float64() < yellowCardRate {
+			event(EventYellowCard, awayTeam)
+		}
+
+		// A red card weakens the offending side and lifts the opponent for the rest of the match
+		if !homeSentOff && rng.Float64() < redCardRate {
+			homeSentOff = true
+			homeRate *= 1 - config.RedCardAttackPenalty
+			awayRate *= 1 + config.RedCardOpponentBoost
+			event(EventRedCard, homeTeam)
+		}
+		if !awaySentOff && rng.Float64() < redCardRate {
+			awaySentOff = true
+			awayRate *= 1 - config.RedCardAttackPenalty
+			homeRate *= 1 + config.RedCardOpponentBoost
+			event(EventRedCard, awayTeam)
+		}
+	}
+
+	if sport.Overtime > 0 && match.HomeTeamScore == match.AwayTeamScore {
+		playOvertime(match, sport, config, homeRate, awayRate, rng)
+	}
+
+	sortMatchEvents(match.Events)
+}
+
+// playOvertime plays sudden death at the rates the match ended on, with no more than
+// one goal, then a shootout if it stays level. The shootout's winner is credited
+// with one goal.
+func playOvertime(match *Match, sport *Sport, config *SimulationConfig, homeRate, awayRate float64, rng RandomSource) {
+	end := sport.Minutes()
+	for minute := end + 1; minute <= end+sport.Overtime; minute++ {
+		for _, side := range []struct {
+			team  *Team
+			rate  float64
+			score *int
+		}{{match.HomeTeam, homeRate, &match.HomeTeamScore}, {match.AwayTeam, awayRate, &match.AwayTeamScore}} {
+			if rng.Float64() < side.rate {
+				*side.score++
+				match.HomeXG += homeRate * float64(minute-end)
+				match.AwayXG += awayRate * float64(minute-end)
+				match.Events = append(match.Events, newMatchEvent(minute, EventGoal, side.team))
+				match.DecidedBy = DecidedByOvertime
+				return
+			}
+		}
+	}
+	match.HomeXG += homeRate * float64(sport.Overtime)
+	match.AwayXG += awayRate * float64(sport.Overtime)
+
+	shootout := SimulateShootout(match.HomeTeam, match.AwayTeam, end+sport.Overtime, config, rng)
+	match.Events = append(match.Events, shootout.Kicks...)
+	if shootout.HomeScore > shootout.AwayScore {
+		match.HomeTeamScore++
+	} else {
+		match.AwayTeamScore++
+	}
+	match.DecidedBy = DecidedByShootout
+}
+
+// SimulateExtraTime plays the extra time of a knockout match level after regulation
+// time and returns each side's goals. Both sides score at the rates regulation time
+// gives them, so the venue and the sport count as they did there. In a sport with
+// sudden-death overtime it ends as soon as a side is ahead. The match itself is left
+// alone.
+func SimulateExtraTime(match *Match, config *SimulationConfig, rng RandomSource) (int, int) {
+	sport := MatchSport(match)
+	minutes := float64(sport.Minutes())
+	homeStrength, awayStrength := MatchStrengths(match, config)
+	homeRate := sport.ExpectedGoals(homeStrength) / minutes
+	awayRate := sport.ExpectedGoals(awayStrength) / minutes
+
+	homeGoals, awayGoals := 0, 0
+	for minute := 1; minute <= sport.ExtraTime; minute++ {
+		if rng.Float64() < homeRate {
+			homeGoals++
+		}
+		if rng.Float64() < awayRate {
+			awayGoals++
+		}
+		if sport.Overtime > 0 && homeGoals != awayGoals {
+			break
+		}
+	}
+	return homeGoals, awayGoals
+}
+
+// sortMatchEvents orders a timeline by match clock, added time after its half
+func sortMatchEvents(events []MatchEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Minute != events[j].Minute {
+			return events[i].Minute < events[j].Minute
+		}
+		return events[i].AddedTime < events[j].AddedTime
+	})
+}
+
+// ExpectedGoals converts a strength rating into expected goals over 90 minutes. The
+// conversion rounds the product before the addition (see portablemath.go). A strength
+// pushed below 0, e.g. by fatigue or poor form, gets the 0.5 floor of strength 0.
+func ExpectedGoals(strength float64) float64 {
+	strength = math.Max(strength, 0)
+	return float64((strength/100.0)*4.0) + 0.5
+}
+
+func newMatchEvent(minute int, eventType string, team *Team) MatchEvent {
+	return MatchEvent{
+		Minute:   minute,
+		Type:     eventType,
+		TeamId:   team.TeamId,
+		TeamName: team.TeamName,
+	}
+}
@@ -0,0 +1,110 @@
+package league
+
+import (
+	"testing"
+)
+
+func TestWeakAttackAgainstStrongDefenseKeepsXGFloor(t *testing.T) {
+	attacker := &Team{TeamName: "Attackers", TeamStrength: 1, AttackRating: 1}
+	defender := &Team{TeamName: "Defenders", TeamStrength: 1, DefenseRating: 100}
+
+	if strength := MatchupStrength(attacker, defender); strength != 0 {
+		t.Errorf("matchupStrength = %v, want 0", strength)
+	}
+	if xg := ExpectedGoals(MatchupStrength(attacker, defender)); xg != 0.5 {
+		t.Errorf("expectedGoals = %v, want the 0.5 floor", xg)
+	}
+	if xg := ExpectedGoals(-40); xg != 0.5 {
+		t.Errorf("expectedGoals(-40) = %v, want the 0.5 floor", xg)
+	}
+}
+
+func TestFixturePlaysOnItsWeeksConfig(t *testing.T) {
+	saved := Config()
+	defer SetConfig(saved)
+
+	capped := DefaultSimulationConfig()
+	capped.MaxGoals = 1
+	teams := []*Team{
+		{TeamName: "Strong", TeamId: 1, TeamStrength: 100},
+		{TeamName: "Weak", TeamId: 2, TeamStrength: 1},
+	}
+	league := &League{Teams: teams, Random: NewPCG(1)}
+	for i := 1; i <= 50; i++ {
+		league.Matches = append(league.Matches, &Match{MatchId: i, Week: 1, HomeTeam: teams[0], AwayTeam: teams[1]})
+	}
+
+	// A reload after the week's snapshot was taken must not reach its matches
+	uncapped := DefaultSimulationConfig()
+	uncapped.MaxGoals = 10
+	SetConfig(uncapped)
+	for _, match := range league.Matches {
+		PlayFixture(league, match, &capped)
+		if match.HomeTeamScore > 1 || match.AwayTeamScore > 1 {
+			t.Fatalf("match %d finished %d-%d, above the snapshot's max_goals of 1", match.MatchId, match.HomeTeamScore, match.AwayTeamScore)
+		}
+	}
+}
+
+// constantRandom draws the same value every time
+type constantRandom float64
+
+func (r constantRandom) Float64() float64 { return float64(r) }
+func (r constantRandom) Intn(n int) int   { return 0 }
+
+func TestExtraTimeFollowsVenueAndSport(t *testing.T) {
+	config := DefaultSimulationConfig()
+	rates := func(match *Match) (float64, float64) {
+		sport := MatchSport(match)
+		home, away := MatchStrengths(match, &config)
+		return sport.ExpectedGoals(home) / float64(sport.Minutes()), sport.ExpectedGoals(away) / float64(sport.Minutes())
+	}
+	newMatch := func(venue string, sport *Sport) *Match {
+		return &Match{
+			HomeTeam: &Team{TeamName: "Home", TeamId: 1, TeamStrength: 70},
+			AwayTeam: &Team{TeamName: "Away", TeamId: 2, TeamStrength: 70},
+			Venue:    venue,
+			sport:    sport,
+		}
+	}
+
+	// A draw between the two sides' rates scores for the home side every minute and
+	// never for the away side, unless the venue leaves them level
+	homeRate, awayRate := rates(newMatch("", nil))
+	if homeRate <= awayRate {
+		t.Fatalf("home rate %v is not above away rate %v at the home ground", homeRate, awayRate)
+	}
+	between := constantRandom((homeRate + awayRate) / 2)
+
+	tests := []struct {
+		name               string
+		match              *Match
+		wantHome, wantAway int
+		wantLevelAtNeutral bool
+	}{
+		{name: "home ground", match: newMatch("", nil), wantHome: 30, wantAway: 0},
+		{name: "futsal", match: newMatch("", sportPresets[SportFutsal]), wantHome: 10, wantAway: 0},
+		{name: "neutral venue", match: newMatch(VenueNeutral, nil), wantLevelAtNeutral: true},
+		{name: "sudden death", match: newMatch("", sportPresets[SportHockey]), wantHome: 1, wantAway: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rng := between
+			if test.match.sport != nil {
+				// Another sport has other rates; draw between its own
+				home, away := rates(test.match)
+				rng = constantRandom((home + away) / 2)
+			}
+			home, away := SimulateExtraTime(test.match, &config, rng)
+			if test.wantLevelAtNeutral {
+				if home != away {
+					t.Errorf("extra time at a neutral venue finished %d-%d between equal sides", home, away)
+				}
+				return
+			}
+			if home != test.wantHome || away != test.wantAway {
+				t.Errorf("extra time finished %d-%d, want %d-%d", home, away, test.wantHome, test.wantAway)
+			}
+		})
+	}
+}
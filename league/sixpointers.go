@@ -0,0 +1,102 @@
+package league
+
+import (
+	"sort"
+)
+
+// A fixture is a six-pointer when its teams sit either side of a zone boundary, at
+// most sixPointerPlaces places and sixPointerPoints points apart: one win for the
+// team below levels or overturns the order, and the loser drops points to a direct rival.
+const (
+	sixPointerPlaces = 2
+	sixPointerPoints = 3
+)
+
+// titleZone names the line under first place when no zone ends there
+const titleZone = "Title"
+
+// SixPointer explains why an upcoming fixture is a six-pointer
+type SixPointer struct {
+	Zone          string // the zone whose edge the teams straddle, or the title
+	Boundary      int    // the last position above the line
+	HomePosition  int
+	AwayPosition  int
+	PointsBetween int
+}
+
+// zoneBoundary is a line in the table under position After
+type zoneBoundary struct {
+	After int
+	Zone  string
+}
+
+// zoneBoundaries lists the lines between zones in a table of teams teams, top first:
+// both edges of every zone, and the line under first place for the title
+func zoneBoundaries(zones []*Zone, teams int) []zoneBoundary {
+	boundaries := []zoneBoundary{}
+	seen := make(map[int]bool)
+	add := func(after int, zone string) {
+		if after >= 1 && after < teams && !seen[after] {
+			seen[after] = true
+			boundaries = append(boundaries, zoneBoundary{After: after, Zone: zone})
+		}
+	}
+	for _, zone := range zones {
+		add(zone.From-1, zone.Name)
+		add(zone.To, zone.Name)
+	}
+	add(1, titleZone)
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].After < boundaries[j].After })
+	return boundaries
+}
+
+// SixPointers finds the unplayed fixtures that are six-pointers on the current
+// standings, by match ID. Before a ball is kicked the table says nothing, so there
+// are none.
+func SixPointers(league *League) map[int]*SixPointer {
+	found := make(map[int]*SixPointer)
+	if league.CurrentWeek == 0 {
+		return found
+	}
+	standings := make(map[string]*LeagueTableEntry)
+	for _, entry := range league.LeagueTable {
+		standings[entry.TeamName] = entry
+	}
+	boundaries := zoneBoundaries(LeagueZones(league).Zones, len(league.LeagueTable))
+
+	for _, match := range league.Matches {
+		home, away := standings[match.HomeTeam.TeamName], standings[match.AwayTeam.TeamName]
+		if match.Played || home == nil || away == nil {
+			continue
+		}
+		upper, lower := home, away
+		if lower.Position < upper.Position {
+			upper, lower = lower, upper
+		}
+		if lower.Position-upper.Position > sixPointerPlaces || upper.Points-lower.Points > sixPointerPoints {
+			continue
+		}
+		for _, boundary := range boundaries {
+			if upper.Position <= boundary.After && boundary.After < lower.Position {
+				found[match.MatchId] = &SixPointer{
+					Zone:          boundary.Zone,
+					Boundary:      boundary.After,
+					HomePosition:  home.Position,
+					AwayPosition:  away.Position,
+					PointsBetween: upper.Points - lower.Points,
+				}
+				break
+			}
+		}
+	}
+	return found
+}
+
+// MarkSixPointers sets SixPointer on response copies of matches
+func MarkSixPointers(league *League, matches []*Match) []*Match {
+	found := SixPointers(league)
+	for _, match := range matches {
+		match.SixPointer = found[match.MatchId]
+	}
+	return matches
+}
@@ -0,0 +1,203 @@
+package league
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Sport presets a league can be created with
+const (
+	SportFootball = "football"
+	SportFutsal   = "futsal"
+	SportHockey   = "hockey"
+)
+
+// How a match level after regulation time was won, in a sport with overtime
+const (
+	DecidedByOvertime = "overtime"
+	DecidedByShootout = "shootout"
+)
+
+var (
+	ErrUnknownSport = errors.New("unknown sport")
+	ErrLevelResult  = errors.New("a match in this sport cannot end level; report the score after overtime or the shootout")
+)
+
+// SportPoints are the table points each result is worth. A loss is worth nothing.
+type SportPoints struct {
+	Win          int
+	Draw         int // in a sport without draws, a match a handicap line leaves level
+	OvertimeWin  int `json:",omitempty"`
+	OvertimeLoss int `json:",omitempty"`
+}
+
+// Sport is the match format and scoring a league plays under
+type Sport struct {
+	Name          string
+	Periods       int // periods of regulation time
+	PeriodMinutes int
+	AddedTime     bool    // a running clock with stoppage time after each period; otherwise the clock is stopped
+	GoalRate      float64 // expected goals as a multiple of football's for the same strengths
+	Overtime      int     `json:",omitempty"` // minutes of sudden death for a level match, then a shootout; 0 allows draws
+	ExtraTime     int     // minutes a knockout match level after regulation time plays on, see SimulateExtraTime
+	Points        SportPoints
+}
+
+// sportPresets are the sports a league can be created with, by name
+var sportPresets = map[string]*Sport{
+	SportFootball: {Name: SportFootball, Periods: 2, PeriodMinutes: 45, AddedTime: true, GoalRate: 1, ExtraTime: 30,
+		Points: SportPoints{Win: 3, Draw: 1}},
+	SportFutsal: {Name: SportFutsal, Periods: 2, PeriodMinutes: 20, GoalRate: 1.6, ExtraTime: 10,
+		Points: SportPoints{Win: 3, Draw: 1}},
+	SportHockey: {Name: SportHockey, Periods: 3, PeriodMinutes: 20, GoalRate: 0.75, Overtime: 5, ExtraTime: 20,
+		Points: SportPoints{Win: 3, Draw: 1, OvertimeWin: 2, OvertimeLoss: 1}},
+}
+
+// sportNames lists the presets, for error messages and the docs
+func sportNames() []string {
+	names := make([]string, 0, len(sportPresets))
+	for name := range sportPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupSport returns the preset with the given name; empty is football
+func LookupSport(name string) (*Sport, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return sportPresets[SportFootball], nil
+	}
+	sport, ok := sportPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q: must be one of %s", ErrUnknownSport, name, strings.Join(sportNames(), ", "))
+	}
+	if err := sport.validate(); err != nil {
+		return nil, fmt.Errorf("sport %s: %v", name, err)
+	}
+	return sport, nil
+}
+
+// validate rejects a match format the engines cannot play. The minute engine times
+// goals by each minute's progress through its period, so a period needs two minutes.
+func (sport *Sport) validate() error {
+	switch {
+	case sport.Periods < 1:
+		return fmt.Errorf("a match needs at least 1 period")
+	case sport.PeriodMinutes < 2:
+		return fmt.Errorf("a period must last at least 2 minutes")
+	case sport.GoalRate <= 0:
+		return fmt.Errorf("goal rate must be above 0")
+	case sport.Overtime < 0 || sport.ExtraTime < 0:
+		return fmt.Errorf("overtime and extra time cannot be negative")
+	}
+	return nil
+}
+
+// DefaultSport is the sport a new league is created with unless its creator picks
+// one, from LEAGUE_SPORT
+func DefaultSport() (*Sport, error) {
+	return LookupSport(os.Getenv("LEAGUE_SPORT"))
+}
+
+// LeagueSport returns the sport the league plays, football unless it was created with another
+func LeagueSport(league *League) *Sport {
+	if sport, ok := sportPresets[league.Sport]; ok {
+		return sport
+	}
+	return sportPresets[SportFootball]
+}
+
+// MatchSport returns the sport a match is simulated under, set by PlayFixture for
+// league fixtures; every other match is football
+func MatchSport(match *Match) *Sport {
+	if match.sport == nil {
+		return sportPresets[SportFootball]
+	}
+	return match.sport
+}
+
+// Minutes is the length of regulation time
+func (sport *Sport) Minutes() int {
+	return sport.Periods * sport.PeriodMinutes
+}
+
+// ExpectedGoals converts a strength rating into expected goals over regulation time.
+// The product is rounded on its own, as in ExpectedGoals.
+func (sport *Sport) ExpectedGoals(strength float64) float64 {
+	return float64(ExpectedGoals(strength) * sport.GoalRate)
+}
+
+// MaxGoals is the config's cap on one side's goals in regulation time, which is
+// set for football and scales with the sport's scoring
+func (sport *Sport) MaxGoals(config *SimulationConfig) int {
+	return int(math.Ceil(float64(config.MaxGoals) * sport.GoalRate))
+}
+
+// ResultPoints are the points a result with the given margin for the home side is
+// worth to each side; overtime marks a result decided after regulation time
+func (sport *Sport) ResultPoints(margin float64, overtime bool) (int, int) {
+	win, loss := sport.Points.Win, 0
+	if overtime && sport.Overtime > 0 {
+		win, loss = sport.Points.OvertimeWin, sport.Points.OvertimeLoss
+	}
+	switch {
+	case margin > 0:
+		return win, loss
+	case margin < 0:
+		return loss, win
+	default:
+		return sport.Points.Draw, sport.Points.Draw
+	}
+}
+
+// MatchPoints are the points a played match is worth to each side
+func (sport *Sport) MatchPoints(match *Match) (int, int) {
+	return sport.ResultPoints(float64(match.HomeTeamScore-match.AwayTeamScore), match.DecidedBy != "")
+}
+
+// levelPoints are the points a side can expect from a match level after regulation
+// time: a draw, or an even chance of winning it afterwards
+func (sport *Sport) levelPoints() float64 {
+	if sport.Overtime == 0 {
+		return float64(sport.Points.Draw)
+	}
+	return float64(sport.Points.OvertimeWin+sport.Points.OvertimeLoss) / 2
+}
+
+// RecordPoints are the points a record is worth; the wins and losses after
+// regulation time are among its wins and losses
+func (sport *Sport) RecordPoints(wins, draws, overtimeWins, overtimeLosses int) int {
+	return sport.Points.Win*(wins-overtimeWins) + sport.Points.OvertimeWin*overtimeWins +
+		sport.Points.Draw*draws + sport.Points.OvertimeLoss*overtimeLosses
+}
+
+// settleLevelMatch decides a match level after regulation time in a sport with
+// overtime, for the classic engine: overtime produces a goal with the chance both
+// sides' expected goals give over its minutes, scored by either side in proportion
+// to them, and otherwise a shootout is an even contest. The winner is credited with
+// one goal. A sport with draws leaves the match alone.
+func settleLevelMatch(match *Match, sport *Sport, rng RandomSource) {
+	if sport.Overtime == 0 || match.HomeTeamScore != match.AwayTeamScore {
+		return
+	}
+	total := match.HomeXG + match.AwayXG
+	var homeWins bool
+	if rng.Float64() < 1-portableExp(float64(-total*float64(sport.Overtime))/float64(sport.Minutes())) {
+		homeWins = rng.Float64() < match.HomeXG/total
+		match.DecidedBy = DecidedByOvertime
+	} else {
+		homeWins = rng.Float64() < 0.5
+		match.DecidedBy = DecidedByShootout
+	}
+	if homeWins {
+		match.HomeTeamScore++
+	} else {
+		match.AwayTeamScore++
+	}
+}
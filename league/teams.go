@@ -0,0 +1,131 @@
+package league
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Slugify turns a team name into its URL form, e.g. "Manchester City" -> "manchester-city"
+func Slugify(name string) string {
+	var b strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			pendingDash = false
+		case r == '\'':
+			// "Nott'm Forest" -> "nottm-forest"
+		default:
+			pendingDash = true
+		}
+	}
+	return b.String()
+}
+
+// AssignSlugs derives each team's slug from its current name
+func AssignSlugs(teams []*Team) {
+	for _, team := range teams {
+		team.Slug = Slugify(team.TeamName)
+	}
+}
+
+// TeamBranding is optional presentation metadata so frontends can render branded tables
+type TeamBranding struct {
+	ShortCode      string `json:",omitempty"` // e.g. "MCI"
+	PrimaryColor   string `json:",omitempty"` // hex, e.g. "#6CABDD"
+	SecondaryColor string `json:",omitempty"`
+	BadgeURL       string `json:",omitempty"`
+}
+
+var (
+	HexColorPattern  = regexp.MustCompile(`^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+	shortCodePattern = regexp.MustCompile(`^[A-Z0-9]{2,4}$`)
+)
+
+// Validate checks the metadata formats; empty fields are allowed and clear the value
+func (b TeamBranding) Validate() error {
+	if b.ShortCode != "" && !shortCodePattern.MatchString(b.ShortCode) {
+		return fmt.Errorf("short_code must be 2-4 uppercase letters or digits")
+	}
+	if b.PrimaryColor != "" && !HexColorPattern.MatchString(b.PrimaryColor) {
+		return fmt.Errorf("primary_color must be a hex color like #6CABDD")
+	}
+	if b.SecondaryColor != "" && !HexColorPattern.MatchString(b.SecondaryColor) {
+		return fmt.Errorf("secondary_color must be a hex color like #1C2C5B")
+	}
+	if b.BadgeURL != "" {
+		parsed, err := url.Parse(b.BadgeURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("badge_url must be an absolute http(s) URL")
+		}
+	}
+	return nil
+}
+
+// TeamRatingsUpdate is a partial update of a team's ratings; nil fields are left unchanged
+type TeamRatingsUpdate struct {
+	Strength *int `json:"strength"`
+	Attack   *int `json:"attack"`
+	Defense  *int `json:"defense"`
+}
+
+// TeamAuditEntry records one manual change to a team rating
+type TeamAuditEntry struct {
+	TeamId    int
+	Field     string
+	OldValue  int
+	NewValue  int
+	ChangedBy string
+	ChangedAt time.Time
+}
+
+// Validate checks the requested ratings; attack and defense may be 0 to fall back to strength
+func (u TeamRatingsUpdate) Validate() error {
+	if u.Strength == nil && u.Attack == nil && u.Defense == nil {
+		return fmt.Errorf("nothing to update: provide strength, attack or defense")
+	}
+	if u.Strength != nil && (*u.Strength < 1 || *u.Strength > 100) {
+		return fmt.Errorf("strength must be between 1 and 100")
+	}
+	if u.Attack != nil && (*u.Attack < 0 || *u.Attack > 100) {
+		return fmt.Errorf("attack must be between 0 and 100")
+	}
+	if u.Defense != nil && (*u.Defense < 0 || *u.Defense > 100) {
+		return fmt.Errorf("defense must be between 0 and 100")
+	}
+	return nil
+}
+
+// ApplyRatingsUpdate changes the team's ratings and returns an audit entry per changed field
+func ApplyRatingsUpdate(team *Team, update TeamRatingsUpdate, changedBy string) []*TeamAuditEntry {
+	now := time.Now().UTC()
+	entries := []*TeamAuditEntry{}
+
+	apply := func(field string, target *int, value *int) {
+		if value == nil || *value == *target {
+			return
+		}
+		entries = append(entries, &TeamAuditEntry{
+			TeamId:    team.TeamId,
+			Field:     field,
+			OldValue:  *target,
+			NewValue:  *value,
+			ChangedBy: changedBy,
+			ChangedAt: now,
+		})
+		*target = *value
+	}
+
+	apply("strength", &team.TeamStrength, update.Strength)
+	apply("attack", &team.AttackRating, update.Attack)
+	apply("defense", &team.DefenseRating, update.Defense)
+
+	return entries
+}
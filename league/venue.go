@@ -0,0 +1,31 @@
+package league
+
+import (
+	"fmt"
+)
+
+// Match venues; a match with no venue is played at the home side's ground
+const (
+	VenueNeutral     = "neutral"      // no home advantage
+	VenueClosedDoors = "closed_doors" // home ground without a crowd
+)
+
+// ValidateVenue accepts the home ground ("") or one of the venue constants
+func ValidateVenue(venue string) error {
+	switch venue {
+	case "", VenueNeutral, VenueClosedDoors:
+		return nil
+	}
+	return fmt.Errorf("venue must be %q, %q or empty for the home ground", VenueNeutral, VenueClosedDoors)
+}
+
+// HomeAdvantageFactor is the share of the usual home advantage a match keeps under config
+func HomeAdvantageFactor(match *Match, config *SimulationConfig) float64 {
+	switch match.Venue {
+	case VenueNeutral:
+		return 0
+	case VenueClosedDoors:
+		return config.ClosedDoorsHomeFactor
+	}
+	return 1
+}
@@ -0,0 +1,149 @@
+package league
+
+import (
+	"sort"
+)
+
+// XGTableEntry compares a team's actual goals with the expected goals behind them
+type XGTableEntry struct {
+	TeamName        string
+	Played          int
+	GoalsFor        int
+	XGFor           float64
+	GoalsAgainst    int
+	XGAgainst       float64
+	XGDifference    float64
+	GoalsMinusXG    float64 // positive when finishing above expectation
+	ConcededMinusXG float64 // positive when conceding more than expected
+}
+
+// BuildXGTable aggregates xG for and against per team from played matches, ordered by xG difference
+func BuildXGTable(league *League) []*XGTableEntry {
+	entries := make(map[string]*XGTableEntry)
+	table := []*XGTableEntry{}
+	for _, team := range league.Teams {
+		entry := &XGTableEntry{TeamName: team.TeamName}
+		entries[team.TeamName] = entry
+		table = append(table, entry)
+	}
+
+	for _, match := range league.Matches {
+		if !match.Played {
+			continue
+		}
+		home := entries[match.HomeTeam.TeamName]
+		away := entries[match.AwayTeam.TeamName]
+		if home == nil || away == nil {
+			continue
+		}
+
+		home.Played++
+		away.Played++
+		home.GoalsFor += match.HomeTeamScore
+		home.GoalsAgainst += match.AwayTeamScore
+		away.GoalsFor += match.AwayTeamScore
+		away.GoalsAgainst += match.HomeTeamScore
+		home.XGFor += match.HomeXG
+		home.XGAgainst += match.AwayXG
+		away.XGFor += match.AwayXG
+		away.XGAgainst += match.HomeXG
+	}
+
+	for _, entry := range table {
+		entry.XGDifference = entry.XGFor - entry.XGAgainst
+		entry.GoalsMinusXG = float64(entry.GoalsFor) - entry.XGFor
+		entry.ConcededMinusXG = float64(entry.GoalsAgainst) - entry.XGAgainst
+	}
+
+	sort.SliceStable(table, func(i, j int) bool {
+		return table[i].XGDifference > table[j].XGDifference
+	})
+
+	return table
+}
+
+// LuckEntry compares a team's points with the points its xG would typically earn
+type LuckEntry struct {
+	TeamName       string
+	Points         int
+	ExpectedPoints float64
+	LuckIndex      float64 // points minus expected points; positive means fortunate
+}
+
+// poissonProbabilities returns P(goals = k) for k up to maxGoals
+func poissonProbabilities(lambda float64, maxGoals int) []float64 {
+	probabilities := make([]float64, maxGoals+1)
+	probability := portableExp(-lambda)
+	for k := 0; k <= maxGoals; k++ {
+		probabilities[k] = probability
+		probability *= lambda / float64(k+1)
+	}
+	return probabilities
+}
+
+// expectedPoints returns the points each side would average given the match xG under
+// the sport's scoring
+func expectedPoints(sport *Sport, homeXG, awayXG float64) (float64, float64) {
+	const maxGoals = 12
+	home := poissonProbabilities(homeXG, maxGoals)
+	away := poissonProbabilities(awayXG, maxGoals)
+
+	homeWin, draw, awayWin := 0.0, 0.0, 0.0
+	for h := 0; h <= maxGoals; h++ {
+		for a := 0; a <= maxGoals; a++ {
+			p := float64(home[h] * away[a])
+			switch {
+			case h > a:
+				homeWin += p
+			case h < a:
+				awayWin += p
+			default:
+				draw += p
+			}
+		}
+	}
+
+	win, level := float64(sport.Points.Win), sport.levelPoints()
+	return float64(win*homeWin) + float64(level*draw), float64(win*awayWin) + float64(level*draw)
+}
+
+// BuildLuckIndex computes the luck index for every team up to the given week, luckiest first
+func BuildLuckIndex(league *League, uptoWeek int) []*LuckEntry {
+	sport := LeagueSport(league)
+	entries := make(map[string]*LuckEntry)
+	luck := []*LuckEntry{}
+	for _, team := range league.Teams {
+		entry := &LuckEntry{TeamName: team.TeamName}
+		entries[team.TeamName] = entry
+		luck = append(luck, entry)
+	}
+
+	for _, match := range league.Matches {
+		if !match.Played || match.Week > uptoWeek {
+			continue
+		}
+		home := entries[match.HomeTeam.TeamName]
+		away := entries[match.AwayTeam.TeamName]
+		if home == nil || away == nil {
+			continue
+		}
+
+		homeExpected, awayExpected := expectedPoints(sport, match.HomeXG, match.AwayXG)
+		home.ExpectedPoints += homeExpected
+		away.ExpectedPoints += awayExpected
+
+		homePoints, awayPoints := sport.MatchPoints(match)
+		home.Points += homePoints
+		away.Points += awayPoints
+	}
+
+	for _, entry := range luck {
+		entry.LuckIndex = float64(entry.Points) - entry.ExpectedPoints
+	}
+
+	sort.SliceStable(luck, func(i, j int) bool {
+		return luck[i].LuckIndex > luck[j].LuckIndex
+	})
+
+	return luck
+}
@@ -0,0 +1,111 @@
+package league
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Zone kinds, which decide what a zone means beyond its label
+const (
+	ZoneChampion      = "champion"      // the title; starts at first place
+	ZoneQualification = "qualification" // places worth reaching, e.g. a continental competition
+	ZoneRelegation    = "relegation"    // places to stay out of
+)
+
+// Zone is a named range of table positions and the colour tables show it in
+type Zone struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+	Color string `json:"color,omitempty"` // "#RGB" or "#RRGGBB"
+}
+
+// ZoneSettings are the zones a league is using and where they come from
+type ZoneSettings struct {
+	Source string  // "league" for zones stored with the league, "rules" for the qualification spots
+	Zones  []*Zone // top of the table first
+}
+
+// ValidateZones checks zones before they are stored: every zone needs a name, a known
+// kind and a range from position 1 down, names are unique, ranges do not overlap, and
+// a champion zone starts at first place. An empty kind is read as qualification.
+func ValidateZones(zones []*Zone) error {
+	names := make(map[string]bool)
+	for _, zone := range zones {
+		zone.Name = strings.TrimSpace(zone.Name)
+		zone.Color = strings.TrimSpace(zone.Color)
+		if zone.Kind == "" {
+			zone.Kind = ZoneQualification
+		}
+		switch {
+		case zone.Name == "":
+			return fmt.Errorf("every zone needs a name")
+		case names[strings.ToLower(zone.Name)]:
+			return fmt.Errorf("zone %q is defined twice", zone.Name)
+		case zone.Kind != ZoneChampion && zone.Kind != ZoneQualification && zone.Kind != ZoneRelegation:
+			return fmt.Errorf("zone %q: kind must be %s, %s or %s", zone.Name, ZoneChampion, ZoneQualification, ZoneRelegation)
+		case zone.From < 1 || zone.To < zone.From:
+			return fmt.Errorf("zone %q: positions must start from 1 with to >= from", zone.Name)
+		case zone.Kind == ZoneChampion && zone.From != 1:
+			return fmt.Errorf("zone %q: a champion zone starts at position 1", zone.Name)
+		case zone.Color != "" && !HexColorPattern.MatchString(zone.Color):
+			return fmt.Errorf("zone %q: color must be a hex color like #1a7f37", zone.Name)
+		}
+		names[strings.ToLower(zone.Name)] = true
+	}
+
+	sorted := sortedZones(zones)
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].From <= sorted[i-1].To {
+			return fmt.Errorf("zones %q and %q overlap", sorted[i-1].Name, sorted[i].Name)
+		}
+	}
+	return nil
+}
+
+// sortedZones returns zones ordered from the top of the table down
+func sortedZones(zones []*Zone) []*Zone {
+	sorted := append([]*Zone{}, zones...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].From < sorted[j].From })
+	return sorted
+}
+
+// LeagueZones returns the zones stored with the league or, for a league without any,
+// the qualification spots of the competition rules. A spot labelled Relegation is a
+// relegation zone; the others are qualification zones without a colour.
+func LeagueZones(league *League) *ZoneSettings {
+	if len(league.Zones) > 0 {
+		return &ZoneSettings{Source: "league", Zones: sortedZones(league.Zones)}
+	}
+	zones := []*Zone{}
+	for _, spot := range competitionRules.Qualification {
+		kind := ZoneQualification
+		if strings.EqualFold(spot.Label, "Relegation") {
+			kind = ZoneRelegation
+		}
+		zones = append(zones, &Zone{Name: spot.Label, Kind: kind, From: spot.From, To: spot.To})
+	}
+	return &ZoneSettings{Source: "rules", Zones: sortedZones(zones)}
+}
+
+// ZoneAt returns the zone position falls in, or nil
+func ZoneAt(zones []*Zone, position int) *Zone {
+	for _, zone := range zones {
+		if position >= zone.From && position <= zone.To {
+			return zone
+		}
+	}
+	return nil
+}
+
+// ApplyZones labels each entry with the zone its position falls in and its colour
+func ApplyZones(zones []*Zone, table []*LeagueTableEntry) {
+	for _, entry := range table {
+		entry.Zone, entry.ZoneColor = "", ""
+		if zone := ZoneAt(zones, entry.Position); zone != nil {
+			entry.Zone, entry.ZoneColor = zone.Name, zone.Color
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultRequestTimeout bounds how long a request may run before the client gets 408
+const defaultRequestTimeout = 30 * time.Second
+
+// defaultMaxBodyBytes caps PUT, POST and PATCH bodies; team imports are the largest
+const defaultMaxBodyBytes = 1 << 20
+
+// A client must send its request headers within readHeaderTimeout and the whole
+// request, body included, within readTimeout, so slow clients cannot hold
+// connections open. Responses, such as event streams, are not limited.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+)
+
+// newHTTPServer returns a server for handler with the read timeouts set
+func newHTTPServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+	}
+}
+
+// requestTimeout reads LEAGUE_REQUEST_TIMEOUT (e.g. "10s"); zero disables the timeout
+func requestTimeout() time.Duration {
+	if value := os.Getenv("LEAGUE_REQUEST_TIMEOUT"); value != "" {
+		if timeout, err := time.ParseDuration(value); err == nil && timeout >= 0 {
+			return timeout
+		}
+		log.Printf("Invalid LEAGUE_REQUEST_TIMEOUT %q, using default", value)
+	}
+	return defaultRequestTimeout
+}
+
+// maxBodyBytes reads LEAGUE_MAX_BODY_BYTES; zero disables the limit
+func maxBodyBytes() int64 {
+	if value := os.Getenv("LEAGUE_MAX_BODY_BYTES"); value != "" {
+		if limit, err := strconv.ParseInt(value, 10, 64); err == nil && limit >= 0 {
+			return limit
+		}
+		log.Printf("Invalid LEAGUE_MAX_BODY_BYTES %q, using default", value)
+	}
+	return defaultMaxBodyBytes
+}
+
+// bodyLimitMiddleware rejects PUT, POST and PATCH bodies over limit bytes with 413.
+// The body is read up front so handlers never see a truncated request.
+func bodyLimitMiddleware(limit int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit <= 0 || (r.Method != http.MethodPut && r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tooLarge := func() {
+				http.Error(w, fmt.Sprintf("Request body exceeds %d bytes", limit), http.StatusRequestEntityTooLarge)
+			}
+			if r.ContentLength > limit {
+				tooLarge()
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			if int64(len(body)) > limit {
+				tooLarge()
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// timeoutMiddleware answers 408 Request Timeout when a handler runs longer than
+// timeout. The deadline is set on the request context, which the simulator service
+// checks between weeks and every transaction checks before committing, so a handler
+// still running after the 408 stops and keeps none of its work: a timed-out play-all
+// rolls back the week in progress and reloads the league. Server-sent event streams
+// are exempt since they are expected to stay open, and so are the pprof endpoints,
+// whose CPU profiles run for as long as they are asked to.
+func timeoutMiddleware(timeout time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if timeout <= 0 || r.URL.Query().Get("stream") == "true" || isProfilingRequest(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- goroutinePanic{value: p, stack: debug.Stack()}
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.flushTo(w)
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if ctx.Err() == context.DeadlineExceeded {
+					http.Error(w, fmt.Sprintf("Request did not complete within %s", timeout), http.StatusRequestTimeout)
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a handler's response so nothing reaches the client unless
+// the handler finishes in time
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+// flushTo copies the buffered response to the real writer
+func (tw *timeoutWriter) flushTo(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	for key, values := range tw.header {
+		w.Header()[key] = values
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	w.WriteHeader(tw.status)
+	w.Write(tw.body.Bytes())
+}
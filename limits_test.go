@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		delay      time.Duration
+		wantStatus int
+		wantErr    error
+	}{
+		{"in time", 0, http.StatusCreated, nil},
+		{"overrun", time.Hour, http.StatusRequestTimeout, context.DeadlineExceeded},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handled := make(chan error, 1)
+			handler := timeoutMiddleware(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(test.delay):
+				case <-r.Context().Done():
+				}
+				handled <- r.Context().Err()
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/league/next-week", nil))
+			if recorder.Code != test.wantStatus {
+				t.Errorf("status %d, want %d", recorder.Code, test.wantStatus)
+			}
+			if err := <-handled; !errors.Is(err, test.wantErr) {
+				t.Errorf("handler's context ended with %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestTimedOutWriteIsRolledBack(t *testing.T) {
+	storage, err := NewSQLStorageService("sqlite3", filepath.Join(t.TempDir(), "league.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+	if err := storage.InitializeTeamsAndMatches(); err != nil {
+		t.Fatal(err)
+	}
+	league, err := loadLeague(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := league.Teams[0].Points
+
+	persisted := make(chan error, 1)
+	handler := timeoutMiddleware(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The handler only gets to its write after the client has had its 408
+		<-r.Context().Done()
+		service := NewLeagueSimulatorService(league, storage).WithContext(r.Context())
+		league.Teams[0].Points += 3
+		persisted <- service.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+			return teams.UpdateTeam(league.Teams[0])
+		})
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/league/teams/1", nil))
+	if recorder.Code != http.StatusRequestTimeout {
+		t.Fatalf("status %d, want %d", recorder.Code, http.StatusRequestTimeout)
+	}
+	if err := <-persisted; err == nil {
+		t.Fatal("a write made after the timeout was committed")
+	}
+
+	stored, err := loadLeague(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Teams[0].Points != points {
+		t.Errorf("stored team has %d points, want %d", stored.Teams[0].Points, points)
+	}
+	if league.Teams[0].Points != points {
+		t.Errorf("team in memory has %d points, want %d", league.Teams[0].Points, points)
+	}
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		knownLength bool
+		limit       int64
+		wantStatus  int
+	}{
+		{"under the limit", http.MethodPost, "0123456789", true, 16, http.StatusOK},
+		{"at the limit", http.MethodPut, "0123456789abcdef", true, 16, http.StatusOK},
+		{"declared over the limit", http.MethodPost, strings.Repeat("x", 32), true, 16, http.StatusRequestEntityTooLarge},
+		{"chunked over the limit", http.MethodPatch, strings.Repeat("x", 32), false, 16, http.StatusRequestEntityTooLarge},
+		{"reads are not limited", http.MethodGet, strings.Repeat("x", 32), true, 16, http.StatusOK},
+		{"no limit", http.MethodPost, strings.Repeat("x", 32), true, 0, http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var received string
+			handler := bodyLimitMiddleware(test.limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				received = string(body)
+			}))
+
+			request := httptest.NewRequest(test.method, "/league/teams/import", strings.NewReader(test.body))
+			if !test.knownLength {
+				request.ContentLength = -1
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			if recorder.Code != test.wantStatus {
+				t.Errorf("status %d, want %d", recorder.Code, test.wantStatus)
+			}
+			if test.wantStatus == http.StatusOK && received != test.body {
+				t.Errorf("handler read %q, want %q", received, test.body)
+			}
+		})
+	}
+}
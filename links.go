@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// linksEnabled reports whether a response should carry _links. They are on unless
+// LEAGUE_API_LINKS=false, and a request can override that with ?links=true or ?links=false.
+func linksEnabled(r *http.Request) bool {
+	switch r.URL.Query().Get("links") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return os.Getenv("LEAGUE_API_LINKS") != "false"
+}
+
+// linksCacheKey distinguishes cached responses with and without _links
+func linksCacheKey(enabled bool) string {
+	if enabled {
+		return ":links"
+	}
+	return ""
+}
+
+func teamPath(team *sim.Team) string {
+	return "/league/teams/" + team.Slug
+}
+
+func weekPath(week int) string {
+	return fmt.Sprintf("/league/matches?week=%d", week)
+}
+
+// linkMatch sets a response copy's links to itself, both teams and its week
+func linkMatch(match *sim.Match) {
+	match.Links = sim.Links{
+		"self":      {Href: fmt.Sprintf("/league/matches/%d", match.MatchId)},
+		"home_team": {Href: teamPath(match.HomeTeam)},
+		"away_team": {Href: teamPath(match.AwayTeam)},
+		"week":      {Href: weekPath(match.Week)},
+	}
+}
+
+// linkMatches sets the links of response copies of matches
+func linkMatches(matches []*sim.Match) []*sim.Match {
+	for _, match := range matches {
+		linkMatch(match)
+	}
+	return matches
+}
+
+// linkTable sets each entry of a response copy of a table to link to its team
+func linkTable(league *sim.League, table []*sim.LeagueTableEntry) []*sim.LeagueTableEntry {
+	teams := make(map[string]*sim.Team)
+	for _, team := range league.Teams {
+		teams[team.TeamName] = team
+	}
+	for _, entry := range table {
+		if team := teams[entry.TeamName]; team != nil {
+			entry.Links = sim.Links{"team": {Href: teamPath(team)}}
+		}
+	}
+	return table
+}
+
+// linkCalendar links each week to its matches and to the weeks either side
+func linkCalendar(calendar []CalendarWeek) {
+	for i := range calendar {
+		links := sim.Links{"matches": {Href: weekPath(calendar[i].Week)}}
+		if i > 0 {
+			links["previous"] = sim.Link{Href: weekPath(calendar[i-1].Week)}
+		}
+		if i < len(calendar)-1 {
+			links["next"] = sim.Link{Href: weekPath(calendar[i+1].Week)}
+		}
+		calendar[i].Links = links
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultListenAddr is the plain HTTP address used when LEAGUE_LISTEN_ADDR is unset
+const defaultListenAddr = ":8080"
+
+// unixSocketPrefix marks a listen address as a Unix domain socket path
+const unixSocketPrefix = "unix:"
+
+// systemdListenFd is the first file descriptor systemd passes to an activated service
+const systemdListenFd = 3
+
+// listenAddr reads LEAGUE_LISTEN_ADDR: host:port, or unix:/path/to/league.sock
+func listenAddr() string {
+	if addr := os.Getenv("LEAGUE_LISTEN_ADDR"); addr != "" {
+		return addr
+	}
+	return defaultListenAddr
+}
+
+// openListener returns the socket passed by systemd when the service is socket
+// activated, and otherwise listens on addr
+func openListener(addr string) (net.Listener, error) {
+	if listener, err := systemdListener(); listener != nil || err != nil {
+		return listener, err
+	}
+
+	if !strings.HasPrefix(addr, unixSocketPrefix) {
+		return net.Listen("tcp", addr)
+	}
+
+	path := strings.TrimPrefix(addr, unixSocketPrefix)
+	// A socket left behind by an unclean shutdown would make the bind fail
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if value := os.Getenv("LEAGUE_SOCKET_MODE"); value != "" {
+		mode, err := strconv.ParseUint(value, 8, 32)
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("invalid LEAGUE_SOCKET_MODE %q: %w", value, err)
+		}
+		if err := os.Chmod(path, os.FileMode(mode)); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return listener, nil
+}
+
+// systemdListener implements the sd_listen_fds protocol: systemd sets LISTEN_PID to
+// our process and LISTEN_FDS to the number of sockets starting at fd 3. Only the
+// first socket is used. It returns nil when the process was not socket activated.
+func systemdListener() (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, nil
+	}
+	// Child processes must not mistake the sockets for their own
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	file := os.NewFile(uintptr(systemdListenFd), "systemd-socket")
+	defer file.Close()
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("systemd socket activation: %w", err)
+	}
+	return listener, nil
+}
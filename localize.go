@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// languageTagPattern accepts BCP 47 style tags such as "tr", "es" or "pt-BR"
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// asciiFallbacks spells non-ASCII letters common in European team names with ASCII
+var asciiFallbacks = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'ł': "l", 'Ł': "L", 'đ': "d", 'Đ': "D", 'ð': "d", 'Ð': "D", 'þ': "th", 'Þ': "Th",
+	'ı': "i", 'İ': "I",
+}
+
+// asciiLetterGroups maps accented letters to their base letter
+var asciiLetterGroups = map[string]string{
+	"àáâãäåāăą": "a", "ÀÁÂÃÄÅĀĂĄ": "A",
+	"çćĉċč": "c", "ÇĆĈĊČ": "C",
+	"ďḑ": "d", "Ď": "D",
+	"èéêëēĕėęě": "e", "ÈÉÊËĒĔĖĘĚ": "E",
+	"ĝğġģ": "g", "ĜĞĠĢ": "G",
+	"ĥħ": "h", "ĤĦ": "H",
+	"ìíîïĩīĭį": "i", "ÌÍÎÏĨĪĬĮ": "I",
+	"ĵ": "j", "Ĵ": "J",
+	"ķ": "k", "Ķ": "K",
+	"ĺļľŀ": "l", "ĹĻĽĿ": "L",
+	"ñńņňŉ": "n", "ÑŃŅŇ": "N",
+	"òóôõöōŏő": "o", "ÒÓÔÕÖŌŎŐ": "O",
+	"ŕŗř": "r", "ŔŖŘ": "R",
+	"śŝşšș": "s", "ŚŜŞŠȘ": "S",
+	"ţťŧț": "t", "ŢŤŦȚ": "T",
+	"ùúûüũūŭůűų": "u", "ÙÚÛÜŨŪŬŮŰŲ": "U",
+	"ŵ": "w", "Ŵ": "W",
+	"ýÿŷ": "y", "ÝŸŶ": "Y",
+	"źżž": "z", "ŹŻŽ": "Z",
+}
+
+func init() {
+	for letters, base := range asciiLetterGroups {
+		for _, r := range letters {
+			asciiFallbacks[r] = base
+		}
+	}
+}
+
+// asciiName spells a name with ASCII only, e.g. "Beşiktaş" -> "Besiktas". Characters
+// without a known fallback become '?'.
+func asciiName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r < 0x80:
+			b.WriteRune(r)
+		case asciiFallbacks[r] != "":
+			b.WriteString(asciiFallbacks[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// validateLocalizedNames checks a team's names by language tag
+func validateLocalizedNames(names map[string]string) error {
+	for tag, name := range names {
+		if !languageTagPattern.MatchString(tag) {
+			return fmt.Errorf("%q is not a language tag like \"tr\" or \"pt-BR\"", tag)
+		}
+		// Localized names never appear in URLs, so unlike team names they need no slug
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%s: name must not be empty", tag)
+		}
+	}
+	return nil
+}
+
+// normalizeLocalizedNames lowercases language tags and trims names
+func normalizeLocalizedNames(names map[string]string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	normalized := make(map[string]string, len(names))
+	for tag, name := range names {
+		normalized[strings.ToLower(tag)] = strings.TrimSpace(name)
+	}
+	return normalized
+}
+
+// encodeLocalizedNames stores a team's localized names as JSON; empty when there are none
+func encodeLocalizedNames(names map[string]string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(names)
+	return string(data)
+}
+
+// decodeLocalizedNames reverses encodeLocalizedNames
+func decodeLocalizedNames(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var names map[string]string
+	if err := json.Unmarshal([]byte(value), &names); err != nil {
+		return nil, fmt.Errorf("failed to decode localized names: %v", err)
+	}
+	return names, nil
+}
+
+// acceptedLanguages lists the request's Accept-Language tags, lowercased, most
+// preferred first; the wildcard and tags with q=0 are left out
+func acceptedLanguages(r *http.Request) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	accepted := []weighted{}
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			accepted = append(accepted, weighted{tag, quality})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].quality > accepted[j].quality })
+
+	tags := make([]string, len(accepted))
+	for i, language := range accepted {
+		tags[i] = language.tag
+	}
+	return tags
+}
+
+// localizedName picks a team's name for the preferred languages: an exact tag match,
+// then the language without its region ("es-mx" -> "es"), else the team's own name
+func localizedName(team *sim.Team, languages []string) string {
+	for _, language := range languages {
+		if name := team.LocalizedNames[language]; name != "" {
+			return name
+		}
+		if base, _, found := strings.Cut(language, "-"); found {
+			if name := team.LocalizedNames[base]; name != "" {
+				return name
+			}
+		}
+	}
+	return team.TeamName
+}
+
+// localizeTable copies a table with each entry's DisplayName set for the preferred languages
+func localizeTable(league *sim.League, table []*sim.LeagueTableEntry, languages []string) []*sim.LeagueTableEntry {
+	teams := make(map[string]*sim.Team)
+	for _, team := range league.Teams {
+		teams[team.TeamName] = team
+	}
+	localized := make([]*sim.LeagueTableEntry, len(table))
+	for i, entry := range table {
+		copied := *entry
+		copied.DisplayName = entry.TeamName
+		if team := teams[entry.TeamName]; team != nil {
+			copied.DisplayName = localizedName(team, languages)
+		}
+		localized[i] = &copied
+	}
+	return localized
+}
+
+// localizeTeam copies a team with its DisplayName set for the preferred languages
+func localizeTeam(team *sim.Team, languages []string) *sim.Team {
+	copied := *team
+	copied.DisplayName = localizedName(team, languages)
+	return &copied
+}
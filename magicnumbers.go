@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// Magic number statuses
+const (
+	MagicClinched   = "clinched"   // no rival can catch the team any more
+	MagicInControl  = "in_control" // PointsNeeded more points clinch it, whatever the rivals do
+	MagicNeedsHelp  = "needs_help" // even winning out is not enough unless rivals drop points
+	MagicEliminated = "eliminated" // too many rivals are already out of reach
+)
+
+// MagicNumber is what a team needs to be sure of finishing in Position or above
+type MagicNumber struct {
+	Target       string // title, top_4 or safety
+	Position     int
+	Status       string
+	PointsNeeded int // more points that guarantee the target; 0 once clinched
+}
+
+// MagicNumbers are a team's magic numbers with the league as it stands
+type MagicNumbers struct {
+	TeamId           int
+	TeamName         string
+	Week             int
+	Points           int
+	RemainingMatches int
+	MaxPoints        int // points the team can still finish on
+	Targets          []*MagicNumber
+}
+
+// safetyPosition is the lowest position that avoids relegation: above the first
+// relegation zone of the league, or above last place without one
+func safetyPosition(league *sim.League, teams int) int {
+	position := teams - 1
+	for _, zone := range sim.LeagueZones(league).Zones {
+		if zone.Kind == sim.ZoneRelegation && zone.From-1 < position {
+			position = zone.From - 1
+		}
+	}
+	return position
+}
+
+// magicNumberTargets are the positions a team is measured against: the title, the
+// top four (or the whole table when smaller) and safety when there is a drop zone
+func magicNumberTargets(league *sim.League, teams int) []*MagicNumber {
+	targets := []*MagicNumber{
+		{Target: "title", Position: 1},
+		{Target: "top_4", Position: min(4, teams)},
+	}
+	if safe := safetyPosition(league, teams); safe >= 1 {
+		targets = append(targets, &MagicNumber{Target: "safety", Position: safe})
+	}
+	return targets
+}
+
+// computeMagicNumbers works out a team's magic numbers from a worst-case view of its
+// rivals: each rival is assumed to win every match it has left, even against other
+// rivals, and a rival finishing level on points is counted as finishing above. The
+// numbers are therefore guarantees that never depend on tiebreakers, and can be a
+// little higher than strictly needed while rivals still have to play each other.
+func computeMagicNumbers(league *sim.League, team *sim.Team) (*MagicNumbers, error) {
+	remaining := make(map[string]int)
+	for _, match := range league.Matches {
+		if !match.Played {
+			remaining[match.HomeTeam.TeamName]++
+			remaining[match.AwayTeam.TeamName]++
+		}
+	}
+
+	win := sim.LeagueSport(league).Points.Win
+	var own *sim.LeagueTableEntry
+	rivals := []*sim.LeagueTableEntry{}
+	for _, entry := range league.LeagueTable {
+		if entry.TeamName == team.TeamName {
+			own = entry
+		} else {
+			rivals = append(rivals, entry)
+		}
+	}
+	if own == nil {
+		return nil, fmt.Errorf("%s is not in the league table", team.TeamName)
+	}
+
+	numbers := &MagicNumbers{
+		TeamId:           team.TeamId,
+		TeamName:         team.TeamName,
+		Week:             league.CurrentWeek,
+		Points:           own.Points,
+		RemainingMatches: remaining[team.TeamName],
+		MaxPoints:        own.Points + win*remaining[team.TeamName],
+		Targets:          magicNumberTargets(league, len(league.LeagueTable)),
+	}
+	// threats can still reach the team's points; rivals out of reach already have more
+	// than the team can finish on
+	threats, outOfReach := 0, 0
+	ceilings := []int{}
+	for _, rival := range rivals {
+		ceiling := rival.Points + win*remaining[rival.TeamName]
+		ceilings = append(ceilings, ceiling)
+		if ceiling >= own.Points {
+			threats++
+		}
+		if rival.Points > numbers.MaxPoints {
+			outOfReach++
+		}
+	}
+
+	// Finishing in Position or above takes more points than all but Position-1 rivals can reach
+	for _, target := range numbers.Targets {
+		switch {
+		case outOfReach >= target.Position:
+			target.Status = MagicEliminated
+			continue
+		case threats < target.Position:
+			target.Status = MagicClinched
+			continue
+		}
+		target.PointsNeeded = kthLargest(ceilings, target.Position) + 1 - own.Points
+		target.Status = MagicInControl
+		if target.PointsNeeded > numbers.MaxPoints-own.Points {
+			target.Status = MagicNeedsHelp
+		}
+	}
+	return numbers, nil
+}
+
+// kthLargest returns the k-th largest of values, counting from 1
+func kthLargest(values []int, k int) int {
+	sorted := append([]int(nil), values...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	return sorted[k-1]
+}
+
+// inRunIn reports whether week is in the run-in, the last third of the season's weeks
+func inRunIn(week, totalWeeks int) bool {
+	return week > 0 && week >= totalWeeks-totalWeeks/3
+}
+
+// renderMagicNumbers draws every team's magic numbers as a table for the console, in
+// table order
+func renderMagicNumbers(league *sim.League) string {
+	var b strings.Builder
+	targets := magicNumberTargets(league, len(league.LeagueTable))
+	fmt.Fprintf(&b, "%-20s", "Team")
+	for _, target := range targets {
+		fmt.Fprintf(&b, " %10s", target.Target)
+	}
+	b.WriteString("\n")
+	teams := make(map[string]*sim.Team)
+	for _, team := range league.Teams {
+		teams[team.TeamName] = team
+	}
+	for _, entry := range league.LeagueTable {
+		numbers, err := computeMagicNumbers(league, teams[entry.TeamName])
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "%-20s", asciiName(entry.TeamName))
+		for _, target := range numbers.Targets {
+			fmt.Fprintf(&b, " %10s", describeMagicNumber(target))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("* more points than the team has left: it needs rivals to drop points\n")
+	return b.String()
+}
+
+// describeMagicNumber is a target's status in a few words for the console
+func describeMagicNumber(target *MagicNumber) string {
+	switch target.Status {
+	case MagicClinched:
+		return "clinched"
+	case MagicEliminated:
+		return "out"
+	case MagicNeedsHelp:
+		return fmt.Sprintf("%d pts*", target.PointsNeeded)
+	}
+	return fmt.Sprintf("%d pts", target.PointsNeeded)
+}
@@ -1,286 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"math"
-	"math/rand"
 	"os"
-	"sort"
-)
-
-type Team struct{
-	TeamName string
-	TeamId int
-	TeamStrength int
-	GoalsFor int
-	GoalsAgainst int
-	Wins int
-	Draws int
-	Losses int
-	Points int
-	GoalsDifference int
-}
-
-type Match struct{
-	MatchId int
-	Week int
-	HomeTeam *Team
-	AwayTeam *Team
-	HomeTeamScore int
-	AwayTeamScore int
-	Played bool
-}
-
-type LeagueTableEntry struct{
-	TeamName string
-	Played int
-	Wins int
-	Draws int
-	Losses int
-	GoalsFor int
-	GoalsAgainst int
-	GoalsDifference int
-	Points int
-	Position int
-}
-
-type League struct {
-	Teams []*Team
-	Matches []*Match
-	CurrentWeek int
-	LeagueTable []*LeagueTableEntry
-}
-
-// create 4 random Premier League teams
-func createPremierLeagueTeams() []*Team {
-	teams := []*Team{
-		{TeamName: "Manchester United", TeamId: 1, TeamStrength: 80},
-		{TeamName: "Liverpool", TeamId: 2, TeamStrength: 85},
-		{TeamName: "Manchester City", TeamId: 3, TeamStrength: 90},
-		{TeamName: "Chelsea", TeamId: 4, TeamStrength: 88},
-	}
-	return teams
-}
-
-// create all matches for the league (home and away for each team pair)
-func createPremierLeagueMatches(teams []*Team) []*Match {
-	matches := []*Match{}
-	matchId := 1
-	week := 1
-
-	// Define fixtures manually to ensure each team plays once per week
-	// Week 1: Team 0 vs Team 1, Team 2 vs Team 3
-	// Week 2: Team 0 vs Team 2, Team 1 vs Team 3
-	// Week 3: Team 0 vs Team 3, Team 1 vs Team 2
-	// Then repeat with reversed home/away for second leg
-	
-	weekFixtures := [][][2]int{
-		{{0, 1}, {2, 3}}, // Week 1
-		{{0, 2}, {1, 3}}, // Week 2
-		{{0, 3}, {1, 2}}, // Week 3
-	}
-	
-	// First leg
-	for _, fixtures := range weekFixtures {
-		for _, fixture := range fixtures {
-			match := &Match{
-				MatchId:       matchId,
-				Week:          week,
-				HomeTeam:      teams[fixture[0]],
-				AwayTeam:      teams[fixture[1]],
-				HomeTeamScore: 0,
-				AwayTeamScore: 0,
-				Played:        false,
-			}
-			matches = append(matches, match)
-			matchId++
-		}
-		week++
-	}
-	
-	// Second leg (reversed home/away)
-	for _, fixtures := range weekFixtures {
-		for _, fixture := range fixtures {
-			match := &Match{
-				MatchId:       matchId,
-				Week:          week,
-				HomeTeam:      teams[fixture[1]], // Reversed
-				AwayTeam:      teams[fixture[0]], // Reversed
-				HomeTeamScore: 0,
-				AwayTeamScore: 0,
-				Played:        false,
-			}
-			matches = append(matches, match)
-			matchId++
-		}
-		week++
-	}
-
-	return matches
-}
-
-// simulate a single match based on team strength
-func simulateMatch(match *Match) {
-	if match.Played {
-		return
-	}
-
-	homeTeam := match.HomeTeam
-	awayTeam := match.AwayTeam
-
-	// Calculate team strength difference and home advantage
-	homeStrength := float64(homeTeam.TeamStrength) + 5.0 // +5 home advantage
-	awayStrength := float64(awayTeam.TeamStrength)
-	
-	// Calculate attack potential based on strength (0.5 to 4.5 goals expected)
-	homeAttack := (homeStrength / 100.0) * 4.0 + 0.5
-	awayAttack := (awayStrength / 100.0) * 4.0 + 0.5
-	
-	// Add some randomness but weighted by strength
-	homeRandomFactor := rand.Float64() * 2.0 - 1.0 // -1 to +1
-	awayRandomFactor := rand.Float64() * 2.0 - 1.0 // -1 to +1
-	
-	homeExpected := homeAttack + homeRandomFactor
-	awayExpected := awayAttack + awayRandomFactor
-	
-	// Ensure minimum 0 goals
-	if homeExpected < 0 {
-		homeExpected = 0
-	}
-	if awayExpected < 0 {
-		awayExpected = 0
-	}
-	
-	// Convert to actual goals (Poisson-like distribution simulation)
-	homeTeamScore := int(homeExpected + 0.5) // Round to nearest int
-	awayTeamScore := int(awayExpected + 0.5)
-	
-	// Cap maximum goals at 6
-	if homeTeamScore > 6 {
-		homeTeamScore = 6
-	}
-	if awayTeamScore > 6 {
-		awayTeamScore = 6
-	}
-
-	match.HomeTeamScore = homeTeamScore
-	match.AwayTeamScore = awayTeamScore
-
-	// Update team stats
-	homeTeam.GoalsFor += homeTeamScore
-	awayTeam.GoalsFor += awayTeamScore
-	homeTeam.GoalsAgainst += awayTeamScore
-	awayTeam.GoalsAgainst += homeTeamScore
-
-	// Update points and match results
-	if homeTeamScore > awayTeamScore {
-		homeTeam.Wins++
-		awayTeam.Losses++
-		homeTeam.Points += 3
-	} else if homeTeamScore < awayTeamScore {
-		awayTeam.Wins++
-		homeTeam.Losses++
-		awayTeam.Points += 3
-	} else {
-		homeTeam.Draws++
-		awayTeam.Draws++
-		homeTeam.Points += 1
-		awayTeam.Points += 1
-	}
-
-	homeTeam.GoalsDifference = homeTeam.GoalsFor - homeTeam.GoalsAgainst
-	awayTeam.GoalsDifference = awayTeam.GoalsFor - awayTeam.GoalsAgainst
-
-	match.Played = true
-}
-
-// update the league table after each match
-func updateLeagueTable(league *League){
-	// at each week, the league table is deleted and recreated
-	league.LeagueTable = []*LeagueTableEntry{}
-	
-	// Collect stats from matches instead of team objects
-	teamStats := make(map[string]*LeagueTableEntry)
-	
-	// Initialize with team names
-	teamNames := []string{"Manchester United", "Liverpool", "Manchester City", "Chelsea"}
-	for _, name := range teamNames {
-		teamStats[name] = &LeagueTableEntry{
-			TeamName: name,
-			Played: 0,
-			Wins: 0,
-			Draws: 0,
-			Losses: 0,
-			GoalsFor: 0,
-			GoalsAgainst: 0,
-			GoalsDifference: 0,
-			Points: 0,
-		}
-	}
-	
-	// Calculate stats from played matches
-	for _, match := range league.Matches {
-		if match.Played {
-			homeEntry := teamStats[match.HomeTeam.TeamName]
-			awayEntry := teamStats[match.AwayTeam.TeamName]
-			
-			homeEntry.Played++
-			awayEntry.Played++
-			homeEntry.GoalsFor += match.HomeTeamScore
-			homeEntry.GoalsAgainst += match.AwayTeamScore
-			awayEntry.GoalsFor += match.AwayTeamScore
-			awayEntry.GoalsAgainst += match.HomeTeamScore
-			
-			if match.HomeTeamScore > match.AwayTeamScore {
-				homeEntry.Wins++
-				homeEntry.Points += 3
-				awayEntry.Losses++
-			} else if match.HomeTeamScore < match.AwayTeamScore {
-				awayEntry.Wins++
-				awayEntry.Points += 3
-				homeEntry.Losses++
-			} else {
-				homeEntry.Draws++
-				awayEntry.Draws++
-				homeEntry.Points += 1
-				awayEntry.Points += 1
-			}
-			
-			homeEntry.GoalsDifference = homeEntry.GoalsFor - homeEntry.GoalsAgainst
-			awayEntry.GoalsDifference = awayEntry.GoalsFor - awayEntry.GoalsAgainst
-		}
-	}
-	
-	// Convert map to slice
-	for _, entry := range teamStats {
-		league.LeagueTable = append(league.LeagueTable, entry)
-	}
-	
-	// Sort by points (descending), then by goal difference (descending)
-	sort.Slice(league.LeagueTable, func(i, j int) bool {
-		if league.LeagueTable[i].Points == league.LeagueTable[j].Points {
-			return league.LeagueTable[i].GoalsDifference > league.LeagueTable[j].GoalsDifference
-		}
-		return league.LeagueTable[i].Points > league.LeagueTable[j].Points
-	})
-	
-	// Assign positions
-	for i, entry := range league.LeagueTable {
-		entry.Position = i + 1
-	}
-}
 
-func weeklySimulator(league *League){
-	league.CurrentWeek++
-	for _, match := range league.Matches {
-		if match.Week == league.CurrentWeek && !match.Played {
-			simulateMatch(match)
-		}
-	}
-	updateLeagueTable(league)
-}
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
 
-func playSeason(league *League){
+func playSeason(league *sim.League){
 	// Calculate total weeks from matches
 	totalWeeks := 0
 	for _, match := range league.Matches { // find the last week of the season
@@ -296,7 +24,15 @@ func playSeason(league *League){
 	fmt.Printf("╚══════════════════════════════════════════════════════════════╝\n\n")
 	
 	for week := 1; week <= totalWeeks; week++ {
-		weeklySimulator(league)
+		sim.WeeklySimulator(league)
+		
+		// Blank weeks have no fixtures; just mark them on the way past
+		if !sim.WeekHasMatches(league, week) {
+			fmt.Printf("┌─────────────────────────────────────────────────────────────┐\n")
+			fmt.Printf("│ WEEK %-2d  %-50s │\n", week, sim.BreakLabel(week, sim.Config().Breaks))
+			fmt.Printf("└─────────────────────────────────────────────────────────────┘\n\n")
+			continue
+		}
 		
 		fmt.Printf("┌─────────────────────────────────────────────────────────────┐\n")
 		fmt.Printf("│                       WEEK %-2d RESULTS                       │\n", week)
@@ -304,8 +40,8 @@ func playSeason(league *League){
 		for _, match := range league.Matches {
 			if match.Week == week && match.Played {
 				fmt.Printf("│ %-20s %d - %-d %-20s             │\n", 
-					match.HomeTeam.TeamName, match.HomeTeamScore,
-					match.AwayTeamScore, match.AwayTeam.TeamName)
+					asciiName(match.HomeTeam.TeamName), match.HomeTeamScore,
+					match.AwayTeamScore, asciiName(match.AwayTeam.TeamName))
 			}
 		}
 		fmt.Printf("└─────────────────────────────────────────────────────────────┘\n\n")
@@ -317,14 +53,14 @@ func playSeason(league *League){
 		fmt.Printf("├─────────────────────────────────────────────────────────────┤\n")
 		for _, entry := range league.LeagueTable {
 			fmt.Printf("│ %-20s %3d %3d %3d %3d %3d %4d               │\n",
-				entry.TeamName, entry.Points, entry.Played,
+				asciiName(entry.TeamName), entry.Points, entry.Played,
 				entry.Wins, entry.Draws, entry.Losses, entry.GoalsDifference)
 		}
 		fmt.Printf("└─────────────────────────────────────────────────────────────┘\n")
 		
 		// Show championship predictions from week 4 onwards
 		if week >= 4 {
-			predictions := predictChampionship(league)
+			predictions := sim.PredictChampionship(league)
 			fmt.Printf("\n┌─────────────────────────────────────────────────────────────┐\n")
 			fmt.Printf("│            CHAMPIONSHIP PREDICTIONS AFTER WEEK %-2d           │\n", week)
 			fmt.Printf("├─────────────────────────────────────────────────────────────┤\n")
@@ -348,90 +84,36 @@ func playSeason(league *League){
 				}
 			}
 			
+			// Remaining strength of schedule explains odds that differ from the table
+			remainingDifficulty := make(map[string]float64)
+			for _, difficulty := range computeScheduleDifficulty(league) {
+				remainingDifficulty[difficulty.TeamName] = difficulty.AverageOpponentStrength
+			}
+			
 			for _, pred := range sortedPredictions {
-				fmt.Printf("│ %-20s                               %5.1f%%   │\n", pred.name, pred.percentage)
+				fmt.Printf("│ %-20s       Remaining SoS: %5.1f    %5.1f%%   │\n",
+					asciiName(pred.name), remainingDifficulty[pred.name], pred.percentage)
 			}
 			fmt.Printf("└─────────────────────────────────────────────────────────────┘\n")
-		}
-		
-		fmt.Println()
-	}
-}
-
-// predict the championship percentages for each team
-func predictChampionship(league *League) map[string]float64 {
-	predictions := make(map[string]float64) // map of team name to prediction percentage
-	
-	// Calculate remaining matches for each team
-	remainingMatches := make(map[string]int)
-	for _, team := range league.Teams {
-		remainingMatches[team.TeamName] = 0
-	}
-	
-	for _, match := range league.Matches {
-		if !match.Played {
-			remainingMatches[match.HomeTeam.TeamName]++
-			remainingMatches[match.AwayTeam.TeamName]++
-		}
-	}
-	
-	// Calculate maximum possible points for each team
-	maxPossiblePoints := make(map[string]int)
-	for _, entry := range league.LeagueTable {
-		maxPossiblePoints[entry.TeamName] = entry.Points + (remainingMatches[entry.TeamName] * 3)
-	}
-	
-	// Simple prediction algorithm based on:
-	// 1. Current points (40%)
-	// 2. Team strength (30%) 
-	// 3. Goal difference (20%)
-	// 4. Recent form/momentum (10%)
-	
-	totalWeight := 0.0
-	teamWeights := make(map[string]float64)
-	
-	for _, entry := range league.LeagueTable {
-		// Find team strength
-		var teamStrength float64 = 75 // default
-		for _, team := range league.Teams {
-			if team.TeamName == entry.TeamName {
-				teamStrength = float64(team.TeamStrength)
-				break
+			
+			// The heat table shows how open every place is, not just the title
+			if matrix, err := buildPositionMatrix(context.Background(), league, ModelPoisson, defaultPredictionIterations); err == nil {
+				fmt.Printf("\n  FINAL POSITION PROBABILITIES AFTER WEEK %d\n", week)
+				fmt.Print(renderPositionHeatTable(matrix))
 			}
 		}
 		
-		// Calculate weighted score
-		pointsWeight := float64(entry.Points) * 0.4
-		strengthWeight := (teamStrength / 100.0) * 30.0
-		gdWeight := math.Max(float64(entry.GoalsDifference) * 0.2, 0)
-		formWeight := float64(entry.Wins) * 1.0 // recent form approximation
-		
-		weight := pointsWeight + strengthWeight + gdWeight + formWeight
-		
-		// Bonus for being in top position
-		if entry.Position == 1 {
-			weight *= 1.2
-		} else if entry.Position == 2 {
-			weight *= 1.1
+		// In the run-in, show what each team needs to make sure of its place
+		if inRunIn(week, totalWeeks) {
+			fmt.Printf("\n  MAGIC NUMBERS AFTER WEEK %d\n", week)
+			fmt.Print(renderMagicNumbers(league))
 		}
 		
-		teamWeights[entry.TeamName] = weight
-		totalWeight += weight
-	}
-	
-	// Convert to percentages
-	for teamName, weight := range teamWeights {
-		if totalWeight > 0 {
-			predictions[teamName] = (weight / totalWeight) * 100
-		} else {
-			predictions[teamName] = 25.0 // equal chance if no data
-		}
+		fmt.Println()
 	}
-	
-	return predictions
 }
 
-func declareChampions(league *League){
+func declareChampions(league *sim.League){
 	fmt.Printf("\n╔══════════════════════════════════════════════════════════════╗\n")
 	fmt.Printf("║                        FINAL RESULTS                         ║\n")
 	fmt.Printf("╠══════════════════════════════════════════════════════════════╣\n")
@@ -450,7 +132,7 @@ func declareChampions(league *League){
 		}
 		
 		fmt.Printf("║ %s %-2d. %-20s %3d pts (%dW-%dD-%dL, %+d GD) ║\n", 
-			trophy, entry.Position, entry.TeamName, entry.Points,
+			trophy, entry.Position, asciiName(entry.TeamName), entry.Points,
 			entry.Wins, entry.Draws, entry.Losses, entry.GoalsDifference)
 	}
 	
@@ -461,7 +143,7 @@ func declareChampions(league *League){
 			fmt.Printf("║                                                              ║\n")
 			fmt.Printf("║                    🎉 CONGRATULATIONS! 🎉                    ║\n")
 			fmt.Printf("║                                                              ║\n")
-			fmt.Printf("║              %-20s IS THE CHAMPION!           ║\n", entry.TeamName)
+			fmt.Printf("║              %-20s IS THE CHAMPION!           ║\n", asciiName(entry.TeamName))
 			fmt.Printf("║                                                              ║\n")
 			break
 		}
@@ -471,18 +153,85 @@ func declareChampions(league *League){
 }
 
 func main(){
+	config, err := sim.LoadSimulationConfig(sim.SimulationConfigPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid simulation config: %v\n", err)
+		os.Exit(1)
+	}
+	sim.SetConfig(config)
+	
+	rules, err := sim.LoadCompetitionRules(sim.CompetitionRulesPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid competition rules: %v\n", err)
+		os.Exit(1)
+	}
+	sim.SetRules(rules)
+	
+	if err := sim.LoadRulesHooks(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid rules plugin: %v\n", err)
+		os.Exit(1)
+	}
+	
 	// Check if HTTP server mode is requested
 	if len(os.Args) > 1 && os.Args[1] == "server" {
 		startHTTPServer()
 		return
 	}
 	
-	teams := createPremierLeagueTeams()
-	league := &League{
+	// Report how the active config's results compare with real leagues
+	if len(os.Args) > 1 && os.Args[1] == "calibrate" {
+		runCalibration(os.Args[2:])
+		return
+	}
+	
+	// Render the stored season as a static site
+	if len(os.Args) > 1 && os.Args[1] == "export-site" {
+		runSiteExport(os.Args[2:])
+		return
+	}
+	
+	// Time the Monte Carlo predictions and count their allocations
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		runBenchmarks(os.Args[2:])
+		return
+	}
+	
+	// Compare seeded seasons with the committed golden outputs
+	if len(os.Args) > 1 && os.Args[1] == "golden" {
+		runGolden(os.Args[2:])
+		return
+	}
+	
+	// Dump every match of seeded simulated seasons for offline analysis
+	if len(os.Args) > 1 && os.Args[1] == "export-matches" {
+		runMatchExport(os.Args[2:])
+		return
+	}
+	
+	// Simulate many seasons in parallel and summarize the outcomes
+	if len(os.Args) > 1 && os.Args[1] == "batch" {
+		runBatch(os.Args[2:])
+		return
+	}
+	
+	// Fit team strengths to historical results
+	if len(os.Args) > 1 && os.Args[1] == "seed-ratings" {
+		runSeedRatings(os.Args[2:])
+		return
+	}
+	
+	// Run a league per chat from Telegram commands
+	if len(os.Args) > 1 && os.Args[1] == "telegram" {
+		runTelegramBot()
+		return
+	}
+	
+	teams := sim.CreatePremierLeagueTeams()
+	league := &sim.League{
 		Teams: teams,
-		Matches: createPremierLeagueMatches(teams),
+		Matches: sim.CreatePremierLeagueMatches(teams),
 		CurrentWeek: 0,
-		LeagueTable: []*LeagueTableEntry{},
+		LeagueTable: []*sim.LeagueTableEntry{},
 	}
 	
 	// Play week by week and show results
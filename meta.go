@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// leagueMetaWriter adds the league metadata headers just before the status is written,
+// so a response reports the state left by the request rather than the one it found
+type leagueMetaWriter struct {
+	http.ResponseWriter
+	scope       *leagueScope // the league the request is served from
+	wroteHeader bool
+}
+
+func (mw *leagueMetaWriter) WriteHeader(status int) {
+	if !mw.wroteHeader {
+		mw.wroteHeader = true
+		setLeagueMetaHeaders(mw.Header(), mw.scope.meta.Load())
+	}
+	mw.ResponseWriter.WriteHeader(status)
+}
+
+func (mw *leagueMetaWriter) Write(p []byte) (int, error) {
+	if !mw.wroteHeader {
+		mw.WriteHeader(http.StatusOK)
+	}
+	return mw.ResponseWriter.Write(p)
+}
+
+// Flush keeps server-sent event streams working through the wrapper
+func (mw *leagueMetaWriter) Flush() {
+	if !mw.wroteHeader {
+		mw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := mw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// leagueMeta is what the metadata headers report of a league
+type leagueMeta struct {
+	Revision   int
+	Season     int
+	Week       int
+	ModifiedAt *time.Time
+}
+
+// setLeagueMetaHeaders reports the league's revision, season, current week and last
+// modification time, so clients can tell whether what they hold is stale
+func setLeagueMetaHeaders(header http.Header, meta *leagueMeta) {
+	if meta == nil {
+		return
+	}
+	header.Set("X-League-Revision", strconv.Itoa(meta.Revision))
+	header.Set("X-League-Season", strconv.Itoa(meta.Season))
+	header.Set("X-League-Week", strconv.Itoa(meta.Week))
+	if meta.ModifiedAt != nil && header.Get("Last-Modified") == "" {
+		header.Set("Last-Modified", meta.ModifiedAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// leagueMetaMiddleware adds the league metadata headers to every response
+func leagueMetaMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// In tenancy mode tenantMiddleware adds them, once it knows the tenant
+		if tenantRegistry != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&leagueMetaWriter{ResponseWriter: w, scope: serverScope}, r)
+	})
+}
+
+// revisionETag is the entity tag of a cached response: the league revision plus a hash
+// of the cache key, so language and time zone variants of a URL get distinct tags
+func revisionETag(key string, revision int) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return fmt.Sprintf(`"r%d-%08x"`, revision, hash.Sum32())
+}
+
+// notModified answers 304 when the client's If-None-Match already names the current
+// representation, and otherwise sets the ETag for the response about to be written
+func notModified(w http.ResponseWriter, r *http.Request, key string, revision int) bool {
+	etag := revisionETag(key, revision)
+	w.Header().Set("ETag", etag)
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		if candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/"); candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
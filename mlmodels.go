@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// MatchFeatures is what an ML model sees of a fixture: the simulator's view of both
+// sides going into the match
+type MatchFeatures struct {
+	MatchId            int     `json:"match_id"`
+	Week               int     `json:"week"`
+	HomeTeam           string  `json:"home_team"`
+	AwayTeam           string  `json:"away_team"`
+	Venue              string  `json:"venue"`
+	HomeStrength       float64 `json:"home_strength"`
+	AwayStrength       float64 `json:"away_strength"`
+	HomeXG             float64 `json:"home_xg"`
+	AwayXG             float64 `json:"away_xg"`
+	HomeElo            float64 `json:"home_elo"`
+	AwayElo            float64 `json:"away_elo"`
+	HomePoints         int     `json:"home_points"`
+	AwayPoints         int     `json:"away_points"`
+	HomeGoalDifference int     `json:"home_goal_difference"`
+	AwayGoalDifference int     `json:"away_goal_difference"`
+}
+
+// numeric returns the numeric features by JSON name, the names a model file refers to
+func (f MatchFeatures) numeric() map[string]float64 {
+	return map[string]float64{
+		"home_strength":        f.HomeStrength,
+		"away_strength":        f.AwayStrength,
+		"home_xg":              f.HomeXG,
+		"away_xg":              f.AwayXG,
+		"home_elo":             f.HomeElo,
+		"away_elo":             f.AwayElo,
+		"home_points":          float64(f.HomePoints),
+		"away_points":          float64(f.AwayPoints),
+		"home_goal_difference": float64(f.HomeGoalDifference),
+		"away_goal_difference": float64(f.AwayGoalDifference),
+	}
+}
+
+// buildMatchFeatures describes each match with the league as it stands
+func buildMatchFeatures(league *sim.League, matches []*sim.Match) []MatchFeatures {
+	ratings := sim.ComputeEloRatings(league, league.CurrentWeek)
+	config := sim.LeagueConfig(league)
+	standings := make(map[string]*sim.LeagueTableEntry)
+	for _, entry := range league.LeagueTable {
+		standings[entry.TeamName] = entry
+	}
+
+	features := make([]MatchFeatures, len(matches))
+	for i, match := range matches {
+		homeStrength, awayStrength := sim.MatchStrengths(match, config)
+		features[i] = MatchFeatures{
+			MatchId:      match.MatchId,
+			Week:         match.Week,
+			HomeTeam:     match.HomeTeam.TeamName,
+			AwayTeam:     match.AwayTeam.TeamName,
+			Venue:        match.Venue,
+			HomeStrength: homeStrength,
+			AwayStrength: awayStrength,
+			HomeXG:       sim.ExpectedGoals(homeStrength),
+			AwayXG:       sim.ExpectedGoals(awayStrength),
+			HomeElo:      ratings[match.HomeTeam.TeamName],
+			AwayElo:      ratings[match.AwayTeam.TeamName],
+		}
+		if entry := standings[match.HomeTeam.TeamName]; entry != nil {
+			features[i].HomePoints, features[i].HomeGoalDifference = entry.Points, entry.GoalsDifference
+		}
+		if entry := standings[match.AwayTeam.TeamName]; entry != nil {
+			features[i].AwayPoints, features[i].AwayGoalDifference = entry.Points, entry.GoalsDifference
+		}
+	}
+	return features
+}
+
+// normalizeForecast checks a model's forecast and scales it to add up to 1
+func normalizeForecast(forecast OutcomeProbabilities) (OutcomeProbabilities, error) {
+	total := forecast.HomeWin + forecast.Draw + forecast.AwayWin
+	if forecast.HomeWin < 0 || forecast.Draw < 0 || forecast.AwayWin < 0 || !(total > 0) || math.IsInf(total, 0) {
+		return forecast, fmt.Errorf("invalid probabilities %g/%g/%g", forecast.HomeWin, forecast.Draw, forecast.AwayWin)
+	}
+	return OutcomeProbabilities{HomeWin: forecast.HomeWin / total, Draw: forecast.Draw / total, AwayWin: forecast.AwayWin / total}, nil
+}
+
+// httpMatchPredictor asks an external model service for match forecasts. It POSTs
+// {"season", "week", "matches": [MatchFeatures...]} and expects
+// {"predictions": [{"match_id", "home_win", "draw", "away_win"}...]} back.
+type httpMatchPredictor struct {
+	url    string
+	client *http.Client
+}
+
+func (p *httpMatchPredictor) PredictMatches(ctx context.Context, league *sim.League, matches []*sim.Match) ([]OutcomeProbabilities, error) {
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"season":  league.Season,
+		"week":    league.CurrentWeek,
+		"matches": buildMatchFeatures(league, matches),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("model service answered %s", resp.Status)
+	}
+
+	var response struct {
+		Predictions []struct {
+			MatchId int     `json:"match_id"`
+			HomeWin float64 `json:"home_win"`
+			Draw    float64 `json:"draw"`
+			AwayWin float64 `json:"away_win"`
+		} `json:"predictions"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid model service response: %v", err)
+	}
+	byMatch := make(map[int]OutcomeProbabilities)
+	for _, prediction := range response.Predictions {
+		byMatch[prediction.MatchId] = OutcomeProbabilities{HomeWin: prediction.HomeWin, Draw: prediction.Draw, AwayWin: prediction.AwayWin}
+	}
+
+	forecasts := make([]OutcomeProbabilities, len(matches))
+	for i, match := range matches {
+		forecast, ok := byMatch[match.MatchId]
+		if !ok {
+			return nil, fmt.Errorf("model service returned no prediction for match %d", match.MatchId)
+		}
+		if forecasts[i], err = normalizeForecast(forecast); err != nil {
+			return nil, fmt.Errorf("match %d: %v", match.MatchId, err)
+		}
+	}
+	return forecasts, nil
+}
+
+// matchPredictorLoaders load a model file per extension. Register another format
+// here, e.g. an ONNX runtime loader under ".onnx", to serve it as the ml model.
+var matchPredictorLoaders = map[string]func(path string) (MatchPredictor, error){
+	".json": loadLinearOutcomeModel,
+}
+
+// linearOutcomeModel is an ordered logit over the match features, the shape of a
+// model exported from e.g. scikit-learn or statsmodels: the score is the intercept
+// plus each weight times its feature, and a result needs the score to clear the
+// draw margin, so P(home) = σ(score - margin), P(away) = σ(-score - margin) and
+// the draw takes the rest.
+type linearOutcomeModel struct {
+	Intercept  float64            `json:"intercept"`
+	Weights    map[string]float64 `json:"weights"`
+	DrawMargin float64            `json:"draw_margin"`
+
+	features []string // weighted features in a fixed order, so scores add up the same every time
+}
+
+// loadLinearOutcomeModel reads a linearOutcomeModel from JSON, rejecting weights for
+// features a match does not have
+func loadLinearOutcomeModel(path string) (MatchPredictor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	model := &linearOutcomeModel{}
+	if err := json.Unmarshal(data, model); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	known := MatchFeatures{}.numeric()
+	for name := range model.Weights {
+		if _, ok := known[name]; !ok {
+			names := make([]string, 0, len(known))
+			for feature := range known {
+				names = append(names, feature)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown feature %q in %s, use one of %s", name, path, strings.Join(names, ", "))
+		}
+		model.features = append(model.features, name)
+	}
+	sort.Strings(model.features)
+	if model.DrawMargin < 0 {
+		return nil, fmt.Errorf("draw_margin in %s cannot be negative", path)
+	}
+	return model, nil
+}
+
+func (m *linearOutcomeModel) PredictMatches(ctx context.Context, league *sim.League, matches []*sim.Match) ([]OutcomeProbabilities, error) {
+	sigmoid := func(x float64) float64 { return 1 / (1 + math.Exp(-x)) }
+	forecasts := make([]OutcomeProbabilities, len(matches))
+	for i, features := range buildMatchFeatures(league, matches) {
+		score := m.Intercept
+		values := features.numeric()
+		for _, name := range m.features {
+			score += m.Weights[name] * values[name]
+		}
+		homeWin, awayWin := sigmoid(score-m.DrawMargin), sigmoid(-score-m.DrawMargin)
+		forecasts[i] = OutcomeProbabilities{HomeWin: homeWin, Draw: 1 - homeWin - awayWin, AwayWin: awayWin}
+	}
+	return forecasts, nil
+}
+
+// newMLPredictor returns the ml model configured by LEAGUE_PREDICTION_MODEL: an
+// http(s) URL of a model service, or a model file loaded by its extension
+func newMLPredictor() (Predictor, error) {
+	location := os.Getenv("LEAGUE_PREDICTION_MODEL")
+	if location == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return MatchModelPredictor{&httpMatchPredictor{url: location, client: &http.Client{Timeout: 5 * time.Second}}}, nil
+	}
+
+	load, ok := matchPredictorLoaders[strings.ToLower(filepath.Ext(location))]
+	if !ok {
+		formats := []string{}
+		for extension := range matchPredictorLoaders {
+			formats = append(formats, extension)
+		}
+		sort.Strings(formats)
+		return nil, fmt.Errorf("unsupported model file %q, use one of %s or an http(s) URL", location, strings.Join(formats, ", "))
+	}
+	model, err := load(location)
+	if err != nil {
+		return nil, err
+	}
+	return MatchModelPredictor{model}, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// newControllerToken generates a controller's secret token and the hash stored for it
+func newControllerToken() (token, hash string, err error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate controller token: %v", err)
+	}
+	token = hex.EncodeToString(raw)
+	return token, hashApiKey(token), nil
+}
+
+// controllerByToken returns the controller a token belongs to, nil for an unknown token
+func controllerByToken(league *sim.League, token string) *sim.TeamController {
+	if token == "" {
+		return nil
+	}
+	hash := hashApiKey(token)
+	for _, controller := range league.Controllers {
+		if controller.TokenHash == hash {
+			return controller
+		}
+	}
+	return nil
+}
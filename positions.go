@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// PositionMatrix is every team's chance of each finishing position under one model
+type PositionMatrix struct {
+	Model      string
+	Iterations int
+	Teams      []*PositionProbabilities // in table order
+	Zones      []*sim.Zone              // the league's zones, see GET /league/zones
+}
+
+// PositionProbabilities is one row of the matrix: Probabilities[i] is the chance in
+// percent of finishing in position i+1, and ZoneProbabilities the chance of finishing
+// in each zone by name. The standard errors, in percentage points, go with them.
+type PositionProbabilities struct {
+	TeamName           string
+	Position           int
+	Probabilities      []float64
+	StandardErrors     []float64
+	ExpectedPosition   float64
+	ZoneProbabilities  map[string]float64 `json:",omitempty"`
+	ZoneStandardErrors map[string]float64 `json:",omitempty"`
+}
+
+// buildPositionMatrix runs a Monte Carlo model for iterations seasons on the current
+// league state
+func buildPositionMatrix(ctx context.Context, league *sim.League, model string, iterations int) (*PositionMatrix, error) {
+	predictor, ok := predictionModels[model].(PositionPredictor)
+	if !ok {
+		return nil, fmt.Errorf("model %q does not predict finishing positions", model)
+	}
+	shares, err := predictor.PredictPositions(ctx, league, iterations)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", errPredictionModel, model, err)
+	}
+
+	zones := sim.LeagueZones(league).Zones
+	matrix := &PositionMatrix{Model: model, Iterations: iterations, Teams: []*PositionProbabilities{}, Zones: zones}
+	for _, entry := range league.LeagueTable {
+		row := &PositionProbabilities{TeamName: entry.TeamName, Position: entry.Position, Probabilities: []float64{}, StandardErrors: []float64{}}
+		zoneShares := make(map[string]float64)
+		for _, zone := range zones {
+			zoneShares[zone.Name] = 0
+		}
+		for i, share := range shares[entry.TeamName] {
+			row.Probabilities = append(row.Probabilities, math.Round(share*10)/10)
+			row.StandardErrors = append(row.StandardErrors, standardError(share, iterations))
+			row.ExpectedPosition += float64(i+1) * share / 100
+			if zone := sim.ZoneAt(zones, i+1); zone != nil {
+				zoneShares[zone.Name] += share
+			}
+		}
+		row.ExpectedPosition = math.Round(row.ExpectedPosition*100) / 100
+		if len(zones) > 0 {
+			row.ZoneProbabilities = make(map[string]float64)
+			row.ZoneStandardErrors = make(map[string]float64)
+			for name, share := range zoneShares {
+				row.ZoneProbabilities[name] = math.Round(share*10) / 10
+				row.ZoneStandardErrors[name] = standardError(share, iterations)
+			}
+		}
+		matrix.Teams = append(matrix.Teams, row)
+	}
+	return matrix, nil
+}
+
+// heatShade is the cell drawn for a probability in percent: a dot below 0.5%, then
+// blocks that darken at 10%, 25% and 50%
+func heatShade(probability float64) string {
+	switch {
+	case probability < 0.5:
+		return " ·"
+	case probability < 10:
+		return "░░"
+	case probability < 25:
+		return "▒▒"
+	case probability < 50:
+		return "▓▓"
+	}
+	return "██"
+}
+
+// renderPositionHeatTable draws the matrix as a compact table for the console: one
+// row per team, one two-character cell per finishing position, then the team's most
+// likely position and its chance
+func renderPositionHeatTable(matrix *PositionMatrix) string {
+	var b strings.Builder
+	positions := len(matrix.Teams)
+	fmt.Fprintf(&b, "%-20s ", "Team")
+	for position := 1; position <= positions; position++ {
+		fmt.Fprintf(&b, "%2d", position)
+	}
+	fmt.Fprintf(&b, "  %s\n", "Likeliest")
+
+	for _, row := range matrix.Teams {
+		fmt.Fprintf(&b, "%-20s ", asciiName(row.TeamName))
+		likeliest := 0
+		for i, probability := range row.Probabilities {
+			b.WriteString(heatShade(probability))
+			if probability > row.Probabilities[likeliest] {
+				likeliest = i
+			}
+		}
+		if len(row.Probabilities) > 0 {
+			fmt.Fprintf(&b, "  %2d (%.0f%%)", likeliest+1, row.Probabilities[likeliest])
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "%s <0.5%%  ░░ <10%%  ▒▒ <25%%  ▓▓ <50%%  ██ 50%%+\n", strings.TrimSpace(heatShade(0)))
+	return b.String()
+}
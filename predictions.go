@@ -0,0 +1,451 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// Prediction models selectable in GET /league/predictions/compare
+const (
+	ModelHeuristic = "heuristic" // weighted points, strength, goal difference and form
+	ModelPoisson   = "poisson"   // Monte Carlo season with Poisson goals around each side's xG
+	ModelElo       = "elo"       // Monte Carlo season with results drawn from Elo win expectancy
+	ModelML        = "ml"        // Monte Carlo season with results drawn from LEAGUE_PREDICTION_MODEL
+)
+
+// errPredictionModel marks a prediction model that failed, e.g. an unreachable model service
+var errPredictionModel = errors.New("prediction model")
+
+// The Monte Carlo models replay the run-in defaultPredictionIterations times unless a
+// request asks for a number between minPredictionIterations and maxPredictionIterations
+const (
+	defaultPredictionIterations = 5000
+	minPredictionIterations     = 100
+	maxPredictionIterations     = 100000
+)
+
+// eloDrawRate is the draw probability between evenly matched sides in the Elo model
+const eloDrawRate = 0.28
+
+// Predictor is a championship prediction model: it returns each team's chance of
+// winning the league, in percent. Monte Carlo models play iterations seasons; the
+// others ignore it.
+type Predictor interface {
+	PredictChampionship(ctx context.Context, league *sim.League, iterations int) (map[string]float64, error)
+}
+
+// PredictorFunc adapts a model that cannot fail and does not sample to Predictor
+type PredictorFunc func(league *sim.League) map[string]float64
+
+func (f PredictorFunc) PredictChampionship(ctx context.Context, league *sim.League, iterations int) (map[string]float64, error) {
+	return f(league), nil
+}
+
+// OutcomeProbabilities is a three-way forecast for one match; the three add up to 1
+type OutcomeProbabilities struct {
+	HomeWin float64
+	Draw    float64
+	AwayWin float64
+}
+
+// MatchPredictor forecasts individual matches. Models that only know win
+// probabilities, such as the Elo model or an ML model, implement it and become a
+// Predictor through MatchModelPredictor.
+type MatchPredictor interface {
+	PredictMatches(ctx context.Context, league *sim.League, matches []*sim.Match) ([]OutcomeProbabilities, error)
+}
+
+// MatchModelPredictor predicts the championship by playing out the remaining fixtures
+// with results drawn from a match model's probabilities; every win counts as a
+// one-goal margin
+type MatchModelPredictor struct {
+	Model MatchPredictor
+}
+
+func (p MatchModelPredictor) PredictChampionship(ctx context.Context, league *sim.League, iterations int) (map[string]float64, error) {
+	fixtures, err := p.fixtures(ctx, league)
+	if err != nil {
+		return nil, err
+	}
+	return monteCarloChampionship(league, fixtures, iterations), nil
+}
+
+func (p MatchModelPredictor) PredictPositions(ctx context.Context, league *sim.League, iterations int) (map[string][]float64, error) {
+	fixtures, err := p.fixtures(ctx, league)
+	if err != nil {
+		return nil, err
+	}
+	return positionShares(monteCarloPositions(league, fixtures, iterations), iterations), nil
+}
+
+// fixtures asks the match model for the remaining fixtures once and draws each
+// fixture's results from its forecast, which is all the results depend on
+func (p MatchModelPredictor) fixtures(ctx context.Context, league *sim.League) (fixtureModel, error) {
+	remaining := []*sim.Match{}
+	for _, match := range league.Matches {
+		if !match.Played {
+			remaining = append(remaining, match)
+		}
+	}
+	forecasts, err := p.Model.PredictMatches(ctx, league, remaining)
+	if err != nil {
+		return nil, err
+	}
+	if len(forecasts) != len(remaining) {
+		return nil, fmt.Errorf("match model returned %d forecasts for %d matches", len(forecasts), len(remaining))
+	}
+	byMatch := make(map[*sim.Match]OutcomeProbabilities, len(remaining))
+	for i, match := range remaining {
+		byMatch[match] = forecasts[i]
+	}
+
+	return func(match *sim.Match) (string, func() (int, int)) {
+		forecast := byMatch[match]
+		signature := fmt.Sprintf("outcome %v %v %v", forecast.HomeWin, forecast.Draw, forecast.AwayWin)
+		return signature, func() (int, int) {
+			roll := rand.Float64()
+			switch {
+			case roll < forecast.HomeWin:
+				return 1, 0
+			case roll < forecast.HomeWin+forecast.Draw:
+				return 0, 0
+			}
+			return 0, 1
+		}
+	}, nil
+}
+
+// PositionPredictor is a model that forecasts every finishing position: for each
+// team, the chance in percent of finishing first, second and so on, from iterations
+// simulated seasons. The Monte Carlo models implement it.
+type PositionPredictor interface {
+	PredictPositions(ctx context.Context, league *sim.League, iterations int) (map[string][]float64, error)
+}
+
+// isMonteCarlo reports whether a model estimates its probabilities by sampling
+// seasons, so that they carry a standard error
+func isMonteCarlo(model string) bool {
+	_, ok := predictionModels[model].(PositionPredictor)
+	return ok
+}
+
+// parsePredictionIterations reads an ?iterations parameter; empty is the default
+func parsePredictionIterations(value string) (int, error) {
+	if value == "" {
+		return defaultPredictionIterations, nil
+	}
+	iterations, err := strconv.Atoi(value)
+	if err != nil || iterations < minPredictionIterations || iterations > maxPredictionIterations {
+		return 0, fmt.Errorf("iterations must be a number from %d to %d", minPredictionIterations, maxPredictionIterations)
+	}
+	return iterations, nil
+}
+
+// standardError is the standard error, in percentage points, of a probability in
+// percent estimated from iterations independent seasons: sqrt(p(100-p)/n)
+func standardError(percent float64, iterations int) float64 {
+	return math.Round(math.Sqrt(percent*(100-percent)/float64(iterations))*100) / 100
+}
+
+// predictionModels maps each model name to its championship predictor. ModelML is
+// added at startup when LEAGUE_PREDICTION_MODEL is set.
+var predictionModels = map[string]Predictor{
+	ModelHeuristic: PredictorFunc(sim.PredictChampionship),
+	ModelPoisson:   poissonPredictor{},
+	ModelElo:       MatchModelPredictor{eloMatchPredictor{}},
+}
+
+// defaultPredictionModels is what GET /league/predictions/compare runs without ?models
+func defaultPredictionModels() []string {
+	models := []string{ModelHeuristic, ModelPoisson, ModelElo}
+	if _, ok := predictionModels[ModelML]; ok {
+		models = append(models, ModelML)
+	}
+	return models
+}
+
+// PredictionComparison holds each model's championship probabilities side by side
+type PredictionComparison struct {
+	Models     []string
+	Iterations int // used by the Monte Carlo models
+	Teams      []*TeamPredictions
+}
+
+// TeamPredictions is one team's championship probability under every requested model,
+// and the standard error of each Monte Carlo model's estimate
+type TeamPredictions struct {
+	TeamName       string
+	Position       int
+	Probabilities  map[string]float64
+	StandardErrors map[string]float64
+}
+
+// comparePredictions runs the given models on the current league state, listing
+// teams in table order
+func comparePredictions(ctx context.Context, league *sim.League, models []string, iterations int) (*PredictionComparison, error) {
+	comparison := &PredictionComparison{Models: models, Iterations: iterations, Teams: []*TeamPredictions{}}
+	results := make(map[string]map[string]float64)
+	for _, model := range models {
+		predictions, err := predictionModels[model].PredictChampionship(ctx, league, iterations)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %v", errPredictionModel, model, err)
+		}
+		results[model] = predictions
+	}
+
+	for _, entry := range league.LeagueTable {
+		team := &TeamPredictions{
+			TeamName:       entry.TeamName,
+			Position:       entry.Position,
+			Probabilities:  make(map[string]float64),
+			StandardErrors: make(map[string]float64),
+		}
+		for _, model := range models {
+			probability := results[model][entry.TeamName]
+			team.Probabilities[model] = math.Round(probability*10) / 10
+			if isMonteCarlo(model) {
+				team.StandardErrors[model] = standardError(probability, iterations)
+			}
+		}
+		comparison.Teams = append(comparison.Teams, team)
+	}
+	return comparison, nil
+}
+
+// poissonPredictor plays out the remaining fixtures with Poisson goals around the
+// simulation's expected goals
+type poissonPredictor struct{}
+
+func (poissonPredictor) PredictChampionship(ctx context.Context, league *sim.League, iterations int) (map[string]float64, error) {
+	return monteCarloChampionship(league, poissonFixtures(league), iterations), nil
+}
+
+func (poissonPredictor) PredictPositions(ctx context.Context, league *sim.League, iterations int) (map[string][]float64, error) {
+	return positionShares(monteCarloPositions(league, poissonFixtures(league), iterations), iterations), nil
+}
+
+// poissonFixtures draws a match's goals from Poisson distributions around both sides'
+// xG, capped at the configured maximum
+func poissonFixtures(league *sim.League) fixtureModel {
+	sport, config := sim.LeagueSport(league), sim.LeagueConfig(league)
+	return func(match *sim.Match) (string, func() (int, int)) {
+		homeStrength, awayStrength := sim.MatchStrengths(match, config)
+		homeXG, awayXG, maxGoals := sport.ExpectedGoals(homeStrength), sport.ExpectedGoals(awayStrength), sport.MaxGoals(config)
+		signature := fmt.Sprintf("poisson %v %v %d", homeXG, awayXG, maxGoals)
+		return signature, func() (int, int) {
+			homeGoals := min(sim.PoissonSample(homeXG, sim.LeagueRandom(league)), maxGoals)
+			awayGoals := min(sim.PoissonSample(awayXG, sim.LeagueRandom(league)), maxGoals)
+			return homeGoals, awayGoals
+		}
+	}
+}
+
+// eloMatchPredictor forecasts matches from the Elo win expectancy of the power
+// rankings. Draws are likeliest between evenly matched sides.
+type eloMatchPredictor struct{}
+
+func (eloMatchPredictor) PredictMatches(ctx context.Context, league *sim.League, matches []*sim.Match) ([]OutcomeProbabilities, error) {
+	ratings := sim.ComputeEloRatings(league, league.CurrentWeek)
+	config := sim.LeagueConfig(league)
+	forecasts := make([]OutcomeProbabilities, len(matches))
+	for i, match := range matches {
+		expected := sim.EloExpectedScore(ratings[match.HomeTeam.TeamName], ratings[match.AwayTeam.TeamName], sim.HomeAdvantageFactor(match, config))
+		draw := eloDrawRate * (1 - math.Abs(2*expected-1))
+		homeWin := expected - draw/2
+		forecasts[i] = OutcomeProbabilities{HomeWin: homeWin, Draw: draw, AwayWin: 1 - homeWin - draw}
+	}
+	return forecasts, nil
+}
+
+// monteCarloChampionship replays the unplayed fixtures iterations times on top of the
+// current table and returns how often each team finished first, in percent
+func monteCarloChampionship(league *sim.League, fixtures fixtureModel, iterations int) map[string]float64 {
+	predictions := make(map[string]float64)
+	for name, positions := range monteCarloPositions(league, fixtures, iterations) {
+		predictions[name] = float64(positions[0]) / float64(iterations) * 100
+	}
+	return predictions
+}
+
+// positionShares turns finishing position counts from iterations seasons into percentages
+func positionShares(counts map[string][]int, iterations int) map[string][]float64 {
+	shares := make(map[string][]float64, len(counts))
+	for name, positions := range counts {
+		shares[name] = make([]float64, len(positions))
+		for i, count := range positions {
+			shares[name][i] = float64(count) / float64(iterations) * 100
+		}
+	}
+	return shares
+}
+
+// monteCarloPositions replays the unplayed fixtures iterations times on top of the
+// current table and counts how often each team finished in each position, first
+// position first. The results come from sampleFixtures, which reuses earlier samples.
+// Every season is played on the same pooled seasonScratch, so a run allocates nothing
+// per season.
+func monteCarloPositions(league *sim.League, fixtures fixtureModel, iterations int) map[string][]int {
+	run := newMonteCarloRun(league, fixtures, iterations)
+	teams := len(league.LeagueTable)
+	counts := make([][]int, teams)
+	for i := range counts {
+		counts[i] = make([]int, teams)
+	}
+
+	scratch := seasonScratchPool.Get().(*seasonScratch)
+	defer seasonScratchPool.Put(scratch)
+	scratch.resize(teams)
+
+	for i := 0; i < iterations; i++ {
+		run.play(scratch, i)
+		for position, t := range scratch.order {
+			counts[t][position]++
+		}
+	}
+
+	positions := make(map[string][]int, teams)
+	for t, entry := range league.LeagueTable {
+		positions[entry.TeamName] = counts[t]
+	}
+	return positions
+}
+
+// monteCarloRun is what every season of a Monte Carlo run replays: the remaining
+// fixtures' sides as indices into the league table, their handicap lines and their
+// sampled results
+type monteCarloRun struct {
+	league  *sim.League
+	sport   *sim.Sport
+	sides   [][2]int
+	lines   []float64
+	columns []*sampleColumn
+}
+
+// newMonteCarloRun samples the unplayed fixtures for iterations seasons
+func newMonteCarloRun(league *sim.League, fixtures fixtureModel, iterations int) *monteCarloRun {
+	remaining := []*sim.Match{}
+	for _, match := range league.Matches {
+		if !match.Played {
+			remaining = append(remaining, match)
+		}
+	}
+
+	index := make(map[string]int, len(league.LeagueTable))
+	for i, entry := range league.LeagueTable {
+		index[entry.TeamName] = i
+	}
+	run := &monteCarloRun{
+		league:  league,
+		sport:   sim.LeagueSport(league),
+		sides:   make([][2]int, len(remaining)),
+		lines:   make([]float64, len(remaining)),
+		columns: sampleFixtures(league, remaining, fixtures, iterations),
+	}
+	for j, match := range remaining {
+		run.sides[j] = [2]int{index[match.HomeTeam.TeamName], index[match.AwayTeam.TeamName]}
+		run.lines[j] = sim.MatchHandicapLine(league, match)
+	}
+	return run
+}
+
+// play resets scratch to the current table, plays season i on it and sorts it
+func (run *monteCarloRun) play(scratch *seasonScratch, i int) {
+	for t, entry := range run.league.LeagueTable {
+		scratch.standings[t] = seasonStanding{name: entry.TeamName, points: entry.Points, goalDiff: entry.GoalsDifference, gf: entry.GoalsFor}
+		scratch.order[t] = t
+	}
+
+	for j, side := range run.sides {
+		home, away := &scratch.standings[side[0]], &scratch.standings[side[1]]
+		homeGoals, awayGoals := int(run.columns[j].home[i]), int(run.columns[j].away[i])
+		// A level score goes to overtime in sports without draws; the samples are
+		// independent, so alternating the winner by season keeps it an even contest
+		overtime := run.sport.Overtime > 0 && homeGoals == awayGoals
+		if overtime {
+			homeGoals, awayGoals = homeGoals+(i+j)%2, awayGoals+(i+j+1)%2
+		}
+		home.goalDiff += homeGoals - awayGoals
+		away.goalDiff += awayGoals - homeGoals
+		home.gf += homeGoals
+		away.gf += awayGoals
+		homePoints, awayPoints := run.sport.ResultPoints(float64(homeGoals-awayGoals)-run.lines[j], overtime)
+		home.points += homePoints
+		away.points += awayPoints
+	}
+
+	sort.Sort(scratch)
+}
+
+// seasonStanding is a team's line in one Monte Carlo season
+type seasonStanding struct {
+	name                 string
+	points, goalDiff, gf int
+}
+
+// seasonScratch is the table a Monte Carlo season is played on: the standings in
+// league table order, and order, the indices of the standings from first place down
+// once sorted. Runs take one from seasonScratchPool and reset it for every season.
+type seasonScratch struct {
+	standings []seasonStanding
+	order     []int
+}
+
+// seasonScratchPool keeps scratch tables between runs, including concurrent ones
+var seasonScratchPool = sync.Pool{New: func() any { return &seasonScratch{} }}
+
+// resize makes room for teams standings, keeping the arrays when they are big enough
+func (s *seasonScratch) resize(teams int) {
+	if cap(s.standings) < teams {
+		s.standings, s.order = make([]seasonStanding, teams), make([]int, teams)
+	}
+	s.standings, s.order = s.standings[:teams], s.order[:teams]
+}
+
+// seasonScratch sorts order by points, goal difference, goals scored and then name.
+// It implements sort.Interface itself because sort.Slice allocates on every call.
+func (s *seasonScratch) Len() int      { return len(s.order) }
+func (s *seasonScratch) Swap(a, b int) { s.order[a], s.order[b] = s.order[b], s.order[a] }
+func (s *seasonScratch) Less(a, b int) bool {
+	x, y := &s.standings[s.order[a]], &s.standings[s.order[b]]
+	if x.points != y.points {
+		return x.points > y.points
+	}
+	if x.goalDiff != y.goalDiff {
+		return x.goalDiff > y.goalDiff
+	}
+	if x.gf != y.gf {
+		return x.gf > y.gf
+	}
+	return x.name < y.name
+}
+
+// PredictionSnapshot is the championship probabilities recorded after a week
+type PredictionSnapshot struct {
+	Season        int
+	Week          int
+	Probabilities map[string]float64
+	RecordedAt    time.Time
+}
+
+// newPredictionSnapshot captures the current championship predictions
+func newPredictionSnapshot(league *sim.League) *PredictionSnapshot {
+	probabilities := make(map[string]float64)
+	for name, probability := range sim.PredictChampionship(league) {
+		probabilities[name] = math.Round(probability*10) / 10
+	}
+	return &PredictionSnapshot{
+		Season:        league.Season,
+		Week:          league.CurrentWeek,
+		Probabilities: probabilities,
+		RecordedAt:    time.Now().UTC(),
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// pprofPath is where the admin router serves the runtime profiles
+const pprofPath = "/admin/debug/pprof/"
+
+// profilingEnabled reports whether the pprof endpoints are served. They expose the
+// process's internals, so unlike the other admin endpoints they are never open: they
+// exist only when LEAGUE_ADMIN_TOKEN is set.
+func profilingEnabled() bool {
+	return os.Getenv("LEAGUE_ADMIN_TOKEN") != ""
+}
+
+// registerProfiling serves net/http/pprof under the admin router. pprof.Index only
+// serves named profiles under /debug/pprof/, so each profile gets its own route here.
+func registerProfiling(admin *mux.Router) {
+	admin.HandleFunc("/debug/pprof/", pprof.Index).Methods("GET")
+	admin.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline).Methods("GET")
+	admin.HandleFunc("/debug/pprof/profile", pprof.Profile).Methods("GET")
+	admin.HandleFunc("/debug/pprof/symbol", pprof.Symbol).Methods("GET", "POST")
+	admin.HandleFunc("/debug/pprof/trace", pprof.Trace).Methods("GET")
+	admin.HandleFunc("/debug/pprof/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(mux.Vars(r)["profile"]).ServeHTTP(w, r)
+	}).Methods("GET")
+}
+
+// isProfilingRequest reports whether a request is for a pprof endpoint. CPU profiles
+// and traces run for ?seconds (30 by default), so they are exempt from the request timeout.
+func isProfilingRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, pprofPath)
+}
+
+// startProfiles starts a CPU profile written to <prefix>.cpu.pprof. The returned stop
+// ends it and writes the heap profile to <prefix>.heap.pprof; its allocation samples
+// cover the whole run.
+func startProfiles(prefix string) (stop func() error, err error) {
+	cpuFile, err := os.Create(prefix + ".cpu.pprof")
+	if err != nil {
+		return nil, err
+	}
+	if err := runtimepprof.StartCPUProfile(cpuFile); err != nil {
+		cpuFile.Close()
+		return nil, err
+	}
+
+	return func() error {
+		runtimepprof.StopCPUProfile()
+		if err := cpuFile.Close(); err != nil {
+			return err
+		}
+		heapFile, err := os.Create(prefix + ".heap.pprof")
+		if err != nil {
+			return err
+		}
+		defer heapFile.Close()
+		runtime.GC() // the in-use figures are as of the last collection
+		if err := runtimepprof.WriteHeapProfile(heapFile); err != nil {
+			return fmt.Errorf("failed to write the heap profile: %w", err)
+		}
+		return heapFile.Close()
+	}, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// All-time record keys
+const (
+	RecordBiggestWin        = "biggest_win"
+	RecordMostGoalsInSeason = "most_goals_in_season"
+	RecordMostPointsSeason  = "most_points_in_season"
+	RecordLongestUnbeaten   = "longest_unbeaten_run"
+)
+
+// recordTitles gives each record key a human readable name
+var recordTitles = map[string]string{
+	RecordBiggestWin:        "Biggest win",
+	RecordMostGoalsInSeason: "Most goals in a season",
+	RecordMostPointsSeason:  "Most points in a season",
+	RecordLongestUnbeaten:   "Longest unbeaten run",
+}
+
+// Record is an entry in the all-time record book
+type Record struct {
+	Key      string
+	Title    string
+	TeamName string
+	Value    int
+	Season   int
+	Detail   string `json:",omitempty"`
+}
+
+// updateRecords folds a completed season into the record book. streaks holds each
+// team's unbeaten run carried over from previous seasons and is updated in place.
+// It returns the records that were set or broken.
+func updateRecords(league *sim.League, records map[string]*Record, streaks map[string]int) []*Record {
+	broken := []*Record{}
+	consider := func(candidate *Record) {
+		current, ok := records[candidate.Key]
+		if ok && candidate.Value <= current.Value {
+			return
+		}
+		candidate.Title = recordTitles[candidate.Key]
+		records[candidate.Key] = candidate
+		broken = append(broken, candidate)
+	}
+
+	matches := make([]*sim.Match, 0, len(league.Matches))
+	for _, match := range league.Matches {
+		if match.Played {
+			matches = append(matches, match)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Week < matches[j].Week
+	})
+
+	// Biggest win, keeping the earliest on equal margins
+	var biggest *sim.Match
+	for _, match := range matches {
+		if biggest == nil || goalMargin(match) > goalMargin(biggest) {
+			biggest = match
+		}
+	}
+	if biggest != nil && goalMargin(biggest) > 0 {
+		winner := biggest.HomeTeam.TeamName
+		if biggest.AwayTeamScore > biggest.HomeTeamScore {
+			winner = biggest.AwayTeam.TeamName
+		}
+		consider(&Record{
+			Key:      RecordBiggestWin,
+			TeamName: winner,
+			Value:    goalMargin(biggest),
+			Season:   league.Season,
+			Detail: fmt.Sprintf("%s %d-%d %s (week %d)", biggest.HomeTeam.TeamName, biggest.HomeTeamScore,
+				biggest.AwayTeamScore, biggest.AwayTeam.TeamName, biggest.Week),
+		})
+	}
+
+	// Season totals come from the final table
+	for _, entry := range league.LeagueTable {
+		consider(&Record{Key: RecordMostGoalsInSeason, TeamName: entry.TeamName, Value: entry.GoalsFor, Season: league.Season})
+		consider(&Record{Key: RecordMostPointsSeason, TeamName: entry.TeamName, Value: entry.Points, Season: league.Season})
+	}
+
+	// Unbeaten runs continue across seasons until the team loses
+	for _, match := range matches {
+		homeLost := match.HomeTeamScore < match.AwayTeamScore
+		awayLost := match.AwayTeamScore < match.HomeTeamScore
+		for _, side := range []struct {
+			name string
+			lost bool
+		}{{match.HomeTeam.TeamName, homeLost}, {match.AwayTeam.TeamName, awayLost}} {
+			if side.lost {
+				streaks[side.name] = 0
+				continue
+			}
+			streaks[side.name]++
+			consider(&Record{
+				Key:      RecordLongestUnbeaten,
+				TeamName: side.name,
+				Value:    streaks[side.name],
+				Season:   league.Season,
+				Detail:   fmt.Sprintf("%d matches without defeat", streaks[side.name]),
+			})
+		}
+	}
+
+	// A run that grows match by match is only reported once
+	return dedupeRecords(broken)
+}
+
+// dedupeRecords keeps the last entry for each record key
+func dedupeRecords(records []*Record) []*Record {
+	latest := make(map[string]*Record)
+	order := []string{}
+	for _, record := range records {
+		if _, ok := latest[record.Key]; !ok {
+			order = append(order, record.Key)
+		}
+		latest[record.Key] = record
+	}
+
+	deduped := make([]*Record, 0, len(order))
+	for _, key := range order {
+		deduped = append(deduped, latest[key])
+	}
+	return deduped
+}
+
+func goalMargin(match *sim.Match) int {
+	if match.HomeTeamScore > match.AwayTeamScore {
+		return match.HomeTeamScore - match.AwayTeamScore
+	}
+	return match.AwayTeamScore - match.HomeTeamScore
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PanicReport describes a recovered panic for an ErrorReporter
+type PanicReport struct {
+	Message    string
+	Stack      string
+	Method     string `json:",omitempty"`
+	Path       string `json:",omitempty"`
+	Route      string `json:",omitempty"`
+	Job        string `json:",omitempty"` // set instead of the request fields for background jobs
+	TraceId    string `json:",omitempty"`
+	OccurredAt time.Time
+}
+
+// ErrorReporter forwards recovered panics to an external service (Sentry, a webhook,
+// ...). Implementations should return quickly; reporting happens on the request path.
+type ErrorReporter interface {
+	ReportPanic(ctx context.Context, report PanicReport)
+}
+
+// errorReporter receives every recovered panic; nil only logs them
+var errorReporter ErrorReporter
+
+// panicLogger writes recovered panics as JSON lines so they can be searched by field
+var panicLogger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// webhookReporter posts each panic report as JSON to a URL
+type webhookReporter struct {
+	url    string
+	client *http.Client
+}
+
+// newErrorReporter returns a webhook reporter when LEAGUE_ERROR_WEBHOOK_URL is set
+func newErrorReporter() ErrorReporter {
+	url := os.Getenv("LEAGUE_ERROR_WEBHOOK_URL")
+	if url == "" {
+		return nil
+	}
+	return &webhookReporter{url: url, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (r *webhookReporter) ReportPanic(ctx context.Context, report PanicReport) {
+	// Sent in the background so a slow endpoint never delays the 500 response
+	go func() {
+		body, err := json.Marshal(report)
+		if err != nil {
+			panicLogger.Error("failed to encode panic report", "error", err.Error())
+			return
+		}
+		resp, err := r.client.Post(r.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			panicLogger.Error("failed to send panic report", "error", err.Error())
+			return
+		}
+		resp.Body.Close()
+	}()
+}
+
+// reportPanic logs a recovered panic and hands it to the configured reporter
+func reportPanic(ctx context.Context, report PanicReport) {
+	attrs := []any{"message", report.Message}
+	for _, field := range [][2]string{
+		{"method", report.Method},
+		{"path", report.Path},
+		{"route", report.Route},
+		{"job", report.Job},
+		{"trace_id", report.TraceId},
+	} {
+		if field[1] != "" {
+			attrs = append(attrs, field[0], field[1])
+		}
+	}
+	panicLogger.Error("panic recovered", append(attrs, "stack", report.Stack)...)
+	if errorReporter != nil {
+		errorReporter.ReportPanic(ctx, report)
+	}
+}
+
+// goroutinePanic carries a panic recovered on another goroutine, together with the
+// stack where it happened, to be re-raised on the request goroutine
+type goroutinePanic struct {
+	value interface{}
+	stack []byte
+}
+
+// newPanicReport captures the panic value, the stack and the trace it happened in
+func newPanicReport(ctx context.Context, recovered interface{}) PanicReport {
+	report := PanicReport{
+		Message:    fmt.Sprint(recovered),
+		Stack:      string(debug.Stack()),
+		OccurredAt: time.Now().UTC(),
+	}
+	if p, ok := recovered.(goroutinePanic); ok {
+		report.Message = fmt.Sprint(p.value)
+		report.Stack = string(p.stack)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		report.TraceId = spanContext.TraceID().String()
+	}
+	return report
+}
+
+// recoveryMiddleware turns a handler panic into a logged, reported JSON 500 instead
+// of a dropped connection
+func recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// The server uses this panic to abort a response on purpose
+			if p, ok := recovered.(goroutinePanic); ok && p.value == http.ErrAbortHandler {
+				recovered = p.value
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			report := newPanicReport(r.Context(), recovered)
+			report.Method = r.Method
+			report.Path = r.URL.Path
+			report.Route, _ = mux.CurrentRoute(r).GetPathTemplate()
+			reportPanic(r.Context(), report)
+
+			// Part of a response may already be on its way; there is nothing left to fix then
+			if rec.status != 0 {
+				return
+			}
+			body := map[string]string{"error": "internal server error"}
+			if report.TraceId != "" {
+				body["trace_id"] = report.TraceId
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(body)
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
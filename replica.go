@@ -0,0 +1,70 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// NewReadOnlySQLStorageService opens a database for reads only, e.g. a streaming
+// replica. Unlike NewSQLStorageService it creates and migrates nothing, since a
+// replica rejects writes; the primary's schema reaches it through replication.
+func NewReadOnlySQLStorageService(driverName, dataSourceName string) (*SQLStorageService, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replica: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping replica: %v", err)
+	}
+
+	return &SQLStorageService{
+		db:         db,
+		conn:       db,
+		driverName: driverName,
+		leagueLock: &sync.Mutex{},
+	}, nil
+}
+
+// openReplicaStorage connects to LEAGUE_DATABASE_REPLICA_URL with the primary's driver;
+// it returns nil when no replica is configured
+func openReplicaStorage(driverName string) (StorageService, error) {
+	url := os.Getenv("LEAGUE_DATABASE_REPLICA_URL")
+	if url == "" {
+		return nil, nil
+	}
+	replica, err := NewReadOnlySQLStorageService(driverName, url)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Serving reads from the replica database")
+	return replica, nil
+}
+
+// readStorage returns the storage a read should use: the replica when one is
+// configured and has caught up with the latest revision this instance knows of,
+// otherwise the primary. A week this instance has just simulated is therefore
+// never read back from a replica that has not received it yet.
+func (s *leagueScope) readStorage() StorageService {
+	if s.replica == nil {
+		return s.storage
+	}
+	latest := int64(s.League().Revision)
+	if s.replicaRevision.Load() >= latest {
+		return s.replica
+	}
+
+	revision, err := s.replica.GetRevision()
+	if err != nil {
+		log.Printf("Failed to read replica revision, reading from the primary: %v", err)
+		return s.storage
+	}
+	if int64(revision) < latest {
+		return s.storage
+	}
+	s.replicaRevision.Store(int64(revision))
+	return s.replica
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// TeamRepository persists teams and their accumulated statistics
+type TeamRepository interface {
+	GetTeams() ([]*sim.Team, error)
+	UpdateTeam(team *sim.Team) error
+	ReplaceTeams(teams []*sim.Team) error
+	RenameTeam(change *TeamNameChange) error
+	GetTeamNameHistory(teamId int) ([]*TeamNameChange, error)
+	SaveTeamAudit(entries []*sim.TeamAuditEntry) error
+	GetTeamAudit(teamId int) ([]*sim.TeamAuditEntry, error)
+	SaveManagers(managers []*sim.Manager) error
+	GetManagers() ([]*sim.Manager, error)
+	SaveTeamController(controller *sim.TeamController) error
+	DeleteTeamController(teamId int) error
+	GetTeamControllers() (map[int]*sim.TeamController, error)
+	SaveDraft(draft *sim.Draft) error
+	SaveDraftPicks(draft *sim.Draft, picks []*sim.DraftPlayer) error
+	GetDraft(season int) (*sim.Draft, error)
+	ReplaceTeamHandicaps(handicaps map[int]float64) error
+	GetTeamHandicaps() (map[int]float64, error)
+}
+
+// MatchRepository persists fixtures, results and their event timelines
+type MatchRepository interface {
+	SaveMatchResult(match *sim.Match) error
+	GetMatches() ([]*sim.Match, error)
+	EachMatch(pageSize int, fn func(match *sim.Match) error) error
+	GetOrphanedMatchIds() ([]int, error)
+	ReplaceMatches(matches []*sim.Match) error
+	ArchiveSeasonMatches(season int) error
+	GetArchivedMatches(season int) ([]*sim.Match, error)
+	SaveReferees(referees []*sim.Referee) error
+	GetReferees() ([]*sim.Referee, error)
+	SaveMatchOrders(orders *sim.MatchOrders) error
+	GetMatchOrders(season int) ([]*sim.MatchOrders, error)
+}
+
+// SeasonRepository persists the league-wide season state and the history of completed seasons
+type SeasonRepository interface {
+	GetCurrentWeek() (int, error)
+	UpdateCurrentWeek(week int) error
+	GetSeason() (int, error)
+	UpdateSeason(season int) error
+	GetSeasonState() (sim.SeasonState, error)
+	UpdateSeasonState(state sim.SeasonState) error
+	SaveSeasonSummary(summary *SeasonSummary) error
+	GetSeasonSummaries() ([]*SeasonSummary, error)
+	AddToAllTimeStandings(standings []*sim.LeagueTableEntry) error
+	GetAllTimeStandings() ([]*AllTimeEntry, error)
+	SaveHonours(honours []*Honour) error
+	GetHonours() ([]*Honour, error)
+	SaveContinentalEntrants(entrants []*ContinentalEntrant) error
+	GetContinentalEntrants(season int) ([]*ContinentalEntrant, error)
+	SaveRecords(records []*Record) error
+	GetRecords() ([]*Record, error)
+	SaveUnbeatenRuns(runs map[string]int) error
+	GetUnbeatenRuns() (map[string]int, error)
+	SavePredictionSnapshot(snapshot *PredictionSnapshot) error
+	GetPredictionHistory(season int) ([]*PredictionSnapshot, error)
+	GetArchivedAt() (*time.Time, error)
+	SetArchivedAt(archivedAt *time.Time) error
+	GetRevision() (int, error)
+	BumpRevision() (int, error)
+	GetModifiedAt() (*time.Time, error)
+	LockWeek(season, week int, lockedAt time.Time) error
+	GetLockedWeeks(season int) (map[int]time.Time, error)
+	ReplaceZones(zones []*sim.Zone) error
+	GetZones() ([]*sim.Zone, error)
+	ReplaceFeatures(features map[string]float64) error
+	GetFeatures() (map[string]float64, error)
+	GetOrdersOpenedAt() (*time.Time, error)
+	UpdateOrdersOpenedAt(openedAt time.Time) error
+	GetSport() (string, error)
+	UpdateSport(sport string) error
+	GetHandicap() (sim.Handicap, error)
+	UpdateHandicap(handicap sim.Handicap) error
+	PurgeLeague() error
+}
+
+// CompetitionRepository persists cup draws and one-off matches outside the league
+type CompetitionRepository interface {
+	SaveCupDraw(draw *CupDraw) error
+	GetCupDraws(competitionId string) ([]*CupDraw, error)
+	SaveShowpieceMatch(match *sim.ShowpieceMatch) error
+	GetShowpieceMatches() ([]*sim.ShowpieceMatch, error)
+	GetExtraTimeMatches(season int) ([]*sim.ShowpieceMatch, error)
+}
+
+// PredictionGameRepository persists players' forecasts and profiles for the prediction game
+type PredictionGameRepository interface {
+	SaveUserPrediction(prediction *UserPrediction) error
+	GetUserPredictions(season int) ([]*UserPrediction, error)
+	SavePlayerProfile(profile *PlayerProfile) error
+	GetPlayerProfile(player string) (*PlayerProfile, error)
+}
+
+// TenantRepository persists the registry of leagues hosted in tenancy mode
+type TenantRepository interface {
+	SaveTenant(tenant *Tenant) error
+	GetTenants() ([]*Tenant, error)
+	DeleteTenant(id string) error
+}
+
+// LockManager serializes league mutations across every instance sharing the storage
+type LockManager interface {
+	LockLeague() (unlock func(), err error)
+}
+
+// TransactionManager runs a unit of work atomically across repositories.
+// The StorageService handed to fn is bound to the transaction; ctx carries the
+// trace the transaction's spans belong to.
+type TransactionManager interface {
+	WithinTransaction(ctx context.Context, fn func(tx StorageService) error) error
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sort"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// ScheduleDifficulty summarises how hard a team's remaining fixtures are
+type ScheduleDifficulty struct {
+	TeamName                string
+	RemainingMatches        int
+	RemainingHome           int
+	RemainingAway           int
+	AverageOpponentStrength float64 // away fixtures count the opponent's home advantage
+	DifficultyRank          int     // 1 = hardest run-in
+}
+
+// computeScheduleDifficulty rates each team's unplayed fixtures, hardest first
+func computeScheduleDifficulty(league *sim.League) []*ScheduleDifficulty {
+	entries := make(map[string]*ScheduleDifficulty)
+	totals := make(map[string]float64)
+	difficulty := []*ScheduleDifficulty{}
+	for _, team := range league.Teams {
+		entry := &ScheduleDifficulty{TeamName: team.TeamName}
+		entries[team.TeamName] = entry
+		difficulty = append(difficulty, entry)
+	}
+
+	config := sim.LeagueConfig(league)
+	for _, match := range league.Matches {
+		if match.Played {
+			continue
+		}
+		home := entries[match.HomeTeam.TeamName]
+		away := entries[match.AwayTeam.TeamName]
+		if home == nil || away == nil {
+			continue
+		}
+
+		// Visiting a team is harder than hosting it by the simulator's home advantage
+		home.RemainingMatches++
+		home.RemainingHome++
+		totals[home.TeamName] += float64(match.AwayTeam.TeamStrength)
+
+		away.RemainingMatches++
+		away.RemainingAway++
+		totals[away.TeamName] += float64(match.HomeTeam.TeamStrength) + sim.HomeAdvantage*sim.HomeAdvantageFactor(match, config)
+	}
+
+	for _, entry := range difficulty {
+		if entry.RemainingMatches > 0 {
+			entry.AverageOpponentStrength = totals[entry.TeamName] / float64(entry.RemainingMatches)
+		}
+	}
+
+	sort.SliceStable(difficulty, func(i, j int) bool {
+		return difficulty[i].AverageOpponentStrength > difficulty[j].AverageOpponentStrength
+	})
+	for i, entry := range difficulty {
+		entry.DifficultyRank = i + 1
+	}
+
+	return difficulty
+}
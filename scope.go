@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// leagueScope is one league the server hosts and everything serving it: the league
+// itself, its storage and replica, and its caches. The server's own league has one,
+// and in tenancy mode so does each tenant's. Requests and jobs carry theirs in the
+// context, so tenants are served side by side.
+type leagueScope struct {
+	// mu guards the league in place: whoever holds lockLeague has it for writing and
+	// readLeagueMiddleware has it for reading. The refresh job swaps in a reloaded
+	// league rather than changing it, so each handler loads it once and works on that.
+	mu      sync.RWMutex
+	league  atomic.Pointer[sim.League]
+	meta    atomic.Pointer[leagueMeta] // the league's metadata as its last writer left it, see publishMeta
+	storage StorageService
+	replica StorageService // serves the read paths when set; nil sends every read to storage
+	cache   ResponseCache
+	history *historyCache
+	stats   *statsCache
+	tenant  *Tenant // nil for the server's own league
+
+	// replicaRevision is the highest revision the replica has been seen at, so reads
+	// only ask it again once this instance knows of a later write
+	replicaRevision atomic.Int64
+}
+
+// serverScope is the server's own league, the one served outside tenancy mode
+var serverScope = newLeagueScope(nil)
+
+// newLeagueScope returns an empty scope for a tenant, or for the server when tenant is nil
+func newLeagueScope(tenant *Tenant) *leagueScope {
+	return &leagueScope{cache: NewMemoryResponseCache(), history: &historyCache{}, stats: &statsCache{}, tenant: tenant}
+}
+
+// League returns the scope's league as it stands
+func (s *leagueScope) League() *sim.League {
+	return s.league.Load()
+}
+
+// setLeague makes league the scope's league, e.g. after it has been reloaded
+func (s *leagueScope) setLeague(league *sim.League) {
+	if s.tenant != nil {
+		league.Tenant = s.tenant.Id
+	}
+	s.league.Store(league)
+	s.publishMeta()
+}
+
+// readLeague returns the scope's league held for reading, for code outside a request
+// that only reads it, and the function that lets it go
+func (s *leagueScope) readLeague() (*sim.League, func()) {
+	s.mu.RLock()
+	return s.League(), s.mu.RUnlock
+}
+
+// publishMeta records the league's metadata for the response headers, which may be
+// written while another request changes the league. Only a writer calls it.
+func (s *leagueScope) publishMeta() {
+	league := s.League()
+	s.meta.Store(&leagueMeta{
+		Revision:   league.Revision,
+		Season:     league.Season,
+		Week:       league.CurrentWeek,
+		ModifiedAt: league.ModifiedAt,
+	})
+}
+
+// readLeagueMiddleware holds the request's league for reading while a GET or HEAD
+// request is served, so it never sees a simulation half-way through. Other methods
+// change the league and take it for writing with lockLeague.
+func readLeagueMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		scope := requestScope(r)
+		scope.mu.RLock()
+		defer scope.mu.RUnlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+type scopeKey struct{}
+
+// withScope returns a context carrying scope
+func withScope(ctx context.Context, scope *leagueScope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// scopeFrom returns the scope a context carries, the server's own when it carries none
+func scopeFrom(ctx context.Context) *leagueScope {
+	if scope, ok := ctx.Value(scopeKey{}).(*leagueScope); ok {
+		return scope
+	}
+	return serverScope
+}
+
+// requestScope returns the scope of the league a request is served from
+func requestScope(r *http.Request) *leagueScope {
+	return scopeFrom(r.Context())
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// Iterative Elo fit: every pass moves each rating by ratingFitStep times the team's
+// average result above expectation, until no rating moves more than ratingFitTolerance
+// points or ratingFitMaxPasses is hit. ratingFitPriorDraws virtual draws against a
+// base-rated side keep a team with few or only one-sided results from running away.
+const (
+	ratingFitStep       = 400.0
+	ratingFitPriorDraws = 2.0
+	ratingFitMaxPasses  = 200
+	ratingFitTolerance  = 0.1
+)
+
+// HistoricalResult is one played match of a past season used to seed ratings
+type HistoricalResult struct {
+	HomeTeam  string `json:"home_team"`
+	AwayTeam  string `json:"away_team"`
+	HomeScore *int   `json:"home_score"`
+	AwayScore *int   `json:"away_score"`
+
+	row int // CSV data row or JSON position, for problems
+}
+
+// SeededRating is a team's fitted Elo rating and the strength it converts to
+type SeededRating struct {
+	TeamName string
+	Matches  int
+	Rating   float64
+	Strength int
+}
+
+// RatingFit is the outcome of fitting ratings to historical results
+type RatingFit struct {
+	Results   int
+	Passes    int
+	Converged bool
+	Ratings   []*SeededRating // highest rating first
+}
+
+// parseHistoricalResultsCSV reads results from CSV with a header row naming the columns
+// (home_team, away_team, home_score and away_score; other columns are ignored)
+func parseHistoricalResultsCSV(r io.Reader) ([]HistoricalResult, []ImportProblem, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("CSV is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+	for _, required := range []string{"home_team", "away_team", "home_score", "away_score"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV header must include a %q column", required)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	results := []HistoricalResult{}
+	problems := []ImportProblem{}
+	for i, record := range records[1:] {
+		result := HistoricalResult{HomeTeam: field(record, "home_team"), AwayTeam: field(record, "away_team"), row: i + 1}
+		readable := true
+		scores := []struct {
+			column string
+			target **int
+		}{{"home_score", &result.HomeScore}, {"away_score", &result.AwayScore}}
+		for _, score := range scores {
+			if value := field(record, score.column); value != "" {
+				parsed, err := strconv.Atoi(value)
+				if err != nil {
+					problems = append(problems, ImportProblem{Row: i + 1, Problem: score.column + " must be a number"})
+					readable = false
+					continue
+				}
+				*score.target = &parsed
+			}
+		}
+		if readable {
+			results = append(results, result)
+		}
+	}
+	return results, problems, nil
+}
+
+// validateHistoricalResults reports results that cannot be rated: missing teams, a
+// team against itself, and scores that are missing or negative
+func validateHistoricalResults(results []HistoricalResult) []ImportProblem {
+	problems := []ImportProblem{}
+	for _, result := range results {
+		switch {
+		case result.HomeTeam == "" || result.AwayTeam == "":
+			problems = append(problems, ImportProblem{Row: result.row, Problem: "home_team and away_team are required"})
+		case strings.EqualFold(result.HomeTeam, result.AwayTeam):
+			problems = append(problems, ImportProblem{Row: result.row, Problem: fmt.Sprintf("%s cannot play itself", result.HomeTeam)})
+		case result.HomeScore == nil || result.AwayScore == nil:
+			problems = append(problems, ImportProblem{Row: result.row, Problem: "home_score and away_score are required"})
+		case *result.HomeScore < 0 || *result.AwayScore < 0:
+			problems = append(problems, ImportProblem{Row: result.row, Problem: "scores cannot be negative"})
+		}
+	}
+	return problems
+}
+
+// readHistoricalResults loads results from a .csv file or a .json array of the same
+// keys, and rejects the file with every problem found
+func readHistoricalResults(path string) ([]HistoricalResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open historical results: %v", err)
+	}
+	defer file.Close()
+
+	var results []HistoricalResult
+	var problems []ImportProblem
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.NewDecoder(file).Decode(&results); err != nil {
+			return nil, fmt.Errorf("failed to parse historical results: %v", err)
+		}
+		for i := range results {
+			results[i].row = i + 1
+		}
+	} else if results, problems, err = parseHistoricalResultsCSV(file); err != nil {
+		return nil, fmt.Errorf("failed to parse historical results: %v", err)
+	}
+
+	problems = append(problems, validateHistoricalResults(results)...)
+	if len(problems) > 0 {
+		sort.SliceStable(problems, func(i, j int) bool { return problems[i].Row < problems[j].Row })
+		lines := make([]string, len(problems))
+		for i, problem := range problems {
+			lines[i] = fmt.Sprintf("row %d: %s", problem.Row, problem.Problem)
+		}
+		return nil, fmt.Errorf("invalid historical results:\n  %s", strings.Join(lines, "\n  "))
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no historical results in %s", path)
+	}
+	return results, nil
+}
+
+// strengthFromElo inverts initialEloRating, so a team seeded with the returned
+// strength starts the season at (close to) the fitted rating
+func strengthFromElo(rating float64) int {
+	strength := int(math.Round(85 + (rating-sim.EloBaseRating)/sim.EloPointsPerStrength))
+	return max(1, min(100, strength))
+}
+
+// fitEloRatings fits a rating per team to the results: the ratings at which every
+// team's margin-weighted results (see eloChange) match what the Elo expectation
+// predicts, home advantage included. Unlike replaying the results in order, the fit
+// does not depend on the order of the file. Teams are matched case-insensitively and
+// keep the name they first appear with.
+func fitEloRatings(results []HistoricalResult) *RatingFit {
+	names := make(map[string]string)
+	matches := make(map[string]int)
+	ratings := make(map[string]float64)
+	for _, result := range results {
+		for _, name := range []string{result.HomeTeam, result.AwayTeam} {
+			key := strings.ToLower(name)
+			if _, ok := names[key]; !ok {
+				names[key] = name
+				ratings[key] = sim.EloBaseRating
+			}
+			matches[key]++
+		}
+	}
+
+	fit := &RatingFit{Results: len(results)}
+	for fit.Passes < ratingFitMaxPasses && !fit.Converged {
+		fit.Passes++
+		surplus := make(map[string]float64, len(ratings))
+		weight := make(map[string]float64, len(ratings))
+		for key, rating := range ratings {
+			surplus[key] = float64(ratingFitPriorDraws * (0.5 - sim.EloExpectedScore(rating, sim.EloBaseRating, 0)))
+			weight[key] = ratingFitPriorDraws
+		}
+		for _, result := range results {
+			home, away := strings.ToLower(result.HomeTeam), strings.ToLower(result.AwayTeam)
+			multiplier := sim.EloMarginMultiplier(*result.HomeScore, *result.AwayScore)
+			difference := float64(multiplier * (sim.EloActualScore(*result.HomeScore, *result.AwayScore) - sim.EloExpectedScore(ratings[home], ratings[away], 1)))
+			surplus[home] += difference
+			surplus[away] -= difference
+			weight[home] += multiplier
+			weight[away] += multiplier
+		}
+
+		largest := 0.0
+		for key := range ratings {
+			step := float64(ratingFitStep*surplus[key]) / weight[key]
+			ratings[key] += step
+			largest = math.Max(largest, math.Abs(step))
+		}
+		fit.Converged = largest < ratingFitTolerance
+	}
+
+	for key, rating := range ratings {
+		fit.Ratings = append(fit.Ratings, &SeededRating{
+			TeamName: names[key],
+			Matches:  matches[key],
+			Rating:   math.Round(rating*10) / 10,
+			Strength: strengthFromElo(rating),
+		})
+	}
+	sort.Slice(fit.Ratings, func(i, j int) bool {
+		if fit.Ratings[i].Rating == fit.Ratings[j].Rating {
+			return fit.Ratings[i].TeamName < fit.Ratings[j].TeamName
+		}
+		return fit.Ratings[i].Rating > fit.Ratings[j].Rating
+	})
+	return fit
+}
+
+// seedRatingsPath returns the historical results new leagues are seeded from, set via
+// LEAGUE_SEED_RATINGS; empty keeps the configured strengths
+func seedRatingsPath() string {
+	return os.Getenv("LEAGUE_SEED_RATINGS")
+}
+
+// applySeedRatings sets the strength of every team found in the LEAGUE_SEED_RATINGS
+// history from its fitted rating, when a new league is created. Teams without
+// historical results keep their strength.
+func applySeedRatings(teams []*sim.Team) error {
+	path := seedRatingsPath()
+	if path == "" {
+		return nil
+	}
+	results, err := readHistoricalResults(path)
+	if err != nil {
+		return err
+	}
+	fit := fitEloRatings(results)
+
+	strengths := make(map[string]int)
+	for _, rating := range fit.Ratings {
+		strengths[strings.ToLower(rating.TeamName)] = rating.Strength
+	}
+	for _, team := range teams {
+		strength, ok := strengths[strings.ToLower(team.TeamName)]
+		if !ok {
+			log.Printf("No historical results for %s in %s, keeping strength %d", team.TeamName, path, team.TeamStrength)
+			continue
+		}
+		team.TeamStrength = strength
+	}
+	log.Printf("Seeded team strengths from %d historical results in %s (%d passes)", fit.Results, path, fit.Passes)
+	return nil
+}
+
+// writeSeededTeams writes the fitted strengths as CSV for POST /league/teams/import
+func writeSeededTeams(fit *RatingFit, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"name", "strength"}); err != nil {
+		return err
+	}
+	for _, rating := range fit.Ratings {
+		if err := writer.Write([]string{rating.TeamName, strconv.Itoa(rating.Strength)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// runSeedRatings implements `./main seed-ratings <results> [teams.csv]`: it fits
+// ratings to historical results, prints them and optionally writes a team import file
+func runSeedRatings(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: ./main seed-ratings <results.csv|results.json> [teams.csv]")
+		os.Exit(1)
+	}
+	results, err := readHistoricalResults(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fit := fitEloRatings(results)
+	convergence := "converged"
+	if !fit.Converged {
+		convergence = "did not converge"
+	}
+	fmt.Printf("Fitted %d teams to %d results in %d passes (%s)\n\n", len(fit.Ratings), fit.Results, fit.Passes, convergence)
+	fmt.Printf("%-25s %7s %8s %8s\n", "Team", "Matches", "Rating", "Strength")
+	for _, rating := range fit.Ratings {
+		fmt.Printf("%-25s %7d %8.1f %8d\n", asciiName(rating.TeamName), rating.Matches, rating.Rating, rating.Strength)
+	}
+
+	if len(args) > 1 {
+		if err := writeSeededTeams(fit, args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write seeded teams: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nWrote %s\n", args[1])
+	}
+}
@@ -1,249 +1,461 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	sim "github.com/Melotachi/GoLeagueMelo/league"
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// Global league instance for the HTTP server
-var globalLeague *League
-var storageService StorageService
+// Errors returned by the simulator service that map to specific HTTP statuses
+var (
+	errMatchNotFound      = errors.New("match not found")
+	errMatchAlreadyPlayed = errors.New("match has already been played")
+	errSeasonNotFinished  = errors.New("season still has unplayed matches")
+	errTeamNotFound       = errors.New("team not found")
+	errSeasonStarted      = errors.New("season has already started")
+	errInvalidTeamImport  = errors.New("invalid team import")
+	errTeamNameTaken      = errors.New("team name is already in use")
+	errShortCodeTaken     = errors.New("short code is already in use")
+)
+
+// defaultArchiveRetention is how long an archived league is kept before purge
+const defaultArchiveRetention = 30 * 24 * time.Hour
+
+// defaultRefreshInterval is how often an instance checks the database for writes made by others
+const defaultRefreshInterval = 5 * time.Second
 
 // SimulatorService interface for testing and business logic access
 type SimulatorService interface {
-	GetLeagueTable() []*LeagueTableEntry
+	GetLeagueTable() []*sim.LeagueTableEntry
 	SimulateNextWeek() error
 	SimulateAllMatches() error
-	GetMatches() []*Match
+	SimulateRemainingWeeks(progress func(week, totalWeeks int)) error
+	SimulateMatch(matchId int) (*sim.Match, error)
+	EditMatchResult(matchId, homeScore, awayScore int) (*sim.Match, error)
+	SetMatchVenue(matchId int, venue string) (*sim.Match, error)
+	GetMatches() []*sim.Match
+	EachMatch(fn func(match *sim.Match) error) error
+	ArchiveLeague() error
+	RestoreLeague() error
+	StartNewSeason() (*SeasonSummary, error)
+	UpdateTeamRatings(teamId int, update sim.TeamRatingsUpdate, changedBy string) (*sim.Team, []*sim.TeamAuditEntry, error)
+	ImportTeams(imported []TeamImport, replace bool) ([]*sim.Team, error)
+	RenameTeam(teamId int, newName string) (*sim.Team, error)
 }
 
 // LeagueSimulatorService implements SimulatorService
 type LeagueSimulatorService struct {
-	league *League
+	league       *sim.League
+	teams        TeamRepository
+	matches      MatchRepository
+	seasons      SeasonRepository
+	transactions TransactionManager // nil keeps the league in memory only
+	storage      StorageService     // reloads the league after a failed write; nil with transactions
+	ctx          context.Context    // trace context for spans; the request's when set via WithContext
+	overrideLock bool               // lets result edits through locked weeks; set via WithLockOverride
+}
+
+// NewLeagueSimulatorService creates a service persisting through the given storage
+func NewLeagueSimulatorService(league *sim.League, storage StorageService) *LeagueSimulatorService {
+	service := &LeagueSimulatorService{league: league, ctx: context.Background()}
+	if storage != nil {
+		service.teams = storage
+		service.matches = storage
+		service.seasons = storage
+		service.transactions = storage
+		service.storage = storage
+	}
+	return service
 }
 
-func NewLeagueSimulatorService(league *League) *LeagueSimulatorService {
-	return &LeagueSimulatorService{league: league}
+// WithContext makes the service's spans children of ctx, normally the request's
+func (s *LeagueSimulatorService) WithContext(ctx context.Context) *LeagueSimulatorService {
+	s.ctx = ctx
+	return s
 }
 
-func (s *LeagueSimulatorService) GetLeagueTable() []*LeagueTableEntry {
-	return s.league.LeagueTable
+// WithLockOverride lets the service edit results in locked weeks, for admins
+func (s *LeagueSimulatorService) WithLockOverride(override bool) *LeagueSimulatorService {
+	s.overrideLock = override
+	return s
 }
 
-func (s *LeagueSimulatorService) SimulateNextWeek() error {
-	// Find the next week to simulate
-	nextWeek := s.league.CurrentWeek + 1
-	hasMatches := false
+// persist runs fn with repositories bound to a single transaction and bumps the
+// shared revision so other instances reload; it is a no-op without storage
+func (s *LeagueSimulatorService) persist(fn func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error) error {
+	if s.transactions == nil {
+		return nil
+	}
 	
-	for _, match := range s.league.Matches {
-		if match.Week == nextWeek && !match.Played {
-			hasMatches = true
-			break
+	ctx, span := tracer.Start(s.ctx, "persist")
+	defer span.End()
+	
+	var revision int
+	var modifiedAt *time.Time
+	err := s.transactions.WithinTransaction(ctx, func(tx StorageService) error {
+		if err := fn(tx, tx, tx); err != nil {
+			return err
+		}
+		if err := tx.UpdateSeasonState(sim.SeasonStateOf(s.league)); err != nil {
+			return err
+		}
+		
+		// In debug mode a mutation that breaks an invariant is rolled back, and the
+		// league restored from storage
+		if invariantChecksEnabled() {
+			if report := checkLeagueIntegrity(s.league); !report.OK {
+				return invariantError(report)
+			}
 		}
+		
+		var err error
+		if revision, err = tx.BumpRevision(); err != nil {
+			return err
+		}
+		modifiedAt, err = tx.GetModifiedAt()
+		return err
+	})
+	if err != nil {
+		recordSpanError(span, err)
+		s.restoreLeague()
+		return err
 	}
 	
-	if !hasMatches {
-		return fmt.Errorf("no more matches to simulate")
+	s.league.Revision = revision
+	s.league.ModifiedAt = modifiedAt
+	// The response headers are to report the state the request left
+	if scope := scopeFrom(s.ctx); scope.League() == s.league {
+		scope.publishMeta()
+	}
+	return nil
+}
+
+// restoreLeague puts the league back as storage has it after a failed write. Callers
+// change the league before persisting it, so a rolled back transaction would otherwise
+// leave memory ahead of storage.
+func (s *LeagueSimulatorService) restoreLeague() {
+	stored, err := loadLeague(s.storage)
+	if err != nil {
+		// Leave the league looking stale, so whoever locks it next reloads it
+		log.Printf("Failed to reload the league after a failed write: %v", err)
+		s.league.Revision = -1
+		return
 	}
 	
-	weeklySimulator(s.league)
-	
-	// Update league table after simulation
-	updateLeagueTable(s.league)
-	
-	// Save updated data to database
-	if storageService != nil {
-		// Update current week
-		if err := storageService.UpdateCurrentWeek(s.league.CurrentWeek); err != nil {
-			return fmt.Errorf("failed to update current week: %v", err)
+	// The league is changed in place, since the caller and the scope both hold it
+	stored.Tenant = s.league.Tenant
+	*s.league = *stored
+}
+
+// saveOutsideLeague runs a write made outside the simulator service, such as a
+// showpiece or a player's profile, in one transaction with a revision bump, so
+// response caches, other instances and replica reads all see it. The context's
+// league takes the new revision only when no other instance wrote in between;
+// otherwise the refresh job reloads it. The caller holds lockLeague.
+func saveOutsideLeague(ctx context.Context, fn func(tx StorageService) error) error {
+	scope := scopeFrom(ctx)
+	var revision int
+	var modifiedAt *time.Time
+	err := scope.storage.WithinTransaction(ctx, func(tx StorageService) error {
+		if err := fn(tx); err != nil {
+			return err
 		}
 		
-		// Save match results and team updates
-		for _, match := range s.league.Matches {
-			if match.Week == s.league.CurrentWeek && match.Played {
-				if err := storageService.SaveMatchResult(match); err != nil {
-					return fmt.Errorf("failed to save match result: %v", err)
-				}
-			}
+		var err error
+		if revision, err = tx.BumpRevision(); err != nil {
+			return err
 		}
-		
-		// Update team statistics
+		modifiedAt, err = tx.GetModifiedAt()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	
+	if league := scope.League(); revision == league.Revision+1 {
+		league.Revision = revision
+		league.ModifiedAt = modifiedAt
+		scope.publishMeta()
+	}
+	return nil
+}
+
+// RepairTeamStats recomputes every team's statistics from the match results, the
+// source of truth, and rewrites the teams in one transaction. It returns the teams
+// whose statistics had drifted.
+func (s *LeagueSimulatorService) RepairTeamStats() ([]*TeamRepair, error) {
+	repairs := recomputeTeamStats(s.league)
+	if len(repairs) == 0 {
+		return repairs, nil
+	}
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
 		for _, team := range s.league.Teams {
-			if err := storageService.UpdateTeam(team); err != nil {
-				return fmt.Errorf("failed to update team: %v", err)
+			if err := teams.UpdateTeam(team); err != nil {
+				return err
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to repair team statistics: %v", err)
 	}
-	
-	return nil
+	return repairs, nil
 }
 
-func (s *LeagueSimulatorService) SimulateAllMatches() error {
-	// Calculate total weeks from matches
-	totalWeeks := 0
+// RepairLoadedLeague fixes the problems checkStartupData finds in a freshly loaded
+// league: matches sharing an ID are dropped after the first, the current week is
+// moved to the one the results give, and team statistics are recomputed. Stored
+// matches are rewritten from the league's when replaceMatches is set, which also
+// removes those referencing teams that no longer exist.
+func (s *LeagueSimulatorService) RepairLoadedLeague(replaceMatches bool) error {
+	seen := make(map[int]bool)
+	kept := s.league.Matches[:0]
 	for _, match := range s.league.Matches {
-		if match.Week > totalWeeks {
-			totalWeeks = match.Week
+		if !seen[match.MatchId] {
+			seen[match.MatchId] = true
+			kept = append(kept, match)
 		}
 	}
+	if len(kept) < len(s.league.Matches) {
+		replaceMatches = true
+	}
+	s.league.Matches = kept
+	s.league.CurrentWeek = expectedCurrentWeek(s.league)
+	recomputeTeamStats(s.league)
 	
-	// Simulate all remaining weeks
-	for week := s.league.CurrentWeek + 1; week <= totalWeeks; week++ {
-		weeklySimulator(s.league)
-		
-		// Save updated data to database after each week
-		if storageService != nil {
-			// Update current week
-			if err := storageService.UpdateCurrentWeek(s.league.CurrentWeek); err != nil {
-				return fmt.Errorf("failed to update current week: %v", err)
-			}
-			
-			// Save match results for this week
-			for _, match := range s.league.Matches {
-				if match.Week == s.league.CurrentWeek && match.Played {
-					if err := storageService.SaveMatchResult(match); err != nil {
-						return fmt.Errorf("failed to save match result: %v", err)
-					}
-				}
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		if replaceMatches {
+			if err := matches.ReplaceMatches(s.league.Matches); err != nil {
+				return err
 			}
-			
-			// Update team statistics
-			for _, team := range s.league.Teams {
-				if err := storageService.UpdateTeam(team); err != nil {
-					return fmt.Errorf("failed to update team: %v", err)
-				}
+		}
+		if err := seasons.UpdateCurrentWeek(s.league.CurrentWeek); err != nil {
+			return err
+		}
+		for _, team := range s.league.Teams {
+			if err := teams.UpdateTeam(team); err != nil {
+				return err
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to repair league: %v", err)
 	}
-	
-	// Update league table after all simulations
-	updateLeagueTable(s.league)
-	
 	return nil
 }
 
-func (s *LeagueSimulatorService) GetMatches() []*Match {
-	return s.league.Matches
+func (s *LeagueSimulatorService) GetLeagueTable() []*sim.LeagueTableEntry {
+	return s.league.LeagueTable
 }
 
-// HTTP Handlers
-
-// GET /league/table - Returns current league table in JSON format
-func getLeagueTableHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	
-	if err := json.NewEncoder(w).Encode(globalLeague.LeagueTable); err != nil {
-		http.Error(w, "Error encoding league table", http.StatusInternalServerError)
-		return
+func (s *LeagueSimulatorService) SimulateNextWeek() error {
+	simulated, err := s.playNextWeek()
+	if err != nil {
+		return err
 	}
+	notifyWeek(s.ctx, s.league, simulated)
+	return nil
 }
 
-// POST /league/next-week - Simulates next week and returns current table
-func simulateNextWeekHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+// playNextWeek simulates and saves the next week with fixtures, returning its results
+func (s *LeagueSimulatorService) playNextWeek() ([]*sim.Match, error) {
+	week, err := sim.NextWeek(s.league)
+	if err != nil {
+		return nil, err
+	}
 	
-	service := NewLeagueSimulatorService(globalLeague)
+	// Give up before touching the league if the request has already timed out
+	if err := s.ctx.Err(); err != nil {
+		return nil, fmt.Errorf("simulation cancelled: %v", err)
+	}
 	
-	if err := service.SimulateNextWeek(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	if err := sim.CheckDraft(s.league); err != nil {
+		return nil, err
+	}
+	if err := sim.CheckOrders(s.league, week, time.Now()); err != nil {
+		return nil, err
 	}
 	
-	if err := json.NewEncoder(w).Encode(globalLeague.LeagueTable); err != nil {
-		http.Error(w, "Error encoding league table", http.StatusInternalServerError)
-		return
+	simulated := []*sim.Match{}
+	for s.league.CurrentWeek < week {
+		simulated = append(simulated, s.simulateWeek()...)
+	}
+	
+	if err := s.persistSimulatedMatches(simulated); err != nil {
+		return nil, err
 	}
+	return simulated, nil
 }
 
-// POST /league/play-all - Simulates all remaining matches and returns final table
-func simulateAllMatchesHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	
-	service := NewLeagueSimulatorService(globalLeague)
+func (s *LeagueSimulatorService) SimulateAllMatches() error {
+	return s.SimulateRemainingWeeks(nil)
+}
+
+// SimulateRemainingWeeks plays out the season, calling progress (if set) after each week is saved
+func (s *LeagueSimulatorService) SimulateRemainingWeeks(progress func(week, totalWeeks int)) error {
+	if sim.SeasonStateOf(s.league) == sim.SeasonFinished {
+		return sim.ErrSeasonFinished
+	}
+	if err := sim.CheckDraft(s.league); err != nil {
+		return err
+	}
 	
-	if err := service.SimulateAllMatches(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	// Calculate total weeks from matches
+	totalWeeks := 0
+	for _, match := range s.league.Matches {
+		if match.Week > totalWeeks {
+			totalWeeks = match.Week
+		}
 	}
 	
-	if err := json.NewEncoder(w).Encode(globalLeague.LeagueTable); err != nil {
-		http.Error(w, "Error encoding league table", http.StatusInternalServerError)
-		return
+	// Simulate all remaining weeks, saving after each one
+	for s.league.CurrentWeek < totalWeeks {
+		// Stop between weeks once the request times out or the client goes away;
+		// every week already played has been saved
+		if err := s.ctx.Err(); err != nil {
+			sim.UpdateLeagueTable(s.league)
+			return fmt.Errorf("simulation stopped after week %d: %v", s.league.CurrentWeek, err)
+		}
+		// Human-controlled teams stop the run at the first week still waiting for them
+		if err := sim.CheckOrders(s.league, s.league.CurrentWeek+1, time.Now()); err != nil {
+			sim.UpdateLeagueTable(s.league)
+			return err
+		}
+		simulated := s.simulateWeek()
+		if err := s.persistSimulatedMatches(simulated); err != nil {
+			return err
+		}
+		notifyWeek(s.ctx, s.league, simulated)
+		if progress != nil {
+			progress(s.league.CurrentWeek, totalWeeks)
+		}
 	}
-}
-
-// GET /league/matches?week=<hafta_no> - Returns matches for specific week or all matches
-func getMatchesHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
 	
-	weekParam := r.URL.Query().Get("week")
+	// Update league table after all simulations
+	sim.UpdateLeagueTable(s.league)
 	
-	var matchesToReturn []*Match
+	return nil
+}
+
+// simulateWeek plays the next calendar week in memory, traced separately from saving it
+func (s *LeagueSimulatorService) simulateWeek() []*sim.Match {
+	_, span := tracer.Start(s.ctx, "simulate week")
+	defer span.End()
 	
-	if weekParam != "" {
-		week, err := strconv.Atoi(weekParam)
-		if err != nil {
-			http.Error(w, "Invalid week parameter", http.StatusBadRequest)
-			return
+	simulated := sim.WeeklySimulator(s.league)
+	span.SetAttributes(attribute.Int("league.week", s.league.CurrentWeek), attribute.Int("league.matches", len(simulated)))
+	return simulated
+}
+
+// persistSimulatedMatches saves the current week, the given match results and the
+// teams involved, and opens the next week for orders
+func (s *LeagueSimulatorService) persistSimulatedMatches(simulated []*sim.Match) error {
+	openedAt := time.Now().UTC()
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		// Update current week
+		if err := seasons.UpdateCurrentWeek(s.league.CurrentWeek); err != nil {
+			return fmt.Errorf("failed to update current week: %v", err)
+		}
+		if err := seasons.UpdateOrdersOpenedAt(openedAt); err != nil {
+			return err
 		}
 		
-		for _, match := range globalLeague.Matches {
-			if match.Week == week {
-				matchesToReturn = append(matchesToReturn, match)
+		// Save match results
+		for _, match := range simulated {
+			if err := matches.SaveMatchResult(match); err != nil {
+				return fmt.Errorf("failed to save match result: %v", err)
 			}
 		}
-	} else {
-		matchesToReturn = globalLeague.Matches
+		
+		// Update team statistics
+		for _, team := range s.league.Teams {
+			if err := teams.UpdateTeam(team); err != nil {
+				return fmt.Errorf("failed to update team: %v", err)
+			}
+		}
+		
+		// Appointments and sackings made after this week
+		if err := teams.SaveManagers(s.league.Managers); err != nil {
+			return fmt.Errorf("failed to save managers: %v", err)
+		}
+		
+		// Record how the title race looks after this week
+		if err := seasons.SavePredictionSnapshot(newPredictionSnapshot(s.league)); err != nil {
+			return fmt.Errorf("failed to save prediction history: %v", err)
+		}
+		
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 	
-	if err := json.NewEncoder(w).Encode(matchesToReturn); err != nil {
-		http.Error(w, "Error encoding matches", http.StatusInternalServerError)
-		return
-	}
+	s.league.OrdersOpenedAt = &openedAt
+	return nil
 }
 
-// GET /league/matches - Returns all matches and their results
-func getAllMatchesHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	
-	if err := json.NewEncoder(w).Encode(globalLeague.Matches); err != nil {
-		http.Error(w, "Error encoding matches", http.StatusInternalServerError)
-		return
+// SimulateMatch simulates a single unplayed match on demand, e.g. a postponed fixture
+func (s *LeagueSimulatorService) SimulateMatch(matchId int) (*sim.Match, error) {
+	var target *sim.Match
+	for _, match := range s.league.Matches {
+		if match.MatchId == matchId {
+			target = match
+			break
+		}
 	}
-}
-
-// PUT /league/matches/{id} - Edit match result
-func editMatchResultHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
 	
-	vars := mux.Vars(r)
-	matchIdStr := vars["id"]
+	if target == nil {
+		return nil, errMatchNotFound
+	}
 	
-	matchId, err := strconv.Atoi(matchIdStr)
-	if err != nil {
-		http.Error(w, "Invalid match ID", http.StatusBadRequest)
-		return
+	if target.Played {
+		return nil, errMatchAlreadyPlayed
 	}
 	
-	// Parse request body
-	var requestBody struct {
-		HomeScore int `json:"home_score"`
-		AwayScore int `json:"away_score"`
+	if err := sim.CheckDraft(s.league); err != nil {
+		return nil, err
+	}
+	if err := sim.CheckMatchOrders(s.league, target, time.Now()); err != nil {
+		return nil, err
 	}
 	
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+	config := sim.LeagueConfig(s.league)
+	sim.RefreshEloAdjustments(s.league, config)
+	sim.RefreshManagerBounces(s.league, config)
+	sim.RefreshMorale(s.league)
+	sim.RefreshFatigue(s.league, config, target.Week)
+	sim.PlayFixture(s.league, target, config)
+	
+	// Completing the last open fixture of a week moves the league on
+	sim.AdvanceCurrentWeek(s.league)
+	sim.UpdateLeagueTable(s.league)
+	
+	if err := s.persistSimulatedMatches([]*sim.Match{target}); err != nil {
+		return nil, err
 	}
 	
+	return target, nil
+}
+
+// EditMatchResult replaces the score of a played match, or records one for an unplayed
+// match, e.g. a real result, and recalculates the table
+func (s *LeagueSimulatorService) EditMatchResult(matchId, homeScore, awayScore int) (*sim.Match, error) {
 	// Find the match
-	var targetMatch *Match
-	for _, match := range globalLeague.Matches {
+	var targetMatch *sim.Match
+	for _, match := range s.league.Matches {
 		if match.MatchId == matchId {
 			targetMatch = match
 			break
@@ -251,44 +463,59 @@ func editMatchResultHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	if targetMatch == nil {
-		http.Error(w, "Match not found", http.StatusNotFound)
-		return
+		return nil, errMatchNotFound
 	}
 	
-	if !targetMatch.Played {
-		http.Error(w, "Cannot edit unplayed match", http.StatusBadRequest)
-		return
+	if !s.overrideLock && weekLocked(s.league, targetMatch.Week) {
+		return nil, errWeekLocked
+	}
+	
+	sport := sim.LeagueSport(s.league)
+	if sport.Overtime > 0 && homeScore == awayScore {
+		return nil, sim.ErrLevelResult
 	}
 	
-	// Revert old match statistics
 	homeTeam := targetMatch.HomeTeam
 	awayTeam := targetMatch.AwayTeam
 	
-	// Revert goals
-	homeTeam.GoalsFor -= targetMatch.HomeTeamScore
-	awayTeam.GoalsFor -= targetMatch.AwayTeamScore
-	homeTeam.GoalsAgainst -= targetMatch.AwayTeamScore
-	awayTeam.GoalsAgainst -= targetMatch.HomeTeamScore
-	
-	// Revert points and match results
-	if targetMatch.HomeTeamScore > targetMatch.AwayTeamScore {
-		homeTeam.Wins--
-		awayTeam.Losses--
-		homeTeam.Points -= 3
-	} else if targetMatch.HomeTeamScore < targetMatch.AwayTeamScore {
-		awayTeam.Wins--
-		homeTeam.Losses--
-		awayTeam.Points -= 3
+	// An unplayed match has no statistics to revert; entering its result plays it
+	recorded := !targetMatch.Played
+	if recorded {
+		targetMatch.Played = true
 	} else {
-		homeTeam.Draws--
-		awayTeam.Draws--
-		homeTeam.Points -= 1
-		awayTeam.Points -= 1
+		// Revert goals
+		homeTeam.GoalsFor -= targetMatch.HomeTeamScore
+		awayTeam.GoalsFor -= targetMatch.AwayTeamScore
+		homeTeam.GoalsAgainst -= targetMatch.AwayTeamScore
+		awayTeam.GoalsAgainst -= targetMatch.HomeTeamScore
+		
+		// Revert points and match results
+		if targetMatch.HomeTeamScore > targetMatch.AwayTeamScore {
+			homeTeam.Wins--
+			awayTeam.Losses--
+		} else if targetMatch.HomeTeamScore < targetMatch.AwayTeamScore {
+			awayTeam.Wins--
+			homeTeam.Losses--
+		} else {
+			homeTeam.Draws--
+			awayTeam.Draws--
+		}
+		homePoints, awayPoints := sport.MatchPoints(targetMatch)
+		homeTeam.Points -= homePoints
+		awayTeam.Points -= awayPoints
 	}
 	
-	// Apply new match result
-	targetMatch.HomeTeamScore = requestBody.HomeScore
-	targetMatch.AwayTeamScore = requestBody.AwayScore
+	// Apply new match result, as one settled in regulation time; the simulated
+	// timeline no longer matches the score
+	targetMatch.HomeTeamScore = homeScore
+	targetMatch.AwayTeamScore = awayScore
+	targetMatch.DecidedBy = ""
+	targetMatch.Events = nil
+	if targetMatch.Explanation != nil {
+		targetMatch.Explanation.ResultEdited = true
+		targetMatch.Explanation.Summary = sim.SummarizeExplanation(targetMatch)
+	}
+	sim.GenerateCommentary(targetMatch)
 	
 	// Update goals
 	homeTeam.GoalsFor += targetMatch.HomeTeamScore
@@ -300,122 +527,4657 @@ func editMatchResultHandler(w http.ResponseWriter, r *http.Request) {
 	if targetMatch.HomeTeamScore > targetMatch.AwayTeamScore {
 		homeTeam.Wins++
 		awayTeam.Losses++
-		homeTeam.Points += 3
 	} else if targetMatch.HomeTeamScore < targetMatch.AwayTeamScore {
 		awayTeam.Wins++
 		homeTeam.Losses++
-		awayTeam.Points += 3
 	} else {
 		homeTeam.Draws++
 		awayTeam.Draws++
-		homeTeam.Points += 1
-		awayTeam.Points += 1
 	}
+	homePoints, awayPoints := sport.MatchPoints(targetMatch)
+	homeTeam.Points += homePoints
+	awayTeam.Points += awayPoints
 	
 	// Update goal differences
 	homeTeam.GoalsDifference = homeTeam.GoalsFor - homeTeam.GoalsAgainst
 	awayTeam.GoalsDifference = awayTeam.GoalsFor - awayTeam.GoalsAgainst
 	
+	// Completing the last open fixture of a week moves the league on
+	if recorded {
+		sim.AdvanceCurrentWeek(s.league)
+	}
+	
 	// Update league table
-	updateLeagueTable(globalLeague)
+	sim.UpdateLeagueTable(s.league)
 	
 	// Save to database
-	if storageService != nil {
-		if err := storageService.SaveMatchResult(targetMatch); err != nil {
-			http.Error(w, fmt.Sprintf("Failed to save match: %v", err), http.StatusInternalServerError)
-			return
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		if recorded {
+			if err := seasons.UpdateCurrentWeek(s.league.CurrentWeek); err != nil {
+				return fmt.Errorf("failed to update current week: %v", err)
+			}
 		}
 		
-		if err := storageService.UpdateTeam(homeTeam); err != nil {
-			http.Error(w, fmt.Sprintf("Failed to update home team: %v", err), http.StatusInternalServerError)
-			return
+		if err := matches.SaveMatchResult(targetMatch); err != nil {
+			return fmt.Errorf("failed to save match: %v", err)
 		}
 		
-		if err := storageService.UpdateTeam(awayTeam); err != nil {
-			http.Error(w, fmt.Sprintf("Failed to update away team: %v", err), http.StatusInternalServerError)
-			return
+		if err := teams.UpdateTeam(homeTeam); err != nil {
+			return fmt.Errorf("failed to update home team: %v", err)
+		}
+		
+		if err := teams.UpdateTeam(awayTeam); err != nil {
+			return fmt.Errorf("failed to update away team: %v", err)
 		}
+		
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	
-	// Return updated league table
-	if err := json.NewEncoder(w).Encode(globalLeague.LeagueTable); err != nil {
-		http.Error(w, "Error encoding league table", http.StatusInternalServerError)
-		return
-	}
+	return targetMatch, nil
 }
 
-// setupRoutes configures all HTTP routes using gorilla/mux
-func setupRoutes() *mux.Router {
-	r := mux.NewRouter()
+// LockWeek locks a week's results against edits for the rest of the season
+func (s *LeagueSimulatorService) LockWeek(week int) (WeekLock, error) {
+	if !sim.WeekHasMatches(s.league, week) {
+		return WeekLock{}, errWeekNotFound
+	}
+	if _, ok := s.league.LockedWeeks[week]; ok {
+		return weekLockState(s.league, week), nil
+	}
 	
-	// API endpoints
-	r.HandleFunc("/league/table", getLeagueTableHandler).Methods("GET")
-	r.HandleFunc("/league/next-week", simulateNextWeekHandler).Methods("POST")
-	r.HandleFunc("/league/play-all", simulateAllMatchesHandler).Methods("POST")
-	r.HandleFunc("/league/matches", getMatchesHandler).Methods("GET")
-	r.HandleFunc("/league/matches/{id}", editMatchResultHandler).Methods("PUT")
+	lockedAt := time.Now().UTC()
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return seasons.LockWeek(s.league.Season, week, lockedAt)
+	})
+	if err != nil {
+		return WeekLock{}, fmt.Errorf("failed to lock week: %v", err)
+	}
 	
-	return r
+	if s.league.LockedWeeks == nil {
+		s.league.LockedWeeks = make(map[int]time.Time)
+	}
+	s.league.LockedWeeks[week] = lockedAt
+	return weekLockState(s.league, week), nil
 }
 
-// initializeLeague creates and initializes the global league instance
-func initializeLeague() {
-	// Initialize storage service (SQLite by default)
-	var err error
-	storageService, err = NewSQLStorageService("sqlite3", "./league.db")
+// SetZones replaces the league's zones and relabels the table; no zones go back to
+// the rules' qualification spots
+func (s *LeagueSimulatorService) SetZones(zones []*sim.Zone) error {
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return seasons.ReplaceZones(zones)
+	})
 	if err != nil {
-		log.Fatalf("Failed to initialize storage service: %v", err)
+		return fmt.Errorf("failed to save zones: %v", err)
 	}
 	
-	// Initialize database with teams and matches if needed
-	if err := storageService.(*SQLStorageService).InitializeTeamsAndMatches(); err != nil {
-		log.Fatalf("Failed to initialize database data: %v", err)
+	s.league.Zones = zones
+	if sim.Rules().Format != sim.FormatGroups {
+		sim.ApplyZones(sim.LeagueZones(s.league).Zones, s.league.LeagueTable)
 	}
-	
-	// Load data from database
-	teams, err := storageService.GetTeams()
+	return nil
+}
+
+// SetFeatures replaces the feature flag rollouts set for the league; none go back to
+// the simulation config's. They apply from the next simulated match.
+func (s *LeagueSimulatorService) SetFeatures(features map[string]float64) error {
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return seasons.ReplaceFeatures(features)
+	})
 	if err != nil {
-		log.Fatalf("Failed to load teams from database: %v", err)
+		return fmt.Errorf("failed to save feature flags: %v", err)
 	}
 	
-	matches, err := storageService.GetMatches()
-	if err != nil {
-		log.Fatalf("Failed to load matches from database: %v", err)
+	s.league.Features = features
+	return nil
+}
+
+// SetHandicap replaces the league's handicap before the season starts and fixes each
+// team's handicap from the current strengths
+func (s *LeagueSimulatorService) SetHandicap(handicap sim.Handicap) error {
+	for _, match := range s.league.Matches {
+		if match.Played {
+			return errSeasonStarted
+		}
 	}
 	
-	currentWeek, err := storageService.GetCurrentWeek()
+	handicaps := sim.TeamHandicaps(s.league.Teams, handicap)
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		if err := seasons.UpdateHandicap(handicap); err != nil {
+			return err
+		}
+		return teams.ReplaceTeamHandicaps(handicaps)
+	})
 	if err != nil {
-		log.Fatalf("Failed to load current week from database: %v", err)
-	}
-	
-	globalLeague = &League{
-		Teams:       teams,
-		Matches:     matches,
-		CurrentWeek: currentWeek,
-		LeagueTable: []*LeagueTableEntry{},
+		return fmt.Errorf("failed to save handicap: %v", err)
 	}
 	
-	// Initialize the league table
-	updateLeagueTable(globalLeague)
+	s.league.Handicap = handicap
+	s.league.Handicaps = handicaps
+	sim.UpdateLeagueTable(s.league)
+	return nil
 }
 
-// startHTTPServer starts the HTTP server on the specified port
-func startHTTPServer() {
-	// Initialize the league
-	initializeLeague()
-	
-	// Setup routes
-	router := setupRoutes()
+// SetMatchVenue moves an unplayed match to a neutral venue, behind closed doors, or
+// back to the home ground ("")
+func (s *LeagueSimulatorService) SetMatchVenue(matchId int, venue string) (*sim.Match, error) {
+	var target *sim.Match
+	for _, match := range s.league.Matches {
+		if match.MatchId == matchId {
+			target = match
+			break
+		}
+	}
+	
+	if target == nil {
+		return nil, errMatchNotFound
+	}
+	
+	if target.Played {
+		return nil, errMatchAlreadyPlayed
+	}
+	
+	target.Venue = venue
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return matches.SaveMatchResult(target)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save match venue: %v", err)
+	}
+	
+	return target, nil
+}
+
+// ClaimTeam hands a team to a player, who controls it with the returned token from
+// then on. The token is only ever returned here.
+func (s *LeagueSimulatorService) ClaimTeam(teamId int, player, mode string) (*sim.TeamController, error) {
+	var team *sim.Team
+	for _, candidate := range s.league.Teams {
+		if candidate.TeamId == teamId {
+			team = candidate
+			break
+		}
+	}
+	if team == nil {
+		return nil, errTeamNotFound
+	}
+	if _, ok := s.league.Controllers[teamId]; ok {
+		return nil, sim.ErrTeamControlled
+	}
+	
+	token, tokenHash, err := newControllerToken()
+	if err != nil {
+		return nil, err
+	}
+	controller := &sim.TeamController{
+		TeamId:    teamId,
+		Player:    player,
+		Mode:      mode,
+		ClaimedAt: time.Now().UTC(),
+		TokenHash: tokenHash,
+	}
+	
+	// A league that has never played a week starts taking orders with its first player
+	openOrders := s.league.OrdersOpenedAt == nil
+	err = s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		if err := teams.SaveTeamController(controller); err != nil {
+			return err
+		}
+		if openOrders {
+			return seasons.UpdateOrdersOpenedAt(controller.ClaimedAt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim team: %v", err)
+	}
+	
+	if s.league.Controllers == nil {
+		s.league.Controllers = make(map[int]*sim.TeamController)
+	}
+	s.league.Controllers[teamId] = controller
+	if openOrders {
+		s.league.OrdersOpenedAt = &controller.ClaimedAt
+	}
+	
+	claimed := *controller
+	claimed.TeamName = team.TeamName
+	claimed.Token = token
+	return &claimed, nil
+}
+
+// ReleaseTeam hands a team back to the simulator. Only its controller, by token, or
+// an admin may release it; its orders for unplayed matches no longer apply.
+func (s *LeagueSimulatorService) ReleaseTeam(teamId int, token string, admin bool) error {
+	controller, ok := s.league.Controllers[teamId]
+	if !ok {
+		return sim.ErrNotControlled
+	}
+	if !admin && controllerByToken(s.league, token) != controller {
+		return sim.ErrNotController
+	}
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return teams.DeleteTeamController(teamId)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release team: %v", err)
+	}
+	
+	delete(s.league.Controllers, teamId)
+	return nil
+}
+
+// SubmitOrders stores the orders of the controller the token belongs to for one of its
+// team's unplayed matches, replacing any it sent before. In hotseat mode the orders are
+// the result; once one side has reported it, the other can only confirm it.
+func (s *LeagueSimulatorService) SubmitOrders(matchId int, token string, orders *sim.MatchOrders) (*sim.MatchOrders, error) {
+	var target *sim.Match
+	for _, match := range s.league.Matches {
+		if match.MatchId == matchId {
+			target = match
+			break
+		}
+	}
+	if target == nil {
+		return nil, errMatchNotFound
+	}
+	if target.Played {
+		return nil, errMatchAlreadyPlayed
+	}
+	
+	controller := controllerByToken(s.league, token)
+	if controller == nil || controller.TeamId != target.HomeTeam.TeamId && controller.TeamId != target.AwayTeam.TeamId {
+		return nil, sim.ErrNotController
+	}
+	if err := sim.ValidateMatchOrders(controller.Mode, orders); err != nil {
+		return nil, err
+	}
+	if controller.Mode == sim.ControlHotseat {
+		if sim.LeagueSport(s.league).Overtime > 0 && *orders.HomeScore == *orders.AwayScore {
+			return nil, fmt.Errorf("%w: %v", sim.ErrInvalidOrders, sim.ErrLevelResult)
+		}
+		if reported := sim.ReportedResult(s.league, target); reported != nil && reported.TeamId != controller.TeamId &&
+			(*reported.HomeScore != *orders.HomeScore || *reported.AwayScore != *orders.AwayScore) {
+			return nil, fmt.Errorf("%w: %d-%d", sim.ErrResultDisputed, *reported.HomeScore, *reported.AwayScore)
+		}
+	}
+	
+	submitted := &sim.MatchOrders{
+		Season:      s.league.Season,
+		MatchId:     matchId,
+		TeamId:      controller.TeamId,
+		Player:      controller.Player,
+		Tactic:      orders.Tactic,
+		HomeScore:   orders.HomeScore,
+		AwayScore:   orders.AwayScore,
+		SubmittedAt: time.Now().UTC(),
+	}
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return matches.SaveMatchOrders(submitted)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save orders: %v", err)
+	}
+	
+	if existing := sim.MatchOrdersFor(s.league, matchId, controller.TeamId); existing != nil {
+		*existing = *submitted
+	} else {
+		s.league.Orders = append(s.league.Orders, submitted)
+	}
+	return submitted, nil
+}
+
+// StartDraft generates a player pool and starts a snake draft of the season's squads
+// in the given first-round order, or a random one. The simulator makes the picks of
+// teams without a controller straight away, so a league without players is drafted
+// at once.
+func (s *LeagueSimulatorService) StartDraft(order []int) (*sim.Draft, error) {
+	for _, match := range s.league.Matches {
+		if match.Played {
+			return nil, errSeasonStarted
+		}
+	}
+	if s.league.Draft != nil {
+		return nil, sim.ErrDraftExists
+	}
+	if len(order) == 0 {
+		order = sim.RandomDraftOrder(s.league)
+	}
+	
+	now := time.Now().UTC()
+	draft := &sim.Draft{
+		Season:        s.league.Season,
+		Order:         order,
+		Rounds:        sim.SquadSize(),
+		StartedAt:     now,
+		PickStartedAt: now,
+		Players:       sim.NewPlayerPool(sim.LeagueRandom(s.league), len(s.league.Teams)),
+	}
+	picks, changes := sim.AdvanceDraft(s.league, draft, now)
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		if err := teams.SaveDraft(draft); err != nil {
+			return err
+		}
+		return saveDraftProgress(s.league, teams, draft, picks, changes)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start draft: %v", err)
+	}
+	
+	s.league.Draft = draft
+	return draft, nil
+}
+
+// MakeDraftPick drafts a player for the team on the clock. The pick needs the team's
+// controller token, or an admin's; the simulator then makes any picks that follow
+// for teams without a controller.
+func (s *LeagueSimulatorService) MakeDraftPick(playerId int, token string, admin bool) (*sim.Draft, error) {
+	draft := s.league.Draft
+	if draft == nil {
+		return nil, sim.ErrDraftNotFound
+	}
+	if draft.CompletedAt != nil {
+		return nil, sim.ErrDraftComplete
+	}
+	
+	// Picks whose time ran out come first, so the clock decides whose turn it is
+	if err := s.AdvanceDraft(); err != nil {
+		return nil, fmt.Errorf("failed to save timed-out draft picks: %v", err)
+	}
+	if draft.CompletedAt != nil {
+		return nil, sim.ErrDraftComplete
+	}
+	
+	turn := sim.CurrentDraftTurn(s.league, draft)
+	if !admin {
+		controller := controllerByToken(s.league, token)
+		if controller == nil {
+			return nil, sim.ErrNotController
+		}
+		if controller.TeamId != turn.TeamId {
+			return nil, fmt.Errorf("%w: %s (%s) is on the clock", sim.ErrNotOnTheClock, turn.TeamName, turn.Player)
+		}
+	}
+	
+	var player *sim.DraftPlayer
+	for _, candidate := range draft.Players {
+		if candidate.PlayerId == playerId {
+			player = candidate
+		}
+	}
+	if player == nil {
+		return nil, fmt.Errorf("%w: no player %d in the pool", sim.ErrInvalidPick, playerId)
+	}
+	if err := sim.ValidateDraftPick(draft, turn.TeamId, player); err != nil {
+		return nil, err
+	}
+	
+	now := time.Now().UTC()
+	sim.PickDraftPlayer(draft, player, now, now, false)
+	following, changes := sim.AdvanceDraft(s.league, draft, now)
+	picks := append([]*sim.DraftPlayer{player}, following...)
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return saveDraftProgress(s.league, teams, draft, picks, changes)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save draft pick: %v", err)
+	}
+	return draft, nil
+}
+
+// AdvanceDraft makes the picks whose time has run out, see startDraftClockJob
+func (s *LeagueSimulatorService) AdvanceDraft() error {
+	draft := s.league.Draft
+	if draft == nil || draft.CompletedAt != nil {
+		return nil
+	}
+	
+	picks, changes := sim.AdvanceDraft(s.league, draft, time.Now().UTC())
+	if len(picks) == 0 {
+		return nil
+	}
+	return s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return saveDraftProgress(s.league, teams, draft, picks, changes)
+	})
+}
+
+// saveDraftProgress stores the draft's new picks and, once it is complete, the
+// ratings the teams got from their squads
+func saveDraftProgress(league *sim.League, teams TeamRepository, draft *sim.Draft, picks []*sim.DraftPlayer, changes []*sim.TeamAuditEntry) error {
+	if err := teams.SaveDraftPicks(draft, picks); err != nil {
+		return err
+	}
+	if draft.CompletedAt == nil {
+		return nil
+	}
+	for _, team := range league.Teams {
+		if err := teams.UpdateTeam(team); err != nil {
+			return err
+		}
+	}
+	if err := teams.ReplaceTeamHandicaps(league.Handicaps); err != nil {
+		return err
+	}
+	return teams.SaveTeamAudit(changes)
+}
+
+func (s *LeagueSimulatorService) GetMatches() []*sim.Match {
+	return s.league.Matches
+}
+
+// EachMatch calls fn with every match in week order. With storage the matches are
+// read from it a page at a time rather than from the league in memory; their teams
+// and referees are the league's own where it has them.
+func (s *LeagueSimulatorService) EachMatch(fn func(match *sim.Match) error) error {
+	if s.matches == nil {
+		for _, match := range s.league.Matches {
+			if err := fn(match); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	
+	teamsById := make(map[int]*sim.Team)
+	for _, team := range s.league.Teams {
+		teamsById[team.TeamId] = team
+	}
+	refereesById := make(map[int]*sim.Referee)
+	for _, referee := range s.league.Referees {
+		refereesById[referee.Id] = referee
+	}
+	return s.matches.EachMatch(matchPageSize, func(match *sim.Match) error {
+		if team, ok := teamsById[match.HomeTeam.TeamId]; ok {
+			match.HomeTeam = team
+		}
+		if team, ok := teamsById[match.AwayTeam.TeamId]; ok {
+			match.AwayTeam = team
+		}
+		if match.Referee != nil {
+			if referee, ok := refereesById[match.Referee.Id]; ok {
+				match.Referee = referee
+			}
+		}
+		return fn(match)
+	})
+}
+
+// ArchiveLeague soft-deletes the league, leaving it readable but read-only
+func (s *LeagueSimulatorService) ArchiveLeague() error {
+	if s.league.ArchivedAt != nil {
+		return nil
+	}
+	
+	now := time.Now().UTC()
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return seasons.SetArchivedAt(&now)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive league: %v", err)
+	}
+	s.league.ArchivedAt = &now
+	return nil
+}
+
+// RestoreLeague brings an archived league back into service
+func (s *LeagueSimulatorService) RestoreLeague() error {
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return seasons.SetArchivedAt(nil)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore league: %v", err)
+	}
+	s.league.ArchivedAt = nil
+	return nil
+}
+
+// StartNewSeason records the finished season in the history and resets the league for the next one
+func (s *LeagueSimulatorService) StartNewSeason() (*SeasonSummary, error) {
+	for _, match := range s.league.Matches {
+		if !match.Played {
+			return nil, errSeasonNotFinished
+		}
+	}
+	
+	summary := summarizeSeason(s.league)
+	completedAt := time.Now().UTC()
+	summary.CompletedAt = &completedAt
+	
+	// Fold the finished season into the record book before its results are cleared
+	records := make(map[string]*Record)
+	unbeatenRuns := make(map[string]int)
+	if s.seasons != nil {
+		stored, err := s.seasons.GetRecords()
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range stored {
+			records[record.Key] = record
+		}
+		if unbeatenRuns, err = s.seasons.GetUnbeatenRuns(); err != nil {
+			return nil, err
+		}
+	}
+	summary.Records = updateRecords(s.league, records, unbeatenRuns)
+	summary.Sackings = sim.ManagerSackings(s.league, summary.Season)
+	summary.Qualified = continentalQualifiers(s.league, summary.Standings, summary.Season+1)
+	summary.Notes = sim.SeasonEndNotes(summary.Season, summary.Standings)
+	
+	resetForNewSeason(s.league)
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		// The stored results are still the finished season's until the loop below
+		if err := matches.ArchiveSeasonMatches(summary.Season); err != nil {
+			return err
+		}
+		if err := seasons.SaveSeasonSummary(summary); err != nil {
+			return err
+		}
+		if err := seasons.AddToAllTimeStandings(summary.Standings); err != nil {
+			return err
+		}
+		if err := seasons.SaveHonours(leagueHonours(s.league, summary)); err != nil {
+			return err
+		}
+		if err := seasons.SaveContinentalEntrants(summary.Qualified); err != nil {
+			return err
+		}
+		if err := seasons.SaveRecords(summary.Records); err != nil {
+			return err
+		}
+		if err := seasons.SaveUnbeatenRuns(unbeatenRuns); err != nil {
+			return err
+		}
+		
+		// The end-of-season report lists every honour won this season, cups included
+		honours, err := seasons.GetHonours()
+		if err != nil {
+			return err
+		}
+		for _, honour := range honours {
+			if honour.Season == summary.Season {
+				summary.Honours = append(summary.Honours, honour)
+			}
+		}
+		
+		if err := seasons.UpdateSeason(s.league.Season); err != nil {
+			return err
+		}
+		if err := seasons.UpdateCurrentWeek(s.league.CurrentWeek); err != nil {
+			return err
+		}
+		if err := seasons.UpdateOrdersOpenedAt(completedAt); err != nil {
+			return err
+		}
+		if err := teams.ReplaceTeamHandicaps(s.league.Handicaps); err != nil {
+			return err
+		}
+		for _, team := range s.league.Teams {
+			if err := teams.UpdateTeam(team); err != nil {
+				return err
+			}
+		}
+		for _, match := range s.league.Matches {
+			if err := matches.SaveMatchResult(match); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start new season: %v", err)
+	}
+	
+	// The first week of the new season takes orders from now
+	s.league.OrdersOpenedAt = &completedAt
+	return summary, nil
+}
+
+// UpdateTeamRatings adjusts a team's ratings for future simulations and records who changed what
+func (s *LeagueSimulatorService) UpdateTeamRatings(teamId int, update sim.TeamRatingsUpdate, changedBy string) (*sim.Team, []*sim.TeamAuditEntry, error) {
+	var team *sim.Team
+	for _, candidate := range s.league.Teams {
+		if candidate.TeamId == teamId {
+			team = candidate
+			break
+		}
+	}
+	if team == nil {
+		return nil, nil, errTeamNotFound
+	}
+	
+	changes := sim.ApplyRatingsUpdate(team, update, changedBy)
+	if len(changes) == 0 {
+		return team, changes, nil
+	}
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		if err := teams.UpdateTeam(team); err != nil {
+			return err
+		}
+		return teams.SaveTeamAudit(changes)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update team ratings: %v", err)
+	}
+	
+	return team, changes, nil
+}
+
+// ImportTeams appends to or replaces the team set of an unstarted season and regenerates its fixtures.
+// When replacing, teams whose names already exist keep their IDs.
+func (s *LeagueSimulatorService) ImportTeams(imported []TeamImport, replace bool) ([]*sim.Team, error) {
+	for _, match := range s.league.Matches {
+		if match.Played {
+			return nil, errSeasonStarted
+		}
+	}
+	if err := sim.CheckDraft(s.league); err != nil {
+		return nil, err
+	}
+	
+	kept := []*sim.Team{}
+	if !replace {
+		kept = s.league.Teams
+	}
+	if err := validateTeamImport(imported, kept); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidTeamImport, err)
+	}
+	
+	existingByName := make(map[string]*sim.Team)
+	nextId := 1
+	for _, team := range s.league.Teams {
+		existingByName[strings.ToLower(team.TeamName)] = team
+		if team.TeamId >= nextId {
+			nextId = team.TeamId + 1
+		}
+	}
+	
+	teams := append([]*sim.Team{}, kept...)
+	for _, entry := range imported {
+		team := &sim.Team{
+			TeamName:       strings.TrimSpace(entry.Name),
+			TeamStrength:   entry.Strength,
+			Stadium:        entry.Stadium,
+			City:           entry.City,
+			TeamBranding:   entry.TeamBrandingUpdate.apply(sim.TeamBranding{}),
+			LocalizedNames: normalizeLocalizedNames(entry.LocalizedNames),
+		}
+		if existing, ok := existingByName[strings.ToLower(team.TeamName)]; ok && replace {
+			team.TeamId = existing.TeamId
+			team.AttackRating = existing.AttackRating
+			team.DefenseRating = existing.DefenseRating
+		} else {
+			team.TeamId = nextId
+			nextId++
+		}
+		teams = append(teams, team)
+	}
+	
+	sim.AssignSlugs(teams)
+	s.league.Teams = teams
+	s.league.Matches = sim.CreatePremierLeagueMatches(teams)
+	s.league.CurrentWeek = 0
+	sim.ScheduleKickoffs(s.league)
+	sim.EnsureReferees(s.league)
+	s.league.Handicaps = sim.TeamHandicaps(s.league.Teams, s.league.Handicap)
+	sim.UpdateLeagueTable(s.league)
+	
+	err := s.persist(func(teamRepo TeamRepository, matchRepo MatchRepository, seasons SeasonRepository) error {
+		// Clear the old fixtures first so removed teams are no longer referenced
+		if err := matchRepo.ReplaceMatches(nil); err != nil {
+			return err
+		}
+		if err := teamRepo.ReplaceTeams(teams); err != nil {
+			return err
+		}
+		if err := matchRepo.ReplaceMatches(s.league.Matches); err != nil {
+			return err
+		}
+		if err := teamRepo.ReplaceTeamHandicaps(s.league.Handicaps); err != nil {
+			return err
+		}
+		return seasons.UpdateCurrentWeek(0)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import teams: %v", err)
+	}
+	
+	return teams, nil
+}
+
+// RegenerateFixtures rebuilds the unplayed fixtures for the current teams, config and
+// rules. Without a confirmation token it only returns the plan; with the plan's token it
+// replaces the fixtures. It refuses plans that would orphan played results.
+func (s *LeagueSimulatorService) RegenerateFixtures(confirmation string) (*FixtureRegeneration, error) {
+	plan := planFixtureRegeneration(s.league)
+	switch {
+	case len(plan.Conflicts) > 0:
+		return plan, errFixtureConflict
+	case confirmation == "" || plan.ConfirmationToken == "":
+		return plan, nil
+	case confirmation != plan.ConfirmationToken:
+		return plan, errConfirmationMismatch
+	}
+	
+	s.league.Matches = plan.matches
+	sim.EnsureKickoffs(s.league)
+	sim.EnsureReferees(s.league)
+	sim.UpdateLeagueTable(s.league)
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return matches.ReplaceMatches(s.league.Matches)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to regenerate fixtures: %v", err)
+	}
+	
+	plan.Applied = true
+	return plan, nil
+}
+
+// ImportFixtures replaces the league's fixtures and results with an import, then
+// recomputes the team statistics and current week from the imported results
+func (s *LeagueSimulatorService) ImportFixtures(fixtures []FixtureImport) (*FixtureImportResult, error) {
+	result := checkFixtureImport(s.league, fixtures, nil)
+	if !result.Valid {
+		return result, errInvalidFixtureImport
+	}
+	
+	s.league.Matches = importedMatches(s.league, fixtures)
+	s.league.CurrentWeek = 0
+	sim.AdvanceCurrentWeek(s.league)
+	recomputeTeamStats(s.league)
+	sim.EnsureKickoffs(s.league)
+	sim.EnsureReferees(s.league)
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		if err := matches.ReplaceMatches(s.league.Matches); err != nil {
+			return err
+		}
+		for _, team := range s.league.Teams {
+			if err := teams.UpdateTeam(team); err != nil {
+				return err
+			}
+		}
+		return seasons.UpdateCurrentWeek(s.league.CurrentWeek)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import fixtures: %v", err)
+	}
+	
+	result.Applied = true
+	return result, nil
+}
+
+// RenameTeam gives a team a new name; its results, history and honours follow it
+func (s *LeagueSimulatorService) RenameTeam(teamId int, newName string) (*sim.Team, error) {
+	var team *sim.Team
+	for _, candidate := range s.league.Teams {
+		if candidate.TeamId == teamId {
+			team = candidate
+		} else if candidate.Slug == sim.Slugify(newName) {
+			return nil, errTeamNameTaken
+		}
+	}
+	if team == nil {
+		return nil, errTeamNotFound
+	}
+	if team.TeamName == newName {
+		return team, nil
+	}
+	
+	change := &TeamNameChange{
+		TeamId:    team.TeamId,
+		OldName:   team.TeamName,
+		NewName:   newName,
+		Season:    s.league.Season,
+		ChangedAt: time.Now().UTC(),
+	}
+	
+	for _, manager := range s.league.Managers {
+		if manager.TeamName == change.OldName {
+			manager.TeamName = newName
+		}
+	}
+	team.TeamName = newName
+	team.Slug = sim.Slugify(newName)
+	sim.UpdateLeagueTable(s.league)
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		if err := teams.UpdateTeam(team); err != nil {
+			return err
+		}
+		return teams.RenameTeam(change)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename team: %v", err)
+	}
+	
+	return team, nil
+}
+
+// UpdateTeamBranding changes a team's presentation metadata; ratings and history are untouched
+func (s *LeagueSimulatorService) UpdateTeamBranding(teamId int, update TeamBrandingUpdate) (*sim.Team, error) {
+	var team *sim.Team
+	for _, candidate := range s.league.Teams {
+		if candidate.TeamId == teamId {
+			team = candidate
+			break
+		}
+	}
+	if team == nil {
+		return nil, errTeamNotFound
+	}
+	
+	branding := update.apply(team.TeamBranding)
+	if branding.ShortCode != "" {
+		for _, candidate := range s.league.Teams {
+			if candidate != team && candidate.ShortCode == branding.ShortCode {
+				return nil, errShortCodeTaken
+			}
+		}
+	}
+	
+	team.TeamBranding = branding
+	sim.UpdateLeagueTable(s.league)
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return teams.UpdateTeam(team)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update team branding: %v", err)
+	}
+	
+	return team, nil
+}
+
+// UpdateTeamNames replaces a team's localized names
+func (s *LeagueSimulatorService) UpdateTeamNames(teamId int, names map[string]string) (*sim.Team, error) {
+	var team *sim.Team
+	for _, candidate := range s.league.Teams {
+		if candidate.TeamId == teamId {
+			team = candidate
+			break
+		}
+	}
+	if team == nil {
+		return nil, errTeamNotFound
+	}
+	
+	team.LocalizedNames = normalizeLocalizedNames(names)
+	
+	err := s.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		return teams.UpdateTeam(team)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update team names: %v", err)
+	}
+	
+	return team, nil
+}
+
+// HTTP Handlers
+
+// writeCachedJSON serves a read response from the cache, building and caching it on a miss.
+// The response carries a revision ETag, and a matching If-None-Match gets 304.
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, league *sim.League, key string, build func() interface{}) {
+	writeCachedJSONResult(w, r, league, key, func() (interface{}, error) {
+		return build(), nil
+	})
+}
+
+// writeCachedJSONResult is writeCachedJSON for a response that can fail to build. A
+// failed prediction model is answered with 502 Bad Gateway and nothing is cached.
+func writeCachedJSONResult(w http.ResponseWriter, r *http.Request, league *sim.League, key string, build func() (interface{}, error)) {
+	scope := requestScope(r)
+	
+	version := league.Revision
+	if notModified(w, r, key, version) {
+		return
+	}
+	if data, ok := scope.cache.Get(key, version); ok {
+		w.Header().Set("X-Cache", "HIT")
+		w.Write(data)
+		return
+	}
+	
+	response, err := build()
+	switch {
+	case errors.Is(err, errPredictionModel):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+	data = append(data, '\n')
+	
+	scope.cache.Set(key, version, data)
+	w.Header().Set("X-Cache", "MISS")
+	w.Write(data)
+}
+
+// GET /league/table - Returns current league table in JSON format.
+// With ?as_of_match={id} the table is recomputed from played matches up to and including that match.
+func getLeagueTableHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Vary", "Accept-Language")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	languages := acceptedLanguages(r)
+	links := linksEnabled(r)
+	
+	if asOfParam := r.URL.Query().Get("as_of_match"); asOfParam != "" {
+		asOfMatch, err := strconv.Atoi(asOfParam)
+		if err != nil {
+			http.Error(w, "Invalid as_of_match parameter", http.StatusBadRequest)
+			return
+		}
+		
+		found := false
+		for _, match := range league.Matches {
+			if match.MatchId == asOfMatch {
+				found = true
+				break
+			}
+		}
+		if !found {
+			http.Error(w, "Match not found", http.StatusNotFound)
+			return
+		}
+		
+		writeCachedJSON(w, r, league, "table:as_of_match:"+asOfParam+":lang:"+strings.Join(languages, ",")+linksCacheKey(links), func() interface{} {
+			table := sim.BuildLeagueTable(league, func(match *sim.Match) bool {
+				return match.MatchId <= asOfMatch
+			})
+			return tableResponse(league, table, languages, links)
+		})
+		return
+	}
+	
+	writeCachedJSON(w, r, league, "table:lang:"+strings.Join(languages, ",")+linksCacheKey(links), func() interface{} {
+		return tableResponse(league, league.LeagueTable, languages, links)
+	})
+}
+
+// tableResponse copies a table for a response, localized and with its links when enabled
+func tableResponse(league *sim.League, table []*sim.LeagueTableEntry, languages []string, links bool) []*sim.LeagueTableEntry {
+	localized := localizeTable(league, table, languages)
+	if links {
+		linkTable(league, localized)
+	}
+	return localized
+}
+
+// GET /league/table.png - Renders the current league table as a PNG image
+func getLeagueTableImageHandler(w http.ResponseWriter, r *http.Request) {
+	scope := requestScope(r)
+	league := scope.League()
+	languages := acceptedLanguages(r)
+	key := "table.png:lang:" + strings.Join(languages, ",")
+	version := league.Revision
+	if notModified(w, r, key, version) {
+		return
+	}
+	data, ok := scope.cache.Get(key, version)
+	if ok {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		title := fmt.Sprintf("Season %d, week %d", league.Season, league.CurrentWeek)
+		var err error
+		data, err = renderTablePNG(title, localizeTable(league, league.LeagueTable, languages))
+		if err != nil {
+			http.Error(w, "Error rendering table image", http.StatusInternalServerError)
+			return
+		}
+		scope.cache.Set(key, version, data)
+		w.Header().Set("X-Cache", "MISS")
+	}
+	
+	w.Header().Set("Vary", "Accept-Language")
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(data)
+}
+
+// GET /league/predictions - Returns championship probabilities for each team
+func getPredictionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	// Report which experimental models shaped the results behind these predictions
+	w.Header().Set("X-Feature-Flags", strings.Join(sim.ActiveFeatures(sim.LeagueConfig(league)), ","))
+	
+	// Predictions depend on the simulation config as well as the league state
+	writeCachedJSON(w, r, league, "predictions:"+sim.CurrentConfig().Fingerprint, func() interface{} {
+		return sim.PredictChampionship(league)
+	})
+}
+
+// Bootstrap is everything a dashboard shows on first load, in one response
+type Bootstrap struct {
+	Season       int
+	CurrentWeek  int
+	Teams        []*sim.Team
+	Table        []*sim.LeagueTableEntry
+	FixturesWeek int      // the next week with fixtures to play, 0 once the season is over
+	Fixtures     []*sim.Match // that week's fixtures
+	ResultsWeek  int      // the last week played, 0 before the first
+	Results      []*sim.Match // that week's results
+	Predictions  map[string]float64
+	Zones        []*sim.Zone // the table's zones, for a legend
+}
+
+// bootstrapWeeks returns the next week with unplayed fixtures and the last week
+// with fixtures up to the current week, skipping blank break weeks; 0 for none
+func bootstrapWeeks(league *sim.League) (fixturesWeek, resultsWeek int) {
+	for _, match := range league.Matches {
+		if !match.Played && (fixturesWeek == 0 || match.Week < fixturesWeek) {
+			fixturesWeek = match.Week
+		}
+		if match.Week <= league.CurrentWeek && match.Week > resultsWeek {
+			resultsWeek = match.Week
+		}
+	}
+	return fixturesWeek, resultsWeek
+}
+
+// GET /league/bootstrap - Returns the teams, table, next fixtures, last results and
+// predictions together for a client's first page load
+func getBootstrapHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Vary", "Accept-Language")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	w.Header().Set("X-Feature-Flags", strings.Join(sim.ActiveFeatures(sim.LeagueConfig(league)), ","))
+	
+	location, ok := kickoffLocation(w, r)
+	if !ok {
+		return
+	}
+	languages := acceptedLanguages(r)
+	links := linksEnabled(r)
+	
+	key := "bootstrap:lang:" + strings.Join(languages, ",") + ":tz:" + location.String() + ":config:" + sim.CurrentConfig().Fingerprint + linksCacheKey(links)
+	writeCachedJSON(w, r, league, key, func() interface{} {
+		fixturesWeek, resultsWeek := bootstrapWeeks(league)
+		bootstrap := &Bootstrap{
+			Season:       league.Season,
+			CurrentWeek:  league.CurrentWeek,
+			Teams:        make([]*sim.Team, len(league.Teams)),
+			Table:        tableResponse(league, league.LeagueTable, languages, links),
+			FixturesWeek: fixturesWeek,
+			Fixtures:     []*sim.Match{},
+			ResultsWeek:  resultsWeek,
+			Results:      []*sim.Match{},
+			Predictions:  sim.PredictChampionship(league),
+			Zones:        sim.LeagueZones(league).Zones,
+		}
+		for i, team := range league.Teams {
+			bootstrap.Teams[i] = localizeTeam(team, languages)
+		}
+		for _, match := range league.Matches {
+			switch match.Week {
+			case fixturesWeek:
+				bootstrap.Fixtures = append(bootstrap.Fixtures, localizeKickoff(match, location))
+			case resultsWeek:
+				bootstrap.Results = append(bootstrap.Results, localizeKickoff(match, location))
+			}
+		}
+		sim.MarkSixPointers(league, bootstrap.Fixtures)
+		if links {
+			linkMatches(bootstrap.Fixtures)
+			linkMatches(bootstrap.Results)
+		}
+		return bootstrap
+	})
+}
+
+// GET /league/predictions/history?season=N - Returns the championship probabilities
+// recorded after each week of a season, the current one by default
+func getPredictionHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	season := league.Season
+	if seasonParam := r.URL.Query().Get("season"); seasonParam != "" {
+		parsed, err := strconv.Atoi(seasonParam)
+		if err != nil {
+			http.Error(w, "Invalid season parameter", http.StatusBadRequest)
+			return
+		}
+		season = parsed
+	}
+	
+	history, err := scope.readStorage().GetPredictionHistory(season)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		http.Error(w, "Error encoding prediction history", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/predictions/compare?models=heuristic,poisson,elo&iterations=N - Runs several
+// prediction models on the current state and returns their probabilities side by side
+func comparePredictionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	iterations, err := parsePredictionIterations(r.URL.Query().Get("iterations"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	models := defaultPredictionModels()
+	if modelsParam := r.URL.Query().Get("models"); modelsParam != "" {
+		models = []string{}
+		for _, model := range strings.Split(modelsParam, ",") {
+			model = strings.TrimSpace(model)
+			if _, ok := predictionModels[model]; !ok {
+				http.Error(w, fmt.Sprintf("Unknown prediction model %q", model), http.StatusBadRequest)
+				return
+			}
+			models = append(models, model)
+		}
+	}
+	
+	key := "predictions:compare:" + strings.Join(models, ",") + ":" + strconv.Itoa(iterations) + ":" + sim.CurrentConfig().Fingerprint
+	writeCachedJSONResult(w, r, league, key, func() (interface{}, error) {
+		return comparePredictions(r.Context(), league, models, iterations)
+	})
+}
+
+// GET /league/predictions/position-matrix - Returns every team's chance of each finishing position
+func getPositionMatrixHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	iterations, err := parsePredictionIterations(r.URL.Query().Get("iterations"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	model := r.URL.Query().Get("model")
+	if model == "" {
+		model = ModelPoisson
+	}
+	if _, ok := predictionModels[model].(PositionPredictor); !ok {
+		http.Error(w, fmt.Sprintf("Prediction model %q does not predict finishing positions", model), http.StatusBadRequest)
+		return
+	}
+	
+	key := "predictions:positions:" + model + ":" + strconv.Itoa(iterations) + ":" + sim.CurrentConfig().Fingerprint
+	writeCachedJSONResult(w, r, league, key, func() (interface{}, error) {
+		return buildPositionMatrix(r.Context(), league, model, iterations)
+	})
+}
+
+// GET /league/features - Returns the feature flags and an A/B comparison of matches played with and without each
+func getFeaturesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildFeatureReport(league)); err != nil {
+		http.Error(w, "Error encoding feature report", http.StatusInternalServerError)
+		return
+	}
+}
+
+// PUT /league/features - Replaces the league's own feature flag rollouts; an empty object goes back to the config's
+func updateFeaturesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	var features map[string]float64
+	if err := json.NewDecoder(r.Body).Decode(&features); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	if err := sim.ValidateFeatures(features); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	if err := service.SetFeatures(features); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildFeatureReport(league)); err != nil {
+		http.Error(w, "Error encoding feature report", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/table/xg - Returns expected goals for and against per team
+func getXGTableHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildXGTable(league)); err != nil {
+		http.Error(w, "Error encoding xG table", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/power-rankings - Returns teams ranked by Elo rating with weekly movement
+func getPowerRankingsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildPowerRankings(league)); err != nil {
+		http.Error(w, "Error encoding power rankings", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/schedule-difficulty - Returns the average strength of each team's remaining opponents
+func getScheduleDifficultyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(computeScheduleDifficulty(league)); err != nil {
+		http.Error(w, "Error encoding schedule difficulty", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/next-week - Simulates next week and returns current table
+func simulateNextWeekHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	err = service.SimulateNextWeek()
+	switch {
+	case errors.Is(err, sim.ErrSeasonFinished):
+		http.Error(w, "Season is finished; start the next one with POST /league/new-season", http.StatusConflict)
+		return
+	case errors.Is(err, sim.ErrAwaitingOrders), errors.Is(err, sim.ErrDraftInProgress):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if err := encodeJSON(r.Context(), w, league.LeagueTable); err != nil {
+		http.Error(w, "Error encoding league table", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/play-all - Simulates all remaining matches and returns final table.
+// With ?async=true it queues a background job and returns it with 202 Accepted;
+// with ?stream=true it streams per-week progress as server-sent events.
+func simulateAllMatchesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	if r.URL.Query().Get("stream") == "true" {
+		streamPlayAll(r.Context(), w)
+		return
+	}
+	
+	if r.URL.Query().Get("async") == "true" {
+		league, done := scope.readLeague()
+		rejected := rejectIfArchived(w, league) || rejectIfFinished(w, league)
+		done()
+		if rejected {
+			return
+		}
+		
+		job, err := jobQueue.Submit(scope, "play-all", playAllJob)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		
+		w.Header().Set("Location", fmt.Sprintf("/jobs/%d", job.JobId))
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(job); err != nil {
+			http.Error(w, "Error encoding job", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	err = service.SimulateAllMatches()
+	switch {
+	case errors.Is(err, sim.ErrSeasonFinished):
+		http.Error(w, "Season is finished; start the next one with POST /league/new-season", http.StatusConflict)
+		return
+	case errors.Is(err, sim.ErrAwaitingOrders), errors.Is(err, sim.ErrDraftInProgress):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if err := encodeJSON(r.Context(), w, league.LeagueTable); err != nil {
+		http.Error(w, "Error encoding league table", http.StatusInternalServerError)
+		return
+	}
+}
+
+// PlayAllProgress is streamed after each simulated week of play-all
+type PlayAllProgress struct {
+	Week       int
+	TotalWeeks int
+	Table      []*sim.LeagueTableEntry
+}
+
+// streamPlayAll simulates the remaining season, sending a "progress" event per week
+// and a final "done" (or "error") event with the table
+func streamPlayAll(ctx context.Context, w http.ResponseWriter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	
+	scope := scopeFrom(ctx)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	if rejectIfArchived(w, league) || rejectIfFinished(w, league) {
+		return
+	}
+	
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	
+	sendEvent := func(event string, payload interface{}) {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			log.Printf("Failed to encode %s event: %v", event, err)
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		flusher.Flush()
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(ctx)
+	err = service.SimulateRemainingWeeks(func(week, totalWeeks int) {
+		sendEvent("progress", PlayAllProgress{Week: week, TotalWeeks: totalWeeks, Table: league.LeagueTable})
+	})
+	if err != nil {
+		sendEvent("error", map[string]string{"error": err.Error()})
+		return
+	}
+	
+	sendEvent("done", league.LeagueTable)
+}
+
+// playAllJob plays out the season in the background, reporting progress per week
+func playAllJob(ctx context.Context, progress func(done, total int)) (interface{}, error) {
+	scope := scopeFrom(ctx)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	
+	league := scope.League()
+	if league.ArchivedAt != nil {
+		return nil, fmt.Errorf("league is archived and read-only; restore it first")
+	}
+	
+	// Jobs outlive the request that queued them, so each run is its own trace
+	ctx, span := tracer.Start(ctx, "play-all job")
+	defer span.End()
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(ctx)
+	if err := service.SimulateRemainingWeeks(progress); err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	return league.LeagueTable, nil
+}
+
+// POST /league/new-season - Archives the finished season's standings and starts the next season
+func startNewSeasonHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	summary, err := service.StartNewSeason()
+	switch {
+	case errors.Is(err, errSeasonNotFinished):
+		http.Error(w, "Season still has unplayed matches", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"completed_season": summary,
+		"season":           league.Season,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding season summary", http.StatusInternalServerError)
+		return
+	}
+}
+
+// seasonSummaries returns every completed season followed by the season in progress
+func (s *leagueScope) seasonSummaries(league *sim.League) ([]*SeasonSummary, error) {
+	summaries, err := s.storedSeasonSummaries(league)
+	if err != nil {
+		return nil, err
+	}
+	// The cached slice is shared, so the season in progress goes on a copy
+	return append(summaries[:len(summaries):len(summaries)], summarizeSeason(league)), nil
+}
+
+// GET /league/seasons - Returns the completed seasons and the season in progress
+func getSeasonsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	summaries, err := scope.seasonSummaries(scope.League())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(summaries); err != nil {
+		http.Error(w, "Error encoding seasons", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/seasons/{season}/matches - Returns a season's matches, from the archive once it is completed
+func getSeasonMatchesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	season, err := strconv.Atoi(mux.Vars(r)["season"])
+	if err != nil {
+		http.Error(w, "Invalid season", http.StatusBadRequest)
+		return
+	}
+	
+	week := 0
+	if weekParam := r.URL.Query().Get("week"); weekParam != "" {
+		if week, err = strconv.Atoi(weekParam); err != nil {
+			http.Error(w, "Invalid week parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	
+	location, ok := kickoffLocation(w, r)
+	if !ok {
+		return
+	}
+	
+	matches := league.Matches
+	if season != league.Season {
+		if matches, err = scope.storedSeasonMatches(league, season); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(matches) == 0 {
+			http.Error(w, "No archived matches for this season", http.StatusNotFound)
+			return
+		}
+	}
+	
+	matchesToReturn := []*sim.Match{}
+	for _, match := range matches {
+		if week == 0 || match.Week == week {
+			matchesToReturn = append(matchesToReturn, match)
+		}
+	}
+	
+	if err := json.NewEncoder(w).Encode(localizeKickoffs(matchesToReturn, location)); err != nil {
+		http.Error(w, "Error encoding matches", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/seasons/compare?a=2023&b=2024 - Returns per-team deltas and league-wide trends between two seasons
+func compareSeasonsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	seasonA, errA := strconv.Atoi(r.URL.Query().Get("a"))
+	seasonB, errB := strconv.Atoi(r.URL.Query().Get("b"))
+	if errA != nil || errB != nil {
+		http.Error(w, "Parameters a and b must be season numbers", http.StatusBadRequest)
+		return
+	}
+	
+	summaries, err := scope.seasonSummaries(scope.League())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	var summaryA, summaryB *SeasonSummary
+	for _, summary := range summaries {
+		if summary.Season == seasonA {
+			summaryA = summary
+		}
+		if summary.Season == seasonB {
+			summaryB = summary
+		}
+	}
+	if summaryA == nil || summaryB == nil {
+		http.Error(w, "Season not found", http.StatusNotFound)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(compareSeasons(summaryA, summaryB)); err != nil {
+		http.Error(w, "Error encoding season comparison", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/all-time-table - Returns cumulative standings over every completed season plus the current one
+func getAllTimeTableHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	stored, err := scope.storedAllTimeStandings(league)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(buildAllTimeTable(stored, league.LeagueTable)); err != nil {
+		http.Error(w, "Error encoding all-time table", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/teams/{id}/honours - Returns a team's titles, cup wins and runner-up finishes
+func getTeamHonoursHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	teamId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	
+	var team *sim.Team
+	for _, candidate := range league.Teams {
+		if candidate.TeamId == teamId {
+			team = candidate
+			break
+		}
+	}
+	if team == nil {
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	}
+	
+	honours, err := scope.storedHonours(league)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	teamHonours := []*Honour{}
+	for _, honour := range honours {
+		if honour.TeamId == teamId {
+			teamHonours = append(teamHonours, honour)
+		}
+	}
+	
+	if err := json.NewEncoder(w).Encode(buildTeamHonours(team, teamHonours)); err != nil {
+		http.Error(w, "Error encoding honours", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/records/all-time - Returns the all-time record book updated at each season end
+func getAllTimeRecordsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	records, err := scope.storedRecords(scope.League())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []*Record{}
+	}
+	
+	if err := json.NewEncoder(w).Encode(records); err != nil {
+		http.Error(w, "Error encoding records", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/teams/{id}/magic-numbers - Returns the points a team needs to guarantee the title, top 4 or safety
+func getTeamMagicNumbersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	teamId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	
+	team, _ := findTeamByReference(league.Teams, strconv.Itoa(teamId))
+	if team == nil {
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	}
+	
+	key := "teams:magic-numbers:" + strconv.Itoa(teamId)
+	writeCachedJSONResult(w, r, league, key, func() (interface{}, error) {
+		return computeMagicNumbers(league, team)
+	})
+}
+
+// PATCH /league/teams/{id} - Adjusts a team's strength, attack or defense rating mid-season
+func updateTeamRatingsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	teamId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	
+	var requestBody struct {
+		sim.TeamRatingsUpdate
+		ChangedBy string `json:"changed_by"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	if err := requestBody.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if requestBody.ChangedBy == "" {
+		requestBody.ChangedBy = "anonymous"
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	team, changes, err := service.UpdateTeamRatings(teamId, requestBody.TeamRatingsUpdate, requestBody.ChangedBy)
+	switch {
+	case errors.Is(err, errTeamNotFound):
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"team":        team,
+		"changes":     changes,
+		"predictions": sim.PredictChampionship(league),
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding team", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/teams/{id}/audit - Returns the history of manual rating changes for a team
+func getTeamAuditHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	teamId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	
+	entries, err := scope.readStorage().GetTeamAudit(teamId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		http.Error(w, "Error encoding audit entries", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/teams/import - Appends or replaces teams from a JSON array or CSV and regenerates fixtures
+func importTeamsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = "append"
+	}
+	if mode != "append" && mode != "replace" {
+		http.Error(w, "mode must be append or replace", http.StatusBadRequest)
+		return
+	}
+	
+	var imported []TeamImport
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		var err error
+		if imported, err = parseTeamImportCSV(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&imported); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	teams, err := service.ImportTeams(imported, mode == "replace")
+	switch {
+	case errors.Is(err, errSeasonStarted):
+		http.Error(w, "Teams can only be imported before the season starts", http.StatusConflict)
+		return
+	case errors.Is(err, sim.ErrDraftInProgress):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, errInvalidTeamImport):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"teams":   teams,
+		"matches": len(league.Matches),
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding teams", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/import - Replaces the fixtures and results from a JSON or CSV file.
+// With ?validate=true the file is only checked and every problem reported.
+func importFixturesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	var fixtures []FixtureImport
+	var parseProblems []ImportProblem
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		var err error
+		if fixtures, parseProblems, err = parseFixtureImportCSV(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&fixtures); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	} else {
+		numberFixtureImports(fixtures)
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	result := checkFixtureImport(league, fixtures, parseProblems)
+	if r.URL.Query().Get("validate") == "true" {
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			http.Error(w, "Error encoding import validation", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	if result.Valid {
+		service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+		if result, err = service.ImportFixtures(fixtures); err != nil && !errors.Is(err, errInvalidFixtureImport) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if !result.Valid {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "Error encoding import result", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/fixtures/regenerate - Previews or applies a rebuilt schedule for the unplayed weeks
+func regenerateFixturesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	var requestBody struct {
+		Confirm string `json:"confirm"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	plan, err := service.RegenerateFixtures(requestBody.Confirm)
+	switch {
+	case errors.Is(err, errFixtureConflict):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, errConfirmationMismatch):
+		w.WriteHeader(http.StatusPreconditionFailed)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(plan); err != nil {
+		http.Error(w, "Error encoding fixture plan", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/teams - Returns all teams with their ratings and presentation metadata
+func getTeamsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept-Language")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	languages := acceptedLanguages(r)
+	writeCachedJSON(w, r, league, "teams:lang:"+strings.Join(languages, ","), func() interface{} {
+		teams := make([]*sim.Team, len(league.Teams))
+		for i, team := range league.Teams {
+			teams[i] = localizeTeam(team, languages)
+		}
+		return teams
+	})
+}
+
+// GET /league/teams/{id} - Returns a single team; {id} may also be its slug or short code
+func getTeamHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	teamId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	
+	team, _ := findTeamByReference(league.Teams, strconv.Itoa(teamId))
+	if team == nil {
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	}
+	
+	w.Header().Set("Vary", "Accept-Language")
+	if err := json.NewEncoder(w).Encode(sim.BuildTeamDetail(league, localizeTeam(team, acceptedLanguages(r)))); err != nil {
+		http.Error(w, "Error encoding team", http.StatusInternalServerError)
+		return
+	}
+}
+
+// PATCH /league/teams/{id}/branding - Sets a team's colors, short code and badge URL
+func updateTeamBrandingHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	teamId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	
+	var update TeamBrandingUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	if err := update.apply(sim.TeamBranding{}).Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	team, err := service.UpdateTeamBranding(teamId, update)
+	switch {
+	case errors.Is(err, errTeamNotFound):
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errShortCodeTaken):
+		http.Error(w, "Another team already uses that short code", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(team); err != nil {
+		http.Error(w, "Error encoding team", http.StatusInternalServerError)
+		return
+	}
+}
+
+// PUT /league/teams/{id}/names - Replaces a team's names by language tag
+func updateTeamNamesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	teamId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	
+	var names map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&names); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	if err := validateLocalizedNames(names); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	team, err := service.UpdateTeamNames(teamId, names)
+	switch {
+	case errors.Is(err, errTeamNotFound):
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(localizeTeam(team, acceptedLanguages(r))); err != nil {
+		http.Error(w, "Error encoding team", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/teams/{id}/rename - Renames a team, carrying its history and honours over
+func renameTeamHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	teamId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	
+	var requestBody struct {
+		Name string `json:"name"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	newName := strings.TrimSpace(requestBody.Name)
+	if newName == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if err := validateTeamName(newName); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	team, err := service.RenameTeam(teamId, newName)
+	switch {
+	case errors.Is(err, errTeamNotFound):
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errTeamNameTaken):
+		http.Error(w, "Another team already uses that name", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	history, err := scope.storage.GetTeamNameHistory(teamId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"team":         team,
+		"name_history": history,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding team", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /jobs/{id} - Returns the status, progress and result of a background job
+func getJobHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	jobId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+	
+	job, ok := jobQueue.Get(jobId)
+	if !ok || job.scope != requestScope(r) {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		http.Error(w, "Error encoding job", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/stats - Returns league-wide aggregates compared with the previous week
+func getLeagueStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(scope.leagueStats(league)); err != nil {
+		http.Error(w, "Error encoding league stats", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/calendar - Returns every week of the season, labelling blank break weeks
+func getCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	location, ok := kickoffLocation(w, r)
+	if !ok {
+		return
+	}
+	
+	calendar := buildCalendar(league)
+	for i := range calendar {
+		calendar[i].LocalKickoff = formatLocalKickoff(calendar[i].Kickoff, location)
+	}
+	if linksEnabled(r) {
+		linkCalendar(calendar)
+	}
+	if err := json.NewEncoder(w).Encode(calendar); err != nil {
+		http.Error(w, "Error encoding calendar", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /simulation/validate - Checks the simulation model against scoreline realism guardrails
+func validateSimulationHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(validateSimulation(league, sim.Config())); err != nil {
+		http.Error(w, "Error encoding validation", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/matches?week=<hafta_no> - Returns matches for specific week or all matches
+func getMatchesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	weekParam := r.URL.Query().Get("week")
+	
+	week := 0
+	if weekParam != "" {
+		var err error
+		week, err = strconv.Atoi(weekParam)
+		if err != nil {
+			http.Error(w, "Invalid week parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	
+	location, ok := kickoffLocation(w, r)
+	if !ok {
+		return
+	}
+	
+	links := linksEnabled(r)
+	if r.URL.Query().Get("stream") == "true" {
+		streamMatches(w, r, week, location, links)
+		return
+	}
+	writeCachedJSON(w, r, league, "matches:"+weekParam+":tz:"+location.String()+linksCacheKey(links), func() interface{} {
+		matchesToReturn := league.Matches
+		if weekParam != "" {
+			matchesToReturn = nil
+			for _, match := range league.Matches {
+				if match.Week == week {
+					matchesToReturn = append(matchesToReturn, match)
+				}
+			}
+		}
+		
+		localized := sim.MarkSixPointers(league, localizeKickoffs(matchesToReturn, location))
+		if links {
+			linkMatches(localized)
+		}
+		return localized
+	})
+}
+
+// streamMatches writes the matches (of one week when week is set) as newline-delimited
+// JSON, reading them from storage a page at a time and flushing after every page, so
+// a long fixture list is never held in memory whole
+func streamMatches(w http.ResponseWriter, r *http.Request, week int, location *time.Location, links bool) {
+	scope := requestScope(r)
+	league := scope.League()
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	
+	encoder := json.NewEncoder(w)
+	written := 0
+	found := sim.SixPointers(league)
+	service := NewLeagueSimulatorService(league, scope.readStorage()).WithContext(r.Context())
+	err := service.EachMatch(func(match *sim.Match) error {
+		if week != 0 && match.Week != week {
+			return nil
+		}
+		if err := r.Context().Err(); err != nil {
+			return err
+		}
+		
+		localized := localizeKickoff(match, location)
+		localized.SixPointer = found[match.MatchId]
+		if links {
+			linkMatch(localized)
+		}
+		if err := encoder.Encode(localized); err != nil {
+			return err
+		}
+		if written++; written%matchPageSize == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// Once the first line is out the status can no longer change, so the
+		// stream just ends early
+		log.Printf("Failed to stream matches: %v", err)
+		if written == 0 {
+			http.Error(w, "Failed to read matches", http.StatusInternalServerError)
+		}
+		return
+	}
+	flusher.Flush()
+}
+
+// GET /league/matches - Returns all matches and their results
+func getAllMatchesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(league.Matches); err != nil {
+		http.Error(w, "Error encoding matches", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/matches/{id}/simulate - Simulates one chosen unplayed match
+func simulateSingleMatchHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	matchId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	match, err := service.SimulateMatch(matchId)
+	switch {
+	case errors.Is(err, errMatchNotFound):
+		http.Error(w, "Match not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errMatchAlreadyPlayed), errors.Is(err, sim.ErrAwaitingOrders), errors.Is(err, sim.ErrDraftInProgress):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"match": match,
+		"table": league.LeagueTable,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding match result", http.StatusInternalServerError)
+		return
+	}
+}
+
+// MatchDetail is a match with its commentary, for GET /league/matches/{id}
+type MatchDetail struct {
+	*sim.Match
+	Commentary string `json:",omitempty"`
+}
+
+// GET /league/matches/{id} - Returns a single match with its events and commentary
+func getMatchHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	matchId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+	
+	location, ok := kickoffLocation(w, r)
+	if !ok {
+		return
+	}
+	
+	for _, match := range league.Matches {
+		if match.MatchId == matchId {
+			localized := localizeKickoff(match, location)
+			localized.SixPointer = sim.SixPointers(league)[match.MatchId]
+			if linksEnabled(r) {
+				linkMatch(localized)
+			}
+			if err := json.NewEncoder(w).Encode(MatchDetail{Match: localized, Commentary: match.Commentary}); err != nil {
+				http.Error(w, "Error encoding match", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+	http.Error(w, "Match not found", http.StatusNotFound)
+}
+
+// GET /league/matches/{id}/explanation - Returns the inputs the simulator used for a played match
+func getMatchExplanationHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	matchId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+	
+	var target *sim.Match
+	for _, match := range league.Matches {
+		if match.MatchId == matchId {
+			target = match
+			break
+		}
+	}
+	switch {
+	case target == nil:
+		http.Error(w, "Match not found", http.StatusNotFound)
+		return
+	case !target.Played:
+		http.Error(w, "Match has not been played yet", http.StatusConflict)
+		return
+	case target.Explanation == nil:
+		http.Error(w, "No simulation inputs were recorded for this match", http.StatusNotFound)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"match_id":    target.MatchId,
+		"home_team":   target.HomeTeam.TeamName,
+		"away_team":   target.AwayTeam.TeamName,
+		"home_score":  target.HomeTeamScore,
+		"away_score":  target.AwayTeamScore,
+		"explanation": target.Explanation,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding match explanation", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/matches/{id}/predictions - Submits a player's forecast for an unplayed match
+func submitUserPredictionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	matchId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+	
+	var requestBody struct {
+		Player  string  `json:"player"`
+		Pick    string  `json:"pick"`
+		HomeWin float64 `json:"home_win"`
+		Draw    float64 `json:"draw"`
+		AwayWin float64 `json:"away_win"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	prediction, err := newUserPrediction(requestBody.Player, requestBody.Pick, requestBody.HomeWin, requestBody.Draw, requestBody.AwayWin)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	var target *sim.Match
+	for _, match := range league.Matches {
+		if match.MatchId == matchId {
+			target = match
+			break
+		}
+	}
+	if target == nil {
+		http.Error(w, "Match not found", http.StatusNotFound)
+		return
+	}
+	if target.Played {
+		http.Error(w, "Match has already been played", http.StatusConflict)
+		return
+	}
+	
+	prediction.Season = league.Season
+	prediction.MatchId = matchId
+	err = saveOutsideLeague(r.Context(), func(tx StorageService) error {
+		return tx.SaveUserPrediction(prediction)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(prediction); err != nil {
+		http.Error(w, "Error encoding prediction", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/leaderboard?week=N - Ranks players and the model by Brier score on played matches
+func getLeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	week := 0
+	if weekParam := r.URL.Query().Get("week"); weekParam != "" {
+		parsed, err := strconv.Atoi(weekParam)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid week parameter", http.StatusBadRequest)
+			return
+		}
+		week = parsed
+	}
+	
+	predictions, err := scope.readStorage().GetUserPredictions(league.Season)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(buildLeaderboard(league, predictions, week)); err != nil {
+		http.Error(w, "Error encoding leaderboard", http.StatusInternalServerError)
+		return
+	}
+}
+
+// PUT /league/players/{player}/profile - Saves a prediction game player's settings
+func savePlayerProfileHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	var requestBody struct {
+		TimeZone string `json:"timezone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	
+	profile, err := newPlayerProfile(mux.Vars(r)["player"], requestBody.TimeZone)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := requestScope(r).lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	err = saveOutsideLeague(r.Context(), func(tx StorageService) error {
+		return tx.SavePlayerProfile(profile)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(profile); err != nil {
+		http.Error(w, "Error encoding profile", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/players/{player}/profile - Returns a prediction game player's settings
+func getPlayerProfileHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	profile, err := scope.readStorage().GetPlayerProfile(mux.Vars(r)["player"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if profile == nil {
+		http.Error(w, "Player profile not found", http.StatusNotFound)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(profile); err != nil {
+		http.Error(w, "Error encoding profile", http.StatusInternalServerError)
+		return
+	}
+}
+
+// PUT /league/matches/{id} - Edit match result
+func editMatchResultHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	vars := mux.Vars(r)
+	matchIdStr := vars["id"]
+	
+	matchId, err := strconv.Atoi(matchIdStr)
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+	
+	// Parse request body
+	var requestBody struct {
+		HomeScore int `json:"home_score"`
+		AwayScore int `json:"away_score"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	// Admins may pass ?override=true to correct a result in a locked week
+	override := r.URL.Query().Get("override") == "true"
+	if override && !isAdminRequest(r) {
+		http.Error(w, "Admin token required to override a week lock", http.StatusUnauthorized)
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context()).WithLockOverride(override)
+	
+	_, err = service.EditMatchResult(matchId, requestBody.HomeScore, requestBody.AwayScore)
+	switch {
+	case errors.Is(err, errMatchNotFound):
+		http.Error(w, "Match not found", http.StatusNotFound)
+		return
+	case errors.Is(err, sim.ErrLevelResult):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, errWeekLocked):
+		http.Error(w, "Match week is locked; an admin can edit it with ?override=true", http.StatusLocked)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	// Return updated league table
+	if err := json.NewEncoder(w).Encode(league.LeagueTable); err != nil {
+		http.Error(w, "Error encoding league table", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/weeks/{n}/lock - Locks a week's results against edits
+func lockWeekHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	week, err := strconv.Atoi(mux.Vars(r)["n"])
+	if err != nil {
+		http.Error(w, "Invalid week", http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	lock, err := service.LockWeek(week)
+	switch {
+	case errors.Is(err, errWeekNotFound):
+		http.Error(w, "Week not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(lock); err != nil {
+		http.Error(w, "Error encoding week lock", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/zones - Returns the named table zones the league uses
+func getZonesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(sim.LeagueZones(league)); err != nil {
+		http.Error(w, "Error encoding zones", http.StatusInternalServerError)
+		return
+	}
+}
+
+// PUT /league/zones - Replaces the league's table zones; an empty list goes back to the rules' qualification spots
+func updateZonesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	var zones []*sim.Zone
+	if err := json.NewDecoder(r.Body).Decode(&zones); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	if err := sim.ValidateZones(zones); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	if err := service.SetZones(zones); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(sim.LeagueZones(league)); err != nil {
+		http.Error(w, "Error encoding zones", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/sport - Returns the sport the league plays: its match length, goal rate and points
+func getSportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(sim.LeagueSport(league)); err != nil {
+		http.Error(w, "Error encoding sport", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/handicap - Returns the league's handicap and each team's handicap for the season
+func getHandicapHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildHandicapStatus(league)); err != nil {
+		http.Error(w, "Error encoding handicap", http.StatusInternalServerError)
+		return
+	}
+}
+
+// PUT /league/handicap - Sets the league's handicap before the season starts; mode none turns it off
+func updateHandicapHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	var handicap sim.Handicap
+	if err := json.NewDecoder(r.Body).Decode(&handicap); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	if err := sim.ValidateHandicap(&handicap); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	err = service.SetHandicap(handicap)
+	switch {
+	case errors.Is(err, errSeasonStarted):
+		http.Error(w, "The handicap can only be changed before the season starts", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildHandicapStatus(league)); err != nil {
+		http.Error(w, "Error encoding handicap", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/referees - Returns each referee's tendencies and disciplinary record
+func getRefereesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildRefereeStats(league)); err != nil {
+		http.Error(w, "Error encoding referees", http.StatusInternalServerError)
+		return
+	}
+}
+
+// titlePlayoffPlayed reports whether this season's title play-off has been played
+func (s *leagueScope) titlePlayoffPlayed(league *sim.League) (bool, error) {
+	if s.storage == nil {
+		return false, nil
+	}
+	honours, err := s.storage.GetHonours()
+	if err != nil {
+		return false, err
+	}
+	for _, honour := range honours {
+		if honour.Competition == sim.TitlePlayoffCompetition && honour.Season == league.Season {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GET /league/standings - Returns the tables defined by the competition rules
+func getStandingsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	played, err := scope.titlePlayoffPlayed(league)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load honours: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildStandings(league, played)); err != nil {
+		http.Error(w, "Error encoding standings", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/playoff - Plays the title play-off when the competition rules call for one
+func playTitlePlayoffHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	played, err := scope.titlePlayoffPlayed(league)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load honours: %v", err), http.StatusInternalServerError)
+		return
+	}
+	status := sim.BuildStandings(league, played).Playoff
+	if status == nil || !status.Required {
+		http.Error(w, "No title play-off is required", http.StatusConflict)
+		return
+	}
+	if status.Played {
+		http.Error(w, "The title play-off has already been played", http.StatusConflict)
+		return
+	}
+	
+	var homeTeam, awayTeam *sim.Team
+	for _, team := range league.Teams {
+		switch team.TeamName {
+		case status.HomeTeam:
+			homeTeam = team
+		case status.AwayTeam:
+			awayTeam = team
+		}
+	}
+	if homeTeam == nil || awayTeam == nil {
+		http.Error(w, "The play-off's teams are no longer in the league", http.StatusConflict)
+		return
+	}
+	rule := sim.Rules().Playoff
+	
+	// A one-off play-off answers with its match, a two-legged one with both legs
+	var matches []*sim.ShowpieceMatch
+	var response interface{}
+	if rule.Legs == 2 {
+		legs, _, err := playTwoLeggedTie(league, sim.TitlePlayoffCompetition, homeTeam, awayTeam, DefaultTieRules())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		matches, response = legs, legs
+	} else {
+		venue := ""
+		if rule.Neutral {
+			venue = sim.VenueNeutral
+		}
+		showpiece, err := playShowpieceMatch(league, sim.TitlePlayoffCompetition, homeTeam.TeamId, awayTeam.TeamId, true, venue)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		matches, response = []*sim.ShowpieceMatch{showpiece}, showpiece
+	}
+	
+	if scope.storage != nil {
+		err := saveOutsideLeague(r.Context(), func(tx StorageService) error {
+			for _, match := range matches {
+				if err := tx.SaveShowpieceMatch(match); err != nil {
+					return err
+				}
+			}
+			// The deciding match carries the play-off's winner
+			return tx.SaveHonours(showpieceHonours(matches[len(matches)-1], league.Season))
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save title play-off: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding title play-off", http.StatusInternalServerError)
+		return
+	}
+}
+
+// loadContinentalCompetitions returns this season's continental competitions and whether each has been played
+func (s *leagueScope) loadContinentalCompetitions(league *sim.League) ([]*ContinentalCompetition, error) {
+	entrants, err := s.storage.GetContinentalEntrants(league.Season)
+	if err != nil {
+		return nil, err
+	}
+	honours, err := s.storage.GetHonours()
+	if err != nil {
+		return nil, err
+	}
+	
+	competitions := continentalCompetitions(entrants, league.Season)
+	for _, competition := range competitions {
+		for _, honour := range honours {
+			if honour.Competition == competition.Name && honour.Season == competition.Season {
+				competition.Played = true
+			}
+		}
+	}
+	return competitions, nil
+}
+
+// GET /league/continental - Returns this season's continental competitions and their entrants
+func getContinentalHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	competitions, err := scope.loadContinentalCompetitions(scope.League())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load continental competitions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(competitions); err != nil {
+		http.Error(w, "Error encoding continental competitions", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/continental/play - Plays this season's continental competitions that have not been played
+func playContinentalHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	competitions, err := scope.loadContinentalCompetitions(league)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load continental competitions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	results := []*ContinentalResult{}
+	for _, competition := range competitions {
+		if competition.Played {
+			continue
+		}
+		result, err := playContinentalCompetition(league, competition)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		http.Error(w, "No continental competition is left to play this season", http.StatusConflict)
+		return
+	}
+	
+	err = saveOutsideLeague(r.Context(), func(tx StorageService) error {
+		for _, result := range results {
+			matches := result.Knockout
+			for _, group := range result.Groups {
+				matches = append(matches, group.Matches...)
+			}
+			for _, match := range matches {
+				if err := tx.SaveShowpieceMatch(match); err != nil {
+					return err
+				}
+			}
+			// The final is the last knockout match and carries the competition's name
+			if err := tx.SaveHonours(showpieceHonours(result.Knockout[len(result.Knockout)-1], result.Season)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save continental competitions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		http.Error(w, "Error encoding continental competitions", http.StatusInternalServerError)
+		return
+	}
+}
+
+// PATCH /league/matches/{id} - Sets the venue of an unplayed match
+func updateMatchVenueHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	matchId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+	
+	var requestBody struct {
+		Venue string `json:"venue"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	if err := sim.ValidateVenue(requestBody.Venue); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	match, err := service.SetMatchVenue(matchId, requestBody.Venue)
+	switch {
+	case errors.Is(err, errMatchNotFound):
+		http.Error(w, "Match not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errMatchAlreadyPlayed):
+		http.Error(w, "Match has already been played", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(match); err != nil {
+		http.Error(w, "Error encoding match", http.StatusInternalServerError)
+		return
+	}
+}
+
+// PUT /league/teams/{id}/controller - Hands a team to a player; the response carries the player's token
+func claimTeamHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	teamId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	
+	var requestBody struct {
+		Player string `json:"player"`
+		Mode   string `json:"mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	player := strings.TrimSpace(requestBody.Player)
+	if player == "" {
+		http.Error(w, "player is required", http.StatusBadRequest)
+		return
+	}
+	mode := requestBody.Mode
+	if mode == "" {
+		mode = sim.ControlTactics
+	}
+	if mode != sim.ControlTactics && mode != sim.ControlHotseat {
+		http.Error(w, fmt.Sprintf("mode must be %s or %s", sim.ControlTactics, sim.ControlHotseat), http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	controller, err := service.ClaimTeam(teamId, player, mode)
+	switch {
+	case errors.Is(err, errTeamNotFound):
+		http.Error(w, "Team not found", http.StatusNotFound)
+		return
+	case errors.Is(err, sim.ErrTeamControlled):
+		http.Error(w, "Team is already controlled by a player; release it first", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(controller); err != nil {
+		http.Error(w, "Error encoding team controller", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DELETE /league/teams/{id}/controller - Hands a team back to the simulator (controller token or admin token)
+func releaseTeamHandler(w http.ResponseWriter, r *http.Request) {
+	scope := requestScope(r)
+	
+	teamId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid team ID", http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	err = service.ReleaseTeam(teamId, r.Header.Get("X-Controller-Token"), isAdminRequest(r))
+	switch {
+	case errors.Is(err, sim.ErrNotControlled):
+		http.Error(w, "Team is not controlled by a player", http.StatusNotFound)
+		return
+	case errors.Is(err, sim.ErrNotController):
+		http.Error(w, "The team's X-Controller-Token or the admin token is required", http.StatusForbidden)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /league/controllers - Lists the teams controlled by players
+func getControllersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(sim.ControllersList(league)); err != nil {
+		http.Error(w, "Error encoding team controllers", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/orders - Returns the next week's orders: who the week is waiting for and until when
+func getOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildOrdersStatus(league, time.Now())); err != nil {
+		http.Error(w, "Error encoding orders", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/matches/{id}/orders - Submits a controller's tactic, or in hotseat mode the result, for a match
+func submitOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	matchId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid match ID", http.StatusBadRequest)
+		return
+	}
+	
+	var requestBody struct {
+		Tactic    string `json:"tactic"`
+		HomeScore *int   `json:"home_score"`
+		AwayScore *int   `json:"away_score"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	orders, err := service.SubmitOrders(matchId, r.Header.Get("X-Controller-Token"), &sim.MatchOrders{
+		Tactic:    requestBody.Tactic,
+		HomeScore: requestBody.HomeScore,
+		AwayScore: requestBody.AwayScore,
+	})
+	switch {
+	case errors.Is(err, errMatchNotFound):
+		http.Error(w, "Match not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errMatchAlreadyPlayed):
+		http.Error(w, "Match has already been played", http.StatusConflict)
+		return
+	case errors.Is(err, sim.ErrNotController):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, sim.ErrInvalidOrders):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, sim.ErrResultDisputed):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(orders); err != nil {
+		http.Error(w, "Error encoding orders", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/draft - Starts a snake draft of the season's squads from a generated player pool
+func startDraftHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	var requestBody struct {
+		Order []int `json:"order"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	if len(requestBody.Order) > 0 {
+		if err := sim.ValidateDraftOrder(league, requestBody.Order); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	draft, err := service.StartDraft(requestBody.Order)
+	switch {
+	case errors.Is(err, errSeasonStarted):
+		http.Error(w, "Squads can only be drafted before the season starts", http.StatusConflict)
+		return
+	case errors.Is(err, sim.ErrDraftExists):
+		http.Error(w, "This season already has a draft", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(sim.BuildDraftStatus(league, draft)); err != nil {
+		http.Error(w, "Error encoding draft", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/draft - Returns the season's draft: the picks so far and the team on the clock
+func getDraftHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if league.Draft == nil {
+		http.Error(w, "This season has no draft", http.StatusNotFound)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildDraftStatus(league, league.Draft)); err != nil {
+		http.Error(w, "Error encoding draft", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /league/draft/players - Lists the draft's player pool, best first; ?position=FWD, ?available=true and ?team_id=N filter it
+func getDraftPlayersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	league := scope.League()
+	
+	if league.Draft == nil {
+		http.Error(w, "This season has no draft", http.StatusNotFound)
+		return
+	}
+	
+	query := r.URL.Query()
+	position := strings.ToUpper(query.Get("position"))
+	if _, ok := sim.DraftFormation[position]; position != "" && !ok {
+		http.Error(w, "position must be GK, DEF, MID or FWD", http.StatusBadRequest)
+		return
+	}
+	teamId := 0
+	if value := query.Get("team_id"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid team_id", http.StatusBadRequest)
+			return
+		}
+		teamId = parsed
+	}
+	
+	players := sim.FilterDraftPlayers(league.Draft, position, query.Get("available") == "true", teamId)
+	if err := json.NewEncoder(w).Encode(players); err != nil {
+		http.Error(w, "Error encoding draft players", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/draft/picks - Drafts a player for the team on the clock (its X-Controller-Token or the admin token)
+func makeDraftPickHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	var requestBody struct {
+		PlayerId int `json:"player_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	if rejectIfArchived(w, league) {
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	draft, err := service.MakeDraftPick(requestBody.PlayerId, r.Header.Get("X-Controller-Token"), isAdminRequest(r))
+	switch {
+	case errors.Is(err, sim.ErrDraftNotFound):
+		http.Error(w, "This season has no draft", http.StatusNotFound)
+		return
+	case errors.Is(err, sim.ErrDraftComplete):
+		http.Error(w, "The draft is complete", http.StatusConflict)
+		return
+	case errors.Is(err, sim.ErrNotController):
+		http.Error(w, "The X-Controller-Token of the team on the clock or the admin token is required", http.StatusForbidden)
+		return
+	case errors.Is(err, sim.ErrNotOnTheClock):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, sim.ErrInvalidPick):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildDraftStatus(league, draft)); err != nil {
+		http.Error(w, "Error encoding draft", http.StatusInternalServerError)
+		return
+	}
+}
+
+// rejectIfArchived writes a 409 and returns true when the league is read-only
+func rejectIfArchived(w http.ResponseWriter, league *sim.League) bool {
+	if league.ArchivedAt == nil {
+		return false
+	}
+	http.Error(w, "League is archived and read-only; restore it first", http.StatusConflict)
+	return true
+}
+
+// rejectIfFinished answers 409 Conflict when every fixture of the season has been played
+func rejectIfFinished(w http.ResponseWriter, league *sim.League) bool {
+	if sim.SeasonStateOf(league) != sim.SeasonFinished {
+		return false
+	}
+	http.Error(w, "Season is finished; start the next one with POST /league/new-season", http.StatusConflict)
+	return true
+}
+
+// GET /league/state - Returns whether the season is not started, in progress or finished
+func getLeagueStateHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	if err := json.NewEncoder(w).Encode(sim.BuildLeagueState(scope.League())); err != nil {
+		http.Error(w, "Error encoding league state", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DELETE /league - Archives the league instead of deleting it
+func archiveLeagueHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	if err := service.ArchiveLeague(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	response := map[string]interface{}{
+		"archived":    true,
+		"archived_at": league.ArchivedAt,
+		"purge_after": league.ArchivedAt.Add(archiveRetention()),
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Error encoding archive state", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /league/restore - Restores an archived league
+func restoreLeagueHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	if league.ArchivedAt == nil {
+		http.Error(w, "League is not archived", http.StatusBadRequest)
+		return
+	}
+	
+	service := NewLeagueSimulatorService(league, scope.storage).WithContext(r.Context())
+	
+	if err := service.RestoreLeague(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(league.LeagueTable); err != nil {
+		http.Error(w, "Error encoding league table", http.StatusInternalServerError)
+		return
+	}
+}
+
+// archiveRetention reads LEAGUE_ARCHIVE_RETENTION (e.g. "720h"), falling back to the default
+func archiveRetention() time.Duration {
+	if value := os.Getenv("LEAGUE_ARCHIVE_RETENTION"); value != "" {
+		if retention, err := time.ParseDuration(value); err == nil && retention > 0 {
+			return retention
+		}
+		log.Printf("Invalid LEAGUE_ARCHIVE_RETENTION %q, using default", value)
+	}
+	return defaultArchiveRetention
+}
+
+// startArchivePurgeJob periodically purges a league archived longer than the retention period
+func startArchivePurgeJob(retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			eachScope(func(scope *leagueScope) {
+				purgeExpiredArchive(scope, retention)
+			})
+		}
+	}()
+}
+
+// purgeExpiredArchive purges the scope's league if it has been archived longer than the retention period
+func purgeExpiredArchive(scope *leagueScope, retention time.Duration) {
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		log.Printf("Failed to lock the league for the archive purge: %v", err)
+		return
+	}
+	defer unlock()
+	
+	archivedAt := scope.League().ArchivedAt
+	if archivedAt == nil || time.Since(*archivedAt) < retention {
+		return
+	}
+	
+	log.Printf("Purging league archived at %s", archivedAt.Format(time.RFC3339))
+	if err := scope.storage.PurgeLeague(); err != nil {
+		log.Printf("Failed to purge archived league: %v", err)
+		return
+	}
+	
+	league, err := loadLeague(scope.storage)
+	if err != nil {
+		log.Printf("Failed to reload purged league: %v", err)
+		return
+	}
+	scope.setLeague(league)
+}
+
+// refreshInterval reads LEAGUE_REFRESH_INTERVAL (e.g. "2s"); zero disables refreshing
+func refreshInterval() time.Duration {
+	if value := os.Getenv("LEAGUE_REFRESH_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval >= 0 {
+			return interval
+		}
+		log.Printf("Invalid LEAGUE_REFRESH_INTERVAL %q, using default", value)
+	}
+	return defaultRefreshInterval
+}
+
+// startStateRefreshJob polls the shared revision and reloads the league when another
+// instance has written to the database, keeping load-balanced instances consistent.
+// With a replica configured it polls the replica once it has caught up.
+func startStateRefreshJob(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			eachScope(func(scope *leagueScope) {
+				// Writers move the league's revision on under the league lock, so it is
+				// only compared under it
+				unlock, err := scope.storage.LockLeague()
+				if err != nil {
+					log.Printf("Failed to lock the league for a refresh: %v", err)
+					return
+				}
+				defer unlock()
+				if err := scope.refreshLeagueIfStale(scope.readStorage()); err != nil {
+					log.Printf("Failed to refresh league: %v", err)
+				}
+			})
+		}
+	}()
+}
+
+// refreshLeagueIfStale reloads the scope's league when the stored revision has moved on
+func (s *leagueScope) refreshLeagueIfStale(storage StorageService) error {
+	revision, err := storage.GetRevision()
+	if err != nil {
+		return err
+	}
+	if revision == s.League().Revision {
+		return nil
+	}
+	
+	league, err := loadLeague(storage)
+	if err != nil {
+		return err
+	}
+	s.setLeague(league)
+	return nil
+}
+
+// lockLeague holds the scope's league for writing, takes the league lock shared by all
+// instances and brings the league up to date, so a simulation never runs against state
+// another instance has advanced nor while a request reads it. The scope's lock comes
+// first, since a read request may take the shared lock too.
+func (s *leagueScope) lockLeague() (func(), error) {
+	s.mu.Lock()
+	unlockShared, err := s.storage.LockLeague()
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	
+	unlock := func() {
+		s.publishMeta()
+		unlockShared()
+		s.mu.Unlock()
+	}
+	if err := s.refreshLeagueIfStale(s.storage); err != nil {
+		unlock()
+		return nil, fmt.Errorf("failed to refresh league: %v", err)
+	}
+	return unlock, nil
+}
+
+// POST /competitions/{id}/draw - Draws and persists a seeded knockout round
+func cupDrawHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	competitionId := mux.Vars(r)["id"]
+	
+	var requestBody struct {
+		Round string          `json:"round"`
+		Pots  [][]DrawEntrant `json:"pots"`
+		Seed  int64           `json:"seed"`
+	}
+	
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	if requestBody.Round == "" {
+		http.Error(w, "Round is required", http.StatusBadRequest)
+		return
+	}
+	for _, pot := range requestBody.Pots {
+		if len(pot) > maxDrawPotSize {
+			http.Error(w, fmt.Sprintf("A pot can hold at most %d teams", maxDrawPotSize), http.StatusBadRequest)
+			return
+		}
+	}
+	
+	seed := requestBody.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	
+	draw, err := performCupDraw(league, competitionId, requestBody.Round, requestBody.Pots, sim.NewPCG(seed))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if scope.storage != nil {
+		err := saveOutsideLeague(r.Context(), func(tx StorageService) error {
+			return tx.SaveCupDraw(draw)
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save draw: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	
+	if err := json.NewEncoder(w).Encode(draw); err != nil {
+		http.Error(w, "Error encoding draw", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /competitions/{id}/draw - Returns every drawn round of a competition
+func getCupDrawsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	draws, err := scope.readStorage().GetCupDraws(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load draws: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(draws); err != nil {
+		http.Error(w, "Error encoding draws", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /showpieces - Plays a one-off match that does not affect the standings
+func playShowpieceHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	unlock, err := scope.lockLeague()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unlock()
+	
+	league := scope.League()
+	
+	requestBody := struct {
+		Name          string `json:"name"`
+		HomeTeamId    int    `json:"home_team_id"`
+		AwayTeamId    int    `json:"away_team_id"`
+		RequireWinner *bool  `json:"require_winner"`
+	}{}
+	
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	
+	if requestBody.Name == "" {
+		requestBody.Name = "Super Cup"
+	}
+	
+	// Showpiece matches need a winner unless explicitly declared friendlies
+	requireWinner := true
+	if requestBody.RequireWinner != nil {
+		requireWinner = *requestBody.RequireWinner
+	}
+	
+	showpiece, err := playShowpieceMatch(league, requestBody.Name, requestBody.HomeTeamId, requestBody.AwayTeamId, requireWinner, "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	
+	if scope.storage != nil {
+		err := saveOutsideLeague(r.Context(), func(tx StorageService) error {
+			if err := tx.SaveShowpieceMatch(showpiece); err != nil {
+				return err
+			}
+			// Only finals that must produce a winner count towards a team's honours
+			if !requireWinner {
+				return nil
+			}
+			return tx.SaveHonours(showpieceHonours(showpiece, league.Season))
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save showpiece match: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(showpiece); err != nil {
+		http.Error(w, "Error encoding showpiece match", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /showpieces - Returns all one-off matches
+func getShowpiecesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	matches, err := scope.readStorage().GetShowpieceMatches()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load showpiece matches: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(matches); err != nil {
+		http.Error(w, "Error encoding showpiece matches", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /showpieces/{id} - Returns a one-off match with its events, shootout kicks included
+func getShowpieceHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid showpiece match ID", http.StatusBadRequest)
+		return
+	}
+	
+	matches, err := scope.readStorage().GetShowpieceMatches()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load showpiece matches: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	for _, match := range matches {
+		if match.Id != id {
+			continue
+		}
+		if err := json.NewEncoder(w).Encode(match); err != nil {
+			http.Error(w, "Error encoding showpiece match", http.StatusInternalServerError)
+		}
+		return
+	}
+	http.Error(w, "Showpiece match not found", http.StatusNotFound)
+}
+
+// GET /showpieces/shootouts - Returns each team's penalty shootout record
+func getShootoutStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	scope := requestScope(r)
+	matches, err := scope.readStorage().GetShowpieceMatches()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load showpiece matches: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	if err := json.NewEncoder(w).Encode(sim.BuildShootoutStats(matches)); err != nil {
+		http.Error(w, "Error encoding shootout stats", http.StatusInternalServerError)
+		return
+	}
+}
+
+// teamReferenceMiddleware lets /league/teams/{id} routes take a slug or short code in
+// place of the numeric ID. The exact slug is served directly; other forms (short code,
+// different case) redirect to the slug URL, 301 for reads and 308 for writes so the
+// method and body are kept.
+func teamReferenceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		template, _ := mux.CurrentRoute(r).GetPathTemplate()
+		vars := mux.Vars(r)
+		reference := vars["id"]
+		if !strings.HasPrefix(template, "/league/teams/{id}") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, err := strconv.Atoi(reference); err == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		
+		// The league is let go before the handler runs, which takes it again itself
+		league, done := requestScope(r).readLeague()
+		team, canonical := findTeamByReference(league.Teams, reference)
+		var teamId int
+		var slug string
+		if team != nil {
+			teamId, slug = team.TeamId, team.Slug
+		}
+		done()
+		if team == nil {
+			http.Error(w, "Team not found", http.StatusNotFound)
+			return
+		}
+		
+		if !canonical {
+			location := "/league/teams/" + slug + strings.TrimPrefix(r.URL.Path, "/league/teams/"+reference)
+			if r.URL.RawQuery != "" {
+				location += "?" + r.URL.RawQuery
+			}
+			status := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				status = http.StatusPermanentRedirect
+			}
+			http.Redirect(w, r, location, status)
+			return
+		}
+		
+		vars["id"] = strconv.Itoa(teamId)
+		next.ServeHTTP(w, mux.SetURLVars(r, vars))
+	})
+}
+
+// setupRoutes configures all HTTP routes using gorilla/mux
+func setupRoutes() *mux.Router {
+	r := mux.NewRouter()
+	
+	// API endpoints
+	r.HandleFunc("/league/table", getLeagueTableHandler).Methods("GET")
+	r.HandleFunc("/league/table.png", getLeagueTableImageHandler).Methods("GET")
+	r.HandleFunc("/league/table/xg", getXGTableHandler).Methods("GET")
+	r.HandleFunc("/league/predictions", getPredictionsHandler).Methods("GET")
+	r.HandleFunc("/league/predictions/compare", comparePredictionsHandler).Methods("GET")
+	r.HandleFunc("/league/predictions/position-matrix", getPositionMatrixHandler).Methods("GET")
+	r.HandleFunc("/league/predictions/history", getPredictionHistoryHandler).Methods("GET")
+	r.HandleFunc("/league/features", getFeaturesHandler).Methods("GET")
+	r.HandleFunc("/league/features", updateFeaturesHandler).Methods("PUT")
+	r.HandleFunc("/league/power-rankings", getPowerRankingsHandler).Methods("GET")
+	r.HandleFunc("/league/schedule-difficulty", getScheduleDifficultyHandler).Methods("GET")
+	r.HandleFunc("/league/next-week", simulateNextWeekHandler).Methods("POST")
+	r.HandleFunc("/league/play-all", simulateAllMatchesHandler).Methods("POST")
+	r.HandleFunc("/league/bootstrap", getBootstrapHandler).Methods("GET")
+	r.HandleFunc("/league/state", getLeagueStateHandler).Methods("GET")
+	r.HandleFunc("/league/matches", getMatchesHandler).Methods("GET")
+	r.HandleFunc("/league/stats", getLeagueStatsHandler).Methods("GET")
+	r.HandleFunc("/league/referees", getRefereesHandler).Methods("GET")
+	r.HandleFunc("/league/calendar", getCalendarHandler).Methods("GET")
+	r.HandleFunc("/league/standings", getStandingsHandler).Methods("GET")
+	r.HandleFunc("/league/playoff", playTitlePlayoffHandler).Methods("POST")
+	r.HandleFunc("/league/continental", getContinentalHandler).Methods("GET")
+	r.HandleFunc("/league/continental/play", playContinentalHandler).Methods("POST")
+	r.HandleFunc("/simulation/validate", validateSimulationHandler).Methods("GET")
+	r.HandleFunc("/league/matches/{id}", getMatchHandler).Methods("GET")
+	r.HandleFunc("/league/matches/{id}", editMatchResultHandler).Methods("PUT")
+	r.HandleFunc("/league/matches/{id}", updateMatchVenueHandler).Methods("PATCH")
+	r.HandleFunc("/league/matches/{id}/simulate", simulateSingleMatchHandler).Methods("POST")
+	r.HandleFunc("/league/matches/{id}/orders", submitOrdersHandler).Methods("POST")
+	r.HandleFunc("/league/orders", getOrdersHandler).Methods("GET")
+	r.HandleFunc("/league/controllers", getControllersHandler).Methods("GET")
+	r.HandleFunc("/league/draft", startDraftHandler).Methods("POST")
+	r.HandleFunc("/league/draft", getDraftHandler).Methods("GET")
+	r.HandleFunc("/league/draft/players", getDraftPlayersHandler).Methods("GET")
+	r.HandleFunc("/league/draft/picks", makeDraftPickHandler).Methods("POST")
+	r.HandleFunc("/league/weeks/{n}/lock", lockWeekHandler).Methods("POST")
+	r.HandleFunc("/league/zones", getZonesHandler).Methods("GET")
+	r.HandleFunc("/league/zones", updateZonesHandler).Methods("PUT")
+	r.HandleFunc("/league/handicap", getHandicapHandler).Methods("GET")
+	r.HandleFunc("/league/handicap", updateHandicapHandler).Methods("PUT")
+	r.HandleFunc("/league/sport", getSportHandler).Methods("GET")
+	r.HandleFunc("/league/matches/{id}/explanation", getMatchExplanationHandler).Methods("GET")
+	r.HandleFunc("/league/matches/{id}/predictions", submitUserPredictionHandler).Methods("POST")
+	r.HandleFunc("/league/leaderboard", getLeaderboardHandler).Methods("GET")
+	r.HandleFunc("/league/players/{player}/profile", savePlayerProfileHandler).Methods("PUT")
+	r.HandleFunc("/league/players/{player}/profile", getPlayerProfileHandler).Methods("GET")
+	r.HandleFunc("/league", archiveLeagueHandler).Methods("DELETE")
+	r.HandleFunc("/league/restore", restoreLeagueHandler).Methods("POST")
+	r.HandleFunc("/competitions/{id}/draw", cupDrawHandler).Methods("POST")
+	r.HandleFunc("/competitions/{id}/draw", getCupDrawsHandler).Methods("GET")
+	r.HandleFunc("/showpieces", playShowpieceHandler).Methods("POST")
+	r.HandleFunc("/showpieces", getShowpiecesHandler).Methods("GET")
+	r.HandleFunc("/showpieces/shootouts", getShootoutStatsHandler).Methods("GET")
+	r.HandleFunc("/showpieces/{id}", getShowpieceHandler).Methods("GET")
+	r.HandleFunc("/jobs/{id}", getJobHandler).Methods("GET")
+	r.HandleFunc("/league/new-season", startNewSeasonHandler).Methods("POST")
+	r.HandleFunc("/league/seasons", getSeasonsHandler).Methods("GET")
+	r.HandleFunc("/league/seasons/compare", compareSeasonsHandler).Methods("GET")
+	r.HandleFunc("/league/seasons/{season}/matches", getSeasonMatchesHandler).Methods("GET")
+	r.HandleFunc("/league/all-time-table", getAllTimeTableHandler).Methods("GET")
+	r.HandleFunc("/league/teams/{id}/honours", getTeamHonoursHandler).Methods("GET")
+	r.HandleFunc("/league/teams/{id}/magic-numbers", getTeamMagicNumbersHandler).Methods("GET")
+	r.HandleFunc("/league/records/all-time", getAllTimeRecordsHandler).Methods("GET")
+	r.HandleFunc("/league/teams", getTeamsHandler).Methods("GET")
+	r.HandleFunc("/league/teams/import", importTeamsHandler).Methods("POST")
+	r.HandleFunc("/league/fixtures/regenerate", regenerateFixturesHandler).Methods("POST")
+	r.HandleFunc("/league/import", importFixturesHandler).Methods("POST")
+	r.HandleFunc("/league/teams/{id}", getTeamHandler).Methods("GET")
+	r.HandleFunc("/league/teams/{id}", updateTeamRatingsHandler).Methods("PATCH")
+	r.HandleFunc("/league/teams/{id}/rename", renameTeamHandler).Methods("POST")
+	r.HandleFunc("/league/teams/{id}/names", updateTeamNamesHandler).Methods("PUT")
+	r.HandleFunc("/league/teams/{id}/audit", getTeamAuditHandler).Methods("GET")
+	r.HandleFunc("/league/teams/{id}/branding", updateTeamBrandingHandler).Methods("PATCH")
+	r.HandleFunc("/league/teams/{id}/controller", claimTeamHandler).Methods("PUT")
+	r.HandleFunc("/league/teams/{id}/controller", releaseTeamHandler).Methods("DELETE")
+	
+	// Admin endpoints, guarded by LEAGUE_ADMIN_TOKEN when set
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.HandleFunc("/reload", reloadConfigHandler).Methods("POST")
+	admin.HandleFunc("/config", getConfigHandler).Methods("GET")
+	admin.HandleFunc("/integrity", integrityHandler).Methods("GET")
+	admin.HandleFunc("/repair", repairHandler).Methods("POST")
+	admin.HandleFunc("/tenants", getTenantsHandler).Methods("GET")
+	admin.HandleFunc("/tenants", createTenantHandler).Methods("POST")
+	admin.HandleFunc("/tenants/{id}", deleteTenantHandler).Methods("DELETE")
+	if profilingEnabled() {
+		registerProfiling(admin)
+	}
+	admin.Use(adminMiddleware)
+	
+	r.Use(tracingMiddleware)
+	r.Use(leagueMetaMiddleware)
+	r.Use(recoveryMiddleware)
+	r.Use(timeoutMiddleware(requestTimeout()))
+	r.Use(bodyLimitMiddleware(maxBodyBytes()))
+	r.Use(tenantMiddleware)
+	r.Use(teamReferenceMiddleware)
+	r.Use(readLeagueMiddleware)
+	
+	return r
+}
+
+// initializeLeague creates and initializes the server's own league
+func initializeLeague() {
+	// Initialize storage service (SQLite by default, shared Postgres when LEAGUE_DATABASE_URL is set)
+	driverName, dataSourceName := "sqlite3", "./league.db"
+	if url := os.Getenv("LEAGUE_DATABASE_URL"); url != "" {
+		driverName, dataSourceName = "postgres", url
+	}
+	
+	storage, err := NewSQLStorageService(driverName, dataSourceName)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage service: %v", err)
+	}
+	serverScope.storage = storage
+	
+	// Initialize database with teams and matches if needed
+	if err := storage.InitializeTeamsAndMatches(); err != nil {
+		log.Fatalf("Failed to initialize database data: %v", err)
+	}
+	
+	// Serve reads from a replica when one is configured, writes stay on the primary
+	if serverScope.replica, err = openReplicaStorage(driverName); err != nil {
+		log.Fatalf("Failed to initialize replica storage: %v", err)
+	}
+	
+	// Load data from database
+	league, err := loadLeague(storage)
+	if err != nil {
+		log.Fatalf("Failed to load league from database: %v", err)
+	}
+	
+	// Never serve a league whose stored data contradicts itself
+	if err := validateLoadedLeague(storage, league); err != nil {
+		log.Fatal(err)
+	}
+	serverScope.setLeague(league)
+}
+
+// loadLeague builds a league from the current storage contents
+func loadLeague(storage StorageService) (*sim.League, error) {
+	teams, err := storage.GetTeams()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load teams: %v", err)
+	}
+	
+	matches, err := storage.GetMatches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load matches: %v", err)
+	}
+	
+	currentWeek, err := storage.GetCurrentWeek()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load current week: %v", err)
+	}
+	
+	archivedAt, err := storage.GetArchivedAt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load archive state: %v", err)
+	}
+	
+	revision, err := storage.GetRevision()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load revision: %v", err)
+	}
+	
+	modifiedAt, err := storage.GetModifiedAt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load modification time: %v", err)
+	}
+	
+	season, err := storage.GetSeason()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load season: %v", err)
+	}
+	
+	lockedWeeks, err := storage.GetLockedWeeks(season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load locked weeks: %v", err)
+	}
+	
+	zones, err := storage.GetZones()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zones: %v", err)
+	}
+	
+	features, err := storage.GetFeatures()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feature flags: %v", err)
+	}
+	
+	controllers, err := storage.GetTeamControllers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team controllers: %v", err)
+	}
+	
+	orders, err := storage.GetMatchOrders(season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load match orders: %v", err)
+	}
+	
+	ordersOpenedAt, err := storage.GetOrdersOpenedAt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load orders opening time: %v", err)
+	}
+	
+	draft, err := storage.GetDraft(season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load draft: %v", err)
+	}
+	
+	sport, err := storage.GetSport()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sport: %v", err)
+	}
+	
+	handicap, err := storage.GetHandicap()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load handicap: %v", err)
+	}
+	
+	handicaps, err := storage.GetTeamHandicaps()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team handicaps: %v", err)
+	}
+	
+	managers, err := storage.GetManagers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load managers: %v", err)
+	}
+	
+	referees, err := storage.GetReferees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load referees: %v", err)
+	}
+	
+	// Only this season's extra-time cup matches affect play; older seasons stay in
+	// storage and are read by the history endpoints when asked for
+	extraTimeMatches, err := storage.GetExtraTimeMatches(season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extra-time matches: %v", err)
+	}
+	
+	sim.AssignSlugs(teams)
+	
+	// Matches must share the league's team objects so stats updates reach both
+	teamsById := make(map[int]*sim.Team)
+	for _, team := range teams {
+		teamsById[team.TeamId] = team
+	}
+	for _, match := range matches {
+		if team, ok := teamsById[match.HomeTeam.TeamId]; ok {
+			match.HomeTeam = team
+		}
+		if team, ok := teamsById[match.AwayTeam.TeamId]; ok {
+			match.AwayTeam = team
+		}
+	}
+	
+	// Likewise for referees, so their stats follow every match they officiate
+	refereesById := make(map[int]*sim.Referee)
+	for _, referee := range referees {
+		refereesById[referee.Id] = referee
+	}
+	for _, match := range matches {
+		if match.Referee != nil {
+			match.Referee = refereesById[match.Referee.Id]
+		}
+	}
+	
+	league := &sim.League{
+		Teams:       teams,
+		Matches:     matches,
+		CurrentWeek: currentWeek,
+		Season:      season,
+		LeagueTable: []*sim.LeagueTableEntry{},
+		ArchivedAt:  archivedAt,
+		Revision:    revision,
+		ModifiedAt:  modifiedAt,
+		LockedWeeks: lockedWeeks,
+		Zones:       zones,
+		Features:    features,
+		Managers:    managers,
+		Referees:    referees,
+		ExtraTimeMatches: extraTimeMatches,
+		Controllers: controllers,
+		Orders:      orders,
+		OrdersOpenedAt: ordersOpenedAt,
+		Draft:          draft,
+		Handicap:       handicap,
+		Handicaps:      handicaps,
+		Sport:          sport,
+	}
+	
+	// Every team starts with a manager; the first load of a league appoints them
+	sim.EnsureManagers(league)
+	if len(league.Managers) > len(managers) {
+		if err := storage.SaveManagers(league.Managers); err != nil {
+			return nil, fmt.Errorf("failed to save managers: %v", err)
+		}
+	}
+	
+	// The first load of a league creates the referee pool and appoints referees to fixtures
+	if sim.EnsureReferees(league) {
+		if err := storage.SaveReferees(league.Referees); err != nil {
+			return nil, fmt.Errorf("failed to save referees: %v", err)
+		}
+		for _, match := range league.Matches {
+			if err := storage.SaveMatchResult(match); err != nil {
+				return nil, fmt.Errorf("failed to save referee appointments: %v", err)
+			}
+		}
+	}
+	
+	// Fixtures stored without a kickoff, e.g. by InitializeTeamsAndMatches, are scheduled on load
+	if sim.EnsureKickoffs(league) {
+		for _, match := range league.Matches {
+			if err := storage.SaveMatchResult(match); err != nil {
+				return nil, fmt.Errorf("failed to save kickoff times: %v", err)
+			}
+		}
+	}
+	
+	// Initialize the league table
+	sim.UpdateLeagueTable(league)
+	
+	return league, nil
+}
+
+// startHTTPServer starts the HTTP server on the specified port
+func startHTTPServer() {
+	// Serve HTTPS when a certificate or autocert domains are configured
+	tlsConfig, err := loadTLSConfig()
+	if err != nil {
+		log.Fatalf("Invalid TLS config: %v", err)
+	}
+	
+	// Initialize the league
+	initializeLeague()
+	
+	// Cache read responses in Redis when configured
+	serverScope.cache = newResponseCache()
+	
+	// Host a league per API key when tenancy mode is on
+	if tenancyEnabled() {
+		// Without the token every request is an admin's, free to create and delete tenants
+		if os.Getenv("LEAGUE_ADMIN_TOKEN") == "" {
+			log.Fatal("LEAGUE_TENANCY=true needs LEAGUE_ADMIN_TOKEN to guard /admin/tenants")
+		}
+		if tenantRegistry, err = NewTenantRegistry(serverScope.storage, serverScope.cache); err != nil {
+			log.Fatalf("Failed to load tenant registry: %v", err)
+		}
+		fmt.Println("Tenancy mode: requests need an X-API-Key")
+	}
+	
+	// Purge leagues left archived past the retention period
+	startArchivePurgeJob(archiveRetention(), time.Hour)
+	
+	// Pick up writes made by other instances sharing the database
+	startStateRefreshJob(refreshInterval())
+	
+	// Make draft picks whose time has run out
+	startDraftClockJob(sim.DraftClockInterval)
+	
+	// Forward recovered panics to a webhook when configured
+	errorReporter = newErrorReporter()
+	
+	// Log every simulated match's inputs and random draws when configured
+	if err = sim.OpenSeasonLog(); err != nil {
+		log.Fatalf("Failed to open the season log: %v", err)
+	}
 	
-	// Start server
-	fmt.Println("Starting HTTP server on :8080")
+	// Post week results to a Discord or Slack channel when configured
+	weekNotifier, err = newWeekNotifier()
+	if err != nil {
+		log.Fatalf("Invalid chat webhook config: %v", err)
+	}
+	
+	// Offer an ML win-probability model for prediction comparisons when configured
+	mlPredictor, err := newMLPredictor()
+	if err != nil {
+		log.Fatalf("Invalid prediction model: %v", err)
+	}
+	if mlPredictor != nil {
+		predictionModels[ModelML] = mlPredictor
+	}
+	
+	// Export traces when an OTLP endpoint is configured
+	if enabled, err := initTracing(context.Background()); err != nil {
+		log.Printf("Tracing disabled: %v", err)
+	} else if enabled {
+		fmt.Println("Exporting traces over OTLP")
+	}
+	
+	// Setup routes
+	router := setupRoutes()
+	
+	// Start server on a TCP address, a Unix socket or the socket passed by systemd
+	addr, scheme := listenAddr(), "HTTP"
+	if tlsConfig.Enabled() {
+		addr, scheme = tlsConfig.Addr, "HTTPS"
+	}
+	listener, err := openListener(addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", addr, err)
+	}
+	fmt.Printf("Starting %s server on %s\n", scheme, listener.Addr())
 	fmt.Println("Available endpoints:")
+	fmt.Println("  GET  /league/bootstrap       - Get teams, table, fixtures, results and predictions at once")
+	fmt.Println("  GET  /league/state           - Get whether the season is not started, in progress or finished")
 	fmt.Println("  GET  /league/table           - Get current league table")
+	fmt.Println("  GET  /league/table?as_of_match=N - Get the table as it stood after match N")
+	fmt.Println("  GET  /league/table.png       - Get the league table as a PNG image")
+	fmt.Println("  GET  /league/table/xg        - Get expected goals table")
+	fmt.Println("  GET  /league/predictions     - Get championship predictions")
+	fmt.Println("  GET  /league/predictions/compare?models=heuristic,poisson,elo - Compare prediction models")
+	fmt.Println("  GET  /league/predictions/position-matrix?model=poisson - Get the chance of each finishing position")
+	fmt.Println("  GET  /league/predictions/history - Get title chances recorded after each week")
+	fmt.Println("  GET  /league/features        - Get feature flags and their A/B comparison")
+	fmt.Println("  PUT  /league/features        - Set the league's own feature flag rollouts")
+	fmt.Println("  GET  /league/power-rankings  - Get Elo-based power rankings")
+	fmt.Println("  GET  /league/schedule-difficulty - Get remaining schedule difficulty")
 	fmt.Println("  POST /league/next-week       - Simulate next week")
 	fmt.Println("  POST /league/play-all        - Simulate all remaining matches")
+	fmt.Println("  POST /league/play-all?async=true - Queue a background play-all job")
+	fmt.Println("  POST /league/play-all?stream=true - Stream play-all progress (SSE)")
 	fmt.Println("  GET  /league/matches         - Get all matches")
 	fmt.Println("  GET  /league/matches?week=N  - Get matches for specific week")
+	fmt.Println("  GET  /league/matches/{id}    - Get a match with its commentary")
 	fmt.Println("  PUT  /league/matches/{id}    - Edit match result")
+	fmt.Println("  PATCH /league/matches/{id}   - Set a match venue (neutral, closed_doors)")
+	fmt.Println("  POST /league/matches/{id}/simulate - Simulate a single match")
+	fmt.Println("  POST /league/matches/{id}/orders - Submit a controller's tactic or hotseat result")
+	fmt.Println("  GET  /league/orders          - Get the orders the next week is waiting for")
+	fmt.Println("  GET  /league/controllers     - Get the teams controlled by players")
+	fmt.Println("  PUT  /league/teams/{id}/controller - Claim a team for a player")
+	fmt.Println("  DELETE /league/teams/{id}/controller - Hand a team back to the simulator")
+	fmt.Println("  POST /league/draft           - Start a snake draft of the season's squads")
+	fmt.Println("  GET  /league/draft           - Get the draft's picks and the team on the clock")
+	fmt.Println("  GET  /league/draft/players   - Get the draft's player pool")
+	fmt.Println("  POST /league/draft/picks     - Draft a player for the team on the clock")
+	fmt.Println("  POST /league/weeks/{n}/lock   - Lock a week's results against edits")
+	fmt.Println("  GET  /league/zones            - Get the league's table zones")
+	fmt.Println("  PUT  /league/zones            - Replace the league's table zones")
+	fmt.Println("  GET  /league/handicap         - Get the league's handicap")
+	fmt.Println("  PUT  /league/handicap         - Set the league's handicap before the season starts")
+	fmt.Println("  GET  /league/sport            - Get the sport the league plays and its scoring")
+	fmt.Println("  GET  /league/matches/{id}/explanation - Explain the inputs behind a result")
+	fmt.Println("  POST /league/matches/{id}/predictions - Forecast a match in the prediction game")
+	fmt.Println("  GET  /league/leaderboard     - Rank players against the model by Brier score")
+	fmt.Println("  GET  /league/stats           - Get league-wide statistics")
+	fmt.Println("  GET  /league/referees        - Get referee tendencies and stats")
+	fmt.Println("  GET  /league/calendar        - Get season calendar with break weeks")
+	fmt.Println("  GET  /league/standings       - Get the tables defined by the competition rules")
+	fmt.Println("  POST /league/playoff         - Play the title play-off when the rules call for one")
+	fmt.Println("  GET  /league/continental     - Get this season's continental entrants")
+	fmt.Println("  POST /league/continental/play - Play this season's continental competitions")
+	fmt.Println("  GET  /simulation/validate    - Check scoreline realism guardrails")
+	fmt.Println("  DELETE /league               - Archive league (read-only, restorable)")
+	fmt.Println("  POST /league/restore         - Restore archived league")
+	fmt.Println("  POST /competitions/{id}/draw - Draw a seeded cup round")
+	fmt.Println("  GET  /competitions/{id}/draw - Get drawn cup rounds")
+	fmt.Println("  POST /showpieces             - Play a one-off showpiece match")
+	fmt.Println("  GET  /showpieces             - Get showpiece matches")
+	fmt.Println("  GET  /showpieces/{id}        - Get a showpiece match with its events")
+	fmt.Println("  GET  /showpieces/shootouts   - Get each team's penalty shootout record")
+	fmt.Println("  GET  /jobs/{id}              - Get background job status and result")
+	fmt.Println("  POST /league/new-season      - Record the finished season and start the next")
+	fmt.Println("  GET  /league/seasons         - Get completed seasons and the current one")
+	fmt.Println("  GET  /league/seasons/compare?a=2023&b=2024 - Compare two seasons")
+	fmt.Println("  GET  /league/seasons/{season}/matches - Get a season's matches, archived ones included")
+	fmt.Println("  GET  /league/all-time-table  - Get cumulative standings across seasons")
+	fmt.Println("  GET  /league/teams/{id}/honours - Get a team's trophy cabinet")
+	fmt.Println("  GET  /league/teams/{id}/magic-numbers - Get the points a team needs for the title, top 4 or safety")
+	fmt.Println("  GET  /league/records/all-time - Get the all-time record book")
+	fmt.Println("  POST /league/teams/import    - Import teams from JSON or CSV")
+	fmt.Println("  POST /league/fixtures/regenerate - Rebuild unplayed fixtures (preview, then confirm)")
+	fmt.Println("  POST /league/import?validate=true - Check (or, without validate, load) a fixtures/results file")
+	fmt.Println("  GET  /league/teams/{id}      - Get a team by ID, slug or short code")
+	fmt.Println("  PATCH /league/teams/{id}     - Adjust team strength/attack/defense")
+	fmt.Println("  POST /league/teams/{id}/rename - Rename a team, keeping its history")
+	fmt.Println("  PUT  /league/teams/{id}/names - Set a team's names by language")
+	fmt.Println("  PUT  /league/players/{player}/profile - Set a player's time zone for kickoff times")
+	fmt.Println("  GET  /league/players/{player}/profile - Get a player's settings")
+	fmt.Println("  GET  /league/teams/{id}/audit - Get a team's rating change history")
+	fmt.Println("  GET  /league/teams           - List teams with colors and badges")
+	fmt.Println("  PATCH /league/teams/{id}/branding - Set a team's colors, short code and badge")
+	fmt.Println("  POST /admin/reload           - Re-read the simulation config without restarting")
+	fmt.Println("  GET  /admin/config           - Get the effective simulation config")
+	fmt.Println("  GET  /admin/integrity        - Check the stored league's invariants")
+	fmt.Println("  POST /admin/repair           - Recompute team statistics from the match results")
+	fmt.Println("  GET  /admin/tenants          - List tenants (tenancy mode)")
+	fmt.Println("  POST /admin/tenants          - Create a tenant and its API key (tenancy mode)")
+	fmt.Println("  DELETE /admin/tenants/{id}   - Remove a tenant, keeping its data (tenancy mode)")
+	fmt.Println("  GET  /admin/debug/pprof/     - Runtime profiles (when LEAGUE_ADMIN_TOKEN is set)")
 	
-	log.Fatal(http.ListenAndServe(":8080", router))
+	if tlsConfig.Enabled() {
+		log.Fatal(serveTLS(tlsConfig, listener, router))
+	}
+	log.Fatal(newHTTPServer(router).Serve(listener))
 } 
\ No newline at end of file
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestFailedWriteRestoresLeague(t *testing.T) {
+	storage, err := NewSQLStorageService("sqlite3", filepath.Join(t.TempDir(), "league.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+	if err := storage.InitializeTeamsAndMatches(); err != nil {
+		t.Fatal(err)
+	}
+	league, err := loadLeague(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revision, points := league.Revision, league.Teams[0].Points
+
+	errDiskFull := errors.New("disk full")
+	service := NewLeagueSimulatorService(league, storage)
+	league.Teams[0].Points += 3
+	err = service.persist(func(teams TeamRepository, matches MatchRepository, seasons SeasonRepository) error {
+		if err := teams.UpdateTeam(league.Teams[0]); err != nil {
+			return err
+		}
+		return errDiskFull
+	})
+	if !errors.Is(err, errDiskFull) {
+		t.Fatalf("persist returned %v, want %v", err, errDiskFull)
+	}
+
+	if league.Teams[0].Points != points {
+		t.Errorf("team has %d points after the failed write, want %d", league.Teams[0].Points, points)
+	}
+	if league.Revision != revision {
+		t.Errorf("league is at revision %d after the failed write, want %d", league.Revision, revision)
+	}
+}
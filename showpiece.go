@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// playShowpieceMatch simulates a one-off match between two league teams.
+// The league's team records are never modified; a level score goes to extra time
+// and then penalties when a winner is required. Extra time is remembered in the
+// league so both sides carry fatigue into their next league match. The venue is one of the match venues, "" for the home side's ground.
+func playShowpieceMatch(league *sim.League, name string, homeTeamId, awayTeamId int, requireWinner bool, venue string) (*sim.ShowpieceMatch, error) {
+	if homeTeamId == awayTeamId {
+		return nil, fmt.Errorf("a team cannot play itself")
+	}
+
+	var homeTeam, awayTeam *sim.Team
+	for _, team := range league.Teams {
+		switch team.TeamId {
+		case homeTeamId:
+			homeTeam = team
+		case awayTeamId:
+			awayTeam = team
+		}
+	}
+	if homeTeam == nil || awayTeam == nil {
+		return nil, fmt.Errorf("unknown team")
+	}
+
+	// Simulate on a scratch match so standings stay untouched
+	match := &sim.Match{HomeTeam: homeTeam, AwayTeam: awayTeam, Venue: venue}
+	sim.SimulateLogged(league, match, sim.CompetitionShowpiece, sim.SimulateScore)
+	showpiece := newShowpiece(league, name, match)
+
+	rng := sim.LeagueRandom(league)
+	if requireWinner && match.HomeTeamScore == match.AwayTeamScore {
+		homeGoals, awayGoals := sim.SimulateExtraTime(match, sim.MatchConfig(match), rng)
+		showpiece.HomeTeamScore += homeGoals
+		showpiece.AwayTeamScore += awayGoals
+		showpiece.ExtraTime = true
+		league.ExtraTimeMatches = append(league.ExtraTimeMatches, showpiece)
+	}
+
+	switch {
+	case showpiece.HomeTeamScore > showpiece.AwayTeamScore:
+		showpiece.WinnerName = homeTeam.TeamName
+	case showpiece.HomeTeamScore < showpiece.AwayTeamScore:
+		showpiece.WinnerName = awayTeam.TeamName
+	case requireWinner:
+		sport := sim.MatchSport(match)
+		shootout := sim.SimulateShootout(homeTeam, awayTeam, sport.Minutes()+sport.ExtraTime, sim.MatchConfig(match), rng)
+		showpiece.PenaltiesHome, showpiece.PenaltiesAway = shootout.HomeScore, shootout.AwayScore
+		showpiece.Events = append(showpiece.Events, shootout.Kicks...)
+		if showpiece.PenaltiesHome > showpiece.PenaltiesAway {
+			showpiece.WinnerName = homeTeam.TeamName
+		} else {
+			showpiece.WinnerName = awayTeam.TeamName
+		}
+	}
+
+	return showpiece, nil
+}
+
+// newShowpiece records a simulated scratch match as a showpiece match, its winner
+// being the side ahead when there is one
+func newShowpiece(league *sim.League, name string, match *sim.Match) *sim.ShowpieceMatch {
+	showpiece := &sim.ShowpieceMatch{
+		Name:          name,
+		HomeTeam:      match.HomeTeam,
+		AwayTeam:      match.AwayTeam,
+		HomeTeamScore: match.HomeTeamScore,
+		AwayTeamScore: match.AwayTeamScore,
+		Events:        match.Events,
+		Season:        league.Season,
+		LeagueWeek:    league.CurrentWeek,
+		PlayedAt:      time.Now().UTC(),
+	}
+	switch {
+	case match.HomeTeamScore > match.AwayTeamScore:
+		showpiece.WinnerName = match.HomeTeam.TeamName
+	case match.HomeTeamScore < match.AwayTeamScore:
+		showpiece.WinnerName = match.AwayTeam.TeamName
+	}
+	return showpiece
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// defaultSiteDir is where `export-site` writes when not told
+const defaultSiteDir = "./site"
+
+// Chart drawing area in SVG user units
+const (
+	chartWidth   = 640
+	chartHeight  = 240
+	chartPadding = 30
+)
+
+// chartPalette colours teams that have no primary colour in their branding
+var chartPalette = []string{"#1f77b4", "#d62728", "#2ca02c", "#ff7f0e", "#9467bd", "#8c564b", "#e377c2", "#17becf"}
+
+// SiteChart is a line chart of one value per team over the weeks played
+type SiteChart struct {
+	Title  string
+	Width  int
+	Height int
+	Lines  []SiteChartLine
+	Weeks  []int
+	AxisY  int // baseline of the week labels
+	Max    int
+	Invert bool // lower values are drawn higher, as for league positions
+}
+
+// SiteChartLine is one team's line in a chart
+type SiteChartLine struct {
+	TeamName string
+	Color    string
+	Points   string // SVG polyline points
+}
+
+// SiteGridRow is one home team's row in the results grid
+type SiteGridRow struct {
+	TeamName string
+	Slug     string
+	Cells    []string // one per away team, in table order; "" against itself
+}
+
+// SiteFixture is one match on a team page, from the team's point of view
+type SiteFixture struct {
+	Week     int
+	Opponent string
+	Slug     string
+	Home     bool
+	Played   bool
+	Score    string
+	Outcome  string // W, D or L once played
+}
+
+// siteTeamPage is the data for a team's page
+type siteTeamPage struct {
+	Season   int
+	Entry    *sim.LeagueTableEntry
+	Fixtures []*SiteFixture
+	Chart    *SiteChart
+}
+
+// siteIndexPage is the data for the season's front page
+type siteIndexPage struct {
+	Season   int
+	Week     int
+	Finished bool
+	Table    []*sim.LeagueTableEntry
+	Slugs    map[string]string
+	Grid     []*SiteGridRow
+	Chart    *SiteChart
+}
+
+var siteFuncs = template.FuncMap{
+	"slug": func(slugs map[string]string, name string) string { return slugs[name] },
+	"chartX": func(chart *SiteChart, i int) int {
+		return chartX(chart, i)
+	},
+}
+
+const siteLayout = `{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.}}</title>
+<link rel="stylesheet" href="{{template "root" .}}style.css">
+</head>
+<body>
+{{end}}
+{{define "chart"}}<figure>
+<figcaption>{{.Title}}</figcaption>
+<svg viewBox="0 0 {{.Width}} {{.Height}}" role="img" aria-label="{{.Title}}">
+{{range $i, $week := .Weeks}}<text x="{{chartX $ $i}}" y="{{$.AxisY}}" class="axis">{{$week}}</text>
+{{end}}{{range .Lines}}<polyline points="{{.Points}}" fill="none" stroke="{{.Color}}" stroke-width="2"><title>{{.TeamName}}</title></polyline>
+{{end}}</svg>
+<ul class="legend">{{range .Lines}}<li><span style="background: {{.Color}}"></span>{{.TeamName}}</li>{{end}}</ul>
+</figure>
+{{end}}`
+
+const siteIndexTemplate = `{{define "root"}}{{end}}{{template "head" (printf "Season %d" .Season)}}<h1>Season {{.Season}}</h1>
+<p>{{if .Finished}}Final standings{{else if .Week}}After week {{.Week}}{{else}}Before the first week{{end}}</p>
+
+<h2>Table</h2>
+<table>
+<tr><th>#</th><th>Team</th><th>P</th><th>W</th><th>D</th><th>L</th><th>GF</th><th>GA</th><th>GD</th><th>Pts</th><th></th></tr>
+{{range .Table}}<tr><td>{{.Position}}</td><td><a href="teams/{{slug $.Slugs .TeamName}}.html">{{.TeamName}}</a></td><td>{{.Played}}</td><td>{{.Wins}}</td><td>{{.Draws}}</td><td>{{.Losses}}</td><td>{{.GoalsFor}}</td><td>{{.GoalsAgainst}}</td><td>{{.GoalsDifference}}</td><td>{{.Points}}</td><td{{if .ZoneColor}} style="border-left: 4px solid {{.ZoneColor}}"{{end}}>{{.Zone}}</td></tr>
+{{end}}</table>
+
+<h2>Results</h2>
+<table class="grid">
+<tr><th>Home \ Away</th>{{range .Table}}<th>{{if .ShortCode}}{{.ShortCode}}{{else}}{{.TeamName}}{{end}}</th>{{end}}</tr>
+{{range .Grid}}<tr><th><a href="teams/{{.Slug}}.html">{{.TeamName}}</a></th>{{range .Cells}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+
+<h2>Points</h2>
+{{template "chart" .Chart}}
+</body>
+</html>
+`
+
+const siteTeamTemplate = `{{define "root"}}../{{end}}{{template "head" .Entry.TeamName}}<p><a href="../index.html">Season {{.Season}}</a></p>
+<h1>{{.Entry.TeamName}}</h1>
+<p>Position {{.Entry.Position}}, {{.Entry.Points}} points from {{.Entry.Played}} matches: {{.Entry.Wins}} won, {{.Entry.Draws}} drawn, {{.Entry.Losses}} lost, goals {{.Entry.GoalsFor}}-{{.Entry.GoalsAgainst}}{{if .Entry.Zone}} ({{.Entry.Zone}}){{end}}</p>
+
+<h2>Matches</h2>
+<table>
+<tr><th>Week</th><th>Opponent</th><th></th><th>Score</th><th></th></tr>
+{{range .Fixtures}}<tr><td>{{.Week}}</td><td><a href="{{.Slug}}.html">{{.Opponent}}</a></td><td>{{if .Home}}H{{else}}A{{end}}</td><td>{{if .Played}}{{.Score}}{{else}}-{{end}}</td><td>{{.Outcome}}</td></tr>
+{{end}}</table>
+
+<h2>League position</h2>
+{{template "chart" .Chart}}
+</body>
+</html>
+`
+
+const siteStylesheet = `body { font-family: sans-serif; max-width: 56rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { padding: 0.25rem 0.6rem; text-align: left; border-bottom: 1px solid #ddd; }
+.grid td { text-align: center; }
+a { color: #1a4f8b; }
+svg { width: 100%; max-width: 40rem; }
+.axis { font-size: 10px; fill: #666; text-anchor: middle; }
+.legend { list-style: none; padding: 0; display: flex; flex-wrap: wrap; gap: 1rem; }
+.legend span { display: inline-block; width: 0.8rem; height: 0.8rem; margin-right: 0.3rem; }
+`
+
+var (
+	siteIndex = template.Must(template.Must(template.New("index").Funcs(siteFuncs).Parse(siteLayout)).Parse(siteIndexTemplate))
+	siteTeam  = template.Must(template.Must(template.New("team").Funcs(siteFuncs).Parse(siteLayout)).Parse(siteTeamTemplate))
+)
+
+// runSiteExport renders the stored league's season into a static site
+func runSiteExport(args []string) {
+	dir := defaultSiteDir
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	initializeLeague()
+	league := serverScope.League()
+	pages, err := exportSite(league, dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export site: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported season %d to %s (%d pages)\n", league.Season, dir, pages)
+}
+
+// exportSite writes an index page with the table, results grid and points chart, a
+// page per team and a stylesheet into dir. Links are relative, so the directory can
+// be served from anywhere, e.g. GitHub Pages. It returns the number of pages written.
+func exportSite(league *sim.League, dir string) (int, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "teams"), 0o755); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "style.css"), []byte(siteStylesheet), 0o644); err != nil {
+		return 0, err
+	}
+
+	table := league.LeagueTable
+	slugs := make(map[string]string)
+	colors := make(map[string]string)
+	for i, team := range league.Teams {
+		slugs[team.TeamName] = team.Slug
+		if slugs[team.TeamName] == "" {
+			slugs[team.TeamName] = sim.Slugify(team.TeamName)
+		}
+		colors[team.TeamName] = team.PrimaryColor
+		if colors[team.TeamName] == "" {
+			colors[team.TeamName] = chartPalette[i%len(chartPalette)]
+		}
+	}
+	weeks, history := weeklyStandings(league)
+
+	index := &siteIndexPage{
+		Season:   league.Season,
+		Week:     league.CurrentWeek,
+		Finished: !sim.HasUnplayedMatches(league),
+		Table:    table,
+		Slugs:    slugs,
+		Grid:     resultsGrid(league, table, slugs),
+		Chart:    standingsChart("Points after each week", table, weeks, history, colors, false),
+	}
+	if err := writeSitePage(filepath.Join(dir, "index.html"), siteIndex, index); err != nil {
+		return 0, err
+	}
+
+	for _, entry := range table {
+		page := &siteTeamPage{
+			Season:   league.Season,
+			Entry:    entry,
+			Fixtures: teamFixtures(league, entry.TeamName, slugs),
+			Chart:    standingsChart("League position after each week", []*sim.LeagueTableEntry{entry}, weeks, history, colors, true),
+		}
+		if err := writeSitePage(filepath.Join(dir, "teams", slugs[entry.TeamName]+".html"), siteTeam, page); err != nil {
+			return 0, err
+		}
+	}
+	return len(table) + 1, nil
+}
+
+// writeSitePage renders a page template to path
+func writeSitePage(path string, page *template.Template, data interface{}) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := page.Execute(file, data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to render %s: %v", filepath.Base(path), err)
+	}
+	return file.Close()
+}
+
+// weeklyStandings returns the weeks with played matches and the table after each of them
+func weeklyStandings(league *sim.League) ([]int, [][]*sim.LeagueTableEntry) {
+	seen := make(map[int]bool)
+	weeks := []int{}
+	for _, match := range league.Matches {
+		if match.Played && !seen[match.Week] {
+			seen[match.Week] = true
+			weeks = append(weeks, match.Week)
+		}
+	}
+	sort.Ints(weeks)
+
+	history := [][]*sim.LeagueTableEntry{}
+	for _, week := range weeks {
+		history = append(history, sim.BuildLeagueTable(league, func(match *sim.Match) bool { return match.Week <= week }))
+	}
+	return weeks, history
+}
+
+// standingsChart plots each listed team's points, or its position when positions is
+// set, from the table after each week
+func standingsChart(title string, teams []*sim.LeagueTableEntry, weeks []int, history [][]*sim.LeagueTableEntry, colors map[string]string, positions bool) *SiteChart {
+	chart := &SiteChart{Title: title, Width: chartWidth, Height: chartHeight, AxisY: chartHeight - chartPadding/3, Weeks: weeks, Invert: positions}
+	values := make(map[string][]int)
+	for _, table := range history {
+		for _, entry := range table {
+			value := entry.Points
+			if positions {
+				value = entry.Position
+			}
+			values[entry.TeamName] = append(values[entry.TeamName], value)
+			chart.Max = max(chart.Max, value)
+		}
+	}
+
+	for _, team := range teams {
+		points := []string{}
+		for i, value := range values[team.TeamName] {
+			points = append(points, fmt.Sprintf("%d,%d", chartX(chart, i), chartY(chart, value)))
+		}
+		chart.Lines = append(chart.Lines, SiteChartLine{TeamName: team.TeamName, Color: colors[team.TeamName], Points: strings.Join(points, " ")})
+	}
+	return chart
+}
+
+// chartX is the horizontal position of the i-th week in a chart
+func chartX(chart *SiteChart, i int) int {
+	if len(chart.Weeks) < 2 {
+		return chart.Width / 2
+	}
+	return chartPadding + i*(chart.Width-2*chartPadding)/(len(chart.Weeks)-1)
+}
+
+// chartY is the vertical position of a value in a chart; larger values are higher
+// unless the chart is inverted
+func chartY(chart *SiteChart, value int) int {
+	low, high := 0, chart.Max
+	if chart.Invert {
+		low = 1
+	}
+	if high <= low {
+		high = low + 1
+	}
+	span := chart.Height - 2*chartPadding
+	offset := (value - low) * span / (high - low)
+	if chart.Invert {
+		return chartPadding + offset
+	}
+	return chartPadding + span - offset
+}
+
+// resultsGrid lays out every result with home teams as rows and away teams as
+// columns, both in table order. A pair that meets more than once at the same home
+// ground lists each score; unplayed fixtures show "v".
+func resultsGrid(league *sim.League, table []*sim.LeagueTableEntry, slugs map[string]string) []*SiteGridRow {
+	grid := []*SiteGridRow{}
+	for _, home := range table {
+		row := &SiteGridRow{TeamName: home.TeamName, Slug: slugs[home.TeamName]}
+		for _, away := range table {
+			scores := []string{}
+			for _, match := range league.Matches {
+				if match.HomeTeam.TeamName != home.TeamName || match.AwayTeam.TeamName != away.TeamName {
+					continue
+				}
+				if match.Played {
+					scores = append(scores, fmt.Sprintf("%d-%d", match.HomeTeamScore, match.AwayTeamScore))
+				} else {
+					scores = append(scores, "v")
+				}
+			}
+			row.Cells = append(row.Cells, strings.Join(scores, ", "))
+		}
+		grid = append(grid, row)
+	}
+	return grid
+}
+
+// teamFixtures lists a team's league matches in week order
+func teamFixtures(league *sim.League, teamName string, slugs map[string]string) []*SiteFixture {
+	fixtures := []*SiteFixture{}
+	for _, match := range league.Matches {
+		home := match.HomeTeam.TeamName == teamName
+		if !home && match.AwayTeam.TeamName != teamName {
+			continue
+		}
+		fixture := &SiteFixture{Week: match.Week, Home: home, Played: match.Played}
+		goalsFor, goalsAgainst := match.HomeTeamScore, match.AwayTeamScore
+		fixture.Opponent = match.AwayTeam.TeamName
+		if !home {
+			goalsFor, goalsAgainst = goalsAgainst, goalsFor
+			fixture.Opponent = match.HomeTeam.TeamName
+		}
+		fixture.Slug = slugs[fixture.Opponent]
+		if match.Played {
+			fixture.Score = fmt.Sprintf("%d-%d", match.HomeTeamScore, match.AwayTeamScore)
+			switch {
+			case goalsFor > goalsAgainst:
+				fixture.Outcome = "W"
+			case goalsFor < goalsAgainst:
+				fixture.Outcome = "L"
+			default:
+				fixture.Outcome = "D"
+			}
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	sort.SliceStable(fixtures, func(i, j int) bool { return fixtures[i].Week < fixtures[j].Week })
+	return fixtures
+}
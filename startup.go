@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// Checks made on the stored league at startup, beyond those of checkLeagueIntegrity
+const (
+	CheckMatchTeams       = "match_teams"       // every stored match references existing teams
+	CheckDuplicateMatches = "duplicate_matches" // no two matches share an ID
+)
+
+// What initializeLeague does when the stored league fails its startup checks
+const (
+	StartupCheckRepair = "repair" // fix the problems, save the result and start
+	StartupCheckStrict = "strict" // report the problems and refuse to start
+	StartupCheckOff    = "off"    // skip the checks
+)
+
+// startupCheckMode reads LEAGUE_STARTUP_CHECK, which defaults to repair
+func startupCheckMode() (string, error) {
+	switch mode := os.Getenv("LEAGUE_STARTUP_CHECK"); mode {
+	case "":
+		return StartupCheckRepair, nil
+	case StartupCheckRepair, StartupCheckStrict, StartupCheckOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("LEAGUE_STARTUP_CHECK must be %s, %s or %s, got %q", StartupCheckRepair, StartupCheckStrict, StartupCheckOff, mode)
+	}
+}
+
+// expectedCurrentWeek is the current week the match results give: the week before
+// the first one with an unplayed fixture, or the last week once every fixture is
+// played. A current week short of that only because of blank break weeks is kept.
+func expectedCurrentWeek(league *sim.League) int {
+	firstUnplayed, lastWeek := 0, 0
+	for _, match := range league.Matches {
+		lastWeek = max(lastWeek, match.Week)
+		if !match.Played && (firstUnplayed == 0 || match.Week < firstUnplayed) {
+			firstUnplayed = match.Week
+		}
+	}
+	if firstUnplayed == 0 {
+		return lastWeek
+	}
+	if league.CurrentWeek >= firstUnplayed {
+		return firstUnplayed - 1
+	}
+	for _, match := range league.Matches {
+		if match.Week > league.CurrentWeek && match.Week < firstUnplayed {
+			return firstUnplayed - 1
+		}
+	}
+	return max(league.CurrentWeek, 0)
+}
+
+// checkStartupData looks for problems in a league just loaded from storage: matches
+// whose teams are gone, duplicate match IDs, a current week the results disagree
+// with, and team statistics or a table that do not match the results
+func checkStartupData(storage StorageService, league *sim.League) ([]IntegrityViolation, error) {
+	violations := []IntegrityViolation{}
+	violate := func(check, team, format string, args ...interface{}) {
+		violations = append(violations, IntegrityViolation{Check: check, TeamName: team, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	orphaned, err := storage.GetOrphanedMatchIds()
+	if err != nil {
+		return nil, err
+	}
+	for _, matchId := range orphaned {
+		violate(CheckMatchTeams, "", "match %d references a team that does not exist", matchId)
+	}
+
+	seen := make(map[int]bool)
+	for _, match := range league.Matches {
+		if seen[match.MatchId] {
+			violate(CheckDuplicateMatches, "", "match ID %d is used more than once", match.MatchId)
+		}
+		seen[match.MatchId] = true
+	}
+
+	if expected := expectedCurrentWeek(league); league.CurrentWeek != expected {
+		violate(CheckCurrentWeek, "", "current week is %d but the results give %d", league.CurrentWeek, expected)
+	}
+
+	// An empty state was stored before the column existed; persist fills it in
+	if stored, err := storage.GetSeasonState(); err != nil {
+		return nil, err
+	} else if expected := sim.SeasonStateOf(league); stored != "" && stored != expected {
+		violate(sim.CheckSeasonState, "", "stored season state is %s but the results give %s", stored, expected)
+	}
+
+	// The current week has been checked above, in both directions
+	for _, violation := range checkLeagueIntegrity(league).Violations {
+		if violation.Check != CheckCurrentWeek {
+			violations = append(violations, violation)
+		}
+	}
+	return violations, nil
+}
+
+// logStartupViolations writes one line per problem found at startup
+func logStartupViolations(violations []IntegrityViolation) {
+	log.Printf("Startup check found %d problem(s) in the stored league:", len(violations))
+	for _, violation := range violations {
+		if violation.TeamName != "" {
+			log.Printf("  %s (%s): %s", violation.Check, violation.TeamName, violation.Detail)
+		} else {
+			log.Printf("  %s: %s", violation.Check, violation.Detail)
+		}
+	}
+}
+
+// validateLoadedLeague runs the startup checks on the loaded league and, depending on
+// LEAGUE_STARTUP_CHECK, repairs what they find or returns an error listing it
+func validateLoadedLeague(storage StorageService, league *sim.League) error {
+	mode, err := startupCheckMode()
+	if err != nil || mode == StartupCheckOff {
+		return err
+	}
+
+	violations, err := checkStartupData(storage, league)
+	if err != nil {
+		return fmt.Errorf("failed to check stored league: %v", err)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	logStartupViolations(violations)
+
+	checks := []string{}
+	seen := make(map[string]bool)
+	replaceMatches := false
+	for _, violation := range violations {
+		if !seen[violation.Check] {
+			seen[violation.Check] = true
+			checks = append(checks, violation.Check)
+		}
+		replaceMatches = replaceMatches || violation.Check == CheckMatchTeams
+	}
+	if mode == StartupCheckStrict {
+		return fmt.Errorf("refusing to start: the stored league failed %s; set LEAGUE_STARTUP_CHECK=%s to repair it", strings.Join(checks, ", "), StartupCheckRepair)
+	}
+
+	if err := NewLeagueSimulatorService(league, storage).RepairLoadedLeague(replaceMatches); err != nil {
+		return err
+	}
+	if remaining, err := checkStartupData(storage, league); err != nil {
+		return fmt.Errorf("failed to check repaired league: %v", err)
+	} else if len(remaining) > 0 {
+		logStartupViolations(remaining)
+		return fmt.Errorf("refusing to start: %d problem(s) remain after repair", len(remaining))
+	}
+	log.Printf("Startup check repaired the stored league")
+	return nil
+}
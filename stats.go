@@ -0,0 +1,190 @@
+package main
+
+import (
+	"math"
+	"sync"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// LeagueStats holds league-wide aggregates over played matches
+type LeagueStats struct {
+	Week                 int
+	MatchesPlayed        int
+	TotalGoals           int
+	AverageGoalsPerMatch float64
+	HomeWinPercentage    float64
+	DrawPercentage       float64
+	AwayWinPercentage    float64
+	CleanSheets          int
+	TeamCleanSheets      map[string]int
+	Luck                 []*sim.LuckEntry
+	// Goal timing from minute-engine timelines; classic results have no goal minutes
+	GoalTiming     []*GoalTimingBucket `json:",omitempty"`
+	LateWinners    int                 // ties broken from the 85th minute on, added time included
+	LateEqualisers int
+	PreviousWeek   *LeagueStats `json:",omitempty"`
+}
+
+// GoalTimingBucket counts the goals scored in one period of the match clock
+type GoalTimingBucket struct {
+	Period     string
+	Goals      int
+	Percentage float64
+}
+
+// goalTimingPeriods splits each half into thirds plus its added time
+var goalTimingPeriods = []struct {
+	label     string
+	from, to  int
+	addedTime bool
+}{
+	{"1-15", 1, 15, false},
+	{"16-30", 16, 30, false},
+	{"31-45", 31, 45, false},
+	{"45+", 45, 45, true},
+	{"46-60", 46, 60, false},
+	{"61-75", 61, 75, false},
+	{"76-90", 76, 90, false},
+	{"90+", 90, 90, true},
+}
+
+// lateDramaMinute is the minute from which a decisive goal counts as late drama
+const lateDramaMinute = 85
+
+// goalTimingPeriod returns the index of the period a goal falls in
+func goalTimingPeriod(event sim.MatchEvent) int {
+	for i, period := range goalTimingPeriods {
+		if period.addedTime == (event.AddedTime > 0) && event.Minute >= period.from && event.Minute <= period.to {
+			return i
+		}
+	}
+	return len(goalTimingPeriods) - 1
+}
+
+// addGoalTiming counts a match's timed goals into the histogram and records late
+// winners and equalisers
+func addGoalTiming(stats *LeagueStats, counts []int, match *sim.Match) {
+	home, away := 0, 0
+	for _, event := range match.Events {
+		if event.Type != sim.EventGoal {
+			continue
+		}
+		counts[goalTimingPeriod(event)]++
+
+		wasLevel := home == away
+		if event.TeamId == match.HomeTeam.TeamId {
+			home++
+		} else {
+			away++
+		}
+		if event.Minute < lateDramaMinute {
+			continue
+		}
+		switch {
+		case home == away:
+			stats.LateEqualisers++
+		case wasLevel && (home > away) == (match.HomeTeamScore > match.AwayTeamScore) && match.HomeTeamScore != match.AwayTeamScore:
+			// Only counts when the side that went ahead held on to win
+			stats.LateWinners++
+		}
+	}
+}
+
+// statsCache keeps a scope's last computed stats until its league is reloaded or the
+// league version changes
+type statsCache struct {
+	mu      sync.Mutex
+	league  *sim.League
+	version int
+	stats   *LeagueStats
+}
+
+// computeLeagueStats aggregates every played match up to and including the given week
+func computeLeagueStats(league *sim.League, uptoWeek int) *LeagueStats {
+	stats := &LeagueStats{
+		Week:            uptoWeek,
+		TeamCleanSheets: make(map[string]int),
+	}
+	for _, team := range league.Teams {
+		stats.TeamCleanSheets[team.TeamName] = 0
+	}
+
+	homeWins, draws, awayWins := 0, 0, 0
+	timingCounts := make([]int, len(goalTimingPeriods))
+	for _, match := range league.Matches {
+		if !match.Played || match.Week > uptoWeek {
+			continue
+		}
+
+		stats.MatchesPlayed++
+		stats.TotalGoals += match.HomeTeamScore + match.AwayTeamScore
+
+		if match.HomeTeamScore > match.AwayTeamScore {
+			homeWins++
+		} else if match.HomeTeamScore < match.AwayTeamScore {
+			awayWins++
+		} else {
+			draws++
+		}
+
+		// A clean sheet is credited to the side that conceded nothing
+		if match.AwayTeamScore == 0 {
+			stats.CleanSheets++
+			stats.TeamCleanSheets[match.HomeTeam.TeamName]++
+		}
+		if match.HomeTeamScore == 0 {
+			stats.CleanSheets++
+			stats.TeamCleanSheets[match.AwayTeam.TeamName]++
+		}
+
+		addGoalTiming(stats, timingCounts, match)
+	}
+
+	timedGoals := 0
+	for _, count := range timingCounts {
+		timedGoals += count
+	}
+	if timedGoals > 0 {
+		for i, period := range goalTimingPeriods {
+			stats.GoalTiming = append(stats.GoalTiming, &GoalTimingBucket{
+				Period:     period.label,
+				Goals:      timingCounts[i],
+				Percentage: math.Round(float64(timingCounts[i])/float64(timedGoals)*1000) / 10,
+			})
+		}
+	}
+
+	if stats.MatchesPlayed > 0 {
+		played := float64(stats.MatchesPlayed)
+		stats.AverageGoalsPerMatch = float64(stats.TotalGoals) / played
+		stats.HomeWinPercentage = float64(homeWins) / played * 100
+		stats.DrawPercentage = float64(draws) / played * 100
+		stats.AwayWinPercentage = float64(awayWins) / played * 100
+	}
+
+	stats.Luck = sim.BuildLuckIndex(league, uptoWeek)
+
+	return stats
+}
+
+// leagueStats returns current stats with the previous week for comparison,
+// reusing the scope's cached result while the league version is unchanged
+func (s *leagueScope) leagueStats(league *sim.League) *LeagueStats {
+	cache := s.stats
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.stats != nil && cache.league == league && cache.version == league.Version {
+		return cache.stats
+	}
+
+	stats := computeLeagueStats(league, league.CurrentWeek)
+	if league.CurrentWeek > 0 {
+		stats.PreviousWeek = computeLeagueStats(league, league.CurrentWeek-1)
+	}
+
+	cache.league = league
+	cache.version = league.Version
+	cache.stats = stats
+	return stats
+}
@@ -1,29 +1,51 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	sim "github.com/Melotachi/GoLeagueMelo/league"
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// StorageService interface for SQL database operations
+// StorageService composes the focused repositories with transaction support
 type StorageService interface {
-	SaveMatchResult(match *Match) error
-	GetMatches() ([]*Match, error)
-	GetTeams() ([]*Team, error)
-	UpdateTeam(team *Team) error
+	TeamRepository
+	MatchRepository
+	SeasonRepository
+	CompetitionRepository
+	PredictionGameRepository
+	TenantRepository
+	TransactionManager
+	LockManager
 	InitializeDatabase() error
-	GetCurrentWeek() (int, error)
-	UpdateCurrentWeek(week int) error
 }
 
+// leagueLockKey identifies the league in pg_advisory_lock
+const leagueLockKey = 20240601
+
 // SQLStorageService implements StorageService for SQL databases
 type SQLStorageService struct {
 	db         *sql.DB
+	conn       sqlConn // the database itself, or the open transaction
+	tx         *sql.Tx
 	driverName string
+	leagueLock *sync.Mutex // serializes simulations when the database is not shared (SQLite)
+	pinned     *sql.Conn   // held open until Close; see pinConnection
+}
+
+// sqlConn is satisfied by both *sql.DB and *sql.Tx
+type sqlConn interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 // NewSQLStorageService creates a new SQL storage service
@@ -39,7 +61,9 @@ func NewSQLStorageService(driverName, dataSourceName string) (*SQLStorageService
 
 	service := &SQLStorageService{
 		db:         db,
+		conn:       db,
 		driverName: driverName,
+		leagueLock: &sync.Mutex{},
 	}
 
 	if err := service.InitializeDatabase(); err != nil {
@@ -49,6 +73,80 @@ func NewSQLStorageService(driverName, dataSourceName string) (*SQLStorageService
 	return service, nil
 }
 
+// WithinTransaction runs fn against a storage bound to a single transaction,
+// committing if fn succeeds and rolling back otherwise. Nested calls reuse the
+// outer transaction. The transaction and each statement in it are traced under ctx.
+func (s *SQLStorageService) WithinTransaction(ctx context.Context, fn func(tx StorageService) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	ctx, span := tracer.Start(ctx, "sql transaction")
+	defer span.End()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		recordSpanError(span, err)
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	scoped := &SQLStorageService{
+		db:         s.db,
+		conn:       &tracedConn{conn: tx, ctx: ctx, driverName: s.driverName},
+		tx:         tx,
+		driverName: s.driverName,
+		leagueLock: s.leagueLock,
+	}
+
+	if err := fn(scoped); err != nil {
+		tx.Rollback()
+		recordSpanError(span, err)
+		return err
+	}
+
+	// A request that has timed out or gone away commits nothing, since its client has
+	// already been told it failed
+	if err := ctx.Err(); err != nil {
+		tx.Rollback()
+		recordSpanError(span, err)
+		return fmt.Errorf("transaction abandoned: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		recordSpanError(span, err)
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// LockLeague blocks until the caller holds the league lock and returns a function
+// releasing it. On Postgres this is a session advisory lock held on a dedicated
+// connection, so it is shared by every instance using the database.
+func (s *SQLStorageService) LockLeague() (func(), error) {
+	if s.driverName != "postgres" {
+		s.leagueLock.Lock()
+		return s.leagueLock.Unlock, nil
+	}
+
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock connection: %v", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", leagueLockKey); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire league lock: %v", err)
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", leagueLockKey); err != nil {
+			log.Printf("Failed to release league lock: %v", err)
+		}
+		conn.Close()
+	}, nil
+}
+
 // InitializeDatabase creates the required tables
 func (s *SQLStorageService) InitializeDatabase() error {
 	// Create teams table
@@ -66,7 +164,7 @@ func (s *SQLStorageService) InitializeDatabase() error {
 		goals_difference INTEGER DEFAULT 0
 	)`
 
-	if _, err := s.db.Exec(teamsSQL); err != nil {
+	if _, err := s.conn.Exec(teamsSQL); err != nil {
 		return fmt.Errorf("failed to create teams table: %v", err)
 	}
 
@@ -84,237 +182,2891 @@ func (s *SQLStorageService) InitializeDatabase() error {
 		FOREIGN KEY (away_team_id) REFERENCES teams(id)
 	)`
 
-	if _, err := s.db.Exec(matchesSQL); err != nil {
+	if _, err := s.conn.Exec(matchesSQL); err != nil {
 		return fmt.Errorf("failed to create matches table: %v", err)
 	}
 
-	// Create league_state table for current week tracking
-	leagueStateSQL := `
-	CREATE TABLE IF NOT EXISTS league_state (
-		id INTEGER PRIMARY KEY DEFAULT 1,
-		current_week INTEGER DEFAULT 0
-	)`
-
-	if _, err := s.db.Exec(leagueStateSQL); err != nil {
-		return fmt.Errorf("failed to create league_state table: %v", err)
+	// Optional attack/defense ratings, 0 means the overall strength is used
+	if err := s.addColumnIfMissing("teams", "attack_rating", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("teams", "defense_rating", "INTEGER DEFAULT 0"); err != nil {
+		return err
 	}
 
-	// Initialize league state if not exists
-	var count int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM league_state").Scan(&count)
-	if err != nil {
-		return fmt.Errorf("failed to check league_state: %v", err)
+	// Optional venue details, set by team import
+	if err := s.addColumnIfMissing("teams", "stadium", "TEXT"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("teams", "city", "TEXT"); err != nil {
+		return err
 	}
 
-	if count == 0 {
-		_, err := s.db.Exec("INSERT INTO league_state (current_week) VALUES (0)")
-		if err != nil {
-			return fmt.Errorf("failed to initialize league_state: %v", err)
+	// Optional presentation metadata
+	for _, column := range []string{"short_code", "primary_color", "secondary_color", "badge_url"} {
+		if err := s.addColumnIfMissing("teams", column, "TEXT"); err != nil {
+			return err
 		}
 	}
 
-	return nil
-}
+	// Names by language tag, as JSON
+	if err := s.addColumnIfMissing("teams", "localized_names", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
 
-// SaveMatchResult saves or updates a match result
-func (s *SQLStorageService) SaveMatchResult(match *Match) error {
-	query := `
-	INSERT OR REPLACE INTO matches (id, week, home_team_id, away_team_id, home_score, away_score, played)
-	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	// Expected goals recorded by the simulation
+	if err := s.addColumnIfMissing("matches", "home_xg", "REAL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("matches", "away_xg", "REAL DEFAULT 0"); err != nil {
+		return err
+	}
 
-	if s.driverName == "postgres" {
-		query = `
-		INSERT INTO matches (id, week, home_team_id, away_team_id, home_score, away_score, played)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (id) DO UPDATE SET
-			week = EXCLUDED.week,
-			home_team_id = EXCLUDED.home_team_id,
-			away_team_id = EXCLUDED.away_team_id,
-			home_score = EXCLUDED.home_score,
-			away_score = EXCLUDED.away_score,
-			played = EXCLUDED.played`
+	// Experimental models the match was simulated with
+	if err := s.addColumnIfMissing("matches", "features", "TEXT DEFAULT ''"); err != nil {
+		return err
 	}
 
-	_, err := s.db.Exec(query, match.MatchId, match.Week, match.HomeTeam.TeamId, 
-		match.AwayTeam.TeamId, match.HomeTeamScore, match.AwayTeamScore, match.Played)
-	
-	if err != nil {
-		return fmt.Errorf("failed to save match result: %v", err)
+	// Simulator inputs behind each result, as JSON
+	if err := s.addColumnIfMissing("matches", "explanation", "TEXT DEFAULT ''"); err != nil {
+		return err
 	}
 
-	return nil
-}
+	// Neutral or behind-closed-doors venue; empty for the home ground
+	if err := s.addColumnIfMissing("matches", "venue", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
 
-// GetMatches retrieves all matches from database
-func (s *SQLStorageService) GetMatches() ([]*Match, error) {
-	query := `
-	SELECT m.id, m.week, m.home_team_id, m.away_team_id, m.home_score, m.away_score, m.played,
-		   ht.name as home_name, ht.strength as home_strength,
-		   at.name as away_name, at.strength as away_strength
-	FROM matches m
-	JOIN teams ht ON m.home_team_id = ht.id
-	JOIN teams at ON m.away_team_id = at.id
-	ORDER BY m.week, m.id`
+	// Referee appointed to the match; 0 until the pool exists
+	if err := s.addColumnIfMissing("matches", "referee_id", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
 
-	rows, err := s.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query matches: %v", err)
+	// Generated commentary paragraph for played matches
+	if err := s.addColumnIfMissing("matches", "commentary", "TEXT DEFAULT ''"); err != nil {
+		return err
 	}
-	defer rows.Close()
 
-	var matches []*Match
-	teamCache := make(map[int]*Team)
+	// Kickoff in UTC, scheduled in the league's time zone
+	if err := s.addColumnIfMissing("matches", "kickoff", "TIMESTAMP NULL"); err != nil {
+		return err
+	}
 
-	for rows.Next() {
-		var match Match
-		var homeTeamId, awayTeamId int
-		var homeName, awayName string
-		var homeStrength, awayStrength int
+	// Create referees table for the pool of match officials
+	refereesSQL := `
+	CREATE TABLE IF NOT EXISTS referees (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		card_tendency REAL NOT NULL,
+		penalty_tendency REAL NOT NULL
+	)`
 
-		err := rows.Scan(&match.MatchId, &match.Week, &homeTeamId, &awayTeamId,
-			&match.HomeTeamScore, &match.AwayTeamScore, &match.Played,
-			&homeName, &homeStrength, &awayName, &awayStrength)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan match: %v", err)
-		}
+	if _, err := s.conn.Exec(refereesSQL); err != nil {
+		return fmt.Errorf("failed to create referees table: %v", err)
+	}
 
-		// Get or create home team
-		if homeTeam, exists := teamCache[homeTeamId]; exists {
-			match.HomeTeam = homeTeam
-		} else {
-			homeTeam := &Team{
-				TeamId:       homeTeamId,
-				TeamName:     homeName,
-				TeamStrength: homeStrength,
-			}
-			teamCache[homeTeamId] = homeTeam
-			match.HomeTeam = homeTeam
-		}
+	// Create match_events table for minute engine timelines
+	matchEventsSQL := `
+	CREATE TABLE IF NOT EXISTS match_events (
+		match_id INTEGER NOT NULL,
+		minute INTEGER NOT NULL,
+		event_type TEXT NOT NULL,
+		team_id INTEGER NOT NULL,
+		FOREIGN KEY (match_id) REFERENCES matches(id),
+		FOREIGN KEY (team_id) REFERENCES teams(id)
+	)`
 
-		// Get or create away team
-		if awayTeam, exists := teamCache[awayTeamId]; exists {
-			match.AwayTeam = awayTeam
-		} else {
-			awayTeam := &Team{
-				TeamId:       awayTeamId,
-				TeamName:     awayName,
-				TeamStrength: awayStrength,
-			}
-			teamCache[awayTeamId] = awayTeam
-			match.AwayTeam = awayTeam
+	if _, err := s.conn.Exec(matchEventsSQL); err != nil {
+		return fmt.Errorf("failed to create match_events table: %v", err)
+	}
+
+	// Minutes into stoppage time for events after 45 or 90
+	if err := s.addColumnIfMissing("match_events", "added_time", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Scorelines around VAR reviews
+	if err := s.addColumnIfMissing("match_events", "score_before", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("match_events", "score_after", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// Indexes for reading matches a page at a time and their events by match
+	indexesSQL := []string{
+		"CREATE INDEX IF NOT EXISTS idx_matches_week_id ON matches (week, id)",
+		"CREATE INDEX IF NOT EXISTS idx_match_events_match ON match_events (match_id)",
+	}
+	for _, indexSQL := range indexesSQL {
+		if _, err := s.conn.Exec(indexSQL); err != nil {
+			return fmt.Errorf("failed to create index: %v", err)
 		}
+	}
 
-		matches = append(matches, &match)
+	// Create archive tables for the matches and events of completed seasons, moved out
+	// of matches and match_events at each rollover. Team and referee names are kept as
+	// they were, so archived seasons read back without joins.
+	archivedMatchesSQL := `
+	CREATE TABLE IF NOT EXISTS archived_matches (
+		season INTEGER NOT NULL,
+		id INTEGER NOT NULL,
+		week INTEGER NOT NULL,
+		home_team_id INTEGER NOT NULL,
+		home_team_name TEXT NOT NULL,
+		away_team_id INTEGER NOT NULL,
+		away_team_name TEXT NOT NULL,
+		home_score INTEGER DEFAULT 0,
+		away_score INTEGER DEFAULT 0,
+		home_xg REAL DEFAULT 0,
+		away_xg REAL DEFAULT 0,
+		features TEXT DEFAULT '',
+		venue TEXT DEFAULT '',
+		referee_id INTEGER DEFAULT 0,
+		referee_name TEXT DEFAULT '',
+		kickoff TIMESTAMP NULL,
+		PRIMARY KEY (season, id)
+	)`
+
+	if _, err := s.conn.Exec(archivedMatchesSQL); err != nil {
+		return fmt.Errorf("failed to create archived_matches table: %v", err)
 	}
 
-	return matches, nil
-}
+	archivedEventsSQL := `
+	CREATE TABLE IF NOT EXISTS archived_match_events (
+		season INTEGER NOT NULL,
+		match_id INTEGER NOT NULL,
+		minute INTEGER NOT NULL,
+		added_time INTEGER DEFAULT 0,
+		event_type TEXT NOT NULL,
+		team_id INTEGER NOT NULL,
+		team_name TEXT NOT NULL,
+		score_before TEXT DEFAULT '',
+		score_after TEXT DEFAULT ''
+	)`
 
-// GetTeams retrieves all teams from database
-func (s *SQLStorageService) GetTeams() ([]*Team, error) {
-	query := `
-	SELECT id, name, strength, goals_for, goals_against, wins, draws, losses, points, goals_difference
-	FROM teams
-	ORDER BY id`
+	if _, err := s.conn.Exec(archivedEventsSQL); err != nil {
+		return fmt.Errorf("failed to create archived_match_events table: %v", err)
+	}
 
-	rows, err := s.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query teams: %v", err)
+	if _, err := s.conn.Exec("CREATE INDEX IF NOT EXISTS idx_archived_match_events_season ON archived_match_events (season, match_id)"); err != nil {
+		return fmt.Errorf("failed to create index: %v", err)
 	}
-	defer rows.Close()
 
-	var teams []*Team
-	for rows.Next() {
-		var team Team
-		err := rows.Scan(&team.TeamId, &team.TeamName, &team.TeamStrength,
-			&team.GoalsFor, &team.GoalsAgainst, &team.Wins, &team.Draws,
-			&team.Losses, &team.Points, &team.GoalsDifference)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan team: %v", err)
-		}
-		teams = append(teams, &team)
+	// Create cup_draws table for drawn knockout brackets
+	cupDrawsSQL := `
+	CREATE TABLE IF NOT EXISTS cup_draws (
+		competition_id TEXT NOT NULL,
+		round TEXT NOT NULL,
+		tie_number INTEGER NOT NULL,
+		home_team_id INTEGER NOT NULL,
+		away_team_id INTEGER NOT NULL,
+		PRIMARY KEY (competition_id, round, tie_number),
+		FOREIGN KEY (home_team_id) REFERENCES teams(id),
+		FOREIGN KEY (away_team_id) REFERENCES teams(id)
+	)`
+
+	if _, err := s.conn.Exec(cupDrawsSQL); err != nil {
+		return fmt.Errorf("failed to create cup_draws table: %v", err)
 	}
 
-	return teams, nil
-}
+	// Create showpiece_matches table for one-off matches outside the standings
+	showpieceSQL := `
+	CREATE TABLE IF NOT EXISTS showpiece_matches (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		home_team_id INTEGER NOT NULL,
+		away_team_id INTEGER NOT NULL,
+		home_score INTEGER DEFAULT 0,
+		away_score INTEGER DEFAULT 0,
+		penalties_home INTEGER DEFAULT 0,
+		penalties_away INTEGER DEFAULT 0,
+		winner_name TEXT,
+		played_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (home_team_id) REFERENCES teams(id),
+		FOREIGN KEY (away_team_id) REFERENCES teams(id)
+	)`
 
-// UpdateTeam updates team statistics
-func (s *SQLStorageService) UpdateTeam(team *Team) error {
-	query := `
-	INSERT OR REPLACE INTO teams (id, name, strength, goals_for, goals_against, wins, draws, losses, points, goals_difference)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := s.conn.Exec(showpieceSQL); err != nil {
+		return fmt.Errorf("failed to create showpiece_matches table: %v", err)
+	}
 
-	if s.driverName == "postgres" {
-		query = `
-		INSERT INTO teams (id, name, strength, goals_for, goals_against, wins, draws, losses, points, goals_difference)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT (id) DO UPDATE SET
-			name = EXCLUDED.name,
-			strength = EXCLUDED.strength,
-			goals_for = EXCLUDED.goals_for,
-			goals_against = EXCLUDED.goals_against,
-			wins = EXCLUDED.wins,
-			draws = EXCLUDED.draws,
-			losses = EXCLUDED.losses,
-			points = EXCLUDED.points,
-			goals_difference = EXCLUDED.goals_difference`
+	// Extra time and when in the league calendar it was played, for fatigue
+	if err := s.addColumnIfMissing("showpiece_matches", "extra_time", "BOOLEAN DEFAULT FALSE"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("showpiece_matches", "season", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("showpiece_matches", "league_week", "INTEGER DEFAULT 0"); err != nil {
+		return err
 	}
 
-	_, err := s.db.Exec(query, team.TeamId, team.TeamName, team.TeamStrength,
-		team.GoalsFor, team.GoalsAgainst, team.Wins, team.Draws,
-		team.Losses, team.Points, team.GoalsDifference)
+	// Events timelines of one-off matches, including penalty shootout kicks
+	showpieceEventsSQL := `
+	CREATE TABLE IF NOT EXISTS showpiece_events (
+		showpiece_id INTEGER NOT NULL,
+		minute INTEGER NOT NULL,
+		added_time INTEGER DEFAULT 0,
+		event_type TEXT NOT NULL,
+		team_id INTEGER NOT NULL,
+		kick INTEGER DEFAULT 0,
+		score_before TEXT DEFAULT '',
+		score_after TEXT DEFAULT '',
+		FOREIGN KEY (showpiece_id) REFERENCES showpiece_matches(id),
+		FOREIGN KEY (team_id) REFERENCES teams(id)
+	)`
 
-	if err != nil {
-		return fmt.Errorf("failed to update team: %v", err)
+	if _, err := s.conn.Exec(showpieceEventsSQL); err != nil {
+		return fmt.Errorf("failed to create showpiece_events table: %v", err)
 	}
 
-	return nil
-}
+	// Create season history tables, filled in when a season is rolled over
+	seasonSummariesSQL := `
+	CREATE TABLE IF NOT EXISTS season_summaries (
+		season INTEGER PRIMARY KEY,
+		matches_played INTEGER NOT NULL,
+		total_goals INTEGER NOT NULL,
+		average_goals REAL NOT NULL,
+		home_win_percentage REAL NOT NULL,
+		completed_at TIMESTAMP NOT NULL
+	)`
 
-// GetCurrentWeek retrieves current week from database
-func (s *SQLStorageService) GetCurrentWeek() (int, error) {
-	var currentWeek int
-	err := s.db.QueryRow("SELECT current_week FROM league_state WHERE id = 1").Scan(&currentWeek)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get current week: %v", err)
+	if _, err := s.conn.Exec(seasonSummariesSQL); err != nil {
+		return fmt.Errorf("failed to create season_summaries table: %v", err)
 	}
-	return currentWeek, nil
-}
 
-// UpdateCurrentWeek updates current week in database
-func (s *SQLStorageService) UpdateCurrentWeek(week int) error {
-	query := "UPDATE league_state SET current_week = ? WHERE id = 1"
-	if s.driverName == "postgres" {
-		query = "UPDATE league_state SET current_week = $1 WHERE id = 1"
+	seasonStandingsSQL := `
+	CREATE TABLE IF NOT EXISTS season_standings (
+		season INTEGER NOT NULL,
+		team_name TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		played INTEGER NOT NULL,
+		wins INTEGER NOT NULL,
+		draws INTEGER NOT NULL,
+		losses INTEGER NOT NULL,
+		goals_for INTEGER NOT NULL,
+		goals_against INTEGER NOT NULL,
+		points INTEGER NOT NULL,
+		PRIMARY KEY (season, team_name)
+	)`
+
+	if _, err := s.conn.Exec(seasonStandingsSQL); err != nil {
+		return fmt.Errorf("failed to create season_standings table: %v", err)
 	}
 
-	_, err := s.db.Exec(query, week)
-	if err != nil {
-		return fmt.Errorf("failed to update current week: %v", err)
+	// Cumulative standings, updated incrementally whenever a season is rolled over
+	allTimeSQL := `
+	CREATE TABLE IF NOT EXISTS all_time_standings (
+		team_name TEXT PRIMARY KEY,
+		seasons INTEGER NOT NULL DEFAULT 0,
+		played INTEGER NOT NULL DEFAULT 0,
+		wins INTEGER NOT NULL DEFAULT 0,
+		draws INTEGER NOT NULL DEFAULT 0,
+		losses INTEGER NOT NULL DEFAULT 0,
+		goals_for INTEGER NOT NULL DEFAULT 0,
+		goals_against INTEGER NOT NULL DEFAULT 0,
+		points INTEGER NOT NULL DEFAULT 0,
+		titles INTEGER NOT NULL DEFAULT 0
+	)`
+
+	if _, err := s.conn.Exec(allTimeSQL); err != nil {
+		return fmt.Errorf("failed to create all_time_standings table: %v", err)
 	}
-	return nil
-}
 
-// Close closes the database connection
-func (s *SQLStorageService) Close() error {
-	return s.db.Close()
-}
+	// Trophies and runner-up finishes across seasons and competitions
+	honoursSQL := `
+	CREATE TABLE IF NOT EXISTS honours (
+		team_id INTEGER NOT NULL,
+		team_name TEXT NOT NULL,
+		season INTEGER NOT NULL,
+		competition TEXT NOT NULL,
+		achievement TEXT NOT NULL
+	)`
 
-// InitializeTeamsAndMatches populates the database with initial data
-func (s *SQLStorageService) InitializeTeamsAndMatches() error {
-	// Check if teams already exist
-	teams, err := s.GetTeams()
-	if err != nil {
-		return err
+	if _, err := s.conn.Exec(honoursSQL); err != nil {
+		return fmt.Errorf("failed to create honours table: %v", err)
 	}
 
-	if len(teams) > 0 {
-		log.Println("Teams already exist in database, skipping initialization")
-		return nil
+	// Teams qualified for continental competitions, by the season they play in
+	continentalSQL := `
+	CREATE TABLE IF NOT EXISTS continental_entrants (
+		competition TEXT NOT NULL,
+		season INTEGER NOT NULL,
+		team_id INTEGER NOT NULL,
+		team_name TEXT NOT NULL,
+		position INTEGER NOT NULL
+	)`
+
+	if _, err := s.conn.Exec(continentalSQL); err != nil {
+		return fmt.Errorf("failed to create continental_entrants table: %v", err)
+	}
+
+	// All-time record book and the unbeaten runs carried between seasons
+	recordsSQL := `
+	CREATE TABLE IF NOT EXISTS records (
+		record_key TEXT PRIMARY KEY,
+		team_name TEXT NOT NULL,
+		value INTEGER NOT NULL,
+		season INTEGER NOT NULL,
+		detail TEXT
+	)`
+
+	if _, err := s.conn.Exec(recordsSQL); err != nil {
+		return fmt.Errorf("failed to create records table: %v", err)
 	}
 
-	// Create initial teams
-	initialTeams := createPremierLeagueTeams()
+	unbeatenRunsSQL := `
+	CREATE TABLE IF NOT EXISTS unbeaten_runs (
+		team_name TEXT PRIMARY KEY,
+		current_run INTEGER NOT NULL DEFAULT 0
+	)`
+
+	if _, err := s.conn.Exec(unbeatenRunsSQL); err != nil {
+		return fmt.Errorf("failed to create unbeaten_runs table: %v", err)
+	}
+
+	// Weeks locked by hand against result edits, per season
+	weekLocksSQL := `
+	CREATE TABLE IF NOT EXISTS week_locks (
+		season INTEGER NOT NULL,
+		week INTEGER NOT NULL,
+		locked_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (season, week)
+	)`
+
+	if _, err := s.conn.Exec(weekLocksSQL); err != nil {
+		return fmt.Errorf("failed to create week_locks table: %v", err)
+	}
+
+	// Named table zones of the league, replacing the rules' qualification spots when set
+	leagueZonesSQL := `
+	CREATE TABLE IF NOT EXISTS league_zones (
+		name TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		from_position INTEGER NOT NULL,
+		to_position INTEGER NOT NULL,
+		color TEXT NOT NULL DEFAULT ''
+	)`
+
+	if _, err := s.conn.Exec(leagueZonesSQL); err != nil {
+		return fmt.Errorf("failed to create league_zones table: %v", err)
+	}
+
+	// Feature flag rollouts set for this league, over those of the simulation config
+	leagueFeaturesSQL := `
+	CREATE TABLE IF NOT EXISTS league_features (
+		name TEXT PRIMARY KEY,
+		rollout REAL NOT NULL
+	)`
+
+	if _, err := s.conn.Exec(leagueFeaturesSQL); err != nil {
+		return fmt.Errorf("failed to create league_features table: %v", err)
+	}
+
+	// Teams controlled by players in multiplayer mode, with a hash of each controller's token
+	teamControllersSQL := `
+	CREATE TABLE IF NOT EXISTS team_controllers (
+		team_id INTEGER PRIMARY KEY,
+		player TEXT NOT NULL,
+		mode TEXT NOT NULL,
+		token_hash TEXT NOT NULL,
+		claimed_at TIMESTAMP NOT NULL
+	)`
+
+	if _, err := s.conn.Exec(teamControllersSQL); err != nil {
+		return fmt.Errorf("failed to create team_controllers table: %v", err)
+	}
+
+	// Controllers' orders for their teams' matches: a tactic, or the result in hotseat mode
+	matchOrdersSQL := `
+	CREATE TABLE IF NOT EXISTS match_orders (
+		season INTEGER NOT NULL,
+		match_id INTEGER NOT NULL,
+		team_id INTEGER NOT NULL,
+		player TEXT NOT NULL,
+		tactic TEXT NOT NULL DEFAULT '',
+		home_score INTEGER NULL,
+		away_score INTEGER NULL,
+		submitted_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (season, match_id, team_id)
+	)`
+
+	if _, err := s.conn.Exec(matchOrdersSQL); err != nil {
+		return fmt.Errorf("failed to create match_orders table: %v", err)
+	}
+
+	// Each season's squad draft; team_order holds the first round's team IDs, comma-separated
+	draftsSQL := `
+	CREATE TABLE IF NOT EXISTS drafts (
+		season INTEGER PRIMARY KEY,
+		team_order TEXT NOT NULL,
+		rounds INTEGER NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		pick_started_at TIMESTAMP NOT NULL,
+		completed_at TIMESTAMP NULL
+	)`
+
+	if _, err := s.conn.Exec(draftsSQL); err != nil {
+		return fmt.Errorf("failed to create drafts table: %v", err)
+	}
+
+	// The generated player pool of each draft, with the team that drafted each player
+	draftPlayersSQL := `
+	CREATE TABLE IF NOT EXISTS draft_players (
+		season INTEGER NOT NULL,
+		player_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		position TEXT NOT NULL,
+		rating INTEGER NOT NULL,
+		team_id INTEGER NULL,
+		pick INTEGER NULL,
+		picked_at TIMESTAMP NULL,
+		auto_picked BOOLEAN DEFAULT FALSE,
+		PRIMARY KEY (season, player_id)
+	)`
+
+	if _, err := s.conn.Exec(draftPlayersSQL); err != nil {
+		return fmt.Errorf("failed to create draft_players table: %v", err)
+	}
+
+	// Each team's handicap for the season, fixed when it starts; teams without one have no row
+	teamHandicapsSQL := `
+	CREATE TABLE IF NOT EXISTS team_handicaps (
+		team_id INTEGER PRIMARY KEY,
+		handicap REAL NOT NULL
+	)`
+
+	if _, err := s.conn.Exec(teamHandicapsSQL); err != nil {
+		return fmt.Errorf("failed to create team_handicaps table: %v", err)
+	}
+
+	// Championship probabilities recorded after every simulated week
+	predictionsHistorySQL := `
+	CREATE TABLE IF NOT EXISTS predictions_history (
+		season INTEGER NOT NULL,
+		week INTEGER NOT NULL,
+		team_name TEXT NOT NULL,
+		probability REAL NOT NULL,
+		recorded_at TIMESTAMP NOT NULL
+	)`
+
+	if _, err := s.conn.Exec(predictionsHistorySQL); err != nil {
+		return fmt.Errorf("failed to create predictions_history table: %v", err)
+	}
+
+	// Players' forecasts for the prediction game, one per player and match
+	userPredictionsSQL := `
+	CREATE TABLE IF NOT EXISTS user_predictions (
+		season INTEGER NOT NULL,
+		match_id INTEGER NOT NULL,
+		player TEXT NOT NULL,
+		home_win REAL NOT NULL,
+		draw REAL NOT NULL,
+		away_win REAL NOT NULL,
+		submitted_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (season, match_id, player)
+	)`
+
+	if _, err := s.conn.Exec(userPredictionsSQL); err != nil {
+		return fmt.Errorf("failed to create user_predictions table: %v", err)
+	}
+
+	// Prediction game players' settings, such as the time zone kickoffs are shown in
+	playerProfilesSQL := `
+	CREATE TABLE IF NOT EXISTS player_profiles (
+		player TEXT PRIMARY KEY,
+		timezone TEXT NOT NULL
+	)`
+
+	if _, err := s.conn.Exec(playerProfilesSQL); err != nil {
+		return fmt.Errorf("failed to create player_profiles table: %v", err)
+	}
+
+	// Managerial spells, including sackings and their reasons
+	managersSQL := `
+	CREATE TABLE IF NOT EXISTS managers (
+		id INTEGER PRIMARY KEY,
+		team_name TEXT NOT NULL,
+		name TEXT NOT NULL,
+		season INTEGER NOT NULL,
+		appointed_week INTEGER NOT NULL,
+		departed_season INTEGER DEFAULT 0,
+		departed_week INTEGER DEFAULT 0,
+		departure_reason TEXT DEFAULT ''
+	)`
+
+	if _, err := s.conn.Exec(managersSQL); err != nil {
+		return fmt.Errorf("failed to create managers table: %v", err)
+	}
+
+	// Audit trail of manual team rating changes
+	teamAuditSQL := `
+	CREATE TABLE IF NOT EXISTS team_audit (
+		team_id INTEGER NOT NULL,
+		field TEXT NOT NULL,
+		old_value INTEGER NOT NULL,
+		new_value INTEGER NOT NULL,
+		changed_by TEXT NOT NULL,
+		changed_at TIMESTAMP NOT NULL
+	)`
+
+	if _, err := s.conn.Exec(teamAuditSQL); err != nil {
+		return fmt.Errorf("failed to create team_audit table: %v", err)
+	}
+
+	// Former names of teams, so history follows a team through renames
+	teamNamesSQL := `
+	CREATE TABLE IF NOT EXISTS team_name_history (
+		team_id INTEGER NOT NULL,
+		old_name TEXT NOT NULL,
+		new_name TEXT NOT NULL,
+		season INTEGER NOT NULL,
+		changed_at TIMESTAMP NOT NULL
+	)`
+
+	if _, err := s.conn.Exec(teamNamesSQL); err != nil {
+		return fmt.Errorf("failed to create team_name_history table: %v", err)
+	}
+
+	// Create league_state table for current week tracking
+	leagueStateSQL := `
+	CREATE TABLE IF NOT EXISTS league_state (
+		id INTEGER PRIMARY KEY DEFAULT 1,
+		current_week INTEGER DEFAULT 0
+	)`
+
+	if _, err := s.conn.Exec(leagueStateSQL); err != nil {
+		return fmt.Errorf("failed to create league_state table: %v", err)
+	}
+
+	// Archive timestamp, NULL while the league is active
+	if err := s.addColumnIfMissing("league_state", "archived_at", "TIMESTAMP NULL"); err != nil {
+		return err
+	}
+
+	// Revision counter bumped on every write so instances sharing the database can detect changes
+	if err := s.addColumnIfMissing("league_state", "revision", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Time of the last revision bump, NULL until the league is first written
+	if err := s.addColumnIfMissing("league_state", "modified_at", "TIMESTAMP NULL"); err != nil {
+		return err
+	}
+
+	// Season number; the first season is the year the database was created
+	if err := s.addColumnIfMissing("league_state", "season", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Season state (not_started, in_progress or finished), rewritten with every change;
+	// empty until the league is first written
+	if err := s.addColumnIfMissing("league_state", "season_state", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// When the next week started taking orders from human controllers, for their deadline
+	if err := s.addColumnIfMissing("league_state", "orders_opened_at", "TIMESTAMP NULL"); err != nil {
+		return err
+	}
+
+	// The league's handicap: its mode ('' for none), the rate per strength point and the cap
+	if err := s.addColumnIfMissing("league_state", "handicap_mode", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("league_state", "handicap_rate", "REAL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("league_state", "handicap_max", "REAL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// The sport preset the league was created with; empty for a league older than presets, which plays football
+	if err := s.addColumnIfMissing("league_state", "sport", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// How a match level after regulation time was won, in a sport with overtime
+	if err := s.addColumnIfMissing("matches", "decided_by", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("archived_matches", "decided_by", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// Create tenants table, the registry of leagues hosted in tenancy mode
+	tenantsSQL := `
+	CREATE TABLE IF NOT EXISTS tenants (
+		id TEXT PRIMARY KEY,
+		api_key_hash TEXT NOT NULL UNIQUE,
+		backend TEXT NOT NULL,
+		location TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	)`
+
+	if _, err := s.conn.Exec(tenantsSQL); err != nil {
+		return fmt.Errorf("failed to create tenants table: %v", err)
+	}
+
+	// Initialize league state if not exists
+	var count int
+	err := s.conn.QueryRow("SELECT COUNT(*) FROM league_state").Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check league_state: %v", err)
+	}
+
+	if count == 0 {
+		_, err := s.conn.Exec("INSERT INTO league_state (current_week) VALUES (0)")
+		if err != nil {
+			return fmt.Errorf("failed to initialize league_state: %v", err)
+		}
+	}
+
+	seasonQuery := "UPDATE league_state SET season = ? WHERE COALESCE(season, 0) = 0"
+	if s.driverName == "postgres" {
+		seasonQuery = "UPDATE league_state SET season = $1 WHERE COALESCE(season, 0) = 0"
+	}
+	if _, err := s.conn.Exec(seasonQuery, time.Now().Year()); err != nil {
+		return fmt.Errorf("failed to initialize season: %v", err)
+	}
+
+	return nil
+}
+
+// addColumnIfMissing adds a column to an existing table so databases created
+// by older versions pick up new fields without a manual migration
+func (s *SQLStorageService) addColumnIfMissing(table, column, definition string) error {
+	if s.driverName == "postgres" {
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, column, definition)
+		if _, err := s.conn.Exec(query); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %v", table, column, err)
+		}
+		return nil
+	}
+
+	rows, err := s.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("failed to scan column info: %v", err)
+		}
+		if strings.EqualFold(name, column) {
+			return nil
+		}
+	}
+	rows.Close()
+
+	query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
+	if _, err := s.conn.Exec(query); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %v", table, column, err)
+	}
+	return nil
+}
+
+// SaveMatchResult saves or updates a match result
+func (s *SQLStorageService) SaveMatchResult(match *sim.Match) error {
+	query := `
+	INSERT OR REPLACE INTO matches (id, week, home_team_id, away_team_id, home_score, away_score, played, home_xg, away_xg, features, explanation, venue, referee_id, commentary, kickoff, decided_by)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	if s.driverName == "postgres" {
+		query = `
+		INSERT INTO matches (id, week, home_team_id, away_team_id, home_score, away_score, played, home_xg, away_xg, features, explanation, venue, referee_id, commentary, kickoff, decided_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (id) DO UPDATE SET
+			week = EXCLUDED.week,
+			home_team_id = EXCLUDED.home_team_id,
+			away_team_id = EXCLUDED.away_team_id,
+			home_score = EXCLUDED.home_score,
+			away_score = EXCLUDED.away_score,
+			played = EXCLUDED.played,
+			home_xg = EXCLUDED.home_xg,
+			away_xg = EXCLUDED.away_xg,
+			features = EXCLUDED.features,
+			explanation = EXCLUDED.explanation,
+			venue = EXCLUDED.venue,
+			referee_id = EXCLUDED.referee_id,
+			commentary = EXCLUDED.commentary,
+			kickoff = EXCLUDED.kickoff,
+			decided_by = EXCLUDED.decided_by`
+	}
+
+	explanation, err := sim.EncodeExplanation(match.Explanation)
+	if err != nil {
+		return err
+	}
+
+	refereeId := 0
+	if match.Referee != nil {
+		refereeId = match.Referee.Id
+	}
+
+	var kickoff interface{}
+	if match.Kickoff != nil {
+		kickoff = match.Kickoff.UTC()
+	}
+
+	_, err = s.conn.Exec(query, match.MatchId, match.Week, match.HomeTeam.TeamId, 
+		match.AwayTeam.TeamId, match.HomeTeamScore, match.AwayTeamScore, match.Played,
+		match.HomeXG, match.AwayXG, sim.EncodeFeatures(match.Features), explanation, match.Venue, refereeId, match.Commentary, kickoff, match.DecidedBy)
+	
+	if err != nil {
+		return fmt.Errorf("failed to save match result: %v", err)
+	}
+
+	return s.saveMatchEvents(match)
+}
+
+// ReplaceMatches discards every fixture and its events and stores the given schedule
+func (s *SQLStorageService) ReplaceMatches(matches []*sim.Match) error {
+	if _, err := s.conn.Exec("DELETE FROM match_events"); err != nil {
+		return fmt.Errorf("failed to clear match events: %v", err)
+	}
+	if _, err := s.conn.Exec("DELETE FROM matches"); err != nil {
+		return fmt.Errorf("failed to clear matches: %v", err)
+	}
+
+	for _, match := range matches {
+		if err := s.SaveMatchResult(match); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveReferees stores the referee pool, replacing existing referees with the same ID
+func (s *SQLStorageService) SaveReferees(referees []*sim.Referee) error {
+	query := "INSERT OR REPLACE INTO referees (id, name, card_tendency, penalty_tendency) VALUES (?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		query = `
+		INSERT INTO referees (id, name, card_tendency, penalty_tendency) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			card_tendency = EXCLUDED.card_tendency,
+			penalty_tendency = EXCLUDED.penalty_tendency`
+	}
+
+	for _, referee := range referees {
+		if _, err := s.conn.Exec(query, referee.Id, referee.Name, referee.CardTendency, referee.PenaltyTendency); err != nil {
+			return fmt.Errorf("failed to save referee: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetReferees retrieves the referee pool
+func (s *SQLStorageService) GetReferees() ([]*sim.Referee, error) {
+	rows, err := s.conn.Query("SELECT id, name, card_tendency, penalty_tendency FROM referees ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query referees: %v", err)
+	}
+	defer rows.Close()
+
+	referees := []*sim.Referee{}
+	for rows.Next() {
+		referee := &sim.Referee{}
+		if err := rows.Scan(&referee.Id, &referee.Name, &referee.CardTendency, &referee.PenaltyTendency); err != nil {
+			return nil, fmt.Errorf("failed to scan referee: %v", err)
+		}
+		referees = append(referees, referee)
+	}
+
+	return referees, nil
+}
+
+// saveMatchEvents replaces the stored events timeline of a match
+func (s *SQLStorageService) saveMatchEvents(match *sim.Match) error {
+	deleteQuery := "DELETE FROM match_events WHERE match_id = ?"
+	insertQuery := "INSERT INTO match_events (match_id, minute, added_time, event_type, team_id, score_before, score_after) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		deleteQuery = "DELETE FROM match_events WHERE match_id = $1"
+		insertQuery = "INSERT INTO match_events (match_id, minute, added_time, event_type, team_id, score_before, score_after) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	}
+
+	if _, err := s.conn.Exec(deleteQuery, match.MatchId); err != nil {
+		return fmt.Errorf("failed to clear match events: %v", err)
+	}
+
+	for _, event := range match.Events {
+		if _, err := s.conn.Exec(insertQuery, match.MatchId, event.Minute, event.AddedTime, event.Type, event.TeamId, event.ScoreBefore, event.ScoreAfter); err != nil {
+			return fmt.Errorf("failed to save match event: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// getMatchEvents retrieves the stored events of the given matches grouped by match ID
+func (s *SQLStorageService) getMatchEvents(matchIds []int) (map[int][]sim.MatchEvent, error) {
+	events := make(map[int][]sim.MatchEvent)
+	if len(matchIds) == 0 {
+		return events, nil
+	}
+
+	placeholders := make([]string, len(matchIds))
+	args := make([]interface{}, len(matchIds))
+	for i, matchId := range matchIds {
+		placeholders[i] = "?"
+		if s.driverName == "postgres" {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		}
+		args[i] = matchId
+	}
+	query := `
+	SELECT e.match_id, e.minute, COALESCE(e.added_time, 0), e.event_type, e.team_id, t.name,
+		COALESCE(e.score_before, ''), COALESCE(e.score_after, '')
+	FROM match_events e
+	JOIN teams t ON e.team_id = t.id
+	WHERE e.match_id IN (` + strings.Join(placeholders, ", ") + `)
+	ORDER BY e.match_id, e.minute, e.added_time`
+
+	rows, err := s.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query match events: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var matchId int
+		var event sim.MatchEvent
+		if err := rows.Scan(&matchId, &event.Minute, &event.AddedTime, &event.Type, &event.TeamId, &event.TeamName, &event.ScoreBefore, &event.ScoreAfter); err != nil {
+			return nil, fmt.Errorf("failed to scan match event: %v", err)
+		}
+		events[matchId] = append(events[matchId], event)
+	}
+
+	return events, nil
+}
+
+// matchPageSize is how many matches EachMatch reads per query
+const matchPageSize = 500
+
+// GetMatches retrieves all matches from database
+func (s *SQLStorageService) GetMatches() ([]*sim.Match, error) {
+	var matches []*sim.Match
+	err := s.EachMatch(matchPageSize, func(match *sim.Match) error {
+		matches = append(matches, match)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// EachMatch calls fn with every match in week order, reading pageSize matches and
+// their events at a time, so the whole fixture list never has to be held in memory.
+// Pages are keyed on the last week and match ID seen rather than an offset, so each
+// query is an index seek however far into the list it starts. An error from fn stops
+// the iteration and is returned.
+func (s *SQLStorageService) EachMatch(pageSize int, fn func(match *sim.Match) error) error {
+	if pageSize < 1 {
+		return fmt.Errorf("page size must be at least 1, got %d", pageSize)
+	}
+
+	teamCache := make(map[int]*sim.Team)
+	afterWeek, afterId := 0, 0
+	for {
+		page, err := s.getMatchPage(afterWeek, afterId, pageSize, teamCache)
+		if err != nil {
+			return err
+		}
+		for _, match := range page {
+			if err := fn(match); err != nil {
+				return err
+			}
+		}
+		if len(page) < pageSize {
+			return nil
+		}
+		last := page[len(page)-1]
+		afterWeek, afterId = last.Week, last.MatchId
+	}
+}
+
+// GetOrphanedMatchIds returns the IDs of stored matches whose home or away team no
+// longer exists. GetMatches cannot see them, since it joins each match to its teams.
+func (s *SQLStorageService) GetOrphanedMatchIds() ([]int, error) {
+	query := `
+	SELECT m.id
+	FROM matches m
+	LEFT JOIN teams ht ON m.home_team_id = ht.id
+	LEFT JOIN teams at ON m.away_team_id = at.id
+	WHERE ht.id IS NULL OR at.id IS NULL
+	ORDER BY m.id`
+
+	rows, err := s.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned matches: %v", err)
+	}
+	defer rows.Close()
+
+	matchIds := []int{}
+	for rows.Next() {
+		var matchId int
+		if err := rows.Scan(&matchId); err != nil {
+			return nil, fmt.Errorf("failed to scan orphaned match: %v", err)
+		}
+		matchIds = append(matchIds, matchId)
+	}
+	return matchIds, nil
+}
+
+// ArchiveSeasonMatches copies the stored matches and events into the archive tables
+// under season, replacing anything archived for it before. Rolling over then
+// overwrites the hot tables with the next season's fixtures, so they only ever hold
+// one season.
+func (s *SQLStorageService) ArchiveSeasonMatches(season int) error {
+	param := "?"
+	if s.driverName == "postgres" {
+		param = "CAST($1 AS INTEGER)"
+	}
+	statements := []string{
+		"DELETE FROM archived_match_events WHERE season = " + param,
+		"DELETE FROM archived_matches WHERE season = " + param,
+		`INSERT INTO archived_matches (season, id, week, home_team_id, home_team_name, away_team_id, away_team_name,
+			home_score, away_score, home_xg, away_xg, features, venue, referee_id, referee_name, kickoff, decided_by)
+		SELECT ` + param + `, m.id, m.week, m.home_team_id, ht.name, m.away_team_id, at.name,
+			m.home_score, m.away_score, m.home_xg, m.away_xg, COALESCE(m.features, ''), COALESCE(m.venue, ''),
+			COALESCE(m.referee_id, 0), COALESCE(r.name, ''), m.kickoff, COALESCE(m.decided_by, '')
+		FROM matches m
+		JOIN teams ht ON m.home_team_id = ht.id
+		JOIN teams at ON m.away_team_id = at.id
+		LEFT JOIN referees r ON m.referee_id = r.id`,
+		`INSERT INTO archived_match_events (season, match_id, minute, added_time, event_type, team_id, team_name, score_before, score_after)
+		SELECT ` + param + `, e.match_id, e.minute, COALESCE(e.added_time, 0), e.event_type, e.team_id, t.name,
+			COALESCE(e.score_before, ''), COALESCE(e.score_after, '')
+		FROM match_events e
+		JOIN teams t ON e.team_id = t.id`,
+	}
+
+	for _, statement := range statements {
+		if _, err := s.conn.Exec(statement, season); err != nil {
+			return fmt.Errorf("failed to archive season %d matches: %v", season, err)
+		}
+	}
+	return nil
+}
+
+// GetArchivedMatches retrieves a completed season's matches and events from the
+// archive in week order; the result is empty for a season that was never archived
+func (s *SQLStorageService) GetArchivedMatches(season int) ([]*sim.Match, error) {
+	matchesQuery := `
+	SELECT id, week, home_team_id, home_team_name, away_team_id, away_team_name, home_score, away_score,
+		home_xg, away_xg, COALESCE(features, ''), COALESCE(venue, ''), COALESCE(referee_id, 0), COALESCE(referee_name, ''), kickoff,
+		COALESCE(decided_by, '')
+	FROM archived_matches
+	WHERE season = ?
+	ORDER BY week, id`
+	eventsQuery := `
+	SELECT match_id, minute, COALESCE(added_time, 0), event_type, team_id, team_name,
+		COALESCE(score_before, ''), COALESCE(score_after, '')
+	FROM archived_match_events
+	WHERE season = ?
+	ORDER BY match_id, minute, added_time`
+	if s.driverName == "postgres" {
+		matchesQuery = strings.Replace(matchesQuery, "season = ?", "season = $1", 1)
+		eventsQuery = strings.Replace(eventsQuery, "season = ?", "season = $1", 1)
+	}
+
+	events := make(map[int][]sim.MatchEvent)
+	eventRows, err := s.conn.Query(eventsQuery, season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archived match events: %v", err)
+	}
+	defer eventRows.Close()
+	for eventRows.Next() {
+		var matchId int
+		var event sim.MatchEvent
+		if err := eventRows.Scan(&matchId, &event.Minute, &event.AddedTime, &event.Type, &event.TeamId, &event.TeamName, &event.ScoreBefore, &event.ScoreAfter); err != nil {
+			return nil, fmt.Errorf("failed to scan archived match event: %v", err)
+		}
+		events[matchId] = append(events[matchId], event)
+	}
+	eventRows.Close()
+
+	rows, err := s.conn.Query(matchesQuery, season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archived matches: %v", err)
+	}
+	defer rows.Close()
+
+	teams := make(map[int]*sim.Team)
+	team := func(id int, name string) *sim.Team {
+		if teams[id] == nil {
+			teams[id] = &sim.Team{TeamId: id, TeamName: name, Slug: sim.Slugify(name)}
+		}
+		return teams[id]
+	}
+
+	matches := []*sim.Match{}
+	for rows.Next() {
+		match := &sim.Match{Played: true}
+		var homeTeamId, awayTeamId, refereeId int
+		var homeName, awayName, features, refereeName string
+		var kickoff sql.NullTime
+
+		err := rows.Scan(&match.MatchId, &match.Week, &homeTeamId, &homeName, &awayTeamId, &awayName,
+			&match.HomeTeamScore, &match.AwayTeamScore, &match.HomeXG, &match.AwayXG, &features, &match.Venue,
+			&refereeId, &refereeName, &kickoff, &match.DecidedBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan archived match: %v", err)
+		}
+		match.HomeTeam = team(homeTeamId, homeName)
+		match.AwayTeam = team(awayTeamId, awayName)
+		match.Features = sim.DecodeFeatures(features)
+		if refereeId != 0 {
+			match.Referee = &sim.Referee{Id: refereeId, Name: refereeName}
+		}
+		if kickoff.Valid {
+			utc := kickoff.Time.UTC()
+			match.Kickoff = &utc
+		}
+		match.Events = events[match.MatchId]
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
+// getMatchPage retrieves up to limit matches ordered after the given week and match
+// ID, with their events; teams are shared through teamCache
+func (s *SQLStorageService) getMatchPage(afterWeek, afterId, limit int, teamCache map[int]*sim.Team) ([]*sim.Match, error) {
+	query := `
+	SELECT m.id, m.week, m.home_team_id, m.away_team_id, m.home_score, m.away_score, m.played,
+		   m.home_xg, m.away_xg, COALESCE(m.features, ''), COALESCE(m.explanation, ''), COALESCE(m.venue, ''), COALESCE(m.referee_id, 0), COALESCE(m.commentary, ''), m.kickoff,
+		   COALESCE(m.decided_by, ''),
+		   ht.name as home_name, ht.strength as home_strength,
+		   at.name as away_name, at.strength as away_strength
+	FROM matches m
+	JOIN teams ht ON m.home_team_id = ht.id
+	JOIN teams at ON m.away_team_id = at.id
+	WHERE m.week > ? OR (m.week = ? AND m.id > ?)
+	ORDER BY m.week, m.id
+	LIMIT ?`
+	if s.driverName == "postgres" {
+		query = strings.Replace(query, "WHERE m.week > ? OR (m.week = ? AND m.id > ?)", "WHERE m.week > $1 OR (m.week = $2 AND m.id > $3)", 1)
+		query = strings.Replace(query, "LIMIT ?", "LIMIT $4", 1)
+	}
+
+	rows, err := s.conn.Query(query, afterWeek, afterWeek, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query matches: %v", err)
+	}
+	defer rows.Close()
+
+	var matches []*sim.Match
+	var matchIds []int
+
+	for rows.Next() {
+		var match sim.Match
+		var homeTeamId, awayTeamId int
+		var homeName, awayName string
+		var homeStrength, awayStrength int
+		var features, explanation string
+		var refereeId int
+		var kickoff sql.NullTime
+
+		err := rows.Scan(&match.MatchId, &match.Week, &homeTeamId, &awayTeamId,
+			&match.HomeTeamScore, &match.AwayTeamScore, &match.Played,
+			&match.HomeXG, &match.AwayXG, &features, &explanation, &match.Venue, &refereeId, &match.Commentary, &kickoff,
+			&match.DecidedBy,
+			&homeName, &homeStrength, &awayName, &awayStrength)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan match: %v", err)
+		}
+		if kickoff.Valid {
+			utc := kickoff.Time.UTC()
+			match.Kickoff = &utc
+		}
+		match.Features = sim.DecodeFeatures(features)
+		if refereeId != 0 {
+			// Placeholder linked to the league's referee pool by loadLeague
+			match.Referee = &sim.Referee{Id: refereeId}
+		}
+		if match.Explanation, err = sim.DecodeExplanation(explanation); err != nil {
+			return nil, err
+		}
+
+		// Get or create home team
+		if homeTeam, exists := teamCache[homeTeamId]; exists {
+			match.HomeTeam = homeTeam
+		} else {
+			homeTeam := &sim.Team{
+				TeamId:       homeTeamId,
+				TeamName:     homeName,
+				TeamStrength: homeStrength,
+			}
+			teamCache[homeTeamId] = homeTeam
+			match.HomeTeam = homeTeam
+		}
+
+		// Get or create away team
+		if awayTeam, exists := teamCache[awayTeamId]; exists {
+			match.AwayTeam = awayTeam
+		} else {
+			awayTeam := &sim.Team{
+				TeamId:       awayTeamId,
+				TeamName:     awayName,
+				TeamStrength: awayStrength,
+			}
+			teamCache[awayTeamId] = awayTeam
+			match.AwayTeam = awayTeam
+		}
+
+		matches = append(matches, &match)
+		matchIds = append(matchIds, match.MatchId)
+	}
+	rows.Close()
+
+	events, err := s.getMatchEvents(matchIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range matches {
+		match.Events = events[match.MatchId]
+	}
+
+	return matches, nil
+}
+
+// GetTeams retrieves all teams from database
+func (s *SQLStorageService) GetTeams() ([]*sim.Team, error) {
+	query := `
+	SELECT id, name, strength, COALESCE(attack_rating, 0), COALESCE(defense_rating, 0),
+		   COALESCE(stadium, ''), COALESCE(city, ''),
+		   COALESCE(short_code, ''), COALESCE(primary_color, ''), COALESCE(secondary_color, ''), COALESCE(badge_url, ''),
+		   COALESCE(localized_names, ''),
+		   goals_for, goals_against, wins, draws, losses, points, goals_difference
+	FROM teams
+	ORDER BY id`
+
+	rows, err := s.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query teams: %v", err)
+	}
+	defer rows.Close()
+
+	var teams []*sim.Team
+	for rows.Next() {
+		var team sim.Team
+		var localizedNames string
+		err := rows.Scan(&team.TeamId, &team.TeamName, &team.TeamStrength,
+			&team.AttackRating, &team.DefenseRating, &team.Stadium, &team.City,
+			&team.ShortCode, &team.PrimaryColor, &team.SecondaryColor, &team.BadgeURL,
+			&localizedNames,
+			&team.GoalsFor, &team.GoalsAgainst, &team.Wins, &team.Draws,
+			&team.Losses, &team.Points, &team.GoalsDifference)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan team: %v", err)
+		}
+		if team.LocalizedNames, err = decodeLocalizedNames(localizedNames); err != nil {
+			return nil, err
+		}
+		teams = append(teams, &team)
+	}
+
+	return teams, nil
+}
+
+// UpdateTeam updates team statistics
+func (s *SQLStorageService) UpdateTeam(team *sim.Team) error {
+	query := `
+	INSERT OR REPLACE INTO teams (id, name, strength, goals_for, goals_against, wins, draws, losses, points, goals_difference, attack_rating, defense_rating, stadium, city, short_code, primary_color, secondary_color, badge_url, localized_names)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	if s.driverName == "postgres" {
+		query = `
+		INSERT INTO teams (id, name, strength, goals_for, goals_against, wins, draws, losses, points, goals_difference, attack_rating, defense_rating, stadium, city, short_code, primary_color, secondary_color, badge_url, localized_names)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			strength = EXCLUDED.strength,
+			goals_for = EXCLUDED.goals_for,
+			goals_against = EXCLUDED.goals_against,
+			wins = EXCLUDED.wins,
+			draws = EXCLUDED.draws,
+			losses = EXCLUDED.losses,
+			points = EXCLUDED.points,
+			goals_difference = EXCLUDED.goals_difference,
+			attack_rating = EXCLUDED.attack_rating,
+			defense_rating = EXCLUDED.defense_rating,
+			stadium = EXCLUDED.stadium,
+			city = EXCLUDED.city,
+			short_code = EXCLUDED.short_code,
+			primary_color = EXCLUDED.primary_color,
+			secondary_color = EXCLUDED.secondary_color,
+			badge_url = EXCLUDED.badge_url,
+			localized_names = EXCLUDED.localized_names`
+	}
+
+	_, err := s.conn.Exec(query, team.TeamId, team.TeamName, team.TeamStrength,
+		team.GoalsFor, team.GoalsAgainst, team.Wins, team.Draws,
+		team.Losses, team.Points, team.GoalsDifference,
+		team.AttackRating, team.DefenseRating, team.Stadium, team.City,
+		team.ShortCode, team.PrimaryColor, team.SecondaryColor, team.BadgeURL, encodeLocalizedNames(team.LocalizedNames))
+
+	if err != nil {
+		return fmt.Errorf("failed to update team: %v", err)
+	}
+
+	return nil
+}
+
+// ReplaceTeams makes the given teams the complete team set. Removed teams are
+// deleted along with the cup draws, showpiece matches and audit entries referencing them.
+func (s *SQLStorageService) ReplaceTeams(teams []*sim.Team) error {
+	existing, err := s.GetTeams()
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[int]bool)
+	for _, team := range teams {
+		keep[team.TeamId] = true
+	}
+
+	statements := []string{
+		"DELETE FROM cup_draws WHERE ? IN (home_team_id, away_team_id)",
+		"DELETE FROM showpiece_events WHERE showpiece_id IN (SELECT id FROM showpiece_matches WHERE ? IN (home_team_id, away_team_id))",
+		"DELETE FROM showpiece_matches WHERE ? IN (home_team_id, away_team_id)",
+		"DELETE FROM team_audit WHERE team_id = ?",
+		"DELETE FROM teams WHERE id = ?",
+	}
+	for _, team := range existing {
+		if keep[team.TeamId] {
+			continue
+		}
+		for _, statement := range statements {
+			if s.driverName == "postgres" {
+				statement = strings.Replace(statement, "?", "$1", 1)
+			}
+			if _, err := s.conn.Exec(statement, team.TeamId); err != nil {
+				return fmt.Errorf("failed to remove team %s: %v", team.TeamName, err)
+			}
+		}
+	}
+
+	for _, team := range teams {
+		if err := s.UpdateTeam(team); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameTeam moves a team's history from its old name to the new one and records
+// the change. Records already kept under the new name (e.g. after a rebrand back
+// to an old name) are merged rather than duplicated.
+func (s *SQLStorageService) RenameTeam(change *TeamNameChange) error {
+	bind := func(query string) string {
+		if s.driverName != "postgres" {
+			return query
+		}
+		for i := 1; strings.Contains(query, "?"); i++ {
+			query = strings.Replace(query, "?", fmt.Sprintf("$%d", i), 1)
+		}
+		return query
+	}
+
+	// Merge all-time standings into a single row under the new name
+	standings, err := s.GetAllTimeStandings()
+	if err != nil {
+		return err
+	}
+	var oldEntry, newEntry *AllTimeEntry
+	for _, entry := range standings {
+		switch entry.TeamName {
+		case change.OldName:
+			oldEntry = entry
+		case change.NewName:
+			newEntry = entry
+		}
+	}
+	if oldEntry != nil && newEntry != nil {
+		query := bind(`
+		UPDATE all_time_standings SET
+			seasons = seasons + ?, played = played + ?, wins = wins + ?, draws = draws + ?,
+			losses = losses + ?, goals_for = goals_for + ?, goals_against = goals_against + ?,
+			points = points + ?, titles = titles + ?
+		WHERE team_name = ?`)
+		_, err := s.conn.Exec(query, oldEntry.Seasons, oldEntry.Played, oldEntry.Wins, oldEntry.Draws,
+			oldEntry.Losses, oldEntry.GoalsFor, oldEntry.GoalsAgainst, oldEntry.Points, oldEntry.Titles, change.NewName)
+		if err != nil {
+			return fmt.Errorf("failed to merge all-time standings: %v", err)
+		}
+		if _, err := s.conn.Exec(bind("DELETE FROM all_time_standings WHERE team_name = ?"), change.OldName); err != nil {
+			return fmt.Errorf("failed to merge all-time standings: %v", err)
+		}
+	}
+
+	// The running unbeaten streak belongs to the team being renamed
+	runs, err := s.GetUnbeatenRuns()
+	if err != nil {
+		return err
+	}
+	if _, ok := runs[change.OldName]; ok {
+		if _, err := s.conn.Exec(bind("DELETE FROM unbeaten_runs WHERE team_name = ?"), change.NewName); err != nil {
+			return fmt.Errorf("failed to move unbeaten run: %v", err)
+		}
+	}
+
+	statements := []string{
+		"UPDATE all_time_standings SET team_name = ? WHERE team_name = ?",
+		"UPDATE unbeaten_runs SET team_name = ? WHERE team_name = ?",
+		"UPDATE season_standings SET team_name = ? WHERE team_name = ?",
+		"UPDATE records SET team_name = ? WHERE team_name = ?",
+		"UPDATE predictions_history SET team_name = ? WHERE team_name = ?",
+		"UPDATE managers SET team_name = ? WHERE team_name = ?",
+		"UPDATE showpiece_matches SET winner_name = ? WHERE winner_name = ?",
+	}
+	for _, statement := range statements {
+		if _, err := s.conn.Exec(bind(statement), change.NewName, change.OldName); err != nil {
+			return fmt.Errorf("failed to rename team history: %v", err)
+		}
+	}
+
+	if _, err := s.conn.Exec(bind("UPDATE honours SET team_name = ? WHERE team_id = ?"), change.NewName, change.TeamId); err != nil {
+		return fmt.Errorf("failed to rename team honours: %v", err)
+	}
+
+	if _, err := s.conn.Exec(bind("UPDATE continental_entrants SET team_name = ? WHERE team_id = ?"), change.NewName, change.TeamId); err != nil {
+		return fmt.Errorf("failed to rename continental entrants: %v", err)
+	}
+
+	_, err = s.conn.Exec(bind("INSERT INTO team_name_history (team_id, old_name, new_name, season, changed_at) VALUES (?, ?, ?, ?, ?)"),
+		change.TeamId, change.OldName, change.NewName, change.Season, change.ChangedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record team name change: %v", err)
+	}
+	return nil
+}
+
+// SaveManagers stores every manager spell, replacing earlier versions of the same spell
+func (s *SQLStorageService) SaveManagers(managers []*sim.Manager) error {
+	query := `
+	INSERT OR REPLACE INTO managers (id, team_name, name, season, appointed_week, departed_season, departed_week, departure_reason)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	if s.driverName == "postgres" {
+		query = `
+		INSERT INTO managers (id, team_name, name, season, appointed_week, departed_season, departed_week, departure_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE SET
+			team_name = EXCLUDED.team_name,
+			departed_season = EXCLUDED.departed_season,
+			departed_week = EXCLUDED.departed_week,
+			departure_reason = EXCLUDED.departure_reason`
+	}
+
+	for _, manager := range managers {
+		_, err := s.conn.Exec(query, manager.Id, manager.TeamName, manager.Name, manager.Season, manager.AppointedWeek,
+			manager.DepartedSeason, manager.DepartedWeek, manager.DepartureReason)
+		if err != nil {
+			return fmt.Errorf("failed to save manager: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetManagers retrieves every manager spell in order of appointment
+func (s *SQLStorageService) GetManagers() ([]*sim.Manager, error) {
+	rows, err := s.conn.Query(`
+	SELECT id, team_name, name, season, appointed_week, COALESCE(departed_season, 0),
+		COALESCE(departed_week, 0), COALESCE(departure_reason, '')
+	FROM managers ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query managers: %v", err)
+	}
+	defer rows.Close()
+
+	managers := []*sim.Manager{}
+	for rows.Next() {
+		manager := &sim.Manager{}
+		err := rows.Scan(&manager.Id, &manager.TeamName, &manager.Name, &manager.Season, &manager.AppointedWeek,
+			&manager.DepartedSeason, &manager.DepartedWeek, &manager.DepartureReason)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan manager: %v", err)
+		}
+		managers = append(managers, manager)
+	}
+
+	return managers, nil
+}
+
+// GetTeamNameHistory retrieves a team's former names, oldest first
+func (s *SQLStorageService) GetTeamNameHistory(teamId int) ([]*TeamNameChange, error) {
+	query := `
+	SELECT team_id, old_name, new_name, season, changed_at
+	FROM team_name_history
+	WHERE team_id = ?
+	ORDER BY changed_at`
+	if s.driverName == "postgres" {
+		query = strings.Replace(query, "?", "$1", 1)
+	}
+
+	rows, err := s.conn.Query(query, teamId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team name history: %v", err)
+	}
+	defer rows.Close()
+
+	changes := []*TeamNameChange{}
+	for rows.Next() {
+		change := &TeamNameChange{}
+		if err := rows.Scan(&change.TeamId, &change.OldName, &change.NewName, &change.Season, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan team name change: %v", err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// SaveTeamAudit records manual changes made to team ratings
+func (s *SQLStorageService) SaveTeamAudit(entries []*sim.TeamAuditEntry) error {
+	query := "INSERT INTO team_audit (team_id, field, old_value, new_value, changed_by, changed_at) VALUES (?, ?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		query = "INSERT INTO team_audit (team_id, field, old_value, new_value, changed_by, changed_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	}
+
+	for _, entry := range entries {
+		_, err := s.conn.Exec(query, entry.TeamId, entry.Field, entry.OldValue, entry.NewValue, entry.ChangedBy, entry.ChangedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save team audit entry: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetTeamAudit retrieves the rating change history of a team, most recent first
+func (s *SQLStorageService) GetTeamAudit(teamId int) ([]*sim.TeamAuditEntry, error) {
+	query := `
+	SELECT team_id, field, old_value, new_value, changed_by, changed_at
+	FROM team_audit
+	WHERE team_id = ?
+	ORDER BY changed_at DESC`
+	if s.driverName == "postgres" {
+		query = strings.Replace(query, "?", "$1", 1)
+	}
+
+	rows, err := s.conn.Query(query, teamId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team audit: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []*sim.TeamAuditEntry{}
+	for rows.Next() {
+		entry := &sim.TeamAuditEntry{}
+		if err := rows.Scan(&entry.TeamId, &entry.Field, &entry.OldValue, &entry.NewValue, &entry.ChangedBy, &entry.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan team audit entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// GetCurrentWeek retrieves current week from database
+func (s *SQLStorageService) GetCurrentWeek() (int, error) {
+	var currentWeek int
+	err := s.conn.QueryRow("SELECT current_week FROM league_state WHERE id = 1").Scan(&currentWeek)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current week: %v", err)
+	}
+	return currentWeek, nil
+}
+
+// UpdateCurrentWeek updates current week in database
+func (s *SQLStorageService) UpdateCurrentWeek(week int) error {
+	query := "UPDATE league_state SET current_week = ? WHERE id = 1"
+	if s.driverName == "postgres" {
+		query = "UPDATE league_state SET current_week = $1 WHERE id = 1"
+	}
+
+	_, err := s.conn.Exec(query, week)
+	if err != nil {
+		return fmt.Errorf("failed to update current week: %v", err)
+	}
+	return nil
+}
+
+// GetArchivedAt retrieves the archive timestamp, nil if the league is active
+func (s *SQLStorageService) GetArchivedAt() (*time.Time, error) {
+	var archivedAt sql.NullTime
+	err := s.conn.QueryRow("SELECT archived_at FROM league_state WHERE id = 1").Scan(&archivedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive state: %v", err)
+	}
+	if !archivedAt.Valid {
+		return nil, nil
+	}
+	return &archivedAt.Time, nil
+}
+
+// SetArchivedAt archives the league at the given time, or restores it when nil
+func (s *SQLStorageService) SetArchivedAt(archivedAt *time.Time) error {
+	query := "UPDATE league_state SET archived_at = ? WHERE id = 1"
+	if s.driverName == "postgres" {
+		query = "UPDATE league_state SET archived_at = $1 WHERE id = 1"
+	}
+
+	var value interface{}
+	if archivedAt != nil {
+		value = archivedAt.UTC()
+	}
+
+	if _, err := s.conn.Exec(query, value); err != nil {
+		return fmt.Errorf("failed to update archive state: %v", err)
+	}
+	return nil
+}
+
+// GetSeason retrieves the number of the season in progress
+func (s *SQLStorageService) GetSeason() (int, error) {
+	var season int
+	err := s.conn.QueryRow("SELECT season FROM league_state WHERE id = 1").Scan(&season)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get season: %v", err)
+	}
+	return season, nil
+}
+
+// GetSeasonState retrieves the stored season state, "" if none has been stored
+func (s *SQLStorageService) GetSeasonState() (sim.SeasonState, error) {
+	var state string
+	err := s.conn.QueryRow("SELECT COALESCE(season_state, '') FROM league_state WHERE id = 1").Scan(&state)
+	if err != nil {
+		return "", fmt.Errorf("failed to get season state: %v", err)
+	}
+	return sim.SeasonState(state), nil
+}
+
+// UpdateSeasonState stores the season state
+func (s *SQLStorageService) UpdateSeasonState(state sim.SeasonState) error {
+	query := "UPDATE league_state SET season_state = ? WHERE id = 1"
+	if s.driverName == "postgres" {
+		query = "UPDATE league_state SET season_state = $1 WHERE id = 1"
+	}
+
+	if _, err := s.conn.Exec(query, string(state)); err != nil {
+		return fmt.Errorf("failed to update season state: %v", err)
+	}
+	return nil
+}
+
+// UpdateSeason sets the number of the season in progress
+func (s *SQLStorageService) UpdateSeason(season int) error {
+	query := "UPDATE league_state SET season = ? WHERE id = 1"
+	if s.driverName == "postgres" {
+		query = "UPDATE league_state SET season = $1 WHERE id = 1"
+	}
+
+	if _, err := s.conn.Exec(query, season); err != nil {
+		return fmt.Errorf("failed to update season: %v", err)
+	}
+	return nil
+}
+
+// SaveSeasonSummary stores the final standings and aggregates of a completed season
+func (s *SQLStorageService) SaveSeasonSummary(summary *SeasonSummary) error {
+	summaryQuery := `
+	INSERT INTO season_summaries (season, matches_played, total_goals, average_goals, home_win_percentage, completed_at)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	standingQuery := `
+	INSERT INTO season_standings (season, team_name, position, played, wins, draws, losses, goals_for, goals_against, points)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if s.driverName == "postgres" {
+		summaryQuery = `
+		INSERT INTO season_summaries (season, matches_played, total_goals, average_goals, home_win_percentage, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+		standingQuery = `
+		INSERT INTO season_standings (season, team_name, position, played, wins, draws, losses, goals_for, goals_against, points)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	}
+
+	_, err := s.conn.Exec(summaryQuery, summary.Season, summary.MatchesPlayed, summary.TotalGoals,
+		summary.AverageGoalsPerMatch, summary.HomeWinPercentage, summary.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save season summary: %v", err)
+	}
+
+	for _, entry := range summary.Standings {
+		_, err := s.conn.Exec(standingQuery, summary.Season, entry.TeamName, entry.Position, entry.Played,
+			entry.Wins, entry.Draws, entry.Losses, entry.GoalsFor, entry.GoalsAgainst, entry.Points)
+		if err != nil {
+			return fmt.Errorf("failed to save season standing: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// GetSeasonSummaries retrieves every completed season, oldest first
+func (s *SQLStorageService) GetSeasonSummaries() ([]*SeasonSummary, error) {
+	rows, err := s.conn.Query(`
+	SELECT season, matches_played, total_goals, average_goals, home_win_percentage, completed_at
+	FROM season_summaries
+	ORDER BY season`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query season summaries: %v", err)
+	}
+	defer rows.Close()
+
+	var summaries []*SeasonSummary
+	bySeason := make(map[int]*SeasonSummary)
+	for rows.Next() {
+		summary := &SeasonSummary{}
+		var completedAt time.Time
+		err := rows.Scan(&summary.Season, &summary.MatchesPlayed, &summary.TotalGoals,
+			&summary.AverageGoalsPerMatch, &summary.HomeWinPercentage, &completedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan season summary: %v", err)
+		}
+		summary.CompletedAt = &completedAt
+		summaries = append(summaries, summary)
+		bySeason[summary.Season] = summary
+	}
+	rows.Close()
+
+	standingRows, err := s.conn.Query(`
+	SELECT season, team_name, position, played, wins, draws, losses, goals_for, goals_against, points
+	FROM season_standings
+	ORDER BY season, position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query season standings: %v", err)
+	}
+	defer standingRows.Close()
+
+	for standingRows.Next() {
+		var season int
+		entry := &sim.LeagueTableEntry{}
+		err := standingRows.Scan(&season, &entry.TeamName, &entry.Position, &entry.Played,
+			&entry.Wins, &entry.Draws, &entry.Losses, &entry.GoalsFor, &entry.GoalsAgainst, &entry.Points)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan season standing: %v", err)
+		}
+		entry.GoalsDifference = entry.GoalsFor - entry.GoalsAgainst
+		if summary, ok := bySeason[season]; ok {
+			summary.Standings = append(summary.Standings, entry)
+		}
+	}
+
+	return summaries, nil
+}
+
+// AddToAllTimeStandings accumulates a completed season's standings into the all-time table
+func (s *SQLStorageService) AddToAllTimeStandings(standings []*sim.LeagueTableEntry) error {
+	query := `
+	INSERT INTO all_time_standings (team_name, seasons, played, wins, draws, losses, goals_for, goals_against, points, titles)
+	VALUES (?, 1, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (team_name) DO UPDATE SET
+		seasons = all_time_standings.seasons + 1,
+		played = all_time_standings.played + EXCLUDED.played,
+		wins = all_time_standings.wins + EXCLUDED.wins,
+		draws = all_time_standings.draws + EXCLUDED.draws,
+		losses = all_time_standings.losses + EXCLUDED.losses,
+		goals_for = all_time_standings.goals_for + EXCLUDED.goals_for,
+		goals_against = all_time_standings.goals_against + EXCLUDED.goals_against,
+		points = all_time_standings.points + EXCLUDED.points,
+		titles = all_time_standings.titles + EXCLUDED.titles`
+	if s.driverName == "postgres" {
+		for i := 1; i <= 9; i++ {
+			query = strings.Replace(query, "?", fmt.Sprintf("$%d", i), 1)
+		}
+	}
+
+	for _, entry := range standings {
+		titles := 0
+		if entry.Position == 1 {
+			titles = 1
+		}
+		_, err := s.conn.Exec(query, entry.TeamName, entry.Played, entry.Wins, entry.Draws,
+			entry.Losses, entry.GoalsFor, entry.GoalsAgainst, entry.Points, titles)
+		if err != nil {
+			return fmt.Errorf("failed to update all-time standings: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// GetAllTimeStandings retrieves the cumulative standings of every completed season
+func (s *SQLStorageService) GetAllTimeStandings() ([]*AllTimeEntry, error) {
+	rows, err := s.conn.Query(`
+	SELECT team_name, seasons, played, wins, draws, losses, goals_for, goals_against, points, titles
+	FROM all_time_standings`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query all-time standings: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []*AllTimeEntry
+	for rows.Next() {
+		entry := &AllTimeEntry{}
+		err := rows.Scan(&entry.TeamName, &entry.Seasons, &entry.Played, &entry.Wins, &entry.Draws,
+			&entry.Losses, &entry.GoalsFor, &entry.GoalsAgainst, &entry.Points, &entry.Titles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan all-time standing: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// SaveHonours records trophies and runner-up finishes
+func (s *SQLStorageService) SaveHonours(honours []*Honour) error {
+	query := "INSERT INTO honours (team_id, team_name, season, competition, achievement) VALUES (?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		query = "INSERT INTO honours (team_id, team_name, season, competition, achievement) VALUES ($1, $2, $3, $4, $5)"
+	}
+
+	for _, honour := range honours {
+		_, err := s.conn.Exec(query, honour.TeamId, honour.TeamName, honour.Season, honour.Competition, honour.Achievement)
+		if err != nil {
+			return fmt.Errorf("failed to save honour: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetHonours retrieves every recorded honour, most recent season first
+func (s *SQLStorageService) GetHonours() ([]*Honour, error) {
+	rows, err := s.conn.Query(`
+	SELECT team_id, team_name, season, competition, achievement
+	FROM honours
+	ORDER BY season DESC, competition`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query honours: %v", err)
+	}
+	defer rows.Close()
+
+	var honours []*Honour
+	for rows.Next() {
+		honour := &Honour{}
+		if err := rows.Scan(&honour.TeamId, &honour.TeamName, &honour.Season, &honour.Competition, &honour.Achievement); err != nil {
+			return nil, fmt.Errorf("failed to scan honour: %v", err)
+		}
+		honours = append(honours, honour)
+	}
+
+	return honours, nil
+}
+
+// SaveContinentalEntrants records the teams qualified for continental competitions
+func (s *SQLStorageService) SaveContinentalEntrants(entrants []*ContinentalEntrant) error {
+	query := "INSERT INTO continental_entrants (competition, season, team_id, team_name, position) VALUES (?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		query = "INSERT INTO continental_entrants (competition, season, team_id, team_name, position) VALUES ($1, $2, $3, $4, $5)"
+	}
+
+	for _, entrant := range entrants {
+		_, err := s.conn.Exec(query, entrant.Competition, entrant.Season, entrant.TeamId, entrant.TeamName, entrant.Position)
+		if err != nil {
+			return fmt.Errorf("failed to save continental entrant: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetContinentalEntrants retrieves a season's continental entrants in qualifying order
+func (s *SQLStorageService) GetContinentalEntrants(season int) ([]*ContinentalEntrant, error) {
+	query := "SELECT competition, season, team_id, team_name, position FROM continental_entrants WHERE season = ? ORDER BY position"
+	if s.driverName == "postgres" {
+		query = "SELECT competition, season, team_id, team_name, position FROM continental_entrants WHERE season = $1 ORDER BY position"
+	}
+
+	rows, err := s.conn.Query(query, season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query continental entrants: %v", err)
+	}
+	defer rows.Close()
+
+	entrants := []*ContinentalEntrant{}
+	for rows.Next() {
+		entrant := &ContinentalEntrant{}
+		if err := rows.Scan(&entrant.Competition, &entrant.Season, &entrant.TeamId, &entrant.TeamName, &entrant.Position); err != nil {
+			return nil, fmt.Errorf("failed to scan continental entrant: %v", err)
+		}
+		entrants = append(entrants, entrant)
+	}
+
+	return entrants, nil
+}
+
+// SaveRecords inserts or replaces entries in the all-time record book
+func (s *SQLStorageService) SaveRecords(records []*Record) error {
+	query := `
+	INSERT INTO records (record_key, team_name, value, season, detail)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (record_key) DO UPDATE SET
+		team_name = EXCLUDED.team_name,
+		value = EXCLUDED.value,
+		season = EXCLUDED.season,
+		detail = EXCLUDED.detail`
+	if s.driverName == "postgres" {
+		for i := 1; i <= 5; i++ {
+			query = strings.Replace(query, "?", fmt.Sprintf("$%d", i), 1)
+		}
+	}
+
+	for _, record := range records {
+		if _, err := s.conn.Exec(query, record.Key, record.TeamName, record.Value, record.Season, record.Detail); err != nil {
+			return fmt.Errorf("failed to save record: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetRecords retrieves the all-time record book
+func (s *SQLStorageService) GetRecords() ([]*Record, error) {
+	rows, err := s.conn.Query("SELECT record_key, team_name, value, season, COALESCE(detail, '') FROM records ORDER BY record_key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %v", err)
+	}
+	defer rows.Close()
+
+	var records []*Record
+	for rows.Next() {
+		record := &Record{}
+		if err := rows.Scan(&record.Key, &record.TeamName, &record.Value, &record.Season, &record.Detail); err != nil {
+			return nil, fmt.Errorf("failed to scan record: %v", err)
+		}
+		record.Title = recordTitles[record.Key]
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// SaveUnbeatenRuns stores each team's unbeaten run at the end of a season
+func (s *SQLStorageService) SaveUnbeatenRuns(runs map[string]int) error {
+	query := `
+	INSERT INTO unbeaten_runs (team_name, current_run) VALUES (?, ?)
+	ON CONFLICT (team_name) DO UPDATE SET current_run = EXCLUDED.current_run`
+	if s.driverName == "postgres" {
+		query = `
+		INSERT INTO unbeaten_runs (team_name, current_run) VALUES ($1, $2)
+		ON CONFLICT (team_name) DO UPDATE SET current_run = EXCLUDED.current_run`
+	}
+
+	for teamName, run := range runs {
+		if _, err := s.conn.Exec(query, teamName, run); err != nil {
+			return fmt.Errorf("failed to save unbeaten run: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetUnbeatenRuns retrieves the unbeaten runs carried over from previous seasons
+func (s *SQLStorageService) GetUnbeatenRuns() (map[string]int, error) {
+	rows, err := s.conn.Query("SELECT team_name, current_run FROM unbeaten_runs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unbeaten runs: %v", err)
+	}
+	defer rows.Close()
+
+	runs := make(map[string]int)
+	for rows.Next() {
+		var teamName string
+		var run int
+		if err := rows.Scan(&teamName, &run); err != nil {
+			return nil, fmt.Errorf("failed to scan unbeaten run: %v", err)
+		}
+		runs[teamName] = run
+	}
+
+	return runs, nil
+}
+
+// LockWeek locks a week of a season against result edits; locking it again keeps the first time
+func (s *SQLStorageService) LockWeek(season, week int, lockedAt time.Time) error {
+	query := "INSERT INTO week_locks (season, week, locked_at) VALUES (?, ?, ?) ON CONFLICT (season, week) DO NOTHING"
+	if s.driverName == "postgres" {
+		query = "INSERT INTO week_locks (season, week, locked_at) VALUES ($1, $2, $3) ON CONFLICT (season, week) DO NOTHING"
+	}
+
+	if _, err := s.conn.Exec(query, season, week, lockedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to lock week: %v", err)
+	}
+	return nil
+}
+
+// GetLockedWeeks retrieves the weeks of a season locked by hand, with when they were locked
+func (s *SQLStorageService) GetLockedWeeks(season int) (map[int]time.Time, error) {
+	query := "SELECT week, locked_at FROM week_locks WHERE season = ?"
+	if s.driverName == "postgres" {
+		query = "SELECT week, locked_at FROM week_locks WHERE season = $1"
+	}
+
+	rows, err := s.conn.Query(query, season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query locked weeks: %v", err)
+	}
+	defer rows.Close()
+
+	locked := make(map[int]time.Time)
+	for rows.Next() {
+		var week int
+		var lockedAt time.Time
+		if err := rows.Scan(&week, &lockedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan locked week: %v", err)
+		}
+		locked[week] = lockedAt.UTC()
+	}
+
+	return locked, nil
+}
+
+// ReplaceZones replaces the league's zones; none leaves the rules' qualification spots in use
+func (s *SQLStorageService) ReplaceZones(zones []*sim.Zone) error {
+	query := "INSERT INTO league_zones (name, kind, from_position, to_position, color) VALUES (?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		query = "INSERT INTO league_zones (name, kind, from_position, to_position, color) VALUES ($1, $2, $3, $4, $5)"
+	}
+
+	if _, err := s.conn.Exec("DELETE FROM league_zones"); err != nil {
+		return fmt.Errorf("failed to clear zones: %v", err)
+	}
+	for _, zone := range zones {
+		if _, err := s.conn.Exec(query, zone.Name, zone.Kind, zone.From, zone.To, zone.Color); err != nil {
+			return fmt.Errorf("failed to save zone: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetZones retrieves the league's zones from the top of the table down
+func (s *SQLStorageService) GetZones() ([]*sim.Zone, error) {
+	rows, err := s.conn.Query("SELECT name, kind, from_position, to_position, color FROM league_zones ORDER BY from_position")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zones: %v", err)
+	}
+	defer rows.Close()
+
+	zones := []*sim.Zone{}
+	for rows.Next() {
+		zone := &sim.Zone{}
+		if err := rows.Scan(&zone.Name, &zone.Kind, &zone.From, &zone.To, &zone.Color); err != nil {
+			return nil, fmt.Errorf("failed to scan zone: %v", err)
+		}
+		zones = append(zones, zone)
+	}
+
+	return zones, nil
+}
+
+// ReplaceFeatures replaces the league's feature flag rollouts; none leaves the config's in use
+func (s *SQLStorageService) ReplaceFeatures(features map[string]float64) error {
+	query := "INSERT INTO league_features (name, rollout) VALUES (?, ?)"
+	if s.driverName == "postgres" {
+		query = "INSERT INTO league_features (name, rollout) VALUES ($1, $2)"
+	}
+
+	if _, err := s.conn.Exec("DELETE FROM league_features"); err != nil {
+		return fmt.Errorf("failed to clear feature flags: %v", err)
+	}
+	for name, rollout := range features {
+		if _, err := s.conn.Exec(query, name, rollout); err != nil {
+			return fmt.Errorf("failed to save feature flag: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetFeatures retrieves the league's feature flag rollouts by flag name
+func (s *SQLStorageService) GetFeatures() (map[string]float64, error) {
+	rows, err := s.conn.Query("SELECT name, rollout FROM league_features")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feature flags: %v", err)
+	}
+	defer rows.Close()
+
+	features := make(map[string]float64)
+	for rows.Next() {
+		var name string
+		var rollout float64
+		if err := rows.Scan(&name, &rollout); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %v", err)
+		}
+		features[name] = rollout
+	}
+
+	return features, nil
+}
+
+// SaveTeamController records a player taking control of a team
+func (s *SQLStorageService) SaveTeamController(controller *sim.TeamController) error {
+	query := "INSERT INTO team_controllers (team_id, player, mode, token_hash, claimed_at) VALUES (?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		query = "INSERT INTO team_controllers (team_id, player, mode, token_hash, claimed_at) VALUES ($1, $2, $3, $4, $5)"
+	}
+
+	if _, err := s.conn.Exec(query, controller.TeamId, controller.Player, controller.Mode, controller.TokenHash, controller.ClaimedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to save team controller: %v", err)
+	}
+	return nil
+}
+
+// DeleteTeamController hands a team back to the simulator
+func (s *SQLStorageService) DeleteTeamController(teamId int) error {
+	query := "DELETE FROM team_controllers WHERE team_id = ?"
+	if s.driverName == "postgres" {
+		query = "DELETE FROM team_controllers WHERE team_id = $1"
+	}
+
+	if _, err := s.conn.Exec(query, teamId); err != nil {
+		return fmt.Errorf("failed to delete team controller: %v", err)
+	}
+	return nil
+}
+
+// GetTeamControllers retrieves the human-controlled teams by team ID
+func (s *SQLStorageService) GetTeamControllers() (map[int]*sim.TeamController, error) {
+	rows, err := s.conn.Query("SELECT team_id, player, mode, token_hash, claimed_at FROM team_controllers")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team controllers: %v", err)
+	}
+	defer rows.Close()
+
+	controllers := make(map[int]*sim.TeamController)
+	for rows.Next() {
+		controller := &sim.TeamController{}
+		if err := rows.Scan(&controller.TeamId, &controller.Player, &controller.Mode, &controller.TokenHash, &controller.ClaimedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan team controller: %v", err)
+		}
+		controller.ClaimedAt = controller.ClaimedAt.UTC()
+		controllers[controller.TeamId] = controller
+	}
+
+	return controllers, nil
+}
+
+// SaveMatchOrders stores a controller's orders for a match, replacing earlier ones
+func (s *SQLStorageService) SaveMatchOrders(orders *sim.MatchOrders) error {
+	query := `
+	INSERT OR REPLACE INTO match_orders (season, match_id, team_id, player, tactic, home_score, away_score, submitted_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	if s.driverName == "postgres" {
+		query = `
+		INSERT INTO match_orders (season, match_id, team_id, player, tactic, home_score, away_score, submitted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (season, match_id, team_id) DO UPDATE SET
+			player = EXCLUDED.player,
+			tactic = EXCLUDED.tactic,
+			home_score = EXCLUDED.home_score,
+			away_score = EXCLUDED.away_score,
+			submitted_at = EXCLUDED.submitted_at`
+	}
+
+	_, err := s.conn.Exec(query, orders.Season, orders.MatchId, orders.TeamId, orders.Player,
+		orders.Tactic, orders.HomeScore, orders.AwayScore, orders.SubmittedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save match orders: %v", err)
+	}
+	return nil
+}
+
+// GetMatchOrders retrieves the orders submitted for a season's matches
+func (s *SQLStorageService) GetMatchOrders(season int) ([]*sim.MatchOrders, error) {
+	query := "SELECT season, match_id, team_id, player, tactic, home_score, away_score, submitted_at FROM match_orders WHERE season = ? ORDER BY match_id, team_id"
+	if s.driverName == "postgres" {
+		query = "SELECT season, match_id, team_id, player, tactic, home_score, away_score, submitted_at FROM match_orders WHERE season = $1 ORDER BY match_id, team_id"
+	}
+
+	rows, err := s.conn.Query(query, season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query match orders: %v", err)
+	}
+	defer rows.Close()
+
+	orders := []*sim.MatchOrders{}
+	for rows.Next() {
+		order := &sim.MatchOrders{}
+		var homeScore, awayScore sql.NullInt64
+		if err := rows.Scan(&order.Season, &order.MatchId, &order.TeamId, &order.Player, &order.Tactic, &homeScore, &awayScore, &order.SubmittedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan match orders: %v", err)
+		}
+		if homeScore.Valid && awayScore.Valid {
+			home, away := int(homeScore.Int64), int(awayScore.Int64)
+			order.HomeScore, order.AwayScore = &home, &away
+		}
+		order.SubmittedAt = order.SubmittedAt.UTC()
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// GetOrdersOpenedAt retrieves when the next week started taking orders, nil if it never has
+func (s *SQLStorageService) GetOrdersOpenedAt() (*time.Time, error) {
+	var openedAt sql.NullTime
+	err := s.conn.QueryRow("SELECT orders_opened_at FROM league_state WHERE id = 1").Scan(&openedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders opening time: %v", err)
+	}
+	if !openedAt.Valid {
+		return nil, nil
+	}
+	opened := openedAt.Time.UTC()
+	return &opened, nil
+}
+
+// UpdateOrdersOpenedAt records when the next week started taking orders
+func (s *SQLStorageService) UpdateOrdersOpenedAt(openedAt time.Time) error {
+	query := "UPDATE league_state SET orders_opened_at = ? WHERE id = 1"
+	if s.driverName == "postgres" {
+		query = "UPDATE league_state SET orders_opened_at = $1 WHERE id = 1"
+	}
+
+	if _, err := s.conn.Exec(query, openedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to update orders opening time: %v", err)
+	}
+	return nil
+}
+
+// GetSport retrieves the name of the sport preset the league was created with, "" for football
+func (s *SQLStorageService) GetSport() (string, error) {
+	var sport string
+	err := s.conn.QueryRow("SELECT COALESCE(sport, '') FROM league_state WHERE id = 1").Scan(&sport)
+	if err != nil {
+		return "", fmt.Errorf("failed to get sport: %v", err)
+	}
+	return sport, nil
+}
+
+// UpdateSport stores the sport preset the league plays
+func (s *SQLStorageService) UpdateSport(sport string) error {
+	query := "UPDATE league_state SET sport = ? WHERE id = 1"
+	if s.driverName == "postgres" {
+		query = "UPDATE league_state SET sport = $1 WHERE id = 1"
+	}
+
+	if _, err := s.conn.Exec(query, sport); err != nil {
+		return fmt.Errorf("failed to update sport: %v", err)
+	}
+	return nil
+}
+
+// GetHandicap retrieves the league's handicap, a zero Handicap for none
+func (s *SQLStorageService) GetHandicap() (sim.Handicap, error) {
+	var handicap sim.Handicap
+	err := s.conn.QueryRow("SELECT COALESCE(handicap_mode, ''), COALESCE(handicap_rate, 0), COALESCE(handicap_max, 0) FROM league_state WHERE id = 1").
+		Scan(&handicap.Mode, &handicap.Rate, &handicap.Max)
+	if err != nil {
+		return sim.Handicap{}, fmt.Errorf("failed to get handicap: %v", err)
+	}
+	return handicap, nil
+}
+
+// UpdateHandicap stores the league's handicap
+func (s *SQLStorageService) UpdateHandicap(handicap sim.Handicap) error {
+	query := "UPDATE league_state SET handicap_mode = ?, handicap_rate = ?, handicap_max = ? WHERE id = 1"
+	if s.driverName == "postgres" {
+		query = "UPDATE league_state SET handicap_mode = $1, handicap_rate = $2, handicap_max = $3 WHERE id = 1"
+	}
+
+	if _, err := s.conn.Exec(query, handicap.Mode, handicap.Rate, handicap.Max); err != nil {
+		return fmt.Errorf("failed to update handicap: %v", err)
+	}
+	return nil
+}
+
+// ReplaceTeamHandicaps replaces every team's handicap for the season
+func (s *SQLStorageService) ReplaceTeamHandicaps(handicaps map[int]float64) error {
+	query := "INSERT INTO team_handicaps (team_id, handicap) VALUES (?, ?)"
+	if s.driverName == "postgres" {
+		query = "INSERT INTO team_handicaps (team_id, handicap) VALUES ($1, $2)"
+	}
+
+	if _, err := s.conn.Exec("DELETE FROM team_handicaps"); err != nil {
+		return fmt.Errorf("failed to clear team handicaps: %v", err)
+	}
+	for teamId, handicap := range handicaps {
+		if _, err := s.conn.Exec(query, teamId, handicap); err != nil {
+			return fmt.Errorf("failed to save team handicap: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetTeamHandicaps retrieves each team's handicap for the season by team ID
+func (s *SQLStorageService) GetTeamHandicaps() (map[int]float64, error) {
+	rows, err := s.conn.Query("SELECT team_id, handicap FROM team_handicaps")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team handicaps: %v", err)
+	}
+	defer rows.Close()
+
+	handicaps := make(map[int]float64)
+	for rows.Next() {
+		var teamId int
+		var handicap float64
+		if err := rows.Scan(&teamId, &handicap); err != nil {
+			return nil, fmt.Errorf("failed to scan team handicap: %v", err)
+		}
+		handicaps[teamId] = handicap
+	}
+	return handicaps, nil
+}
+
+// nullableTime binds an optional timestamp, NULL when unset
+func nullableTime(value *time.Time) interface{} {
+	if value == nil {
+		return nil
+	}
+	return value.UTC()
+}
+
+// SaveDraft stores a new draft with its player pool
+func (s *SQLStorageService) SaveDraft(draft *sim.Draft) error {
+	draftQuery := "INSERT INTO drafts (season, team_order, rounds, started_at, pick_started_at, completed_at) VALUES (?, ?, ?, ?, ?, ?)"
+	playerQuery := "INSERT INTO draft_players (season, player_id, name, position, rating) VALUES (?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		draftQuery = "INSERT INTO drafts (season, team_order, rounds, started_at, pick_started_at, completed_at) VALUES ($1, $2, $3, $4, $5, $6)"
+		playerQuery = "INSERT INTO draft_players (season, player_id, name, position, rating) VALUES ($1, $2, $3, $4, $5)"
+	}
+
+	order := make([]string, len(draft.Order))
+	for i, teamId := range draft.Order {
+		order[i] = strconv.Itoa(teamId)
+	}
+	if _, err := s.conn.Exec(draftQuery, draft.Season, strings.Join(order, ","), draft.Rounds, draft.StartedAt.UTC(), draft.PickStartedAt.UTC(), nullableTime(draft.CompletedAt)); err != nil {
+		return fmt.Errorf("failed to save draft: %v", err)
+	}
+	for _, player := range draft.Players {
+		if _, err := s.conn.Exec(playerQuery, draft.Season, player.PlayerId, player.Name, player.Position, player.Rating); err != nil {
+			return fmt.Errorf("failed to save draft player: %v", err)
+		}
+	}
+	return nil
+}
+
+// SaveDraftPicks records drafted players and moves the draft's clock on
+func (s *SQLStorageService) SaveDraftPicks(draft *sim.Draft, picks []*sim.DraftPlayer) error {
+	draftQuery := "UPDATE drafts SET pick_started_at = ?, completed_at = ? WHERE season = ?"
+	pickQuery := "UPDATE draft_players SET team_id = ?, pick = ?, picked_at = ?, auto_picked = ? WHERE season = ? AND player_id = ?"
+	if s.driverName == "postgres" {
+		draftQuery = "UPDATE drafts SET pick_started_at = $1, completed_at = $2 WHERE season = $3"
+		pickQuery = "UPDATE draft_players SET team_id = $1, pick = $2, picked_at = $3, auto_picked = $4 WHERE season = $5 AND player_id = $6"
+	}
+
+	for _, player := range picks {
+		if _, err := s.conn.Exec(pickQuery, player.TeamId, player.Pick, nullableTime(player.PickedAt), player.AutoPicked, draft.Season, player.PlayerId); err != nil {
+			return fmt.Errorf("failed to save draft pick: %v", err)
+		}
+	}
+	if _, err := s.conn.Exec(draftQuery, draft.PickStartedAt.UTC(), nullableTime(draft.CompletedAt), draft.Season); err != nil {
+		return fmt.Errorf("failed to update draft: %v", err)
+	}
+	return nil
+}
+
+// GetDraft retrieves a season's draft with its player pool, nil if the season has none
+func (s *SQLStorageService) GetDraft(season int) (*sim.Draft, error) {
+	draftQuery := "SELECT team_order, rounds, started_at, pick_started_at, completed_at FROM drafts WHERE season = ?"
+	playersQuery := "SELECT player_id, name, position, rating, team_id, pick, picked_at, auto_picked FROM draft_players WHERE season = ? ORDER BY player_id"
+	if s.driverName == "postgres" {
+		draftQuery = "SELECT team_order, rounds, started_at, pick_started_at, completed_at FROM drafts WHERE season = $1"
+		playersQuery = "SELECT player_id, name, position, rating, team_id, pick, picked_at, auto_picked FROM draft_players WHERE season = $1 ORDER BY player_id"
+	}
+
+	draft := &sim.Draft{Season: season}
+	var order string
+	var completedAt sql.NullTime
+	err := s.conn.QueryRow(draftQuery, season).Scan(&order, &draft.Rounds, &draft.StartedAt, &draft.PickStartedAt, &completedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft: %v", err)
+	}
+	for _, value := range strings.Split(order, ",") {
+		teamId, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid draft order %q: %v", order, err)
+		}
+		draft.Order = append(draft.Order, teamId)
+	}
+	draft.StartedAt = draft.StartedAt.UTC()
+	draft.PickStartedAt = draft.PickStartedAt.UTC()
+	if completedAt.Valid {
+		completed := completedAt.Time.UTC()
+		draft.CompletedAt = &completed
+	}
+
+	rows, err := s.conn.Query(playersQuery, season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query draft players: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		player := &sim.DraftPlayer{}
+		var teamId, pick sql.NullInt64
+		var pickedAt sql.NullTime
+		var autoPicked sql.NullBool
+		if err := rows.Scan(&player.PlayerId, &player.Name, &player.Position, &player.Rating, &teamId, &pick, &pickedAt, &autoPicked); err != nil {
+			return nil, fmt.Errorf("failed to scan draft player: %v", err)
+		}
+		player.TeamId, player.Pick = int(teamId.Int64), int(pick.Int64)
+		if pickedAt.Valid {
+			picked := pickedAt.Time.UTC()
+			player.PickedAt = &picked
+		}
+		player.AutoPicked = autoPicked.Bool
+		draft.Players = append(draft.Players, player)
+	}
+
+	return draft, nil
+}
+
+// SavePredictionSnapshot stores the predictions for a week. Any snapshot for that
+// week or a later one in the season is replaced, so regenerated fixtures restart the history.
+func (s *SQLStorageService) SavePredictionSnapshot(snapshot *PredictionSnapshot) error {
+	deleteQuery := "DELETE FROM predictions_history WHERE season = ? AND week >= ?"
+	insertQuery := "INSERT INTO predictions_history (season, week, team_name, probability, recorded_at) VALUES (?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		deleteQuery = "DELETE FROM predictions_history WHERE season = $1 AND week >= $2"
+		insertQuery = "INSERT INTO predictions_history (season, week, team_name, probability, recorded_at) VALUES ($1, $2, $3, $4, $5)"
+	}
+
+	if _, err := s.conn.Exec(deleteQuery, snapshot.Season, snapshot.Week); err != nil {
+		return fmt.Errorf("failed to clear prediction history: %v", err)
+	}
+	for teamName, probability := range snapshot.Probabilities {
+		if _, err := s.conn.Exec(insertQuery, snapshot.Season, snapshot.Week, teamName, probability, snapshot.RecordedAt); err != nil {
+			return fmt.Errorf("failed to save prediction history: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetPredictionHistory retrieves a season's prediction snapshots in week order
+func (s *SQLStorageService) GetPredictionHistory(season int) ([]*PredictionSnapshot, error) {
+	query := "SELECT week, team_name, probability, recorded_at FROM predictions_history WHERE season = ? ORDER BY week, team_name"
+	if s.driverName == "postgres" {
+		query = "SELECT week, team_name, probability, recorded_at FROM predictions_history WHERE season = $1 ORDER BY week, team_name"
+	}
+
+	rows, err := s.conn.Query(query, season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prediction history: %v", err)
+	}
+	defer rows.Close()
+
+	history := []*PredictionSnapshot{}
+	for rows.Next() {
+		var week int
+		var teamName string
+		var probability float64
+		var recordedAt time.Time
+		if err := rows.Scan(&week, &teamName, &probability, &recordedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan prediction history: %v", err)
+		}
+		if len(history) == 0 || history[len(history)-1].Week != week {
+			history = append(history, &PredictionSnapshot{Season: season, Week: week, Probabilities: make(map[string]float64), RecordedAt: recordedAt})
+		}
+		history[len(history)-1].Probabilities[teamName] = probability
+	}
+
+	return history, nil
+}
+
+// SaveUserPrediction stores a player's forecast, replacing any earlier one for the match
+func (s *SQLStorageService) SaveUserPrediction(prediction *UserPrediction) error {
+	query := `
+	INSERT OR REPLACE INTO user_predictions (season, match_id, player, home_win, draw, away_win, submitted_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	if s.driverName == "postgres" {
+		query = `
+		INSERT INTO user_predictions (season, match_id, player, home_win, draw, away_win, submitted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (season, match_id, player) DO UPDATE SET
+			home_win = EXCLUDED.home_win,
+			draw = EXCLUDED.draw,
+			away_win = EXCLUDED.away_win,
+			submitted_at = EXCLUDED.submitted_at`
+	}
+
+	_, err := s.conn.Exec(query, prediction.Season, prediction.MatchId, prediction.Player,
+		prediction.HomeWin, prediction.Draw, prediction.AwayWin, prediction.SubmittedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save user prediction: %v", err)
+	}
+	return nil
+}
+
+// GetUserPredictions retrieves every forecast made in a season
+func (s *SQLStorageService) GetUserPredictions(season int) ([]*UserPrediction, error) {
+	query := `
+	SELECT season, match_id, player, home_win, draw, away_win, submitted_at
+	FROM user_predictions WHERE season = ? ORDER BY match_id, player`
+	if s.driverName == "postgres" {
+		query = strings.Replace(query, "?", "$1", 1)
+	}
+
+	rows, err := s.conn.Query(query, season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user predictions: %v", err)
+	}
+	defer rows.Close()
+
+	predictions := []*UserPrediction{}
+	for rows.Next() {
+		prediction := &UserPrediction{}
+		err := rows.Scan(&prediction.Season, &prediction.MatchId, &prediction.Player,
+			&prediction.HomeWin, &prediction.Draw, &prediction.AwayWin, &prediction.SubmittedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user prediction: %v", err)
+		}
+		predictions = append(predictions, prediction)
+	}
+
+	return predictions, nil
+}
+
+// SavePlayerProfile stores a player's settings, replacing any earlier ones
+func (s *SQLStorageService) SavePlayerProfile(profile *PlayerProfile) error {
+	query := "INSERT OR REPLACE INTO player_profiles (player, timezone) VALUES (?, ?)"
+	if s.driverName == "postgres" {
+		query = `
+		INSERT INTO player_profiles (player, timezone) VALUES ($1, $2)
+		ON CONFLICT (player) DO UPDATE SET timezone = EXCLUDED.timezone`
+	}
+
+	if _, err := s.conn.Exec(query, profile.Player, profile.TimeZone); err != nil {
+		return fmt.Errorf("failed to save player profile: %v", err)
+	}
+	return nil
+}
+
+// GetPlayerProfile retrieves a player's settings, nil if the player has none
+func (s *SQLStorageService) GetPlayerProfile(player string) (*PlayerProfile, error) {
+	query := "SELECT player, timezone FROM player_profiles WHERE player = ?"
+	if s.driverName == "postgres" {
+		query = "SELECT player, timezone FROM player_profiles WHERE player = $1"
+	}
+
+	profile := &PlayerProfile{}
+	err := s.conn.QueryRow(query, player).Scan(&profile.Player, &profile.TimeZone)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player profile: %v", err)
+	}
+	return profile, nil
+}
+
+// GetRevision retrieves the league state revision shared by all instances
+func (s *SQLStorageService) GetRevision() (int, error) {
+	var revision int
+	err := s.conn.QueryRow("SELECT COALESCE(revision, 0) FROM league_state WHERE id = 1").Scan(&revision)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get revision: %v", err)
+	}
+	return revision, nil
+}
+
+// BumpRevision increments the league state revision, records the time of the change
+// and returns the new revision
+func (s *SQLStorageService) BumpRevision() (int, error) {
+	query := "UPDATE league_state SET revision = COALESCE(revision, 0) + 1, modified_at = ? WHERE id = 1"
+	if s.driverName == "postgres" {
+		query = "UPDATE league_state SET revision = COALESCE(revision, 0) + 1, modified_at = $1 WHERE id = 1"
+	}
+
+	if _, err := s.conn.Exec(query, time.Now().UTC()); err != nil {
+		return 0, fmt.Errorf("failed to bump revision: %v", err)
+	}
+	return s.GetRevision()
+}
+
+// GetModifiedAt retrieves the time of the last revision bump, nil if there has been none
+func (s *SQLStorageService) GetModifiedAt() (*time.Time, error) {
+	var modifiedAt sql.NullTime
+	err := s.conn.QueryRow("SELECT modified_at FROM league_state WHERE id = 1").Scan(&modifiedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get modification time: %v", err)
+	}
+	if !modifiedAt.Valid {
+		return nil, nil
+	}
+	modified := modifiedAt.Time.UTC()
+	return &modified, nil
+}
+
+// PurgeLeague permanently removes all league data and reseeds a fresh league
+func (s *SQLStorageService) PurgeLeague() error {
+	statements := []string{
+		"DELETE FROM team_name_history",
+		"DELETE FROM team_audit",
+		"DELETE FROM records",
+		"DELETE FROM unbeaten_runs",
+		"DELETE FROM week_locks",
+		"DELETE FROM league_zones",
+		"DELETE FROM league_features",
+		"DELETE FROM team_controllers",
+		"DELETE FROM match_orders",
+		"DELETE FROM draft_players",
+		"DELETE FROM drafts",
+		"DELETE FROM team_handicaps",
+		"DELETE FROM predictions_history",
+		"DELETE FROM user_predictions",
+		"DELETE FROM managers",
+		"DELETE FROM referees",
+		"DELETE FROM honours",
+		"DELETE FROM continental_entrants",
+		"DELETE FROM all_time_standings",
+		"DELETE FROM season_standings",
+		"DELETE FROM season_summaries",
+		"DELETE FROM archived_match_events",
+		"DELETE FROM archived_matches",
+		"DELETE FROM match_events",
+		"DELETE FROM cup_draws",
+		"DELETE FROM showpiece_events",
+		"DELETE FROM showpiece_matches",
+		"DELETE FROM matches",
+		"DELETE FROM teams",
+		"UPDATE league_state SET current_week = 0, archived_at = NULL, season_state = 'not_started', orders_opened_at = NULL, handicap_mode = '', handicap_rate = 0, handicap_max = 0, revision = COALESCE(revision, 0) + 1, modified_at = CURRENT_TIMESTAMP WHERE id = 1",
+	}
+
+	err := s.WithinTransaction(context.Background(), func(tx StorageService) error {
+		conn := tx.(*SQLStorageService).conn
+		for _, statement := range statements {
+			if _, err := conn.Exec(statement); err != nil {
+				return fmt.Errorf("failed to purge league: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.InitializeTeamsAndMatches()
+}
+
+// SaveCupDraw stores a drawn bracket, replacing any earlier draw of the same round
+func (s *SQLStorageService) SaveCupDraw(draw *CupDraw) error {
+	deleteQuery := "DELETE FROM cup_draws WHERE competition_id = ? AND round = ?"
+	insertQuery := "INSERT INTO cup_draws (competition_id, round, tie_number, home_team_id, away_team_id) VALUES (?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		deleteQuery = "DELETE FROM cup_draws WHERE competition_id = $1 AND round = $2"
+		insertQuery = "INSERT INTO cup_draws (competition_id, round, tie_number, home_team_id, away_team_id) VALUES ($1, $2, $3, $4, $5)"
+	}
+
+	return s.WithinTransaction(context.Background(), func(tx StorageService) error {
+		conn := tx.(*SQLStorageService).conn
+		if _, err := conn.Exec(deleteQuery, draw.CompetitionId, draw.Round); err != nil {
+			return fmt.Errorf("failed to clear previous draw: %v", err)
+		}
+
+		for _, tie := range draw.Ties {
+			if _, err := conn.Exec(insertQuery, draw.CompetitionId, draw.Round, tie.TieNumber, tie.HomeTeamId, tie.AwayTeamId); err != nil {
+				return fmt.Errorf("failed to save drawn tie: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetCupDraws retrieves every drawn round of a competition
+func (s *SQLStorageService) GetCupDraws(competitionId string) ([]*CupDraw, error) {
+	query := `
+	SELECT d.round, d.tie_number, d.home_team_id, ht.name, d.away_team_id, at.name
+	FROM cup_draws d
+	JOIN teams ht ON d.home_team_id = ht.id
+	JOIN teams at ON d.away_team_id = at.id
+	WHERE d.competition_id = ?
+	ORDER BY d.round, d.tie_number`
+	if s.driverName == "postgres" {
+		query = strings.Replace(query, "?", "$1", 1)
+	}
+
+	rows, err := s.conn.Query(query, competitionId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cup draws: %v", err)
+	}
+	defer rows.Close()
+
+	var draws []*CupDraw
+	byRound := make(map[string]*CupDraw)
+	for rows.Next() {
+		var round string
+		var tie DrawnTie
+		if err := rows.Scan(&round, &tie.TieNumber, &tie.HomeTeamId, &tie.HomeTeamName, &tie.AwayTeamId, &tie.AwayTeamName); err != nil {
+			return nil, fmt.Errorf("failed to scan drawn tie: %v", err)
+		}
+
+		draw, exists := byRound[round]
+		if !exists {
+			draw = &CupDraw{CompetitionId: competitionId, Round: round}
+			byRound[round] = draw
+			draws = append(draws, draw)
+		}
+		draw.Ties = append(draw.Ties, tie)
+	}
+
+	return draws, nil
+}
+
+// SaveShowpieceMatch stores a one-off match result and assigns its ID
+func (s *SQLStorageService) SaveShowpieceMatch(match *sim.ShowpieceMatch) error {
+	query := `
+	INSERT INTO showpiece_matches (id, name, home_team_id, away_team_id, home_score, away_score,
+		penalties_home, penalties_away, winner_name, played_at, extra_time, season, league_week)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if s.driverName == "postgres" {
+		query = `
+		INSERT INTO showpiece_matches (id, name, home_team_id, away_team_id, home_score, away_score,
+			penalties_home, penalties_away, winner_name, played_at, extra_time, season, league_week)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+	}
+
+	var nextId int
+	if err := s.conn.QueryRow("SELECT COALESCE(MAX(id), 0) + 1 FROM showpiece_matches").Scan(&nextId); err != nil {
+		return fmt.Errorf("failed to allocate showpiece match id: %v", err)
+	}
+
+	_, err := s.conn.Exec(query, nextId, match.Name, match.HomeTeam.TeamId, match.AwayTeam.TeamId,
+		match.HomeTeamScore, match.AwayTeamScore, match.PenaltiesHome, match.PenaltiesAway,
+		match.WinnerName, match.PlayedAt, match.ExtraTime, match.Season, match.LeagueWeek)
+	if err != nil {
+		return fmt.Errorf("failed to save showpiece match: %v", err)
+	}
+
+	eventQuery := "INSERT INTO showpiece_events (showpiece_id, minute, added_time, event_type, team_id, kick, score_before, score_after) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		eventQuery = "INSERT INTO showpiece_events (showpiece_id, minute, added_time, event_type, team_id, kick, score_before, score_after) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	}
+	for _, event := range match.Events {
+		if _, err := s.conn.Exec(eventQuery, nextId, event.Minute, event.AddedTime, event.Type, event.TeamId, event.Kick, event.ScoreBefore, event.ScoreAfter); err != nil {
+			return fmt.Errorf("failed to save showpiece event: %v", err)
+		}
+	}
+
+	match.Id = nextId
+	return nil
+}
+
+// GetShowpieceMatches retrieves all one-off matches, most recent first
+func (s *SQLStorageService) GetShowpieceMatches() ([]*sim.ShowpieceMatch, error) {
+	query := `
+	SELECT m.id, m.name, m.home_score, m.away_score, m.penalties_home, m.penalties_away,
+		   COALESCE(m.winner_name, ''), m.played_at,
+		   COALESCE(m.extra_time, FALSE), COALESCE(m.season, 0), COALESCE(m.league_week, 0),
+		   ht.id, ht.name, ht.strength, at.id, at.name, at.strength
+	FROM showpiece_matches m
+	JOIN teams ht ON m.home_team_id = ht.id
+	JOIN teams at ON m.away_team_id = at.id
+	ORDER BY m.id DESC`
+
+	rows, err := s.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query showpiece matches: %v", err)
+	}
+	defer rows.Close()
+
+	var matches []*sim.ShowpieceMatch
+	for rows.Next() {
+		match := &sim.ShowpieceMatch{HomeTeam: &sim.Team{}, AwayTeam: &sim.Team{}}
+		err := rows.Scan(&match.Id, &match.Name, &match.HomeTeamScore, &match.AwayTeamScore,
+			&match.PenaltiesHome, &match.PenaltiesAway, &match.WinnerName, &match.PlayedAt,
+			&match.ExtraTime, &match.Season, &match.LeagueWeek,
+			&match.HomeTeam.TeamId, &match.HomeTeam.TeamName, &match.HomeTeam.TeamStrength,
+			&match.AwayTeam.TeamId, &match.AwayTeam.TeamName, &match.AwayTeam.TeamStrength)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan showpiece match: %v", err)
+		}
+		matches = append(matches, match)
+	}
+	rows.Close()
+
+	events, err := s.getShowpieceEvents()
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range matches {
+		match.Events = events[match.Id]
+	}
+
+	return matches, nil
+}
+
+// GetExtraTimeMatches retrieves a season's showpiece matches that went to extra time,
+// without their events, which is all fatigue needs when the league is loaded
+func (s *SQLStorageService) GetExtraTimeMatches(season int) ([]*sim.ShowpieceMatch, error) {
+	query := `
+	SELECT m.id, m.name, m.home_score, m.away_score, m.penalties_home, m.penalties_away,
+		   COALESCE(m.winner_name, ''), m.played_at, COALESCE(m.league_week, 0),
+		   ht.id, ht.name, ht.strength, at.id, at.name, at.strength
+	FROM showpiece_matches m
+	JOIN teams ht ON m.home_team_id = ht.id
+	JOIN teams at ON m.away_team_id = at.id
+	WHERE m.extra_time = TRUE AND m.season = ?
+	ORDER BY m.id DESC`
+	if s.driverName == "postgres" {
+		query = strings.Replace(query, "m.season = ?", "m.season = $1", 1)
+	}
+
+	rows, err := s.conn.Query(query, season)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query extra-time matches: %v", err)
+	}
+	defer rows.Close()
+
+	matches := []*sim.ShowpieceMatch{}
+	for rows.Next() {
+		match := &sim.ShowpieceMatch{HomeTeam: &sim.Team{}, AwayTeam: &sim.Team{}, ExtraTime: true, Season: season}
+		err := rows.Scan(&match.Id, &match.Name, &match.HomeTeamScore, &match.AwayTeamScore,
+			&match.PenaltiesHome, &match.PenaltiesAway, &match.WinnerName, &match.PlayedAt, &match.LeagueWeek,
+			&match.HomeTeam.TeamId, &match.HomeTeam.TeamName, &match.HomeTeam.TeamStrength,
+			&match.AwayTeam.TeamId, &match.AwayTeam.TeamName, &match.AwayTeam.TeamStrength)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan extra-time match: %v", err)
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// getShowpieceEvents retrieves all stored showpiece events grouped by showpiece ID
+func (s *SQLStorageService) getShowpieceEvents() (map[int][]sim.MatchEvent, error) {
+	query := `
+	SELECT e.showpiece_id, e.minute, COALESCE(e.added_time, 0), e.event_type, e.team_id, t.name,
+		COALESCE(e.kick, 0), COALESCE(e.score_before, ''), COALESCE(e.score_after, '')
+	FROM showpiece_events e
+	JOIN teams t ON e.team_id = t.id
+	ORDER BY e.showpiece_id, e.minute, e.added_time, e.kick`
+
+	rows, err := s.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query showpiece events: %v", err)
+	}
+	defer rows.Close()
+
+	events := make(map[int][]sim.MatchEvent)
+	for rows.Next() {
+		var showpieceId int
+		var event sim.MatchEvent
+		if err := rows.Scan(&showpieceId, &event.Minute, &event.AddedTime, &event.Type, &event.TeamId, &event.TeamName, &event.Kick, &event.ScoreBefore, &event.ScoreAfter); err != nil {
+			return nil, fmt.Errorf("failed to scan showpiece event: %v", err)
+		}
+		events[showpieceId] = append(events[showpieceId], event)
+	}
+
+	return events, nil
+}
+
+// SaveTenant adds a tenant to the registry
+func (s *SQLStorageService) SaveTenant(tenant *Tenant) error {
+	query := "INSERT INTO tenants (id, api_key_hash, backend, location, created_at) VALUES (?, ?, ?, ?, ?)"
+	if s.driverName == "postgres" {
+		query = "INSERT INTO tenants (id, api_key_hash, backend, location, created_at) VALUES ($1, $2, $3, $4, $5)"
+	}
+
+	if _, err := s.conn.Exec(query, tenant.Id, tenant.keyHash, tenant.Backend, tenant.Location, tenant.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save tenant: %v", err)
+	}
+	return nil
+}
+
+// GetTenants retrieves the registry of tenants ordered by ID
+func (s *SQLStorageService) GetTenants() ([]*Tenant, error) {
+	rows, err := s.conn.Query("SELECT id, api_key_hash, backend, location, created_at FROM tenants ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenants: %v", err)
+	}
+	defer rows.Close()
+
+	tenants := []*Tenant{}
+	for rows.Next() {
+		tenant := &Tenant{}
+		if err := rows.Scan(&tenant.Id, &tenant.keyHash, &tenant.Backend, &tenant.Location, &tenant.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %v", err)
+		}
+		tenant.CreatedAt = tenant.CreatedAt.UTC()
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
+
+// DeleteTenant removes a tenant from the registry; its database is left in place
+func (s *SQLStorageService) DeleteTenant(id string) error {
+	query := "DELETE FROM tenants WHERE id = ?"
+	if s.driverName == "postgres" {
+		query = "DELETE FROM tenants WHERE id = $1"
+	}
+
+	if _, err := s.conn.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to delete tenant: %v", err)
+	}
+	return nil
+}
+
+// pinConnection holds one connection open until Close, for a database that only
+// lasts while a connection to it is open, like SQLite's shared in-memory ones
+func (s *SQLStorageService) pinConnection() error {
+	conn, err := s.db.Conn(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to pin database connection: %v", err)
+	}
+	s.pinned = conn
+	return nil
+}
+
+// Close closes the database connection
+func (s *SQLStorageService) Close() error {
+	if s.pinned != nil {
+		s.pinned.Close()
+	}
+	return s.db.Close()
+}
+
+// InitializeTeamsAndMatches populates the database with initial data
+func (s *SQLStorageService) InitializeTeamsAndMatches() error {
+	// Check if teams already exist
+	teams, err := s.GetTeams()
+	if err != nil {
+		return err
+	}
+
+	if len(teams) > 0 {
+		log.Println("Teams already exist in database, skipping initialization")
+		return nil
+	}
+
+	// A new league plays LEAGUE_SPORT; one purged and reseeded keeps the sport it was created with
+	sport, err := s.GetSport()
+	if err != nil {
+		return err
+	}
+	if sport == "" {
+		preset, err := sim.DefaultSport()
+		if err != nil {
+			return err
+		}
+		if err := s.UpdateSport(preset.Name); err != nil {
+			return err
+		}
+	}
+
+	// Create initial teams, with strengths fitted to LEAGUE_SEED_RATINGS when set
+	initialTeams := sim.CreatePremierLeagueTeams()
+	if err := applySeedRatings(initialTeams); err != nil {
+		return err
+	}
 	for _, team := range initialTeams {
 		if err := s.UpdateTeam(team); err != nil {
 			return fmt.Errorf("failed to initialize team %s: %v", team.TeamName, err)
@@ -322,7 +3074,7 @@ func (s *SQLStorageService) InitializeTeamsAndMatches() error {
 	}
 
 	// Create initial matches
-	initialMatches := createPremierLeagueMatches(initialTeams)
+	initialMatches := sim.CreatePremierLeagueMatches(initialTeams)
 	for _, match := range initialMatches {
 		if err := s.SaveMatchResult(match); err != nil {
 			return fmt.Errorf("failed to initialize match %d: %v", match.MatchId, err)
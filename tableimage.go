@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// Table image layout in pixels; glyphs are 5x7 drawn at glyphScale
+const (
+	glyphScale     = 2
+	glyphAdvance   = 6 * glyphScale // glyph width plus one column of spacing
+	tableRowHeight = 24
+	tablePadding   = 12
+	tableStripe    = 6 // width of the team colour stripe at the start of each row
+)
+
+// Table image colours
+var (
+	tableBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	tableAltRow     = color.RGBA{0xf2, 0xf4, 0xf7, 0xff}
+	tableHeader     = color.RGBA{0x1c, 0x2c, 0x5b, 0xff}
+	tableHeaderText = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	tableText       = color.RGBA{0x22, 0x22, 0x22, 0xff}
+	tableMutedText  = color.RGBA{0x66, 0x66, 0x66, 0xff}
+)
+
+// tableFont is a 5x7 bitmap font for printable ASCII from ' ' to '~'. Each glyph is
+// five columns, the lowest bit of a column being its top pixel.
+var tableFont = [95][5]byte{
+	{0x00, 0x00, 0x00, 0x00, 0x00}, {0x00, 0x00, 0x5F, 0x00, 0x00}, {0x00, 0x07, 0x00, 0x07, 0x00}, {0x14, 0x7F, 0x14, 0x7F, 0x14},
+	{0x24, 0x2A, 0x7F, 0x2A, 0x12}, {0x23, 0x13, 0x08, 0x64, 0x62}, {0x36, 0x49, 0x55, 0x22, 0x50}, {0x00, 0x05, 0x03, 0x00, 0x00},
+	{0x00, 0x1C, 0x22, 0x41, 0x00}, {0x00, 0x41, 0x22, 0x1C, 0x00}, {0x08, 0x2A, 0x1C, 0x2A, 0x08}, {0x08, 0x08, 0x3E, 0x08, 0x08},
+	{0x00, 0x50, 0x30, 0x00, 0x00}, {0x08, 0x08, 0x08, 0x08, 0x08}, {0x00, 0x60, 0x60, 0x00, 0x00}, {0x20, 0x10, 0x08, 0x04, 0x02},
+	{0x3E, 0x51, 0x49, 0x45, 0x3E}, {0x00, 0x42, 0x7F, 0x40, 0x00}, {0x42, 0x61, 0x51, 0x49, 0x46}, {0x21, 0x41, 0x45, 0x4B, 0x31},
+	{0x18, 0x14, 0x12, 0x7F, 0x10}, {0x27, 0x45, 0x45, 0x45, 0x39}, {0x3C, 0x4A, 0x49, 0x49, 0x30}, {0x01, 0x71, 0x09, 0x05, 0x03},
+	{0x36, 0x49, 0x49, 0x49, 0x36}, {0x06, 0x49, 0x49, 0x29, 0x1E}, {0x00, 0x36, 0x36, 0x00, 0x00}, {0x00, 0x56, 0x36, 0x00, 0x00},
+	{0x08, 0x14, 0x22, 0x41, 0x00}, {0x14, 0x14, 0x14, 0x14, 0x14}, {0x00, 0x41, 0x22, 0x14, 0x08}, {0x02, 0x01, 0x51, 0x09, 0x06},
+	{0x32, 0x49, 0x79, 0x41, 0x3E}, {0x7E, 0x11, 0x11, 0x11, 0x7E}, {0x7F, 0x49, 0x49, 0x49, 0x36}, {0x3E, 0x41, 0x41, 0x41, 0x22},
+	{0x7F, 0x41, 0x41, 0x22, 0x1C}, {0x7F, 0x49, 0x49, 0x49, 0x41}, {0x7F, 0x09, 0x09, 0x01, 0x01}, {0x3E, 0x41, 0x41, 0x51, 0x32},
+	{0x7F, 0x08, 0x08, 0x08, 0x7F}, {0x00, 0x41, 0x7F, 0x41, 0x00}, {0x20, 0x40, 0x41, 0x3F, 0x01}, {0x7F, 0x08, 0x14, 0x22, 0x41},
+	{0x7F, 0x40, 0x40, 0x40, 0x40}, {0x7F, 0x02, 0x04, 0x02, 0x7F}, {0x7F, 0x04, 0x08, 0x10, 0x7F}, {0x3E, 0x41, 0x41, 0x41, 0x3E},
+	{0x7F, 0x09, 0x09, 0x09, 0x06}, {0x3E, 0x41, 0x51, 0x21, 0x5E}, {0x7F, 0x09, 0x19, 0x29, 0x46}, {0x46, 0x49, 0x49, 0x49, 0x31},
+	{0x01, 0x01, 0x7F, 0x01, 0x01}, {0x3F, 0x40, 0x40, 0x40, 0x3F}, {0x1F, 0x20, 0x40, 0x20, 0x1F}, {0x7F, 0x20, 0x18, 0x20, 0x7F},
+	{0x63, 0x14, 0x08, 0x14, 0x63}, {0x03, 0x04, 0x78, 0x04, 0x03}, {0x61, 0x51, 0x49, 0x45, 0x43}, {0x00, 0x7F, 0x41, 0x41, 0x00},
+	{0x02, 0x04, 0x08, 0x10, 0x20}, {0x00, 0x41, 0x41, 0x7F, 0x00}, {0x04, 0x02, 0x01, 0x02, 0x04}, {0x40, 0x40, 0x40, 0x40, 0x40},
+	{0x00, 0x01, 0x02, 0x04, 0x00}, {0x20, 0x54, 0x54, 0x54, 0x78}, {0x7F, 0x48, 0x44, 0x44, 0x38}, {0x38, 0x44, 0x44, 0x44, 0x20},
+	{0x38, 0x44, 0x44, 0x48, 0x7F}, {0x38, 0x54, 0x54, 0x54, 0x18}, {0x08, 0x7E, 0x09, 0x01, 0x02}, {0x08, 0x14, 0x54, 0x54, 0x3C},
+	{0x7F, 0x08, 0x04, 0x04, 0x78}, {0x00, 0x44, 0x7D, 0x40, 0x00}, {0x20, 0x40, 0x44, 0x3D, 0x00}, {0x00, 0x7F, 0x10, 0x28, 0x44},
+	{0x00, 0x41, 0x7F, 0x40, 0x00}, {0x7C, 0x04, 0x18, 0x04, 0x78}, {0x7C, 0x08, 0x04, 0x04, 0x78}, {0x38, 0x44, 0x44, 0x44, 0x38},
+	{0x7C, 0x14, 0x14, 0x14, 0x08}, {0x08, 0x14, 0x14, 0x18, 0x7C}, {0x7C, 0x08, 0x04, 0x04, 0x08}, {0x48, 0x54, 0x54, 0x54, 0x20},
+	{0x04, 0x3F, 0x44, 0x40, 0x20}, {0x3C, 0x40, 0x40, 0x20, 0x7C}, {0x1C, 0x20, 0x40, 0x20, 0x1C}, {0x3C, 0x40, 0x30, 0x40, 0x3C},
+	{0x44, 0x28, 0x10, 0x28, 0x44}, {0x0C, 0x50, 0x50, 0x50, 0x3C}, {0x44, 0x64, 0x54, 0x4C, 0x44}, {0x00, 0x08, 0x36, 0x41, 0x00},
+	{0x00, 0x00, 0x7F, 0x00, 0x00}, {0x00, 0x41, 0x36, 0x08, 0x00}, {0x08, 0x04, 0x08, 0x10, 0x08},
+}
+
+// tableImageColumn is a right-aligned numeric column of the table image
+type tableImageColumn struct {
+	title string
+	width int // in characters
+	value func(entry *sim.LeagueTableEntry) string
+}
+
+var tableImageColumns = []tableImageColumn{
+	{"P", 3, func(e *sim.LeagueTableEntry) string { return strconv.Itoa(e.Played) }},
+	{"W", 3, func(e *sim.LeagueTableEntry) string { return strconv.Itoa(e.Wins) }},
+	{"D", 3, func(e *sim.LeagueTableEntry) string { return strconv.Itoa(e.Draws) }},
+	{"L", 3, func(e *sim.LeagueTableEntry) string { return strconv.Itoa(e.Losses) }},
+	{"GD", 4, func(e *sim.LeagueTableEntry) string { return fmt.Sprintf("%+d", e.GoalsDifference) }},
+	{"Pts", 4, func(e *sim.LeagueTableEntry) string { return strconv.Itoa(e.Points) }},
+}
+
+// renderTablePNG draws the table as a PNG: a title line, a header row and one row per
+// team with the team's primary colour as a stripe. Teams are shown by DisplayName when
+// set, spelled with ASCII (see asciiName) as the font has no other characters.
+func renderTablePNG(title string, table []*sim.LeagueTableEntry) ([]byte, error) {
+	nameWidth := len("Team")
+	for _, entry := range table {
+		nameWidth = max(nameWidth, len(tableImageName(entry)))
+	}
+	numbersWidth := 0
+	for _, column := range tableImageColumns {
+		numbersWidth += column.width
+	}
+	// Position (3 characters), a space, the name and the numeric columns, with half a
+	// character of margin either side
+	width := 2*tablePadding + tableStripe + (3+1+nameWidth+numbersWidth+1)*glyphAdvance
+	width = max(width, 2*tablePadding+len([]rune(title))*glyphAdvance)
+	height := 2*tablePadding + (len(table)+2)*tableRowHeight
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{tableBackground}, image.Point{}, draw.Src)
+
+	textY := (tableRowHeight - 7*glyphScale) / 2
+	y := tablePadding
+	drawTableText(img, tablePadding, y+textY, title, tableText)
+	y += tableRowHeight
+
+	// Header row
+	draw.Draw(img, image.Rect(tablePadding, y, width-tablePadding, y+tableRowHeight), &image.Uniform{tableHeader}, image.Point{}, draw.Src)
+	x := tablePadding + tableStripe + glyphAdvance/2
+	drawTableText(img, x, y+textY, "#", tableHeaderText)
+	drawTableText(img, x+4*glyphAdvance, y+textY, "Team", tableHeaderText)
+	x += (4 + nameWidth) * glyphAdvance
+	for _, column := range tableImageColumns {
+		x += column.width * glyphAdvance
+		drawTableText(img, x-len(column.title)*glyphAdvance, y+textY, column.title, tableHeaderText)
+	}
+	y += tableRowHeight
+
+	for i, entry := range table {
+		if i%2 == 1 {
+			draw.Draw(img, image.Rect(tablePadding, y, width-tablePadding, y+tableRowHeight), &image.Uniform{tableAltRow}, image.Point{}, draw.Src)
+		}
+		if stripe, ok := parseHexColor(entry.PrimaryColor); ok {
+			draw.Draw(img, image.Rect(tablePadding, y, tablePadding+tableStripe, y+tableRowHeight), &image.Uniform{stripe}, image.Point{}, draw.Src)
+		}
+
+		x := tablePadding + tableStripe + glyphAdvance/2
+		drawTableText(img, x, y+textY, strconv.Itoa(entry.Position), tableMutedText)
+		drawTableText(img, x+4*glyphAdvance, y+textY, tableImageName(entry), tableText)
+		x += (4 + nameWidth) * glyphAdvance
+		for _, column := range tableImageColumns {
+			x += column.width * glyphAdvance
+			value := column.value(entry)
+			drawTableText(img, x-len(value)*glyphAdvance, y+textY, value, tableText)
+		}
+		y += tableRowHeight
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// tableImageName is the ASCII name a table image shows for an entry
+func tableImageName(entry *sim.LeagueTableEntry) string {
+	if entry.DisplayName != "" {
+		return asciiName(entry.DisplayName)
+	}
+	return asciiName(entry.TeamName)
+}
+
+// drawTableText draws ASCII text with its top-left corner at (x, y); other characters
+// are drawn as '?'
+func drawTableText(img *image.RGBA, x, y int, text string, c color.Color) {
+	for _, r := range text {
+		if r < ' ' || r > '~' {
+			r = '?'
+		}
+		glyph := tableFont[r-' ']
+		for col, bits := range glyph {
+			for row := 0; row < 7; row++ {
+				if bits&(1<<row) == 0 {
+					continue
+				}
+				px, py := x+col*glyphScale, y+row*glyphScale
+				draw.Draw(img, image.Rect(px, py, px+glyphScale, py+glyphScale), &image.Uniform{c}, image.Point{}, draw.Src)
+			}
+		}
+		x += glyphAdvance
+	}
+}
+
+// parseHexColor reads a validated branding colour, "#RGB" or "#RRGGBB"
+func parseHexColor(hex string) (color.RGBA, bool) {
+	if !sim.HexColorPattern.MatchString(hex) {
+		return color.RGBA{}, false
+	}
+	digits := hex[1:]
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xff}, true
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// validateTeamName checks that a name yields a usable slug: one that is not empty and
+// cannot be mistaken for a numeric team ID
+func validateTeamName(name string) error {
+	slug := sim.Slugify(name)
+	if slug == "" {
+		return fmt.Errorf("name %q must contain letters or digits", name)
+	}
+	if _, err := strconv.Atoi(slug); err == nil {
+		return fmt.Errorf("name %q must not be only digits", name)
+	}
+	return nil
+}
+
+// findTeamByReference looks a team up by numeric ID, slug or short code. canonical
+// reports whether the reference was already the form used in URLs (the ID or the
+// exact slug); other matches should be redirected to the team's slug.
+func findTeamByReference(teams []*sim.Team, reference string) (team *sim.Team, canonical bool) {
+	if id, err := strconv.Atoi(reference); err == nil {
+		for _, candidate := range teams {
+			if candidate.TeamId == id {
+				return candidate, true
+			}
+		}
+		return nil, false
+	}
+	for _, candidate := range teams {
+		if candidate.Slug == reference {
+			return candidate, true
+		}
+	}
+	for _, candidate := range teams {
+		if candidate.Slug == sim.Slugify(reference) ||
+			(candidate.ShortCode != "" && strings.EqualFold(candidate.ShortCode, reference)) {
+			return candidate, false
+		}
+	}
+	return nil, false
+}
+
+// TeamBrandingUpdate is a partial update of a team's branding; nil fields are left unchanged
+type TeamBrandingUpdate struct {
+	ShortCode      *string `json:"short_code"`
+	PrimaryColor   *string `json:"primary_color"`
+	SecondaryColor *string `json:"secondary_color"`
+	BadgeURL       *string `json:"badge_url"`
+}
+
+// apply returns the team's branding with the requested fields replaced
+func (u TeamBrandingUpdate) apply(branding sim.TeamBranding) sim.TeamBranding {
+	if u.ShortCode != nil {
+		branding.ShortCode = strings.ToUpper(strings.TrimSpace(*u.ShortCode))
+	}
+	if u.PrimaryColor != nil {
+		branding.PrimaryColor = strings.TrimSpace(*u.PrimaryColor)
+	}
+	if u.SecondaryColor != nil {
+		branding.SecondaryColor = strings.TrimSpace(*u.SecondaryColor)
+	}
+	if u.BadgeURL != nil {
+		branding.BadgeURL = strings.TrimSpace(*u.BadgeURL)
+	}
+	return branding
+}
+
+// TeamNameChange records a rename; the team keeps its ID and history
+type TeamNameChange struct {
+	TeamId    int
+	OldName   string
+	NewName   string
+	Season    int
+	ChangedAt time.Time
+}
+
+// TeamImport is one team in a bulk import
+type TeamImport struct {
+	Name     string `json:"name"`
+	Strength int    `json:"strength"`
+	Stadium  string `json:"stadium"`
+	City     string `json:"city"`
+	// Names by language tag; CSV imports take them from name_<tag> columns, e.g. name_tr
+	LocalizedNames map[string]string `json:"localized_names"`
+	TeamBrandingUpdate
+}
+
+// parseTeamImportCSV reads teams from CSV with a header row naming the columns
+// (name and strength required; stadium, city, branding and localized names optional;
+// in any order)
+func parseTeamImportCSV(r io.Reader) ([]TeamImport, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+	for _, required := range []string{"name", "strength"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header must include a %q column", required)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	teams := []TeamImport{}
+	for row, record := range records[1:] {
+		strength, err := strconv.Atoi(field(record, "strength"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: strength must be a number", row+1)
+		}
+		team := TeamImport{
+			Name:     field(record, "name"),
+			Strength: strength,
+			Stadium:  field(record, "stadium"),
+			City:     field(record, "city"),
+		}
+		optional := map[string]**string{
+			"short_code":      &team.ShortCode,
+			"primary_color":   &team.PrimaryColor,
+			"secondary_color": &team.SecondaryColor,
+			"badge_url":       &team.BadgeURL,
+		}
+		for column, target := range optional {
+			if value := field(record, column); value != "" {
+				*target = &value
+			}
+		}
+		for column := range columns {
+			if tag, ok := strings.CutPrefix(column, "name_"); ok && field(record, column) != "" {
+				if team.LocalizedNames == nil {
+					team.LocalizedNames = make(map[string]string)
+				}
+				team.LocalizedNames[tag] = field(record, column)
+			}
+		}
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+// validateTeamImport checks each imported team and that names are unique within
+// the import and against the teams kept alongside it
+func validateTeamImport(imported []TeamImport, kept []*sim.Team) error {
+	// Names are compared by slug so two teams can never share a URL
+	names := make(map[string]bool)
+	shortCodes := make(map[string]bool)
+	for _, team := range kept {
+		names[sim.Slugify(team.TeamName)] = true
+		if team.ShortCode != "" {
+			shortCodes[team.ShortCode] = true
+		}
+	}
+
+	problems := []string{}
+	for i, team := range imported {
+		name := strings.TrimSpace(team.Name)
+		switch {
+		case name == "":
+			problems = append(problems, fmt.Sprintf("team %d: name is required", i+1))
+		case validateTeamName(name) != nil:
+			problems = append(problems, fmt.Sprintf("team %d: %v", i+1, validateTeamName(name)))
+		case names[sim.Slugify(name)]:
+			problems = append(problems, fmt.Sprintf("team %d: duplicate name %q", i+1, name))
+		}
+		names[sim.Slugify(name)] = true
+
+		if team.Strength < 1 || team.Strength > 100 {
+			problems = append(problems, fmt.Sprintf("team %d: strength must be between 1 and 100", i+1))
+		}
+		if err := validateLocalizedNames(team.LocalizedNames); err != nil {
+			problems = append(problems, fmt.Sprintf("team %d: %v", i+1, err))
+		}
+		branding := team.TeamBrandingUpdate.apply(sim.TeamBranding{})
+		if err := branding.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("team %d: %v", i+1, err))
+		} else if branding.ShortCode != "" {
+			if shortCodes[branding.ShortCode] {
+				problems = append(problems, fmt.Sprintf("team %d: duplicate short code %q", i+1, branding.ShortCode))
+			}
+			shortCodes[branding.ShortCode] = true
+		}
+	}
+
+	if len(kept)+len(imported) < 2 {
+		problems = append(problems, "a league needs at least 2 teams")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// telegramPollTimeout is how long each getUpdates long poll waits for messages
+const telegramPollTimeout = 30 * time.Second
+
+// maxOpenChats bounds how many chats' databases the bot keeps open; the least
+// recently used chat's is closed when another chat needs one
+const maxOpenChats = 64
+
+// telegramHelp lists the bot's commands
+const telegramHelp = `Commands:
+/table - the league table
+/next - play the next week
+/simulate - play the rest of the season
+/predict - championship chances
+/help - this message`
+
+// telegramBot answers chat commands, running a separate league for every chat
+type telegramBot struct {
+	api     string // Bot API base URL including the token
+	token   string
+	dataDir string
+	client  *http.Client
+	leagues map[int64]*openChat
+	clock   int
+}
+
+// openChat is a chat's league with its open database
+type openChat struct {
+	service  *LeagueSimulatorService
+	storage  *SQLStorageService
+	lastUsed int
+}
+
+// telegramUpdate is the part of a Bot API update the bot reads
+type telegramUpdate struct {
+	UpdateId int64 `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			Id int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+// runTelegramBot polls the Telegram Bot API for commands until the process stops.
+// The token comes from LEAGUE_TELEGRAM_TOKEN; each chat's league is stored in its
+// own SQLite file under LEAGUE_TELEGRAM_DATA_DIR (default ./telegram).
+func runTelegramBot() {
+	token := os.Getenv("LEAGUE_TELEGRAM_TOKEN")
+	if token == "" {
+		log.Fatal("LEAGUE_TELEGRAM_TOKEN is required for the telegram mode")
+	}
+	api := os.Getenv("LEAGUE_TELEGRAM_API")
+	if api == "" {
+		api = "https://api.telegram.org"
+	}
+	dataDir := os.Getenv("LEAGUE_TELEGRAM_DATA_DIR")
+	if dataDir == "" {
+		dataDir = "./telegram"
+	}
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		log.Fatalf("Failed to create telegram data directory: %v", err)
+	}
+
+	bot := &telegramBot{
+		api:     strings.TrimRight(api, "/") + "/bot" + token,
+		token:   token,
+		dataDir: dataDir,
+		client:  &http.Client{Timeout: telegramPollTimeout + 10*time.Second},
+		leagues: make(map[int64]*openChat),
+	}
+	fmt.Println("Telegram bot started, waiting for commands")
+
+	offset := int64(0)
+	for {
+		updates, err := bot.getUpdates(offset)
+		if err != nil {
+			log.Printf("Failed to get telegram updates: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		for _, update := range updates {
+			offset = update.UpdateId + 1
+			if update.Message == nil || !strings.HasPrefix(update.Message.Text, "/") {
+				continue
+			}
+			chatId := update.Message.Chat.Id
+			reply := bot.handleCommand(chatId, update.Message.Text)
+			if err := bot.sendMessage(chatId, reply); err != nil {
+				log.Printf("Failed to reply to chat %d: %v", chatId, err)
+			}
+		}
+	}
+}
+
+// getUpdates long-polls for the updates after offset
+func (b *telegramBot) getUpdates(offset int64) ([]telegramUpdate, error) {
+	query := url.Values{}
+	query.Set("offset", fmt.Sprint(offset))
+	query.Set("timeout", fmt.Sprint(int(telegramPollTimeout.Seconds())))
+	resp, err := b.client.Get(b.api + "/getUpdates?" + query.Encode())
+	if err != nil {
+		return nil, b.redact(err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Ok          bool             `json:"ok"`
+		Description string           `json:"description"`
+		Result      []telegramUpdate `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode updates: %v", err)
+	}
+	if !body.Ok {
+		return nil, fmt.Errorf("telegram error: %s", body.Description)
+	}
+	return body.Result, nil
+}
+
+// sendMessage posts an HTML-formatted reply to a chat
+func (b *telegramBot) sendMessage(chatId int64, text string) error {
+	payload, err := json.Marshal(map[string]interface{}{"chat_id": chatId, "text": text, "parse_mode": "HTML"})
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Post(b.api+"/sendMessage", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return b.redact(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telegram rejected the message: %s", resp.Status)
+	}
+	return nil
+}
+
+// redact keeps the bot token out of an error, e.g. a *url.Error quoting the request URL
+func (b *telegramBot) redact(err error) error {
+	return errors.New(strings.ReplaceAll(err.Error(), b.token, "<token>"))
+}
+
+// chatLeague returns the chat's league service, opening the chat's database and
+// creating its league on first use. At most maxOpenChats databases stay open.
+func (b *telegramBot) chatLeague(chatId int64) (*LeagueSimulatorService, error) {
+	b.clock++
+	if chat, ok := b.leagues[chatId]; ok {
+		chat.lastUsed = b.clock
+		return chat.service, nil
+	}
+
+	storage, err := NewSQLStorageService("sqlite3", filepath.Join(b.dataDir, fmt.Sprintf("chat-%d.db", chatId)))
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.InitializeTeamsAndMatches(); err != nil {
+		storage.Close()
+		return nil, err
+	}
+	league, err := loadLeague(storage)
+	if err != nil {
+		storage.Close()
+		return nil, err
+	}
+
+	if len(b.leagues) >= maxOpenChats {
+		b.closeLeastRecentChat()
+	}
+	service := NewLeagueSimulatorService(league, storage)
+	b.leagues[chatId] = &openChat{service: service, storage: storage, lastUsed: b.clock}
+	return service, nil
+}
+
+// closeLeastRecentChat closes the database of the chat that has gone longest without
+// a command; its league is reloaded from the file when the chat next needs it
+func (b *telegramBot) closeLeastRecentChat() {
+	var oldest int64
+	oldestUse := b.clock + 1
+	for chatId, chat := range b.leagues {
+		if chat.lastUsed < oldestUse {
+			oldest, oldestUse = chatId, chat.lastUsed
+		}
+	}
+	if chat, ok := b.leagues[oldest]; ok {
+		chat.storage.Close()
+		delete(b.leagues, oldest)
+	}
+}
+
+// handleCommand runs a chat command against the chat's league and returns the reply
+func (b *telegramBot) handleCommand(chatId int64, text string) string {
+	// In groups commands may be addressed to the bot, e.g. /table@LeagueBot
+	command := strings.Fields(text)[0]
+	if at := strings.Index(command, "@"); at >= 0 {
+		command = command[:at]
+	}
+
+	switch command {
+	case "/start", "/help":
+		return html.EscapeString(telegramHelp)
+	case "/table", "/next", "/simulate", "/predict":
+	default:
+		return html.EscapeString("Unknown command.\n\n" + telegramHelp)
+	}
+
+	service, err := b.chatLeague(chatId)
+	if err != nil {
+		log.Printf("Failed to load league for chat %d: %v", chatId, err)
+		return "Sorry, this chat's league could not be loaded."
+	}
+	league := service.league
+
+	switch command {
+	case "/table":
+		return telegramPre(formatTableText(service.GetLeagueTable()))
+	case "/predict":
+		return html.EscapeString(formatPredictions(league))
+	}
+
+	// /next and /simulate: report the matches the command played
+	unplayed := make(map[*sim.Match]bool)
+	for _, match := range league.Matches {
+		if !match.Played {
+			unplayed[match] = true
+		}
+	}
+	if command == "/next" {
+		err = service.SimulateNextWeek()
+	} else {
+		err = service.SimulateAllMatches()
+	}
+	if err != nil {
+		return html.EscapeString(fmt.Sprintf("Could not simulate: %v", err))
+	}
+
+	played := []*sim.Match{}
+	for _, match := range league.Matches {
+		if unplayed[match] && match.Played {
+			played = append(played, match)
+		}
+	}
+	update := newWeekUpdate(league, played)
+	if command == "/simulate" {
+		// A whole season's results would swamp the chat; the table tells the story
+		return fmt.Sprintf("<b>Season %d played out</b>\n%s\n%s", update.Season, telegramPre(formatTableText(update.Table)), html.EscapeString(formatTitleRace(update)))
+	}
+	return fmt.Sprintf("<b>%s</b>\n%s\n%s\n%s", html.EscapeString(weekUpdateTitle(update)), html.EscapeString(formatWeekResults(update)),
+		telegramPre(formatTableText(update.Table)), html.EscapeString(formatTitleRace(update)))
+}
+
+// formatPredictions lists every team's championship chance, most likely first
+func formatPredictions(league *sim.League) string {
+	update := newWeekUpdate(league, nil)
+	if update.Champion != "" || update.Playoff != nil {
+		return formatTitleRace(update)
+	}
+
+	predictions := sim.PredictChampionship(league)
+	names := make([]string, 0, len(predictions))
+	for name := range predictions {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if predictions[names[i]] != predictions[names[j]] {
+			return predictions[names[i]] > predictions[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	lines := []string{"Championship chances:"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s %.1f%%", name, predictions[name]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// telegramPre wraps text in a monospaced block
+func telegramPre(text string) string {
+	return "<pre>" + html.EscapeString(text) + "</pre>"
+}
@@ -0,0 +1,529 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+	"github.com/gorilla/mux"
+)
+
+// Where a tenant's league is stored
+const (
+	TenantBackendSQLite   = "sqlite"   // a SQLite file of its own under LEAGUE_TENANT_DIR
+	TenantBackendPostgres = "postgres" // a schema of its own in the tenants' Postgres database
+	TenantBackendMemory   = "memory"   // an in-memory SQLite database, started afresh on every run
+)
+
+// defaultTenantDir holds the tenants' SQLite files when LEAGUE_TENANT_DIR is unset
+const defaultTenantDir = "tenants"
+
+var (
+	errTenantExists     = errors.New("tenant already exists")
+	errTenantNotFound   = errors.New("tenant not found")
+	errTenantDeleted    = errors.New("tenant has been deleted")
+	errInvalidTenantId  = errors.New("tenant ID must be lowercase letters, digits and underscores, starting with a letter, at most 40 characters")
+	errInvalidBackend   = errors.New("backend must be sqlite, postgres or memory")
+	errNoTenantPostgres = errors.New("postgres tenants need LEAGUE_TENANT_DATABASE_URL or LEAGUE_DATABASE_URL")
+)
+
+// tenantIdPattern keeps tenant IDs usable as file and Postgres schema names
+var tenantIdPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// Tenant is a league hosted for one API key in tenancy mode, isolated from the
+// others in a database of its own
+type Tenant struct {
+	Id        string
+	Backend   string // TenantBackendSQLite, TenantBackendPostgres or TenantBackendMemory
+	Location  string // the SQLite file, the Postgres schema or the in-memory database's name
+	CreatedAt time.Time
+	ApiKey    string `json:",omitempty"` // only set on the response creating the tenant
+
+	keyHash string // SHA-256 of the API key, the only form of it stored
+	deleted bool
+
+	// mu is held shared by every request and job on the tenant's league, and
+	// exclusively while the league is opened or closed
+	mu    sync.RWMutex
+	scope *leagueScope // opened on the tenant's first request
+}
+
+// TenantRegistry maps API keys to tenants; the registry itself is kept in the
+// tenants table of the server's own database
+type TenantRegistry struct {
+	mu      sync.Mutex
+	storage StorageService
+	cache   ResponseCache // the server's own cache, shared with tenants when it is Redis
+	tenants map[string]*Tenant
+	keys    map[string]*Tenant
+}
+
+// tenantRegistry is set when LEAGUE_TENANCY=true, nil otherwise
+var tenantRegistry *TenantRegistry
+
+// tenancyEnabled reports whether LEAGUE_TENANCY turns on tenancy mode
+func tenancyEnabled() bool {
+	return os.Getenv("LEAGUE_TENANCY") == "true"
+}
+
+// NewTenantRegistry loads the registry kept in storage
+func NewTenantRegistry(storage StorageService, cache ResponseCache) (*TenantRegistry, error) {
+	tenants, err := storage.GetTenants()
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &TenantRegistry{
+		storage: storage,
+		cache:   cache,
+		tenants: make(map[string]*Tenant),
+		keys:    make(map[string]*Tenant),
+	}
+	for _, tenant := range tenants {
+		registry.tenants[tenant.Id] = tenant
+		registry.keys[tenant.keyHash] = tenant
+	}
+	return registry, nil
+}
+
+// hashApiKey is the form an API key is stored and looked up in
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// lookup returns the tenant an API key belongs to, nil for an unknown key
+func (reg *TenantRegistry) lookup(key string) *Tenant {
+	if key == "" {
+		return nil
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.keys[hashApiKey(key)]
+}
+
+// list returns the tenants ordered by ID
+func (reg *TenantRegistry) list() []*Tenant {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	tenants := make([]*Tenant, 0, len(reg.tenants))
+	for _, tenant := range reg.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Id < tenants[j].Id })
+	return tenants
+}
+
+// create registers a tenant stored on the given backend, by default the one the
+// server's own database uses, creates its league and returns it with its new API key
+func (reg *TenantRegistry) create(id, backend, sport string) (*Tenant, string, error) {
+	if !tenantIdPattern.MatchString(id) {
+		return nil, "", errInvalidTenantId
+	}
+	preset, err := sim.DefaultSport()
+	if sport != "" {
+		preset, err = sim.LookupSport(sport)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if backend == "" {
+		backend = TenantBackendSQLite
+		if os.Getenv("LEAGUE_DATABASE_URL") != "" {
+			backend = TenantBackendPostgres
+		}
+	}
+	location := ""
+	switch backend {
+	case TenantBackendSQLite:
+		location = filepath.Join(tenantDir(), id+".db")
+	case TenantBackendPostgres:
+		if tenantDatabaseURL() == "" {
+			return nil, "", errNoTenantPostgres
+		}
+		location = "tenant_" + id
+	case TenantBackendMemory:
+		location = "tenant_" + id
+	default:
+		return nil, "", errInvalidBackend
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %v", err)
+	}
+	key := hex.EncodeToString(raw)
+	tenant := &Tenant{
+		Id:        id,
+		Backend:   backend,
+		Location:  location,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		keyHash:   hashApiKey(key),
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if reg.tenants[id] != nil {
+		return nil, "", errTenantExists
+	}
+
+	// Create the league before registering it, so a tenant that cannot be stored is never handed out
+	if err := reg.open(tenant); err != nil {
+		return nil, "", err
+	}
+	if err := tenant.scope.storage.UpdateSport(preset.Name); err != nil {
+		tenant.close()
+		return nil, "", err
+	}
+	tenant.scope.League().Sport = preset.Name
+	if err := reg.storage.SaveTenant(tenant); err != nil {
+		tenant.close()
+		return nil, "", err
+	}
+	reg.tenants[id] = tenant
+	reg.keys[tenant.keyHash] = tenant
+	return tenant, key, nil
+}
+
+// remove unregisters a tenant and closes its database, leaving the data in place
+func (reg *TenantRegistry) remove(id string) error {
+	reg.mu.Lock()
+	tenant := reg.tenants[id]
+	if tenant == nil {
+		reg.mu.Unlock()
+		return errTenantNotFound
+	}
+	if err := reg.storage.DeleteTenant(id); err != nil {
+		reg.mu.Unlock()
+		return err
+	}
+	delete(reg.tenants, id)
+	delete(reg.keys, tenant.keyHash)
+	reg.mu.Unlock()
+
+	// Wait for any request or job on the tenant's league to finish before closing its database
+	tenant.mu.Lock()
+	defer tenant.mu.Unlock()
+	tenant.deleted = true
+	tenant.close()
+	return nil
+}
+
+// tenantDir reads LEAGUE_TENANT_DIR, where SQLite tenants keep their files
+func tenantDir() string {
+	if dir := os.Getenv("LEAGUE_TENANT_DIR"); dir != "" {
+		return dir
+	}
+	return defaultTenantDir
+}
+
+// tenantDatabaseURL is the Postgres database holding the postgres tenants' schemas:
+// LEAGUE_TENANT_DATABASE_URL, or else the server's own LEAGUE_DATABASE_URL
+func tenantDatabaseURL() string {
+	if url := os.Getenv("LEAGUE_TENANT_DATABASE_URL"); url != "" {
+		return url
+	}
+	return os.Getenv("LEAGUE_DATABASE_URL")
+}
+
+// openTenantStorage opens a tenant's database on the backend recorded in the
+// registry, creating the file's directory when it does not exist yet
+func openTenantStorage(tenant *Tenant) (*SQLStorageService, error) {
+	switch tenant.Backend {
+	case TenantBackendSQLite:
+		if err := os.MkdirAll(filepath.Dir(tenant.Location), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create tenant directory: %v", err)
+		}
+		return NewSQLStorageService("sqlite3", tenant.Location)
+	case TenantBackendPostgres:
+		return openTenantSchema(tenant)
+	case TenantBackendMemory:
+		// A named shared-cache database is seen by every connection in the pool and
+		// lasts until the last of them closes, so the storage keeps one open for the
+		// tenant's lifetime; the pool may close its idle ones at any time
+		storage, err := NewSQLStorageService("sqlite3", "file:"+tenant.Location+"?mode=memory&cache=shared")
+		if err != nil {
+			return nil, err
+		}
+		if err := storage.pinConnection(); err != nil {
+			storage.Close()
+			return nil, err
+		}
+		return storage, nil
+	default:
+		return nil, fmt.Errorf("tenant %s has unknown backend %q", tenant.Id, tenant.Backend)
+	}
+}
+
+// openTenantSchema opens a postgres tenant's schema, creating it on first use
+func openTenantSchema(tenant *Tenant) (*SQLStorageService, error) {
+	databaseURL := tenantDatabaseURL()
+	if databaseURL == "" {
+		return nil, fmt.Errorf("tenant %s: %v", tenant.Id, errNoTenantPostgres)
+	}
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	// The schema name comes from a validated tenant ID, so it is safe to splice in
+	if _, err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + tenant.Location); err != nil {
+		return nil, fmt.Errorf("failed to create tenant schema: %v", err)
+	}
+	return NewSQLStorageService("postgres", withSearchPath(databaseURL, tenant.Location))
+}
+
+// withSearchPath points a Postgres connection string at a schema; lib/pq passes
+// search_path on to the server as a run-time parameter
+func withSearchPath(databaseURL, schema string) string {
+	if parsed, err := url.Parse(databaseURL); err == nil && parsed.Scheme != "" {
+		query := parsed.Query()
+		query.Set("search_path", schema)
+		parsed.RawQuery = query.Encode()
+		return parsed.String()
+	}
+	return databaseURL + " search_path=" + schema
+}
+
+// newTenantCache gives a tenant a response cache of its own, or its own key space
+// in the shared Redis
+func (reg *TenantRegistry) newTenantCache(tenant *Tenant) ResponseCache {
+	if _, ok := reg.cache.(*RedisResponseCache); ok {
+		return prefixedResponseCache{cache: reg.cache, prefix: "tenant:" + tenant.Id + ":"}
+	}
+	return NewMemoryResponseCache()
+}
+
+// prefixedResponseCache keeps one tenant's entries apart from the others' in a shared cache
+type prefixedResponseCache struct {
+	cache  ResponseCache
+	prefix string
+}
+
+func (c prefixedResponseCache) Get(key string, version int) ([]byte, bool) {
+	return c.cache.Get(c.prefix+key, version)
+}
+
+func (c prefixedResponseCache) Set(key string, version int, data []byte) {
+	c.cache.Set(c.prefix+key, version, data)
+}
+
+// open loads a tenant's league, running the startup checks on it as initializeLeague
+// does for the server's own
+func (reg *TenantRegistry) open(tenant *Tenant) error {
+	storage, err := openTenantStorage(tenant)
+	if err != nil {
+		return err
+	}
+	if err := storage.InitializeTeamsAndMatches(); err != nil {
+		storage.Close()
+		return fmt.Errorf("failed to initialize tenant %s: %v", tenant.Id, err)
+	}
+	league, err := loadLeague(storage)
+	if err != nil {
+		storage.Close()
+		return fmt.Errorf("failed to load tenant %s: %v", tenant.Id, err)
+	}
+	if err := validateLoadedLeague(storage, league); err != nil {
+		storage.Close()
+		return fmt.Errorf("tenant %s: %v", tenant.Id, err)
+	}
+
+	scope := newLeagueScope(tenant)
+	scope.storage = storage
+	scope.cache = reg.newTenantCache(tenant)
+	scope.setLeague(league)
+	tenant.scope = scope
+	return nil
+}
+
+// close releases a tenant's database
+func (t *Tenant) close() {
+	if t.scope == nil {
+		return
+	}
+	if storage, ok := t.scope.storage.(*SQLStorageService); ok {
+		storage.Close()
+	}
+	t.scope = nil
+}
+
+// hold keeps a tenant's league open until the returned function is called
+func (t *Tenant) hold() (func(), error) {
+	t.mu.RLock()
+	if t.deleted {
+		t.mu.RUnlock()
+		return nil, errTenantDeleted
+	}
+	return t.mu.RUnlock, nil
+}
+
+// enter returns a tenant's scope, opening its league on first use, and holds it
+// open until the returned function is called. Tenants are entered independently,
+// so one tenant's requests never wait on another's.
+func (reg *TenantRegistry) enter(tenant *Tenant) (*leagueScope, func(), error) {
+	leave, err := tenant.hold()
+	if err != nil {
+		return nil, nil, err
+	}
+	if tenant.scope != nil {
+		return tenant.scope, leave, nil
+	}
+	leave()
+
+	tenant.mu.Lock()
+	if !tenant.deleted && tenant.scope == nil {
+		err = reg.open(tenant)
+	}
+	tenant.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	return reg.enter(tenant)
+}
+
+// eachScope runs fn on the server's league or, in tenancy mode, on each tenant's
+// league that is open
+func eachScope(fn func(scope *leagueScope)) {
+	if tenantRegistry == nil {
+		fn(serverScope)
+		return
+	}
+	for _, tenant := range tenantRegistry.list() {
+		leave, err := tenant.hold()
+		if err != nil {
+			continue
+		}
+		if tenant.scope != nil {
+			fn(tenant.scope)
+		}
+		leave()
+	}
+}
+
+// tenantMiddleware serves each request from the league of the tenant its X-API-Key
+// header names, carried in the request context. Registry administration is the only
+// thing done outside a tenant's league. It sits inside timeoutMiddleware, so a
+// handler that overruns its deadline holds its tenant open until it returns.
+func tenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tenantRegistry == nil || strings.HasPrefix(r.URL.Path, "/admin/tenants") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenant := tenantRegistry.lookup(r.Header.Get("X-API-Key"))
+		if tenant == nil {
+			http.Error(w, "A valid X-API-Key is required", http.StatusUnauthorized)
+			return
+		}
+		scope, leave, err := tenantRegistry.enter(tenant)
+		if err != nil {
+			log.Printf("Failed to open tenant %s: %v", tenant.Id, err)
+			http.Error(w, "League unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		defer leave()
+
+		// leagueMetaMiddleware leaves the metadata headers to us, since only here is the
+		// tenant's league known
+		next.ServeHTTP(&leagueMetaWriter{ResponseWriter: w, scope: scope}, r.WithContext(withScope(r.Context(), scope)))
+	})
+}
+
+// rejectIfNoTenancy answers 404 Not Found when tenancy mode is off
+func rejectIfNoTenancy(w http.ResponseWriter) bool {
+	if tenantRegistry != nil {
+		return false
+	}
+	http.Error(w, "Tenancy mode is off; set LEAGUE_TENANCY=true", http.StatusNotFound)
+	return true
+}
+
+// GET /admin/tenants - Lists the tenants in the registry
+func getTenantsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if rejectIfNoTenancy(w) {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(tenantRegistry.list()); err != nil {
+		http.Error(w, "Error encoding tenants", http.StatusInternalServerError)
+		return
+	}
+}
+
+// POST /admin/tenants - Creates a tenant with its own league and returns its API key
+func createTenantHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if rejectIfNoTenancy(w) {
+		return
+	}
+
+	var requestBody struct {
+		Id      string `json:"id"`
+		Backend string `json:"backend"`
+		Sport   string `json:"sport"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tenant, key, err := tenantRegistry.create(requestBody.Id, requestBody.Backend, requestBody.Sport)
+	switch {
+	case errors.Is(err, errInvalidTenantId), errors.Is(err, errInvalidBackend), errors.Is(err, errNoTenantPostgres),
+		errors.Is(err, sim.ErrUnknownSport):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, errTenantExists):
+		http.Error(w, "Tenant already exists", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The key is shown this once; only its hash is kept
+	created := Tenant{Id: tenant.Id, Backend: tenant.Backend, Location: tenant.Location, CreatedAt: tenant.CreatedAt, ApiKey: key}
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(&created); err != nil {
+		http.Error(w, "Error encoding tenant", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DELETE /admin/tenants/{id} - Removes a tenant from the registry, keeping its data
+func deleteTenantHandler(w http.ResponseWriter, r *http.Request) {
+	if rejectIfNoTenancy(w) {
+		return
+	}
+
+	err := tenantRegistry.remove(mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, errTenantNotFound):
+		http.Error(w, "Tenant not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// newTestRegistry turns on tenancy mode for a test, with the registry kept in a
+// database of its own and SQLite tenants under a temporary directory
+func newTestRegistry(t *testing.T) *TenantRegistry {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("LEAGUE_TENANT_DIR", filepath.Join(dir, "tenants"))
+
+	storage, err := NewSQLStorageService("sqlite3", filepath.Join(dir, "league.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.InitializeTeamsAndMatches(); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := NewTenantRegistry(storage, NewMemoryResponseCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tenantRegistry = registry
+	t.Cleanup(func() {
+		for _, tenant := range registry.list() {
+			registry.remove(tenant.Id)
+		}
+		tenantRegistry = nil
+		storage.Close()
+	})
+	return registry
+}
+
+func TestTenantIsolation(t *testing.T) {
+	registry := newTestRegistry(t)
+	_, first, err := registry.create("first", TenantBackendSQLite, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, second, err := registry.create("second", TenantBackendMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := setupRoutes()
+
+	send := func(method, path, key string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			request.Header.Set("X-API-Key", key)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// The first tenant plays two weeks and the second one, each on its own league
+	for _, play := range []string{first, first, second} {
+		if response := send(http.MethodPost, "/league/next-week", play); response.Code != http.StatusOK {
+			t.Fatalf("next week answered %d: %s", response.Code, response.Body)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		key        string
+		wantStatus int
+		wantWeek   string
+	}{
+		{name: "first tenant", key: first, wantStatus: http.StatusOK, wantWeek: "2"},
+		{name: "second tenant", key: second, wantStatus: http.StatusOK, wantWeek: "1"},
+		{name: "no key", wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", key: "not-a-key", wantStatus: http.StatusUnauthorized},
+	}
+	tables := make(map[string]string)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, path := range []string{"/league/table", "/league/state"} {
+				response := send(http.MethodGet, path, test.key)
+				if response.Code != test.wantStatus {
+					t.Fatalf("%s answered %d, want %d", path, response.Code, test.wantStatus)
+				}
+				if week := response.Header().Get("X-League-Week"); week != test.wantWeek {
+					t.Errorf("%s is at week %q, want %q", path, week, test.wantWeek)
+				}
+				if path == "/league/table" && test.key != "" {
+					tables[test.key] = response.Body.String()
+				}
+			}
+		})
+	}
+	// Each tenant's table counts only the weeks its own league has played
+	if tables[first] == tables[second] {
+		t.Error("both tenants were served the same table")
+	}
+
+	// Removing a tenant turns its key away and leaves the other's league alone
+	if err := registry.remove("first"); err != nil {
+		t.Fatal(err)
+	}
+	if response := send(http.MethodGet, "/league/state", first); response.Code != http.StatusUnauthorized {
+		t.Errorf("a removed tenant's key answered %d, want %d", response.Code, http.StatusUnauthorized)
+	}
+	if response := send(http.MethodGet, "/league/state", second); response.Header().Get("X-League-Week") != "1" {
+		t.Errorf("the remaining tenant answered %d at week %q, want week 1", response.Code, response.Header().Get("X-League-Week"))
+	}
+}
+
+func TestCreateTenant(t *testing.T) {
+	registry := newTestRegistry(t)
+	if _, _, err := registry.create("taken", TenantBackendMemory, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		backend string
+		sport   string
+		wantErr error
+	}{
+		{name: "sqlite", id: "club", backend: TenantBackendSQLite},
+		{name: "another sport", id: "rink", backend: TenantBackendMemory, sport: "hockey"},
+		{name: "taken", id: "taken", backend: TenantBackendMemory, wantErr: errTenantExists},
+		{name: "uppercase", id: "Club", backend: TenantBackendMemory, wantErr: errInvalidTenantId},
+		{name: "path", id: "../club", backend: TenantBackendSQLite, wantErr: errInvalidTenantId},
+		{name: "unknown backend", id: "mongo", backend: "mongodb", wantErr: errInvalidBackend},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tenant, key, err := registry.create(test.id, test.backend, test.sport)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("create returned %v, want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if registry.lookup(key) != tenant {
+				t.Error("the new API key does not find its tenant")
+			}
+			if test.sport != "" && tenant.scope.League().Sport != test.sport {
+				t.Errorf("tenant plays %q, want %q", tenant.scope.League().Sport, test.sport)
+			}
+		})
+	}
+}
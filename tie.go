@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// How a two-legged tie was decided
+const (
+	DecidedByAggregate = "aggregate"
+	DecidedByAwayGoals = "away_goals"
+	DecidedByExtraTime = "extra_time"
+	DecidedByPenalties = "penalties"
+)
+
+// TieRules configures how a level aggregate is broken
+type TieRules struct {
+	AwayGoals bool // away goals count double when the aggregate is level
+	ExtraTime bool // play 30 extra minutes at the end of the second leg
+	Penalties bool // settle a tie still level with a penalty shootout
+}
+
+// DefaultTieRules mirrors current UEFA rules: no away goals, extra time, then penalties
+func DefaultTieRules() TieRules {
+	return TieRules{AwayGoals: false, ExtraTime: true, Penalties: true}
+}
+
+// TwoLeggedTie pairs two matches between the same teams with reversed venues
+type TwoLeggedTie struct {
+	FirstLeg  *sim.Match
+	SecondLeg *sim.Match
+	Random    sim.RandomSource // seeded source for extra time and penalties; nil draws from the shared generator
+}
+
+// TieResult is the outcome of a resolved two-legged tie
+type TieResult struct {
+	Winner              *sim.Team
+	Loser               *sim.Team
+	AggregateFirstTeam  int // goals of the first leg's home team
+	AggregateSecondTeam int
+	AwayGoalsFirstTeam  int
+	AwayGoalsSecondTeam int
+	ExtraTimeHomeGoals  int // second leg home side
+	ExtraTimeAwayGoals  int
+	PenaltiesHome       int // second leg home side
+	PenaltiesAway       int
+	DecidedBy           string
+}
+
+// NewTwoLeggedTie validates that the legs form a home-and-away pairing
+func NewTwoLeggedTie(firstLeg, secondLeg *sim.Match) (*TwoLeggedTie, error) {
+	if firstLeg.HomeTeam.TeamId != secondLeg.AwayTeam.TeamId || firstLeg.AwayTeam.TeamId != secondLeg.HomeTeam.TeamId {
+		return nil, fmt.Errorf("legs must be between the same teams with reversed venues")
+	}
+	return &TwoLeggedTie{FirstLeg: firstLeg, SecondLeg: secondLeg}, nil
+}
+
+// Resolve decides the tie from the played legs, simulating extra time and penalties when needed
+func (t *TwoLeggedTie) Resolve(rules TieRules) (*TieResult, error) {
+	if !t.FirstLeg.Played || !t.SecondLeg.Played {
+		return nil, fmt.Errorf("both legs must be played before the tie can be resolved")
+	}
+
+	first := t.FirstLeg.HomeTeam
+	second := t.FirstLeg.AwayTeam
+
+	result := &TieResult{
+		AggregateFirstTeam:  t.FirstLeg.HomeTeamScore + t.SecondLeg.AwayTeamScore,
+		AggregateSecondTeam: t.FirstLeg.AwayTeamScore + t.SecondLeg.HomeTeamScore,
+		AwayGoalsFirstTeam:  t.SecondLeg.AwayTeamScore,
+		AwayGoalsSecondTeam: t.FirstLeg.AwayTeamScore,
+	}
+
+	if result.decide(first, second, rules, DecidedByAggregate) {
+		return result, nil
+	}
+
+	rng := t.Random
+	if rng == nil {
+		rng = sim.SharedRandom{}
+	}
+	config := sim.MatchConfig(t.SecondLeg)
+
+	if rules.ExtraTime {
+		// Extra time is played at the second leg venue; goals there are away goals for the first team
+		result.ExtraTimeHomeGoals, result.ExtraTimeAwayGoals = sim.SimulateExtraTime(t.SecondLeg, config, rng)
+		result.AggregateSecondTeam += result.ExtraTimeHomeGoals
+		result.AggregateFirstTeam += result.ExtraTimeAwayGoals
+		result.AwayGoalsFirstTeam += result.ExtraTimeAwayGoals
+
+		if result.decide(first, second, rules, DecidedByExtraTime) {
+			return result, nil
+		}
+	}
+
+	if !rules.Penalties {
+		return nil, fmt.Errorf("tie is level and no penalty shootout is configured")
+	}
+
+	// The shootout's kicks join the second leg's events timeline
+	sport := sim.MatchSport(t.SecondLeg)
+	minute := sport.Minutes()
+	if rules.ExtraTime {
+		minute += sport.ExtraTime
+	}
+	shootout := sim.SimulateShootout(t.SecondLeg.HomeTeam, t.SecondLeg.AwayTeam, minute, config, rng)
+	t.SecondLeg.Events = append(t.SecondLeg.Events, shootout.Kicks...)
+	result.PenaltiesHome, result.PenaltiesAway = shootout.HomeScore, shootout.AwayScore
+	if result.PenaltiesHome > result.PenaltiesAway {
+		result.Winner, result.Loser = t.SecondLeg.HomeTeam, t.SecondLeg.AwayTeam
+	} else {
+		result.Winner, result.Loser = t.SecondLeg.AwayTeam, t.SecondLeg.HomeTeam
+	}
+	result.DecidedBy = DecidedByPenalties
+	return result, nil
+}
+
+// decide sets the winner if aggregate (or away goals, when enabled) separates the teams
+func (r *TieResult) decide(first, second *sim.Team, rules TieRules, stage string) bool {
+	switch {
+	case r.AggregateFirstTeam > r.AggregateSecondTeam:
+		r.Winner, r.Loser, r.DecidedBy = first, second, stage
+	case r.AggregateFirstTeam < r.AggregateSecondTeam:
+		r.Winner, r.Loser, r.DecidedBy = second, first, stage
+	case rules.AwayGoals && r.AwayGoalsFirstTeam > r.AwayGoalsSecondTeam:
+		r.Winner, r.Loser, r.DecidedBy = first, second, DecidedByAwayGoals
+	case rules.AwayGoals && r.AwayGoalsFirstTeam < r.AwayGoalsSecondTeam:
+		r.Winner, r.Loser, r.DecidedBy = second, first, DecidedByAwayGoals
+	default:
+		return false
+	}
+	return true
+}
+
+// playTwoLeggedTie plays a knockout tie home and away, the first leg at the away
+// side's ground and the second at the home side's, and resolves it by the rules. Both
+// legs are returned as showpiece matches named after the tie. The second carries the
+// tie's winner, and its score includes any extra time.
+func playTwoLeggedTie(league *sim.League, name string, home, away *sim.Team, rules TieRules) ([]*sim.ShowpieceMatch, *TieResult, error) {
+	firstLeg := &sim.Match{HomeTeam: away, AwayTeam: home}
+	secondLeg := &sim.Match{HomeTeam: home, AwayTeam: away}
+	for _, leg := range []*sim.Match{firstLeg, secondLeg} {
+		sim.SimulateLogged(league, leg, sim.CompetitionShowpiece, sim.SimulateScore)
+		leg.Played = true
+	}
+
+	tie, err := NewTwoLeggedTie(firstLeg, secondLeg)
+	if err != nil {
+		return nil, nil, err
+	}
+	tie.Random = sim.LeagueRandom(league)
+	result, err := tie.Resolve(rules)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	first, second := newShowpiece(league, name, firstLeg), newShowpiece(league, name, secondLeg)
+	if result.DecidedBy == DecidedByExtraTime || (rules.ExtraTime && result.DecidedBy == DecidedByPenalties) {
+		second.HomeTeamScore += result.ExtraTimeHomeGoals
+		second.AwayTeamScore += result.ExtraTimeAwayGoals
+		second.ExtraTime = true
+		league.ExtraTimeMatches = append(league.ExtraTimeMatches, second)
+	}
+	// Resolve added the shootout's kicks to the second leg
+	second.Events = secondLeg.Events
+	second.PenaltiesHome, second.PenaltiesAway = result.PenaltiesHome, result.PenaltiesAway
+	second.WinnerName = result.Winner.TeamName
+	return []*sim.ShowpieceMatch{first, second}, result, nil
+}
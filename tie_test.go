@@ -0,0 +1,137 @@
+package main
+
+import (
+	"testing"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// scriptedRandom plays back its Float64 draws in order, repeating the last one once
+// the script runs out; Intn always draws 0, so the home side kicks first
+type scriptedRandom struct {
+	draws []float64
+}
+
+func (r *scriptedRandom) Float64() float64 {
+	draw := r.draws[0]
+	if len(r.draws) > 1 {
+		r.draws = r.draws[1:]
+	}
+	return draw
+}
+
+func (r *scriptedRandom) Intn(n int) int { return 0 }
+
+func TestTwoLeggedTieResolve(t *testing.T) {
+	first := &sim.Team{TeamName: "First", TeamId: 1, TeamStrength: 70}
+	second := &sim.Team{TeamName: "Second", TeamId: 2, TeamStrength: 70}
+
+	// Second hosts the second leg, so its extra time and shootout are at home
+	tests := []struct {
+		name          string
+		firstLeg      [2]int // first's home score, second's away score
+		secondLeg     [2]int // second's home score, first's away score
+		rules         TieRules
+		draws         []float64
+		wantWinner    *sim.Team
+		wantDecidedBy string
+	}{
+		{
+			name:     "aggregate",
+			firstLeg: [2]int{2, 0}, secondLeg: [2]int{1, 0},
+			rules:      DefaultTieRules(),
+			wantWinner: first, wantDecidedBy: DecidedByAggregate,
+		},
+		{
+			name:     "away goals",
+			firstLeg: [2]int{3, 1}, secondLeg: [2]int{2, 0},
+			rules:      TieRules{AwayGoals: true, ExtraTime: true, Penalties: true},
+			wantWinner: second, wantDecidedBy: DecidedByAwayGoals,
+		},
+		{
+			name:     "away goals off",
+			firstLeg: [2]int{3, 1}, secondLeg: [2]int{2, 0},
+			rules: DefaultTieRules(),
+			// Second scores in the first minute of extra time and no one else does
+			draws:      []float64{0, 0.99},
+			wantWinner: second, wantDecidedBy: DecidedByExtraTime,
+		},
+		{
+			name:     "extra time",
+			firstLeg: [2]int{1, 0}, secondLeg: [2]int{1, 0},
+			rules: DefaultTieRules(),
+			// First scores away from home in the first minute of extra time
+			draws:      []float64{0.99, 0},
+			wantWinner: first, wantDecidedBy: DecidedByExtraTime,
+		},
+		{
+			name:     "penalties after extra time",
+			firstLeg: [2]int{0, 0}, secondLeg: [2]int{0, 0},
+			rules: DefaultTieRules(),
+			// No goal in 30 minutes of extra time, then second, kicking first, scores
+			// every kick and first misses every one
+			draws:      append(repeatDraw(0.99, 60), 0, 0.99, 0, 0.99, 0, 0.99),
+			wantWinner: second, wantDecidedBy: DecidedByPenalties,
+		},
+		{
+			name:     "penalties without extra time",
+			firstLeg: [2]int{2, 2}, secondLeg: [2]int{1, 1},
+			rules:      TieRules{ExtraTime: false, Penalties: true},
+			draws:      []float64{0.99, 0, 0.99, 0, 0.99, 0},
+			wantWinner: first, wantDecidedBy: DecidedByPenalties,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			firstLeg := &sim.Match{HomeTeam: first, AwayTeam: second, HomeTeamScore: test.firstLeg[0], AwayTeamScore: test.firstLeg[1], Played: true}
+			secondLeg := &sim.Match{HomeTeam: second, AwayTeam: first, HomeTeamScore: test.secondLeg[0], AwayTeamScore: test.secondLeg[1], Played: true}
+			tie, err := NewTwoLeggedTie(firstLeg, secondLeg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tie.Random = &scriptedRandom{draws: append(test.draws, 0.99)}
+
+			result, err := tie.Resolve(test.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Winner != test.wantWinner || result.DecidedBy != test.wantDecidedBy {
+				t.Errorf("%s won by %s, want %s by %s", result.Winner.TeamName, result.DecidedBy, test.wantWinner.TeamName, test.wantDecidedBy)
+			}
+			if result.DecidedBy == DecidedByPenalties {
+				if len(secondLeg.Events) == 0 {
+					t.Error("the shootout's kicks are not in the second leg's events")
+				}
+				if result.AggregateFirstTeam != result.AggregateSecondTeam {
+					t.Errorf("went to penalties at %d-%d on aggregate", result.AggregateFirstTeam, result.AggregateSecondTeam)
+				}
+			}
+		})
+	}
+}
+
+func TestTwoLeggedTieNeedsReversedVenues(t *testing.T) {
+	first := &sim.Team{TeamName: "First", TeamId: 1}
+	second := &sim.Team{TeamName: "Second", TeamId: 2}
+	leg := &sim.Match{HomeTeam: first, AwayTeam: second}
+	if _, err := NewTwoLeggedTie(leg, &sim.Match{HomeTeam: first, AwayTeam: second}); err == nil {
+		t.Error("legs at the same ground formed a tie")
+	}
+
+	tie, err := NewTwoLeggedTie(leg, &sim.Match{HomeTeam: second, AwayTeam: first})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tie.Resolve(DefaultTieRules()); err == nil {
+		t.Error("a tie with unplayed legs was resolved")
+	}
+}
+
+// repeatDraw scripts the same draw n times
+func repeatDraw(draw float64, n int) []float64 {
+	draws := make([]float64, n)
+	for i := range draws {
+		draws[i] = draw
+	}
+	return draws
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// defaultTLSAddr is where the HTTPS server listens when TLS is enabled
+const defaultTLSAddr = ":8443"
+
+// defaultRedirectAddr serves the HTTP→HTTPS redirect in place of the plain API
+const defaultRedirectAddr = ":8080"
+
+// defaultAutocertCache keeps certificates issued by Let's Encrypt across restarts
+const defaultAutocertCache = "./certs"
+
+// TLSConfig describes how the server terminates TLS. Either a certificate/key pair
+// or a list of autocert domains enables it.
+type TLSConfig struct {
+	CertFile        string
+	KeyFile         string
+	AutocertDomains []string
+	AutocertCache   string
+	Addr            string
+	RedirectAddr    string // empty disables the HTTP→HTTPS redirect listener
+}
+
+// Enabled reports whether the server should serve HTTPS instead of plain HTTP
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || len(c.AutocertDomains) > 0
+}
+
+// loadTLSConfig reads the LEAGUE_TLS_* variables. A certificate needs both
+// LEAGUE_TLS_CERT and LEAGUE_TLS_KEY; LEAGUE_TLS_AUTOCERT_DOMAINS (comma-separated)
+// obtains certificates from Let's Encrypt instead.
+func loadTLSConfig() (TLSConfig, error) {
+	config := TLSConfig{
+		CertFile:      os.Getenv("LEAGUE_TLS_CERT"),
+		KeyFile:       os.Getenv("LEAGUE_TLS_KEY"),
+		AutocertCache: defaultAutocertCache,
+		Addr:          defaultTLSAddr,
+		RedirectAddr:  defaultRedirectAddr,
+	}
+	for _, domain := range strings.Split(os.Getenv("LEAGUE_TLS_AUTOCERT_DOMAINS"), ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			config.AutocertDomains = append(config.AutocertDomains, domain)
+		}
+	}
+	if cache := os.Getenv("LEAGUE_TLS_AUTOCERT_CACHE"); cache != "" {
+		config.AutocertCache = cache
+	}
+	if addr := os.Getenv("LEAGUE_TLS_ADDR"); addr != "" {
+		config.Addr = addr
+	}
+	if addr, ok := os.LookupEnv("LEAGUE_HTTP_REDIRECT_ADDR"); ok {
+		config.RedirectAddr = addr
+	}
+
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return config, fmt.Errorf("LEAGUE_TLS_CERT and LEAGUE_TLS_KEY must be set together")
+	}
+	if config.CertFile != "" && len(config.AutocertDomains) > 0 {
+		return config, fmt.Errorf("use either LEAGUE_TLS_CERT/LEAGUE_TLS_KEY or LEAGUE_TLS_AUTOCERT_DOMAINS, not both")
+	}
+	return config, nil
+}
+
+// httpsRedirectHandler sends plain HTTP requests to the same path on the HTTPS
+// listener. 308 keeps the method and body, so a POST to /league/play-all is replayed
+// as a POST rather than turned into a GET.
+func httpsRedirectHandler(tlsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// serveTLS serves handler over HTTPS on listener, with HTTP/2 negotiated through
+// ALPN, and starts the redirect listener when one is configured. Server-sent event
+// streams keep flushing per event over both HTTP/1.1 and HTTP/2.
+func serveTLS(config TLSConfig, listener net.Listener, handler http.Handler) error {
+	server := newHTTPServer(handler)
+	server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	redirect := httpsRedirectHandler(config.Addr)
+
+	if len(config.AutocertDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(config.AutocertDomains...),
+			Cache:      autocert.DirCache(config.AutocertCache),
+		}
+		// The manager's config answers TLS-ALPN challenges and advertises h2
+		server.TLSConfig = manager.TLSConfig()
+		server.TLSConfig.MinVersion = tls.VersionTLS12
+		// HTTP-01 challenges arrive on the redirect listener
+		redirect = manager.HTTPHandler(redirect)
+	}
+
+	if config.RedirectAddr != "" {
+		go func() {
+			fmt.Printf("Redirecting HTTP on %s to HTTPS\n", config.RedirectAddr)
+			redirectServer := newHTTPServer(redirect)
+			redirectServer.Addr = config.RedirectAddr
+			if err := redirectServer.ListenAndServe(); err != nil {
+				log.Printf("HTTP redirect listener stopped: %v", err)
+			}
+		}()
+	}
+
+	return server.ServeTLS(listener, config.CertFile, config.KeyFile)
+}
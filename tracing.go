@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer is used for every span the server creates. Until initTracing installs a
+// provider it is a no-op, so instrumented code costs next to nothing when tracing is off.
+var tracer = otel.Tracer("goleaguemelo")
+
+// initTracing exports spans over OTLP/HTTP when an OTLP endpoint is configured through
+// the standard OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+// variables (Jaeger accepts OTLP directly). Without one, tracing stays disabled.
+func initTracing(ctx context.Context) (bool, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return false, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to create OTLP exporter: %v", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName("goleaguemelo")),
+		resource.Environment(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to build trace resource: %v", err)
+	}
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	))
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return true, nil
+}
+
+// recordSpanError marks a span as failed
+func recordSpanError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// Flush keeps server-sent event streams working through the recorder
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// tracingMiddleware starts a server span per request, named after the matched route and
+// continuing any trace propagated by the caller
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, _ := mux.CurrentRoute(r).GetPathTemplate()
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// encodeJSON writes v as JSON inside its own span, so encoding shows up separately
+// from simulation and persistence
+func encodeJSON(ctx context.Context, w http.ResponseWriter, v interface{}) error {
+	_, span := tracer.Start(ctx, "encode json")
+	defer span.End()
+
+	err := json.NewEncoder(w).Encode(v)
+	recordSpanError(span, err)
+	return err
+}
+
+// tracedConn wraps a database or transaction so every statement gets a client span
+// under the context of the unit of work that issued it. Query and QueryRow spans
+// cover execution only; scanning the rows happens after the span ends.
+type tracedConn struct {
+	conn       sqlConn
+	ctx        context.Context
+	driverName string
+}
+
+func (c *tracedConn) start(query string) trace.Span {
+	operation := "QUERY"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	_, span := tracer.Start(c.ctx, "sql "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", c.driverName),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+		),
+	)
+	return span
+}
+
+func (c *tracedConn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	span := c.start(query)
+	defer span.End()
+	result, err := c.conn.Exec(query, args...)
+	recordSpanError(span, err)
+	return result, err
+}
+
+func (c *tracedConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	span := c.start(query)
+	defer span.End()
+	rows, err := c.conn.Query(query, args...)
+	recordSpanError(span, err)
+	return rows, err
+}
+
+func (c *tracedConn) QueryRow(query string, args ...interface{}) *sql.Row {
+	span := c.start(query)
+	defer span.End()
+	row := c.conn.QueryRow(query, args...)
+	recordSpanError(span, row.Err())
+	return row
+}
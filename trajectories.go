@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+	"os"
+	"sync"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// maxCachedSamples bounds the sampled results kept between Monte Carlo runs, two bytes
+// each: 8M samples is 16 MB, a full 380-fixture run-in at the default iterations for
+// four models
+const maxCachedSamples = 1 << 23
+
+// fixtureModel describes how a Monte Carlo model plays one remaining fixture: a
+// signature of every input its results depend on, and a function drawing one result.
+// Two fixtures with the same signature have the same distribution of results.
+type fixtureModel func(match *sim.Match) (signature string, play func() (int, int))
+
+// sampleColumn is one fixture's results over every iteration of a run. Columns are
+// never changed once sampled, so concurrent runs can share them.
+type sampleColumn struct {
+	home, away []uint8 // goals; scores never come near the uint8 limit
+}
+
+// sampleKey identifies a column: the fixture, the model inputs it was sampled with and
+// the length of the run
+type sampleKey struct {
+	matchId    int
+	signature  string
+	iterations int
+}
+
+// sampleCache keeps the columns of recent runs, least recently used dropped first
+type sampleCache struct {
+	mu      sync.Mutex
+	columns map[sampleKey]*cachedColumn
+	samples int
+	clock   int
+}
+
+type cachedColumn struct {
+	column   *sampleColumn
+	lastUsed int
+}
+
+// predictionSamples is shared by every league in the process. A column depends on
+// nothing but its key, so leagues can never see each other's results in it.
+var predictionSamples = &sampleCache{columns: make(map[sampleKey]*cachedColumn)}
+
+// incrementalPredictionsEnabled reports whether Monte Carlo runs reuse the samples of
+// earlier runs; set LEAGUE_INCREMENTAL_PREDICTIONS=false to sample every run afresh
+func incrementalPredictionsEnabled() bool {
+	value := os.Getenv("LEAGUE_INCREMENTAL_PREDICTIONS")
+	return value != "0" && value != "false"
+}
+
+// sampleFixtures returns a column of iterations results for each remaining fixture,
+// in order. A fixture whose signature is unchanged since an earlier run of the same
+// length keeps that run's results, which are still draws from its distribution, so
+// after a single result is edited only the fixtures whose inputs moved (usually those
+// of the two teams involved) are sampled again. Seeded leagues always sample afresh,
+// so that a seed replays the same predictions.
+func sampleFixtures(league *sim.League, remaining []*sim.Match, fixtures fixtureModel, iterations int) []*sampleColumn {
+	columns := make([]*sampleColumn, len(remaining))
+	reuse := incrementalPredictionsEnabled() && league.Random == nil && iterations*len(remaining) <= maxCachedSamples
+	keys := make([]sampleKey, len(remaining))
+	plays := make([]func() (int, int), len(remaining))
+	for i, match := range remaining {
+		signature, play := fixtures(match)
+		keys[i], plays[i] = sampleKey{matchId: match.MatchId, signature: signature, iterations: iterations}, play
+	}
+
+	if reuse {
+		predictionSamples.mu.Lock()
+		for i, key := range keys {
+			if cached, ok := predictionSamples.columns[key]; ok {
+				columns[i] = cached.column
+			}
+		}
+		predictionSamples.mu.Unlock()
+	}
+
+	for i, play := range plays {
+		if columns[i] != nil {
+			continue
+		}
+		column := &sampleColumn{home: make([]uint8, iterations), away: make([]uint8, iterations)}
+		for j := 0; j < iterations; j++ {
+			homeGoals, awayGoals := play()
+			column.home[j], column.away[j] = uint8(min(homeGoals, math.MaxUint8)), uint8(min(awayGoals, math.MaxUint8))
+		}
+		columns[i] = column
+	}
+
+	if reuse {
+		predictionSamples.store(keys, columns)
+	}
+	return columns
+}
+
+// store marks the run's columns as the most recently used, adding the new ones, then
+// drops the least recently used columns until the cache is within maxCachedSamples
+func (c *sampleCache) store(keys []sampleKey, columns []*sampleColumn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clock++
+	for i, key := range keys {
+		if cached, ok := c.columns[key]; ok {
+			cached.lastUsed = c.clock
+			continue
+		}
+		c.columns[key] = &cachedColumn{column: columns[i], lastUsed: c.clock}
+		c.samples += key.iterations
+	}
+
+	for c.samples > maxCachedSamples {
+		var oldest sampleKey
+		oldestUse := c.clock + 1
+		for key, cached := range c.columns {
+			if cached.lastUsed < oldestUse {
+				oldest, oldestUse = key, cached.lastUsed
+			}
+		}
+		delete(c.columns, oldest)
+		c.samples -= oldest.iterations
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+// SimulationValidation reports whether the configured model produces realistic scorelines
+type SimulationValidation struct {
+	Valid                bool
+	Warnings             []string
+	ModelAverageGoals    float64
+	ObservedAverageGoals float64
+	ObservedMatches      int
+	MaxGoals             int
+}
+
+// validateSimulation checks the model's expected goals and the observed results against the guardrails
+func validateSimulation(league *sim.League, config sim.SimulationConfig) *SimulationValidation {
+	validation := &SimulationValidation{
+		Valid:    true,
+		Warnings: []string{},
+	}
+
+	// Expected goals per match implied by the model across every fixture
+	sport := sim.LeagueSport(league)
+	validation.MaxGoals = int(math.Ceil(float64(config.MaxGoals) * sport.GoalRate))
+	totalExpected := 0.0
+	for _, match := range league.Matches {
+		homeExpected := sport.ExpectedGoals(sim.MatchupStrength(match.HomeTeam, match.AwayTeam) + 5.0)
+		awayExpected := sport.ExpectedGoals(sim.MatchupStrength(match.AwayTeam, match.HomeTeam))
+		totalExpected += homeExpected + awayExpected
+	}
+	if len(league.Matches) > 0 {
+		validation.ModelAverageGoals = totalExpected / float64(len(league.Matches))
+	}
+
+	stats := computeLeagueStats(league, league.CurrentWeek)
+	validation.ObservedMatches = stats.MatchesPlayed
+	validation.ObservedAverageGoals = stats.AverageGoalsPerMatch
+
+	// The guardrails are set for football and scale with the sport's scoring
+	maxAverage, minAverage := config.MaxAverageGoals*sport.GoalRate, config.MinAverageGoals*sport.GoalRate
+	check := func(source string, average float64) {
+		if average > maxAverage {
+			validation.Warnings = append(validation.Warnings,
+				fmt.Sprintf("%s average of %.2f goals per match exceeds %.2f", source, average, maxAverage))
+		}
+		if average < minAverage {
+			validation.Warnings = append(validation.Warnings,
+				fmt.Sprintf("%s average of %.2f goals per match is below %.2f", source, average, minAverage))
+		}
+	}
+
+	if len(league.Matches) > 0 {
+		check("model", validation.ModelAverageGoals)
+	}
+	if stats.MatchesPlayed > 0 {
+		check("observed", validation.ObservedAverageGoals)
+	}
+
+	// A cap the model regularly hits flattens the score distribution
+	capped := 0
+	for _, match := range league.Matches {
+		if match.Played && (match.HomeTeamScore >= validation.MaxGoals || match.AwayTeamScore >= validation.MaxGoals) {
+			capped++
+		}
+	}
+	if stats.MatchesPlayed > 0 && float64(capped)/float64(stats.MatchesPlayed) > 0.1 {
+		validation.Warnings = append(validation.Warnings,
+			fmt.Sprintf("%d of %d played matches hit the %d-goal cap", capped, stats.MatchesPlayed, validation.MaxGoals))
+	}
+
+	validation.Valid = len(validation.Warnings) == 0
+	return validation
+}
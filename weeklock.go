@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	sim "github.com/Melotachi/GoLeagueMelo/league"
+)
+
+var (
+	errWeekLocked   = errors.New("week is locked")
+	errWeekNotFound = errors.New("week has no fixtures")
+)
+
+// weekLockAfter reads LEAGUE_WEEK_LOCK_AFTER: a week locks itself once this many later
+// weeks have been played. Zero, the default, leaves weeks to be locked by hand.
+func weekLockAfter() int {
+	if value := os.Getenv("LEAGUE_WEEK_LOCK_AFTER"); value != "" {
+		if after, err := strconv.Atoi(value); err == nil && after >= 0 {
+			return after
+		}
+		log.Printf("Invalid LEAGUE_WEEK_LOCK_AFTER %q, locking weeks by hand only", value)
+	}
+	return 0
+}
+
+// WeekLock describes whether a week's results can still be edited
+type WeekLock struct {
+	Week      int
+	Locked    bool
+	Automatic bool       `json:",omitempty"` // locked because later weeks have been played
+	LockedAt  *time.Time `json:",omitempty"` // when the week was locked by hand
+}
+
+// weekLockState reports a week's lock: by hand through POST /league/weeks/{n}/lock, or
+// automatically once LEAGUE_WEEK_LOCK_AFTER later weeks have been played
+func weekLockState(league *sim.League, week int) WeekLock {
+	lock := WeekLock{Week: week}
+	if lockedAt, ok := league.LockedWeeks[week]; ok {
+		lock.Locked = true
+		lock.LockedAt = &lockedAt
+	}
+	if after := weekLockAfter(); after > 0 && league.CurrentWeek-week >= after {
+		lock.Locked = true
+		lock.Automatic = lock.LockedAt == nil
+	}
+	return lock
+}
+
+// weekLocked reports whether a week's results are protected from edits
+func weekLocked(league *sim.League, week int) bool {
+	return weekLockState(league, week).Locked
+}